@@ -0,0 +1,749 @@
+// Copyright 2018 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package filecache implements a file cache for Hugo.
+package filecache
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gohugoio/hugo/helpers"
+
+	"github.com/BurntSushi/locker"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Policy values for cacheConfig.Policy; see its doc comment.
+const (
+	PolicyAge     = "age"
+	PolicyLRU     = "lru"
+	PolicySize    = "size"
+	PolicyAgeSize = "age+size"
+)
+
+// indexFilename is the sidecar file a Cache keeps in the root of its own
+// directory to remember entry size/access time across process restarts,
+// since the afero fs itself doesn't give us atime.
+const indexFilename = "index.json"
+
+// ItemInfo contains info about a cached file.
+type ItemInfo struct {
+	Name string
+}
+
+// Cache caches a set of files in a directory. This is usually a file on
+// disk, but since this is a filecache, not a filesystem cache, that's a
+// technicality.
+//
+// If MaxSize and/or MaxEntries is set, a write that would push the cache
+// past either limit evicts the least-recently-used entries first. If the
+// Cache was handed a shared budget (see NewCaches' handling of
+// caches.totalMaxSize), writes may also trigger eviction in a sibling
+// Cache.
+type Cache struct {
+	Fs afero.Fs
+
+	nlocker *locker.Locker
+
+	maxAge     time.Duration
+	maxEntries int
+	maxSize    uint64
+	policy     string
+
+	budget *sizeBudget
+
+	indexMu sync.Mutex
+	index   *cacheIndex
+
+	// evictions counts how many entries Prune has removed over this
+	// Cache's lifetime, for Caches.Stats.
+	evictions int
+
+	// remote, if set, is where this Cache's entries actually live;
+	// reads/writes go through it instead of Fs, and the index/eviction
+	// bookkeeping above -- which exists to bound local disk usage -- is
+	// skipped entirely. See cacheBackend's doc comment.
+	remote cacheBackend
+}
+
+// Caches is a named set of Cache, one per entry configured under "caches"
+// in the site config (plus the built-ins in defaultCacheConfigs).
+type Caches map[string]*Cache
+
+// Get gets the named cache, or nil if name isn't a configured cache.
+func (f Caches) Get(name string) *Cache {
+	return f[name]
+}
+
+// cacheIndexEntry is what a cacheIndex remembers about one cached file.
+type cacheIndexEntry struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	AccessedAt time.Time `json:"accessedAt"`
+}
+
+// cacheIndex is the in-memory (and, via indexFilename, on-disk) record of
+// what a Cache has stored, used to decide what to evict once MaxSize or
+// MaxEntries is exceeded.
+type cacheIndex struct {
+	Entries map[string]*cacheIndexEntry `json:"entries"`
+}
+
+func newCacheIndex() *cacheIndex {
+	return &cacheIndex{Entries: make(map[string]*cacheIndexEntry)}
+}
+
+// sizeBudget is a size limit shared by every Cache configured with a
+// caches.totalMaxSize; it evicts across cache boundaries, unlike a single
+// Cache's own MaxSize/MaxEntries.
+type sizeBudget struct {
+	max uint64
+
+	mu     sync.Mutex
+	used   uint64
+	caches []*Cache
+}
+
+func (b *sizeBudget) add(delta int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if delta < 0 && uint64(-delta) > b.used {
+		b.used = 0
+	} else {
+		b.used = uint64(int64(b.used) + delta)
+	}
+}
+
+// evictIfNeeded removes the globally least-recently-used entries, across
+// every Cache sharing this budget, until used is back under max.
+func (b *sizeBudget) evictIfNeeded() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	caches := append([]*Cache(nil), b.caches...)
+	over := b.used > b.max
+	b.mu.Unlock()
+
+	if !over {
+		return nil
+	}
+
+	type candidate struct {
+		cache *Cache
+		entry *cacheIndexEntry
+	}
+
+	for {
+		b.mu.Lock()
+		over = b.used > b.max
+		b.mu.Unlock()
+		if !over {
+			return nil
+		}
+
+		var oldest *candidate
+		for _, c := range caches {
+			e := c.oldestEntry()
+			if e == nil {
+				continue
+			}
+			if oldest == nil || e.AccessedAt.Before(oldest.entry.AccessedAt) {
+				oldest = &candidate{cache: c, entry: e}
+			}
+		}
+
+		if oldest == nil {
+			// Nothing left to evict.
+			return nil
+		}
+
+		if err := oldest.cache.removeAndForget(oldest.entry.Name); err != nil {
+			return err
+		}
+	}
+}
+
+// NewCaches creates a new set of file caches from the given configuration.
+func NewCaches(p *helpers.PathSpec) (Caches, error) {
+	dcfg, totalMaxSize, err := decodeConfig(p)
+	if err != nil {
+		return nil, err
+	}
+
+	fs := p.Fs.Source
+
+	var budget *sizeBudget
+	if totalMaxSize > 0 {
+		budget = &sizeBudget{max: totalMaxSize}
+	}
+
+	caches := make(Caches)
+	for k, v := range dcfg {
+		if err := fs.MkdirAll(v.Dir, 0777); err != nil && !os.IsExist(err) {
+			return nil, errors.Wrapf(err, "failed to create cache dir %q", v.Dir)
+		}
+
+		bfs := afero.NewBasePathFs(fs, v.Dir)
+
+		cache := NewCache(bfs, v.MaxAge, v.MaxSize, v.MaxEntries, v.Policy)
+		if v.MaxSize > 0 {
+			cache.budget = budget
+		}
+
+		remote, err := newBackend(k, v)
+		if err != nil {
+			return nil, err
+		}
+		cache.remote = remote
+
+		caches[k] = cache
+	}
+
+	if budget != nil {
+		for _, c := range caches {
+			if c.budget == budget {
+				budget.caches = append(budget.caches, c)
+			}
+		}
+	}
+
+	return caches, nil
+}
+
+// NewCache creates a new Cache backed by fs, with the given MaxAge,
+// MaxSize (0 meaning unbounded), MaxEntries (0 meaning unbounded) and
+// Policy (see cacheConfig.Policy; "" behaves like PolicyAgeSize).
+func NewCache(fs afero.Fs, maxAge time.Duration, maxSize uint64, maxEntries int, policy string) *Cache {
+	c := &Cache{
+		Fs:         fs,
+		nlocker:    locker.NewLocker(),
+		maxAge:     maxAge,
+		maxEntries: maxEntries,
+		maxSize:    maxSize,
+		policy:     policy,
+		index:      newCacheIndex(),
+	}
+	if maxSize > 0 {
+		c.budget = &sizeBudget{max: maxSize, caches: []*Cache{c}}
+	}
+	c.loadIndex()
+	return c
+}
+
+// loadIndex reads the on-disk index.json sidecar, falling back to an
+// empty index (not an error) if it doesn't exist yet -- e.g. the very
+// first build, or a cache dir pre-dating this feature.
+func (c *Cache) loadIndex() {
+	f, err := c.Fs.Open(indexFilename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var idx cacheIndex
+	if err := json.NewDecoder(f).Decode(&idx); err != nil || idx.Entries == nil {
+		return
+	}
+
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+	c.index = &idx
+
+	if c.budget != nil {
+		var sum int64
+		for _, e := range idx.Entries {
+			sum += e.Size
+		}
+		c.budget.add(sum)
+	}
+}
+
+// persistIndex writes the in-memory index back to indexFilename. Best
+// effort: a failure here only means the next process rebuilds LRU
+// ordering from scratch, it doesn't lose any cached content.
+func (c *Cache) persistIndex() {
+	c.indexMu.Lock()
+	idx := c.index
+	c.indexMu.Unlock()
+
+	b, err := json.Marshal(idx)
+	if err != nil {
+		return
+	}
+
+	f, err := c.Fs.Create(indexFilename)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.Write(b)
+}
+
+// recordWrite updates the index for id, evicts if needed, and persists
+// the index.
+func (c *Cache) recordWrite(id string, size int64) {
+	c.indexMu.Lock()
+	prev, had := c.index.Entries[id]
+	entry := &cacheIndexEntry{Name: id, Size: size, AccessedAt: time.Now()}
+	c.index.Entries[id] = entry
+	c.indexMu.Unlock()
+
+	var delta int64
+	if had {
+		delta = size - prev.Size
+	} else {
+		delta = size
+	}
+
+	if c.budget != nil {
+		c.budget.add(delta)
+		c.budget.evictIfNeeded()
+	}
+
+	c.evictLocalIfNeeded()
+	c.persistIndex()
+}
+
+// recordTouch refreshes id's access time without changing its size, so a
+// Get/GetOrCreate hit keeps a hot entry from looking least-recently-used.
+func (c *Cache) recordTouch(id string) {
+	c.indexMu.Lock()
+	if e, found := c.index.Entries[id]; found {
+		e.AccessedAt = time.Now()
+	}
+	c.indexMu.Unlock()
+	c.persistIndex()
+}
+
+func (c *Cache) forget(id string) {
+	c.indexMu.Lock()
+	e, had := c.index.Entries[id]
+	if had {
+		delete(c.index.Entries, id)
+	}
+	c.indexMu.Unlock()
+
+	if had && c.budget != nil {
+		c.budget.add(-e.Size)
+	}
+}
+
+// oldestEntry returns this cache's least-recently-accessed entry, or nil
+// if it's empty.
+func (c *Cache) oldestEntry() *cacheIndexEntry {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+	var oldest *cacheIndexEntry
+	for _, e := range c.index.Entries {
+		if oldest == nil || e.AccessedAt.Before(oldest.AccessedAt) {
+			oldest = e
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+	// Return a copy: the caller (sizeBudget.evictIfNeeded) reads it after
+	// releasing indexMu.
+	cp := *oldest
+	return &cp
+}
+
+// removeAndForget deletes id's cached file and its index entry, counting
+// it towards this Cache's Stats().Evictions regardless of whether it was
+// prompted by Prune, MaxEntries or MaxSize eviction.
+func (c *Cache) removeAndForget(id string) error {
+	c.forget(id)
+	if err := c.Fs.Remove(id); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	c.persistIndex()
+	c.indexMu.Lock()
+	c.evictions++
+	c.indexMu.Unlock()
+	return nil
+}
+
+// prunesByAge reports whether Prune should remove entries older than
+// maxAge, per this cache's Policy.
+func (c *Cache) prunesByAge() bool {
+	switch c.policy {
+	case PolicyLRU, PolicySize:
+		return false
+	default:
+		return c.maxAge > 0
+	}
+}
+
+// prunesBySize reports whether Prune should evict least-recently-used
+// entries down to maxSize, per this cache's Policy.
+func (c *Cache) prunesBySize() bool {
+	switch c.policy {
+	case PolicyAge:
+		return false
+	default:
+		return c.maxSize > 0
+	}
+}
+
+// evictLocalIfNeeded evicts this cache's own least-recently-used entries
+// until it satisfies MaxEntries (MaxSize is enforced via c.budget, shared
+// or not).
+func (c *Cache) evictLocalIfNeeded() {
+	if c.maxEntries <= 0 {
+		return
+	}
+	for {
+		c.indexMu.Lock()
+		n := len(c.index.Entries)
+		c.indexMu.Unlock()
+		if n <= c.maxEntries {
+			return
+		}
+		oldest := c.oldestEntry()
+		if oldest == nil {
+			return
+		}
+		c.removeAndForget(oldest.Name)
+	}
+}
+
+// pruneFileEntry is what Prune's size-based pass gathers per cached file
+// while walking the cache directory, so it doesn't have to trust the
+// in-memory index alone -- a cache dir can outlive the process that wrote
+// index.json, or be populated by a version of Hugo that predates it.
+type pruneFileEntry struct {
+	name       string
+	size       int64
+	accessedAt time.Time
+}
+
+// pruneFileHeap is a container/heap min-heap of pruneFileEntry ordered by
+// accessedAt, so Prune's size-based pass can repeatedly pop the
+// least-recently-used entry in O(log n) instead of rescanning every
+// candidate for the oldest one on every eviction.
+type pruneFileHeap []pruneFileEntry
+
+func (h pruneFileHeap) Len() int            { return len(h) }
+func (h pruneFileHeap) Less(i, j int) bool  { return h[i].accessedAt.Before(h[j].accessedAt) }
+func (h pruneFileHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *pruneFileHeap) Push(x interface{}) { *h = append(*h, x.(pruneFileEntry)) }
+func (h *pruneFileHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// Prune removes from this cache every entry older than MaxAge (if its
+// Policy enforces age), and/or evicts least-recently-used entries until
+// the cache's total size is at most MaxSize (if its Policy enforces
+// size), returning how many entries were removed in total. A cache with
+// neither set (the default) never prunes. Intended to be run from
+// `hugo --gc`.
+func (c *Cache) Prune() (int, error) {
+	if c.remote != nil {
+		// A remote backend is a shared store; this process pruning its own
+		// idea of "old"/"oldest" would fight every other worker writing to it.
+		return 0, nil
+	}
+
+	var count int
+
+	if c.prunesByAge() {
+		err := afero.Walk(c.Fs, ".", func(name string, fi os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if fi.IsDir() || name == indexFilename {
+				return nil
+			}
+
+			if time.Since(fi.ModTime()) > c.maxAge {
+				if err := c.removeAndForget(name); err != nil {
+					return err
+				}
+				count++
+			}
+
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+	}
+
+	if c.prunesBySize() {
+		evicted, err := c.pruneBySize()
+		if err != nil {
+			return count, err
+		}
+		count += evicted
+	}
+
+	return count, nil
+}
+
+// pruneBySize walks the cache directory gathering every entry's
+// (path, size, last-access-time) into a min-heap keyed by last access,
+// then pops and removes the oldest until the cache's total size is at
+// most MaxSize.
+func (c *Cache) pruneBySize() (int, error) {
+	var h pruneFileHeap
+	var total int64
+
+	err := afero.Walk(c.Fs, ".", func(name string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if fi.IsDir() || name == indexFilename {
+			return nil
+		}
+
+		accessedAt := fi.ModTime()
+		c.indexMu.Lock()
+		if e, found := c.index.Entries[name]; found {
+			accessedAt = e.AccessedAt
+		}
+		c.indexMu.Unlock()
+
+		total += fi.Size()
+		h = append(h, pruneFileEntry{name: name, size: fi.Size(), accessedAt: accessedAt})
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	heap.Init(&h)
+
+	var count int
+	for uint64(total) > c.maxSize && h.Len() > 0 {
+		oldest := heap.Pop(&h).(pruneFileEntry)
+		if err := c.removeAndForget(oldest.name); err != nil {
+			return count, err
+		}
+		total -= oldest.size
+		count++
+	}
+
+	return count, nil
+}
+
+// Prune runs Cache.Prune on every cache in the set, returning the total
+// number of entries removed.
+func (f Caches) Prune() (int, error) {
+	var total int
+	for _, c := range f {
+		count, err := c.Prune()
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// CacheStats is a point-in-time snapshot of one Cache's size and eviction
+// history, returned by Caches.Stats so a user can size MaxSize/MaxEntries
+// for e.g. a CI environment with bounded disk.
+type CacheStats struct {
+	// Count is the number of entries currently in the cache.
+	Count int
+	// Bytes is their total size on disk, as recorded in the index (i.e.
+	// the size last written, not a live stat of the file).
+	Bytes int64
+	// Evictions is how many entries this Cache has removed via Prune or
+	// MaxSize/MaxEntries eviction over its lifetime.
+	Evictions int
+}
+
+// Stats returns a CacheStats snapshot for every cache in the set, keyed by
+// the same names as Caches itself.
+func (f Caches) Stats() map[string]CacheStats {
+	stats := make(map[string]CacheStats, len(f))
+	for name, c := range f {
+		stats[name] = c.stats()
+	}
+	return stats
+}
+
+func (c *Cache) stats() CacheStats {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	s := CacheStats{Count: len(c.index.Entries), Evictions: c.evictions}
+	for _, e := range c.index.Entries {
+		s.Bytes += e.Size
+	}
+	return s
+}
+
+// GetOrCreate gets the file with the given id. If it doesn't already
+// exist in the cache, f is called and its result is stored and returned.
+func (c *Cache) GetOrCreate(id string, f func() (io.ReadCloser, error)) (ItemInfo, io.ReadCloser, error) {
+	id = cleanID(id)
+
+	c.nlocker.Lock(id)
+	defer c.nlocker.Unlock(id)
+
+	info := ItemInfo{Name: id}
+
+	if c.remote != nil {
+		if rc, found, err := c.remote.Get(id); err != nil {
+			return info, nil, err
+		} else if found {
+			return info, rc, nil
+		}
+
+		r, err := f()
+		if err != nil {
+			return info, nil, err
+		}
+		defer r.Close()
+
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return info, nil, err
+		}
+		if err := c.remote.Put(id, bytes.NewReader(b)); err != nil {
+			return info, nil, err
+		}
+		return info, ioutil.NopCloser(bytes.NewReader(b)), nil
+	}
+
+	if r, err := c.Fs.Open(id); err == nil {
+		c.recordTouch(id)
+		return info, r, nil
+	} else if !os.IsNotExist(err) {
+		return info, nil, err
+	}
+
+	r, err := f()
+	if err != nil {
+		return info, nil, err
+	}
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return info, nil, err
+	}
+
+	if err := c.writeFile(id, b); err != nil {
+		return info, nil, err
+	}
+
+	return info, ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// GetOrCreateBytes is GetOrCreate for callers that want the raw bytes
+// rather than a Reader.
+func (c *Cache) GetOrCreateBytes(id string, f func() ([]byte, error)) (ItemInfo, []byte, error) {
+	id = cleanID(id)
+
+	c.nlocker.Lock(id)
+	defer c.nlocker.Unlock(id)
+
+	info := ItemInfo{Name: id}
+
+	if c.remote != nil {
+		if rc, found, err := c.remote.Get(id); err != nil {
+			return info, nil, err
+		} else if found {
+			defer rc.Close()
+			b, err := ioutil.ReadAll(rc)
+			return info, b, err
+		}
+
+		b, err := f()
+		if err != nil {
+			return info, nil, err
+		}
+		if err := c.remote.Put(id, bytes.NewReader(b)); err != nil {
+			return info, nil, err
+		}
+		return info, b, nil
+	}
+
+	if b, err := afero.ReadFile(c.Fs, id); err == nil {
+		c.recordTouch(id)
+		return info, b, nil
+	} else if !os.IsNotExist(err) {
+		return info, nil, err
+	}
+
+	b, err := f()
+	if err != nil {
+		return info, nil, err
+	}
+
+	if err := c.writeFile(id, b); err != nil {
+		return info, nil, err
+	}
+
+	return info, b, nil
+}
+
+// writeFile writes b to id, creating parent directories as needed, then
+// updates this cache's (and any shared) index and evicts if needed.
+func (c *Cache) writeFile(id string, b []byte) error {
+	dir := filepath.Dir(id)
+	if dir != "." {
+		if err := c.Fs.MkdirAll(dir, 0777); err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+
+	if err := afero.WriteFile(c.Fs, id, b, 0666); err != nil {
+		return err
+	}
+
+	c.recordWrite(id, int64(len(b)))
+
+	return nil
+}
+
+// getString returns the content of id as a string, or "" if not cached.
+// Used by tests.
+func (c *Cache) getString(id string) string {
+	b, err := afero.ReadFile(c.Fs, cleanID(id))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func cleanID(id string) string {
+	return filepath.Clean(id)
+}
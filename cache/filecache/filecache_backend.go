@@ -0,0 +1,251 @@
+// Copyright 2018 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filecache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// backendLocal, backendHTTP and backendS3 are the values cacheConfig.Backend
+// accepts.
+const (
+	backendLocal = "local"
+	backendHTTP  = "http"
+	backendS3    = "s3"
+)
+
+// cacheBackend is where a Cache's bytes actually live, for any backend
+// other than the default ("local", a directory on the afero fs Hugo
+// already uses for everything else, which Cache talks to directly rather
+// than through this interface). httpBackend and s3Backend let that
+// directory instead be a store shared across ephemeral CI workers, so an
+// image/asset cache warmed by one build is there for the next.
+//
+// A Cache with a non-local backend (remote != nil) skips the
+// index.json/LRU bookkeeping filecache.go otherwise does: that bookkeeping
+// exists to bound local disk usage, which isn't this Cache's concern once
+// its content lives in a shared store.
+type cacheBackend interface {
+	// Get returns the content stored under key. found is false (with a nil
+	// error) if key isn't present.
+	Get(key string) (rc io.ReadCloser, found bool, err error)
+
+	// Put stores r under key, replacing any existing content.
+	Put(key string, r io.Reader) error
+
+	// Remove deletes key. Removing a key that doesn't exist is not an
+	// error.
+	Remove(key string) error
+
+	// Iter lists every key currently stored.
+	Iter() ([]string, error)
+}
+
+// newBackend returns the cacheBackend cc.Backend selects, or nil for
+// backendLocal (the caller keeps using Cache.Fs directly in that case).
+func newBackend(name string, cc cacheConfig) (cacheBackend, error) {
+	switch cc.Backend {
+	case "", backendLocal:
+		return nil, nil
+	case backendHTTP:
+		if cc.BaseURL == "" {
+			return nil, errors.Errorf("cache %q: backend %q requires baseURL", name, backendHTTP)
+		}
+		return &httpBackend{
+			baseURL:     strings.TrimSuffix(cc.BaseURL, "/"),
+			bearerToken: cc.BearerToken,
+			client:      http.DefaultClient,
+		}, nil
+	case backendS3:
+		if cc.Bucket == "" {
+			return nil, errors.Errorf("cache %q: backend %q requires bucket", name, backendS3)
+		}
+		sess, err := session.NewSession(&aws.Config{Region: aws.String(cc.Region)})
+		if err != nil {
+			return nil, errors.Wrapf(err, "cache %q: failed to create AWS session", name)
+		}
+		return &s3Backend{
+			bucket: cc.Bucket,
+			prefix: strings.Trim(cc.Prefix, "/"),
+			svc:    s3.New(sess),
+		}, nil
+	default:
+		return nil, errors.Errorf("cache %q: %q is not a valid backend (valid values are %q, %q or %q)", name, cc.Backend, backendLocal, backendHTTP, backendS3)
+	}
+}
+
+// httpBackend reads and writes through plain HTTP GET/PUT/DELETE against
+// baseURL, optionally authenticating with a bearer token. It's a
+// read-through cache for any HTTP server willing to act as one (a simple
+// static file server with PUT support, an artifact store, etc.).
+type httpBackend struct {
+	baseURL     string
+	bearerToken string
+	client      *http.Client
+}
+
+func (b *httpBackend) url(key string) string {
+	return b.baseURL + "/" + strings.TrimPrefix(key, "/")
+}
+
+func (b *httpBackend) newRequest(method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, b.url(key), body)
+	if err != nil {
+		return nil, err
+	}
+	if b.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+b.bearerToken)
+	}
+	return req, nil
+}
+
+func (b *httpBackend) Get(key string) (io.ReadCloser, bool, error) {
+	req, err := b.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, true, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, false, nil
+	default:
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("GET %s: unexpected status %s", b.url(key), resp.Status)
+	}
+}
+
+func (b *httpBackend) Put(key string, r io.Reader) error {
+	req, err := b.newRequest(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("PUT %s: unexpected status %s", b.url(key), resp.Status)
+	}
+	return nil
+}
+
+func (b *httpBackend) Remove(key string) error {
+	req, err := b.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("DELETE %s: unexpected status %s", b.url(key), resp.Status)
+	}
+	return nil
+}
+
+// Iter isn't meaningful for a plain HTTP read-through store: there's no
+// standard way to list keys behind an arbitrary URL, so an httpBackend
+// cache can't be pruned or globally evicted, only read and written by key.
+func (b *httpBackend) Iter() ([]string, error) {
+	return nil, errors.New("filecache: the http backend does not support listing keys")
+}
+
+// s3Backend stores cache entries as objects in an S3-compatible bucket,
+// under prefix, so image/asset caches can be shared across ephemeral CI
+// workers instead of rebuilt by each one.
+type s3Backend struct {
+	bucket string
+	prefix string
+	svc    *s3.S3
+}
+
+func (b *s3Backend) objectKey(key string) string {
+	if b.prefix == "" {
+		return key
+	}
+	return b.prefix + "/" + key
+}
+
+func (b *s3Backend) Get(key string) (io.ReadCloser, bool, error) {
+	out, err := b.svc.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == s3.ErrCodeNoSuchKey || aerr.Code() == "NotFound") {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return out.Body, true, nil
+}
+
+func (b *s3Backend) Put(key string, r io.Reader) error {
+	body, ok := r.(io.ReadSeeker)
+	if !ok {
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+	_, err := b.svc.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+		Body:   body,
+	})
+	return err
+}
+
+func (b *s3Backend) Remove(key string) error {
+	_, err := b.svc.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	return err
+}
+
+func (b *s3Backend) Iter() ([]string, error) {
+	var keys []string
+	err := b.svc.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(b.prefix),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(strings.TrimPrefix(aws.StringValue(obj.Key), b.prefix), "/"))
+		}
+		return true
+	})
+	return keys, err
+}
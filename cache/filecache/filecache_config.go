@@ -16,11 +16,13 @@ package filecache
 import (
 	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/gohugoio/hugo/helpers"
 
+	"github.com/dustin/go-humanize"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
@@ -29,6 +31,11 @@ import (
 const (
 	cachesConfigKey = "caches"
 
+	// totalMaxSizeConfigKey is a sibling of the named cache entries under
+	// "caches", not a cache itself -- it's pulled out of that map before
+	// the per-cache entries are decoded.
+	totalMaxSizeConfigKey = "totalmaxsize"
+
 	resourcesGenDir = ":resourceDir/_gen"
 )
 
@@ -68,11 +75,71 @@ type cacheConfig struct {
 	// The directory where files are stored.
 	Dir string
 
+	// MaxSize is the total size, in bytes, this cache may occupy on disk.
+	// Once a Get/GetOrCreate write would exceed it, the least-recently-used
+	// entries are evicted until it fits. Zero (the default) means
+	// unbounded. Decoded from human-readable units, e.g. "500MB", "2GB".
+	MaxSize uint64
+
+	// MaxEntries caps the number of entries in this cache, evicting the
+	// least-recently-used ones once exceeded. Zero means unbounded.
+	MaxEntries int
+
+	// Policy selects what Cache.Prune (run by `hugo --gc`) enforces: one
+	// of PolicyAge (only remove entries older than MaxAge), PolicyLRU /
+	// PolicySize (two names for the same thing -- evict the
+	// least-recently-used entries until MaxSize is satisfied, ignoring
+	// MaxAge), or PolicyAgeSize (do both). The zero value behaves like
+	// PolicyAgeSize: each of MaxAge/MaxSize is enforced if and only if
+	// it's set, which is also what Prune already did before Policy and
+	// MaxSize-aware pruning existed, so an existing config with only
+	// MaxAge set keeps behaving exactly as before.
+	Policy string
+
+	// Backend selects where this cache's entries are stored: "local" (the
+	// default, a directory under Dir on the afero fs), "http" (read
+	// through to BaseURL) or "s3" (an S3-compatible bucket). A remote
+	// backend lets e.g. an image cache be shared across ephemeral CI
+	// workers instead of rebuilt by every one of them.
+	Backend string
+
+	// BaseURL is the root URL the "http" backend GETs/PUTs/DELETEs keys
+	// against.
+	BaseURL string
+
+	// BearerToken, if set, authenticates "http" backend requests.
+	BearerToken string
+
+	// Bucket is the S3 bucket the "s3" backend stores entries in.
+	Bucket string
+
+	// Prefix is an optional key prefix the "s3" backend stores entries
+	// under within Bucket.
+	Prefix string
+
+	// Region is the AWS region the "s3" backend's bucket lives in.
+	Region string
+
 	// Will resources/_gen will get its own composite filesystem that
 	// also checks any theme.
 	isResourceDir bool
 }
 
+// stringToByteSizeHookFunc decodes a human-readable size ("500MB", "2GB")
+// into the uint64 byte count mapstructure needs for cacheConfig.MaxSize.
+func stringToByteSizeHookFunc() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to.Kind() != reflect.Uint64 {
+			return data, nil
+		}
+		s := data.(string)
+		if s == "" {
+			return uint64(0), nil
+		}
+		return humanize.ParseBytes(s)
+	}
+}
+
 // GetJSONCache gets the file cache for getJSON.
 func (f Caches) GetJSONCache() *Cache {
 	return f[cacheKeyGetJSON]
@@ -93,7 +160,10 @@ func (f Caches) AssetsCache() *Cache {
 	return f[cacheKeyAssets]
 }
 
-func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
+// decodeConfig decodes the per-cache settings under "caches", and returns
+// alongside them the "caches.totalMaxSize" budget (0 meaning unbounded)
+// applied across every named cache, not just one.
+func decodeConfig(p *helpers.PathSpec) (cachesConfig, uint64, error) {
 	c := make(cachesConfig)
 	valid := make(map[string]bool)
 	// Add defaults
@@ -106,6 +176,18 @@ func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
 
 	m := cfg.GetStringMap(cachesConfigKey)
 
+	var totalMaxSize uint64
+	if raw, found := m[totalMaxSizeConfigKey]; found {
+		delete(m, totalMaxSizeConfigKey)
+		if s, ok := raw.(string); ok && s != "" {
+			size, err := humanize.ParseBytes(s)
+			if err != nil {
+				return c, 0, errors.Wrapf(err, "invalid caches.totalMaxSize %q", s)
+			}
+			totalMaxSize = size
+		}
+	}
+
 	_, isOsFs := p.Fs.Source.(*afero.OsFs)
 
 	for k, v := range m {
@@ -113,26 +195,37 @@ func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
 
 		dc := &mapstructure.DecoderConfig{
 			Result:           &cc,
-			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			DecodeHook:       mapstructure.ComposeDecodeHookFunc(mapstructure.StringToTimeDurationHookFunc(), stringToByteSizeHookFunc()),
 			WeaklyTypedInput: true,
 		}
 
 		decoder, err := mapstructure.NewDecoder(dc)
 		if err != nil {
-			return c, err
+			return c, 0, err
 		}
 
 		if err := decoder.Decode(v); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 
 		if cc.Dir == "" {
-			return c, errors.New("must provide cache Dir")
+			return c, 0, errors.New("must provide cache Dir")
+		}
+
+		cc.Policy = strings.ToLower(cc.Policy)
+		switch cc.Policy {
+		case "", PolicyAge, PolicyLRU, PolicySize, PolicyAgeSize:
+		default:
+			return c, 0, errors.Errorf("%q is not a valid cache policy", cc.Policy)
 		}
 
 		name := strings.ToLower(k)
 		if !valid[name] {
-			return nil, errors.Errorf("%q is not a valid cache name", name)
+			return nil, 0, errors.Errorf("%q is not a valid cache name", name)
+		}
+
+		if _, err := newBackend(name, cc); err != nil {
+			return c, 0, err
 		}
 
 		c[name] = cc
@@ -150,7 +243,7 @@ func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
 			if strings.HasPrefix(part, ":") {
 				resolved, isResource, err := resolveDirPlaceholder(p, part)
 				if err != nil {
-					return c, err
+					return c, 0, err
 				}
 				if isResource {
 					v.isResourceDir = true
@@ -167,12 +260,12 @@ func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
 
 		if !v.isResourceDir {
 			if isOsFs && !filepath.IsAbs(v.Dir) {
-				return c, errors.Errorf("%q must resolve to an absolute directory", v.Dir)
+				return c, 0, errors.Errorf("%q must resolve to an absolute directory", v.Dir)
 			}
 
 			// Avoid cache in root, e.g. / (Unix) or c:\ (Windows)
 			if len(strings.TrimPrefix(v.Dir, filepath.VolumeName(v.Dir))) == 1 {
-				return c, errors.Errorf("%q is a root folder and not allowed as cache dir", v.Dir)
+				return c, 0, errors.Errorf("%q is a root folder and not allowed as cache dir", v.Dir)
 			}
 		}
 
@@ -183,7 +276,7 @@ func decodeConfig(p *helpers.PathSpec) (cachesConfig, error) {
 		c[k] = v
 	}
 
-	return c, nil
+	return c, totalMaxSize, nil
 }
 
 // Resolves :resourceDir => /myproject/resources etc., :cacheDir => ...
@@ -116,3 +116,128 @@ dir = ":resourceDir/_gen"
 	}
 
 }
+
+func TestPruneBySize(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	configStr := `
+resourceDir = "myresources"
+contentDir = "content"
+dataDir = "data"
+i18nDir = "i18n"
+layoutDir = "layouts"
+assetDir = "assets"
+archeTypedir = "archetypes"
+
+[caches]
+[caches.getjson]
+maxAge = "-1ms"
+maxSize = "30B"
+dir = "/cache/c"
+`
+
+	cfg, err := config.FromConfigString(configStr, "toml")
+	assert.NoError(err)
+
+	fs := hugofs.NewMem(cfg)
+	p, err := helpers.NewPathSpec(fs, cfg)
+	assert.NoError(err)
+	caches, err := NewCaches(p)
+	assert.NoError(err)
+	cache := caches[cacheKeyGetJSON]
+
+	// Each entry is 10 bytes ("0123456789"), and maxSize is 30B, so at
+	// most 3 of the 5 written below can survive a prune.
+	content := []byte("0123456789")
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("i%d", i)
+		_, _, err := cache.GetOrCreateBytes(id, func() ([]byte, error) {
+			return content, nil
+		})
+		assert.NoError(err)
+	}
+
+	// Touch i0 so it's no longer the least-recently-used entry, then
+	// prune: i1 and i2 (the two oldest by access time after the touch)
+	// should be evicted to bring the cache back under maxSize, while i0,
+	// i3 and i4 survive.
+	_, _, err = cache.GetOrCreateBytes("i0", func() ([]byte, error) {
+		return content, nil
+	})
+	assert.NoError(err)
+
+	count, err := caches.Prune()
+	assert.NoError(err)
+	assert.Equal(2, count)
+
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("i%d", i)
+		v := cache.getString(id)
+		switch i {
+		case 1, 2:
+			assert.Equal("", v, id)
+		default:
+			assert.Equal(string(content), v, id)
+		}
+	}
+
+	stats := caches.Stats()[cacheKeyGetJSON]
+	assert.Equal(3, stats.Count)
+	assert.Equal(int64(30), stats.Bytes)
+	assert.True(stats.Evictions >= 2, "expected at least 2 evictions, got %d", stats.Evictions)
+}
+
+func TestPruneAgeOnlyPolicyIgnoresSize(t *testing.T) {
+	t.Parallel()
+
+	assert := require.New(t)
+
+	configStr := `
+resourceDir = "myresources"
+contentDir = "content"
+dataDir = "data"
+i18nDir = "i18n"
+layoutDir = "layouts"
+assetDir = "assets"
+archeTypedir = "archetypes"
+
+[caches]
+[caches.getjson]
+maxAge = "-1ms"
+maxSize = "1B"
+policy = "age"
+dir = "/cache/c"
+`
+
+	cfg, err := config.FromConfigString(configStr, "toml")
+	assert.NoError(err)
+
+	fs := hugofs.NewMem(cfg)
+	p, err := helpers.NewPathSpec(fs, cfg)
+	assert.NoError(err)
+	caches, err := NewCaches(p)
+	assert.NoError(err)
+	cache := caches[cacheKeyGetJSON]
+
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("i%d", i)
+		_, _, err := cache.GetOrCreateBytes(id, func() ([]byte, error) {
+			return []byte("abc"), nil
+		})
+		assert.NoError(err)
+	}
+
+	// MaxAge is negative (never expire) and Policy is "age", so the 1B
+	// MaxSize -- which would otherwise force eviction down to nothing --
+	// must be ignored entirely.
+	count, err := caches.Prune()
+	assert.NoError(err)
+	assert.Equal(0, count)
+
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("i%d", i)
+		assert.Equal("abc", cache.getString(id), id)
+	}
+}
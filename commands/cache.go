@@ -0,0 +1,65 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/helpers"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cacheBuckets []string
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage Hugo's on-disk caches",
+	Long:  `Cache provides subcommands for maintaining the on-disk caches written via helpers.GetCacheDirForKey, e.g. the dataSources remote-fetch cache.`,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired or oversized cache entries",
+	Long: `Prune removes, for each --bucket, every entry older than its
+configured caches.<bucket>.maxAge, then -- if the bucket is still over its
+configured caches.<bucket>.maxSize -- its least-recently-written entries
+until it fits.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := InitializeConfig(cacheCmd); err != nil {
+			return err
+		}
+		return pruneCacheBuckets(afero.NewOsFs(), viper.GetViper(), cacheBuckets)
+	},
+}
+
+func init() {
+	cachePruneCmd.Flags().StringSliceVar(&cacheBuckets, "bucket", []string{"dataSources"}, "cache bucket(s) to prune")
+	cacheCmd.AddCommand(cachePruneCmd)
+}
+
+// pruneCacheBuckets runs helpers.NewCache(...).Prune for each named bucket
+// and reports how many entries it removed from each.
+func pruneCacheBuckets(fs afero.Fs, cfg config.Provider, buckets []string) error {
+	for _, bucket := range buckets {
+		removed, err := helpers.NewCache(fs, cfg, bucket).Prune()
+		if err != nil {
+			return fmt.Errorf("cache bucket %q: %w", bucket, err)
+		}
+		fmt.Printf("cache bucket %q: pruned %d entries\n", bucket, removed)
+	}
+	return nil
+}
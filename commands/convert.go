@@ -15,18 +15,21 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"path"
-	"time"
+	"runtime"
 
-	"github.com/spf13/cast"
 	"github.com/spf13/cobra"
 	"github.com/spf13/hugo/hugolib"
 	"github.com/spf13/hugo/parser"
+	"github.com/spf13/hugo/source"
 	jww "github.com/spf13/jwalterweatherman"
 )
 
 var OutputDir string
 var Unsafe bool
+var DryRun bool
+var Jobs int
 
 var convertCmd = &cobra.Command{
 	Use:   "convert",
@@ -41,8 +44,7 @@ var toJSONCmd = &cobra.Command{
 	Long: `toJSON will convert all front matter in the content
 	directory to use JSON for the front matter`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := convertContents(rune([]byte(parser.JSON_LEAD)[0]))
-		if err != nil {
+		if err := convertContents("json"); err != nil {
 			jww.ERROR.Println(err)
 		}
 	},
@@ -54,8 +56,7 @@ var toTOMLCmd = &cobra.Command{
 	Long: `toTOML will convert all front matter in the content
 	directory to use TOML for the front matter`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := convertContents(rune([]byte(parser.TOML_LEAD)[0]))
-		if err != nil {
+		if err := convertContents("toml"); err != nil {
 			jww.ERROR.Println(err)
 		}
 	},
@@ -67,8 +68,31 @@ var toYAMLCmd = &cobra.Command{
 	Long: `toYAML will convert all front matter in the content
 	directory to use YAML for the front matter`,
 	Run: func(cmd *cobra.Command, args []string) {
-		err := convertContents(rune([]byte(parser.YAML_LEAD)[0]))
-		if err != nil {
+		if err := convertContents("yaml"); err != nil {
+			jww.ERROR.Println(err)
+		}
+	},
+}
+
+var toYAMLFlowCmd = &cobra.Command{
+	Use:   "toYAMLFlow",
+	Short: "Convert front matter to flow-style YAML",
+	Long: `toYAMLFlow will convert all front matter in the content
+	directory to use single-line, flow-style YAML for the front matter`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := convertContents("yamlflow"); err != nil {
+			jww.ERROR.Println(err)
+		}
+	},
+}
+
+var toXMLCmd = &cobra.Command{
+	Use:   "toXML",
+	Short: "Convert front matter to XML",
+	Long: `toXML will convert all front matter in the content
+	directory to use XML for the front matter`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := convertContents("xml"); err != nil {
 			jww.ERROR.Println(err)
 		}
 	},
@@ -78,11 +102,35 @@ func init() {
 	convertCmd.AddCommand(toJSONCmd)
 	convertCmd.AddCommand(toTOMLCmd)
 	convertCmd.AddCommand(toYAMLCmd)
+	convertCmd.AddCommand(toYAMLFlowCmd)
+	convertCmd.AddCommand(toXMLCmd)
 	convertCmd.PersistentFlags().StringVarP(&OutputDir, "output", "o", "", "filesystem path to write files to")
 	convertCmd.PersistentFlags().BoolVar(&Unsafe, "unsafe", false, "enable less safe operations, please backup first")
+	convertCmd.PersistentFlags().BoolVar(&DryRun, "dry-run", false, "print a diff of the proposed changes instead of writing them")
+	convertCmd.PersistentFlags().IntVar(&Jobs, "jobs", runtime.NumCPU(), "number of files to convert concurrently")
 }
 
-func convertContents(mark rune) (err error) {
+// convertContents rewrites every content file's front matter into the named
+// format, which must be registered in parser.FormatByName (see
+// parser/frontmatter.go for the built-ins and how to register your own).
+func convertContents(format string) (err error) {
+	return convertContentsWithTransforms(format, nil)
+}
+
+// convertContentsWithTransforms is convertContents plus an ordered list of
+// metadata transforms (see rewrite.go). Passing an empty format preserves
+// each file's existing front matter format instead of converting it, which
+// is what the rewrite command wants: it only touches the fields named by
+// --set/--rename/--delete/--touch, not the on-disk representation.
+func convertContentsWithTransforms(format string, transforms []rewriteOp) (err error) {
+	var f *parser.FrontmatterFormat
+	if format != "" {
+		f, err = parser.FormatByName(format)
+		if err != nil {
+			return err
+		}
+	}
+
 	InitializeConfig()
 	site := &hugolib.Site{}
 
@@ -97,50 +145,94 @@ func convertContents(mark rune) (err error) {
 		return fmt.Errorf("No source files found")
 	}
 
-	jww.FEEDBACK.Println("processing", len(site.Source.Files()), "content files")
-	for _, file := range site.Source.Files() {
-		jww.INFO.Println("Attempting to convert", file.LogicalName)
-		page, err := hugolib.NewPage(file.LogicalName)
-		if err != nil {
-			return err
-		}
+	files := site.Source.Files()
+	jww.FEEDBACK.Println("processing", len(files), "content files")
 
-		psr, err := parser.ReadFrom(file.Contents)
-		if err != nil {
-			jww.ERROR.Println("Error processing file:", path.Join(file.Dir, file.LogicalName))
-			return err
-		}
-		metadata, err := psr.Metadata()
+	pool := newConvertPool(Jobs, len(files))
+	for _, file := range files {
+		file := file
+		pool.run(func() error {
+			return convertOneFile(file, f, transforms)
+		})
+	}
+	return pool.wait()
+}
+
+// convertOneFile parses, transforms and re-emits a single content file. It's
+// the unit of work handed to each convertPool worker.
+func convertOneFile(file *source.File, f *parser.FrontmatterFormat, transforms []rewriteOp) error {
+	jww.INFO.Println("Attempting to convert", file.LogicalName)
+	page, err := hugolib.NewPage(file.LogicalName)
+	if err != nil {
+		return err
+	}
+
+	psr, err := parser.ReadFrom(file.Contents)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path.Join(file.Dir, file.LogicalName), err)
+	}
+	metadata, err := psr.Metadata()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path.Join(file.Dir, file.LogicalName), err)
+	}
+
+	targetFormat := f
+	if targetFormat == nil {
+		targetFormat, err = parser.FormatByLeadRune(psr.LeadRune())
 		if err != nil {
-			jww.ERROR.Println("Error processing file:", path.Join(file.Dir, file.LogicalName))
-			return err
+			return fmt.Errorf("%s: %w", path.Join(file.Dir, file.LogicalName), err)
 		}
+	}
 
-		// better handling of dates in formats that don't have support for them
-		if mark == parser.FormatToLeadRune("json") || mark == parser.FormatToLeadRune("yaml") {
-			newmetadata := cast.ToStringMap(metadata)
-			for k, v := range newmetadata {
-				switch vv := v.(type) {
-				case time.Time:
-					newmetadata[k] = vv.Format(time.RFC3339)
-				}
-			}
-			metadata = newmetadata
-		}
+	if m, ok := metadata.(map[string]interface{}); ok && len(transforms) > 0 {
+		metadata = applyRewriteTransforms(m, transforms)
+	}
 
-		page.Dir = file.Dir
-		page.SetSourceContent(psr.Content())
-		page.SetSourceMetaData(metadata, mark)
-
-		if OutputDir != "" {
-			page.SaveSourceAs(path.Join(OutputDir, page.FullFilePath()))
-		} else {
-			if Unsafe {
-				page.SaveSource()
-			} else {
-				jww.FEEDBACK.Println("Unsafe operation not allowed, use --unsafe or set a different output path")
-			}
-		}
+	if targetFormat.NormalizeDates != nil {
+		metadata = targetFormat.NormalizeDates(metadata)
 	}
-	return
+
+	page.Dir = file.Dir
+	page.SetSourceContent(psr.Content())
+	page.SetSourceMetaData(metadata, targetFormat.LeadRune)
+
+	inPlacePath := page.FullFilePath()
+	targetPath := inPlacePath
+	if OutputDir != "" {
+		targetPath = path.Join(OutputDir, inPlacePath)
+	}
+
+	if DryRun {
+		return printSourceDiff(page, targetPath)
+	}
+
+	if OutputDir != "" {
+		return saveSourceSafely(page, targetPath)
+	}
+	if Unsafe {
+		return saveSourceSafely(page, inPlacePath)
+	}
+	jww.FEEDBACK.Println("Unsafe operation not allowed, use --unsafe, --dry-run, or set a different output path")
+	return nil
+}
+
+// printSourceDiff prints a unified diff between what's currently on disk at
+// targetPath and what convertContents would write there, without touching
+// the filesystem. It's the backing implementation for --dry-run.
+func printSourceDiff(page *hugolib.Page, targetPath string) error {
+	before, err := os.ReadFile(targetPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	after := page.SourceContent()
+
+	diff := unifiedDiff(targetPath, string(before), string(after))
+	if diff == "" {
+		jww.FEEDBACK.Println(targetPath, "unchanged")
+		return nil
+	}
+
+	fmt.Print(diff)
+	return nil
 }
@@ -0,0 +1,157 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/hugo/hugolib"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// progressEvery caps how often convertPool logs progress on a large content
+// tree: at most once a second, and at most once every progressEvery files,
+// whichever comes first.
+const progressEvery = 100
+
+// convertPool fans a content tree's files out across a bounded number of
+// workers, collecting every per-file error instead of aborting the run on
+// the first one (a single malformed file shouldn't stop convert from
+// finishing the rest of the site).
+type convertPool struct {
+	jobs  chan func() error
+	wg    sync.WaitGroup
+	total int
+
+	done int64 // atomic count of files processed, for progress reporting
+
+	mu     sync.Mutex
+	errs   []error
+	lastAt time.Time
+}
+
+// newConvertPool starts workers workers (at least 1) ready to process up to
+// total files. The job channel is sized to total so run never blocks the
+// caller; for very large trees it still bounds worker concurrency to
+// workers, which is the backpressure that matters.
+func newConvertPool(workers, total int) *convertPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	p := &convertPool{
+		jobs:   make(chan func() error, total),
+		total:  total,
+		lastAt: time.Now(),
+	}
+
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+
+	return p
+}
+
+func (p *convertPool) worker() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		err := job()
+		p.recordResult(err)
+	}
+}
+
+func (p *convertPool) recordResult(err error) {
+	done := atomic.AddInt64(&p.done, 1)
+
+	p.mu.Lock()
+	if err != nil {
+		p.errs = append(p.errs, err)
+	}
+	reportNow := done == int64(p.total) || done%progressEvery == 0 || time.Since(p.lastAt) >= time.Second
+	if reportNow {
+		p.lastAt = time.Now()
+	}
+	p.mu.Unlock()
+
+	if reportNow {
+		jww.FEEDBACK.Printf("converted %d of %d files\n", done, p.total)
+	}
+}
+
+// run enqueues a unit of work. It must not be called after wait.
+func (p *convertPool) run(job func() error) {
+	p.jobs <- job
+}
+
+// wait closes the job queue, blocks until every worker has drained it, and
+// returns the aggregated errors from every failed file, if any.
+func (p *convertPool) wait() error {
+	close(p.jobs)
+	p.wg.Wait()
+
+	if len(p.errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(p.errs))
+	for i, err := range p.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Errorf("%d of %d files failed to convert:\n%s", len(p.errs), p.total, joinLines(msgs))
+}
+
+func joinLines(lines []string) string {
+	out := ""
+	for i, l := range lines {
+		if i > 0 {
+			out += "\n"
+		}
+		out += l
+	}
+	return out
+}
+
+// saveSourceSafely writes a page's source to targetPath without ever
+// leaving a half-written file behind: it writes to a temp file in the same
+// directory, then atomically renames it into place. Needed now that
+// multiple convertPool workers can be writing content files at once.
+func saveSourceSafely(page *hugolib.Page, targetPath string) error {
+	dir := filepath.Dir(targetPath)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".convert-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(page.SourceContent()); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, targetPath)
+}
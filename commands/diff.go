@@ -0,0 +1,115 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unifiedDiff renders a minimal unified diff between before and after,
+// labelling both sides with path. It returns the empty string when the two
+// are identical. This is deliberately small: convert's --dry-run only ever
+// diffs a handful of front matter lines, not arbitrary files, so a classic
+// Myers diff with context windows would be overkill.
+func unifiedDiff(path, before, after string) string {
+	if before == after {
+		return ""
+	}
+
+	beforeLines := splitLines(before)
+	afterLines := splitLines(after)
+
+	ops := diffLines(beforeLines, afterLines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n", path)
+	fmt.Fprintf(&b, "+++ %s\n", path)
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			fmt.Fprintf(&b, " %s\n", op.line)
+		case diffRemove:
+			fmt.Fprintf(&b, "-%s\n", op.line)
+		case diffAdd:
+			fmt.Fprintf(&b, "+%s\n", op.line)
+		}
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffRemove
+	diffAdd
+)
+
+type diffOp struct {
+	kind diffKind
+	line string
+}
+
+// diffLines computes a line-level diff using the standard longest-common-
+// subsequence backtrack.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffRemove, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffAdd, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffRemove, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffAdd, b[j]})
+	}
+	return ops
+}
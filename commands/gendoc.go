@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
 	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/hugo/hugofs"
 	jww "github.com/spf13/jwalterweatherman"
@@ -21,43 +22,137 @@ url: %s
 `
 
 var gendocdir string
+var gendocFormat string
+var gendocSplitBy string
+
 var gendocCmd = &cobra.Command{
 	Use:   "doc",
-	Short: "Generate Markdown documentation for the Hugo CLI.",
-	Long: `Generate Markdown documentation for the Hugo CLI.
+	Short: "Generate documentation for the Hugo CLI.",
+	Long: `Generate documentation for the Hugo CLI in Markdown, man, reST or YAML form.
 
 This command is, mostly, used to create up-to-date documentation
-of Hugo's command-line interface for http://gohugo.io/.
-
-It creates one Markdown file per command with front matter suitable
-for rendering in Hugo.`,
+of Hugo's command-line interface for http://gohugo.io/, but --format=man
+lets distro packagers ship proper man pages without maintaining them out
+of tree.`,
 
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if !strings.HasSuffix(gendocdir, helpers.FilePathSeparator) {
 			gendocdir += helpers.FilePathSeparator
 		}
 		if found, _ := helpers.Exists(gendocdir, hugofs.OsFs); !found {
 			hugofs.OsFs.Mkdir(gendocdir, 0777)
 		}
-		now := time.Now().Format(time.RFC3339)
-		prepender := func(filename string) string {
-			name := filepath.Base(filename)
-			base := strings.TrimSuffix(name, path.Ext(name))
-			url := "/commands/" + strings.ToLower(base) + "/"
-			return fmt.Sprintf(gendocFrontmatterTemplate, now, strings.Replace(base, "_", " ", -1), base, url)
-		}
 
-		linkHandler := func(name string) string {
-			base := strings.TrimSuffix(name, path.Ext(name))
-			return "/commands/" + strings.ToLower(base) + "/"
+		jww.FEEDBACK.Println("Generating Hugo command-line documentation in", gendocdir, "...")
+
+		root := cmd.Root()
+		if gendocSplitBy == "command" {
+			for _, c := range root.Commands() {
+				if err := genDocFor(c, gendocdir); err != nil {
+					return err
+				}
+			}
+		} else {
+			if err := genDocFor(root, gendocdir); err != nil {
+				return err
+			}
 		}
 
-		jww.FEEDBACK.Println("Generating Hugo command-line documentation in", gendocdir, "...")
-		cobra.GenMarkdownTreeCustom(cmd.Root(), gendocdir, prepender, linkHandler)
 		jww.FEEDBACK.Println("Done.")
+
+		return nil
+	},
+}
+
+// genDocFor writes the docs for cmd (and its subcommands) in gendocFormat to dir.
+func genDocFor(cmd *cobra.Command, dir string) error {
+	switch gendocFormat {
+	case "man":
+		header := &doc.GenManHeader{
+			Section: "1",
+			Manual:  "Hugo Manual",
+			Source:  "Hugo",
+		}
+		return doc.GenManTree(cmd, header, dir)
+	case "rest":
+		prepender, linkHandler := gendocHandlers()
+		return doc.GenReSTTreeCustom(cmd, dir, prepender, linkHandler)
+	case "yaml":
+		prepender, linkHandler := gendocHandlers()
+		return doc.GenYamlTreeCustom(cmd, dir, prepender, linkHandler)
+	default:
+		prepender, linkHandler := gendocHandlers()
+		return doc.GenMarkdownTreeCustom(cmd, dir, prepender, linkHandler)
+	}
+}
+
+// gendocHandlers returns the front-matter prepender and link handler shared
+// by the text-based (non-man) formats.
+func gendocHandlers() (func(string) string, func(string) string) {
+	now := time.Now().Format(time.RFC3339)
+
+	prepender := func(filename string) string {
+		name := filepath.Base(filename)
+		base := strings.TrimSuffix(name, path.Ext(name))
+		url := "/commands/" + strings.ToLower(base) + "/"
+		return fmt.Sprintf(gendocFrontmatterTemplate, now, strings.Replace(base, "_", " ", -1), base, url)
+	}
+
+	linkHandler := func(name string) string {
+		base := strings.TrimSuffix(name, path.Ext(name))
+		return "/commands/" + strings.ToLower(base) + "/"
+	}
+
+	return prepender, linkHandler
+}
+
+var gencompletiondir string
+
+var gencompletionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: "Generate shell completion scripts for the Hugo CLI.",
+	Long: `Generate completion scripts for bash, zsh, fish and powershell,
+written to --dir so packagers can ship them alongside the man pages
+produced by "hugo gen doc --format=man".`,
+}
+
+var gencompletionBashCmd = &cobra.Command{
+	Use:   "bash",
+	Short: "Generate bash completion script",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Root().GenBashCompletionFile(filepath.Join(gencompletiondir, "hugo.bash"))
+	},
+}
+
+var gencompletionZshCmd = &cobra.Command{
+	Use:   "zsh",
+	Short: "Generate zsh completion script",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Root().GenZshCompletionFile(filepath.Join(gencompletiondir, "hugo.zsh"))
+	},
+}
+
+var gencompletionFishCmd = &cobra.Command{
+	Use:   "fish",
+	Short: "Generate fish completion script",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Root().GenFishCompletionFile(filepath.Join(gencompletiondir, "hugo.fish"), true)
+	},
+}
+
+var gencompletionPowershellCmd = &cobra.Command{
+	Use:   "powershell",
+	Short: "Generate PowerShell completion script",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Root().GenPowerShellCompletionFile(filepath.Join(gencompletiondir, "hugo.ps1"))
 	},
 }
 
 func init() {
 	gendocCmd.PersistentFlags().StringVar(&gendocdir, "dir", "/tmp/hugodoc/", "the directory to write the doc.")
+	gendocCmd.PersistentFlags().StringVar(&gendocFormat, "format", "markdown", "doc format: markdown, man, rest or yaml")
+	gendocCmd.PersistentFlags().StringVar(&gendocSplitBy, "split-by", "tree", "split generated pages by: tree (single tarball) or command (one per top-level command)")
+
+	gencompletionCmd.PersistentFlags().StringVar(&gencompletiondir, "dir", "/tmp/hugocompletion/", "the directory to write the completion scripts.")
+	gencompletionCmd.AddCommand(gencompletionBashCmd, gencompletionZshCmd, gencompletionFishCmd, gencompletionPowershellCmd)
 }
@@ -16,23 +16,27 @@
 package commands
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/spf13/hugo/parser"
-
 	"regexp"
 
+	"github.com/gohugoio/hugo/hugofs/glob"
+	"github.com/gohugoio/hugo/hugolib/contentaddressable"
+	"github.com/gohugoio/hugo/themes"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"github.com/spf13/fsync"
 	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/hugo/hugofs"
 	"github.com/spf13/hugo/hugolib"
@@ -119,7 +123,62 @@ var hugoCmdV *cobra.Command
 
 // Flags that are to be added to commands.
 var BuildWatch, IgnoreCache, Draft, Future, UglyURLs, CanonifyURLs, Verbose, Logging, VerboseLog, DisableRSS, DisableSitemap, DisableRobotsTXT, PluralizeListTitles, PreserveTaxonomyNames, NoTimes, ForceSync, CleanDestination bool
-var Source, CacheDir, Destination, Theme, BaseURL, CfgFile, LogFile, Editor string
+var Source, CacheDir, Destination, Theme, BaseURL, CfgFile, LogFile, Editor, ManifestFile, Environment string
+var StaticSyncWorkers int
+
+// defaultStaticSyncWorkers is what --staticSyncWorkers falls back to when
+// unset or given a non-positive value.
+const defaultStaticSyncWorkers = 4
+
+// Watcher backend selection and tuning. WatcherBackend is one of
+// watcherBackendAuto/Fsnotify/Poll; WatchDebounce batches events arriving
+// within that window into a single rebuild; WatchIgnore replaces the
+// hard-coded temp-file suffix list with user-supplied globs (matched via
+// hugofs/glob).
+var (
+	WatcherBackend string
+	WatchDebounce  time.Duration
+	WatchIgnore    []string
+)
+
+const (
+	watcherBackendAuto     = "auto"
+	watcherBackendFsnotify = "fsnotify"
+	watcherBackendPoll     = "poll"
+)
+
+// defaultWatchIgnore is what WatchIgnore falls back to when unset, matching
+// the suffixes NewWatcher always used to skip.
+var defaultWatchIgnore = []string{"**/*~", "**/*.swp", "**/*.swx", "**/*.tmp", "**/.goutputstream*", "**/*jb_old___", "**/*jb_bak___", "**/*.DS_Store"}
+
+// effectiveConfigFiles records, in load order, every config file merged
+// into viper for the current run -- the base --config list followed by any
+// --environment overrides -- so buildSite can log what's actually in effect.
+var effectiveConfigFiles []string
+
+// Profile, ProfileOut and StatsOut drive build() to optionally collect a
+// runtime/pprof or runtime/trace profile and/or emit a machine-readable
+// JSON summary of phase durations and page counts, so CI can track
+// build-time regressions across commits. This complements nitro's
+// --stepAnalysis, which is interactive/human-oriented rather than
+// tool-consumable.
+var (
+	Profile    string
+	ProfileOut string
+	StatsOut   string
+)
+
+// defaultProfileOut is what --profileOut falls back to when --profile is
+// set but --profileOut isn't.
+const defaultProfileOut = "profile.out"
+
+// buildStats is the shape written to --statsOut.
+type buildStats struct {
+	TotalMs      int64 `json:"totalMs"`
+	CopyStaticMs int64 `json:"copyStaticMs"`
+	BuildSiteMs  int64 `json:"buildSiteMs"`
+	PageCount    int   `json:"pageCount"`
+}
 
 // Execute adds all child commands to the root command HugoCmd and sets flags appropriately.
 func Execute() {
@@ -151,11 +210,13 @@ func AddCommands() {
 	HugoCmd.AddCommand(listCmd)
 	HugoCmd.AddCommand(undraftCmd)
 	HugoCmd.AddCommand(importCmd)
+	HugoCmd.AddCommand(cacheCmd)
 
 	HugoCmd.AddCommand(genCmd)
 	genCmd.AddCommand(genautocompleteCmd)
 	genCmd.AddCommand(gendocCmd)
 	genCmd.AddCommand(genmanCmd)
+	genCmd.AddCommand(gencompletionCmd)
 }
 
 // initCoreCommonFlags initializes common flags used by Hugo core commands
@@ -175,12 +236,21 @@ func initCoreCommonFlags(cmd *cobra.Command) {
 	cmd.Flags().BoolVar(&UglyURLs, "uglyURLs", false, "if true, use /filename.html instead of /filename/")
 	cmd.Flags().BoolVar(&CanonifyURLs, "canonifyURLs", false, "if true, all relative URLs will be canonicalized using baseURL")
 	cmd.Flags().StringVarP(&BaseURL, "baseURL", "b", "", "hostname (and path) to the root, e.g. http://spf13.com/")
-	cmd.Flags().StringVar(&CfgFile, "config", "", "config file (default is path/config.yaml|json|toml)")
+	cmd.Flags().StringVar(&CfgFile, "config", "", "config file(s), comma-separated (default is path/config.yaml|json|toml)")
+	cmd.Flags().StringVarP(&Environment, "environment", "e", "", "build environment; auto-loads config/ENVIRONMENT/config.{toml,yaml,json} under the source dir on top of the base config")
 	cmd.Flags().StringVar(&Editor, "editor", "", "edit new content with this editor, if provided")
 	cmd.Flags().BoolVar(&nitro.AnalysisOn, "stepAnalysis", false, "display memory and timing of different steps of the program")
 	cmd.Flags().BoolVar(&PluralizeListTitles, "pluralizeListTitles", true, "Pluralize titles in lists using inflect")
 	cmd.Flags().BoolVar(&PreserveTaxonomyNames, "preserveTaxonomyNames", false, `Preserve taxonomy names as written ("Gérard Depardieu" vs "gerard-depardieu")`)
 	cmd.Flags().BoolVarP(&ForceSync, "forceSyncStatic", "", false, "Copy all files when static is changed.")
+	cmd.Flags().StringVar(&ManifestFile, "manifest", "", "write a JSON manifest of published path -> SHA-256 content hash to this file")
+	cmd.Flags().IntVar(&StaticSyncWorkers, "staticSyncWorkers", defaultStaticSyncWorkers, "number of concurrent workers used to hash and copy static files")
+	cmd.Flags().StringVar(&WatcherBackend, "watcher", "", "file-watching backend to use when watching for changes: fsnotify, poll, or auto (default)")
+	cmd.Flags().DurationVar(&WatchDebounce, "watchDebounce", 0, "batch filesystem events arriving within this window into a single rebuild, deduplicating repeated paths and folding a remove immediately followed by a recreate into a single sync (default 200ms)")
+	cmd.Flags().StringSliceVar(&WatchIgnore, "watchIgnore", nil, "glob patterns of paths to ignore when watching for changes")
+	cmd.Flags().StringVar(&Profile, "profile", "", "collect a build profile of this kind: cpu, mem, or trace")
+	cmd.Flags().StringVar(&ProfileOut, "profileOut", defaultProfileOut, "file to write the --profile output to")
+	cmd.Flags().StringVar(&StatsOut, "statsOut", "", "write a JSON summary of build phase durations and page counts to this file")
 	// For bash-completion
 	validConfigFilenames := []string{"json", "js", "yaml", "yml", "toml", "tml"}
 	cmd.Flags().SetAnnotation("config", cobra.BashCompFilenameExt, validConfigFilenames)
@@ -252,26 +322,20 @@ func LoadDefaultSettings() {
 	viper.SetDefault("SectionPagesMenu", "")
 	viper.SetDefault("DisablePathToLower", false)
 	viper.SetDefault("HasCJKLanguage", false)
+	viper.SetDefault("Watcher", watcherBackendAuto)
+	viper.SetDefault("WatchDebounce", 200*time.Millisecond)
+	viper.SetDefault("WatchIgnore", defaultWatchIgnore)
 }
 
 // InitializeConfig initializes a config file with sensible default configuration flags.
 // A Hugo command that calls initCoreCommonFlags() can pass itself
 // as an argument to have its command-line flags processed here.
 func InitializeConfig(subCmdVs ...*cobra.Command) error {
-	viper.SetConfigFile(CfgFile)
-	// See https://github.com/spf13/viper/issues/73#issuecomment-126970794
-	if Source == "" {
-		viper.AddConfigPath(".")
-	} else {
-		viper.AddConfigPath(Source)
-	}
-	err := viper.ReadInConfig()
-	if err != nil {
+	if err := loadConfigFiles(); err != nil {
 		if _, ok := err.(viper.ConfigParseError); ok {
 			return newSystemError(err)
-		} else {
-			return newSystemErrorF("Unable to locate Config file. Perhaps you need to create a new site.\n       Run `hugo help new` for details. (%s)\n", err)
 		}
+		return newSystemErrorF("Unable to locate Config file. Perhaps you need to create a new site.\n       Run `hugo help new` for details. (%s)\n", err)
 	}
 
 	viper.RegisterAlias("indexes", "taxonomies")
@@ -325,6 +389,15 @@ func InitializeConfig(subCmdVs ...*cobra.Command) error {
 		if cmdV.Flags().Lookup("ignoreCache").Changed {
 			viper.Set("IgnoreCache", IgnoreCache)
 		}
+		if cmdV.Flags().Lookup("watcher").Changed {
+			viper.Set("Watcher", WatcherBackend)
+		}
+		if cmdV.Flags().Lookup("watchDebounce").Changed {
+			viper.Set("WatchDebounce", WatchDebounce)
+		}
+		if cmdV.Flags().Lookup("watchIgnore").Changed {
+			viper.Set("WatchIgnore", WatchIgnore)
+		}
 	}
 
 	if hugoCmdV.Flags().Lookup("noTimes").Changed {
@@ -390,7 +463,7 @@ func InitializeConfig(subCmdVs ...*cobra.Command) error {
 		jww.SetLogThreshold(jww.LevelInfo)
 	}
 
-	jww.INFO.Println("Using config file:", viper.ConfigFileUsed())
+	jww.INFO.Println("Using config file(s):", strings.Join(effectiveConfigFiles, ", "))
 
 	themeDir := helpers.GetThemeDir()
 	if themeDir != "" {
@@ -399,16 +472,103 @@ func InitializeConfig(subCmdVs ...*cobra.Command) error {
 		}
 	}
 
-	themeVersionMismatch, minVersion := isThemeVsHugoVersionMismatch()
+	for _, issue := range validateThemeManifest() {
+		jww.ERROR.Println(issue)
+	}
 
-	if themeVersionMismatch {
-		jww.ERROR.Printf("Current theme does not support Hugo version %s. Minimum version required is %s\n",
-			helpers.HugoReleaseVersion(), minVersion)
+	return nil
+}
+
+// loadConfigFiles loads CfgFile, a comma-separated list of config files,
+// into viper -- the first file replaces viper's config outright and every
+// subsequent one is merged on top of it, letting later files override keys
+// set by earlier ones -- then, if Environment is set, merges in whichever
+// of config/<Environment>/config.{toml,yaml,json} exist under the source
+// dir, in that order, so they take precedence over the base --config list.
+// The files actually used end up in effectiveConfigFiles, in load order.
+func loadConfigFiles() error {
+	effectiveConfigFiles = nil
+
+	loadedAny := false
+	for _, f := range strings.Split(CfgFile, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if err := mergeOrReadConfig(f, loadedAny); err != nil {
+			return err
+		}
+		loadedAny = true
+		effectiveConfigFiles = append(effectiveConfigFiles, f)
+	}
+
+	if !loadedAny {
+		// No explicit --config given: fall back to the pre-multi-config
+		// behaviour of discovering "config.*" on the config path.
+		viper.SetConfigFile(CfgFile)
+		// See https://github.com/spf13/viper/issues/73#issuecomment-126970794
+		if Source == "" {
+			viper.AddConfigPath(".")
+		} else {
+			viper.AddConfigPath(Source)
+		}
+		if err := viper.ReadInConfig(); err != nil {
+			return err
+		}
+		effectiveConfigFiles = append(effectiveConfigFiles, viper.ConfigFileUsed())
+	}
+
+	if Environment != "" {
+		envFiles, err := discoverEnvironmentConfigFiles(Environment)
+		if err != nil {
+			return err
+		}
+		for _, f := range envFiles {
+			if err := mergeOrReadConfig(f, true); err != nil {
+				return err
+			}
+			effectiveConfigFiles = append(effectiveConfigFiles, f)
+		}
 	}
 
 	return nil
 }
 
+// mergeOrReadConfig loads f into viper: merge false replaces viper's config
+// outright (SetConfigFile+ReadInConfig), merge true layers f on top of
+// whatever's already loaded (SetConfigFile+MergeInConfig).
+func mergeOrReadConfig(f string, merge bool) error {
+	viper.SetConfigFile(f)
+	if merge {
+		return viper.MergeInConfig()
+	}
+	return viper.ReadInConfig()
+}
+
+// discoverEnvironmentConfigFiles looks for config/<env>/config.{toml,yaml,json}
+// under Source (or the working directory, if Source isn't set), returning
+// whichever exist, in that extension order.
+func discoverEnvironmentConfigFiles(env string) ([]string, error) {
+	base := Source
+	if base == "" {
+		base = "."
+	}
+	envDir := filepath.Join(base, "config", env)
+
+	var found []string
+	for _, ext := range []string{"toml", "yaml", "json"} {
+		candidate := filepath.Join(envDir, "config."+ext)
+		exists, err := helpers.Exists(candidate, hugofs.SourceFs)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			found = append(found, candidate)
+		}
+	}
+	return found, nil
+}
+
 func watchConfig() {
 	viper.WatchConfig()
 	viper.OnConfigChange(func(e fsnotify.Event) {
@@ -422,17 +582,61 @@ func watchConfig() {
 }
 
 func build(watches ...bool) error {
+	stopProfiling, err := startProfiling()
+	if err != nil {
+		return fmt.Errorf("Error starting profiler: %s", err)
+	}
+
+	buildStart := time.Now()
 
+	copyStaticStart := time.Now()
 	if err := copyStatic(); err != nil {
 		return fmt.Errorf("Error copying static files to %s: %s", helpers.AbsPathify(viper.GetString("PublishDir")), err)
 	}
+	copyStaticMs := int64(1000 * time.Since(copyStaticStart).Seconds())
+
 	watch := false
 	if len(watches) > 0 && watches[0] {
 		watch = true
 	}
+
+	buildSiteStart := time.Now()
 	if err := buildSite(BuildWatch || watch); err != nil {
 		return fmt.Errorf("Error building site: %s", err)
 	}
+	buildSiteMs := int64(1000 * time.Since(buildSiteStart).Seconds())
+
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir")) + helpers.FilePathSeparator
+	if publishDir == "//" {
+		publishDir = helpers.FilePathSeparator
+	}
+	if err := globalStaticProvenance.claimContentFromPublishDir(hugofs.DestinationFS, publishDir); err != nil {
+		jww.WARN.Println("Unable to update static/content provenance index:", err)
+	}
+
+	if err := stopProfiling(); err != nil {
+		jww.ERROR.Println("Error stopping profiler:", err)
+	}
+
+	if StatsOut != "" {
+		stats := buildStats{
+			TotalMs:      int64(1000 * time.Since(buildStart).Seconds()),
+			CopyStaticMs: copyStaticMs,
+			BuildSiteMs:  buildSiteMs,
+		}
+		if mainSite != nil {
+			stats.PageCount = len(mainSite.Pages)
+		}
+		if err := writeBuildStats(stats); err != nil {
+			jww.ERROR.Println("Error writing build stats:", err)
+		}
+	}
+
+	if ManifestFile != "" {
+		if err := writeManifest(ManifestFile); err != nil {
+			return fmt.Errorf("Error writing manifest to %s: %s", ManifestFile, err)
+		}
+	}
 
 	if BuildWatch {
 		jww.FEEDBACK.Println("Watching for changes in", helpers.AbsPathify(viper.GetString("ContentDir")))
@@ -443,6 +647,67 @@ func build(watches ...bool) error {
 	return nil
 }
 
+// startProfiling starts collecting the profile kind named by --profile (cpu,
+// mem, or trace) to --profileOut, returning a stop func that finalizes and
+// closes it. If --profile is unset, it returns a no-op stop func.
+func startProfiling() (stop func() error, err error) {
+	noop := func() error { return nil }
+
+	if Profile == "" {
+		return noop, nil
+	}
+
+	out := ProfileOut
+	if out == "" {
+		out = defaultProfileOut
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return nil, err
+	}
+
+	switch Profile {
+	case "cpu":
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		}, nil
+	case "mem":
+		return func() error {
+			defer f.Close()
+			return pprof.WriteHeapProfile(f)
+		}, nil
+	case "trace":
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return func() error {
+			trace.Stop()
+			return f.Close()
+		}, nil
+	default:
+		f.Close()
+		return nil, fmt.Errorf("unrecognized --profile kind %q (must be cpu, mem, or trace)", Profile)
+	}
+}
+
+// writeBuildStats writes stats as JSON to StatsOut.
+func writeBuildStats(stats buildStats) error {
+	f, err := hugofs.SourceFs.Create(StatsOut)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(stats)
+}
+
 func getStaticSourceFs() afero.Fs {
 	source := hugofs.SourceFs
 	themeDir, err := helpers.GetThemeStaticDirPath()
@@ -488,6 +753,224 @@ func getStaticSourceFs() afero.Fs {
 	return afero.NewCopyOnWriteFs(base, overlay)
 }
 
+// writeManifest walks the already-published output under PublishDir,
+// hashes each file with contentaddressable.Manifest, and writes the result
+// as JSON to manifestPath. It runs after the site and static files are
+// written, so -- unlike contentaddressable.Writer's Accept/Abort -- it
+// records what landed on disk rather than gating the write itself; wiring
+// the Writer into the publish path so every file gets atomic, verified
+// writes is left to that package's callers.
+func writeManifest(manifestPath string) error {
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir")) + helpers.FilePathSeparator
+
+	manifest := contentaddressable.NewManifest()
+
+	err := afero.Walk(hugofs.DestinationFS, publishDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := afero.ReadFile(hugofs.DestinationFS, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		rel := strings.TrimPrefix(p, publishDir)
+		manifest.Record(filepath.ToSlash(rel), hex.EncodeToString(sum[:]))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := hugofs.SourceFs.Create(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = manifest.WriteTo(f)
+	return err
+}
+
+// staticHashIndexFilename is where copyStatic persists each synced static
+// file's content hash, keyed by its path relative to the static root, under
+// CacheDir. Reloading it on the next cold build lets that build skip
+// rehashing+recopying files whose content hasn't changed either.
+const staticHashIndexFilename = "static-hash-index.json"
+
+func staticHashIndexPath() string {
+	return filepath.Join(viper.GetString("CacheDir"), staticHashIndexFilename)
+}
+
+// loadStaticHashIndex reads the persisted path->SHA-256 index, returning an
+// empty one if it doesn't exist yet or can't be parsed.
+func loadStaticHashIndex() *contentaddressable.Manifest {
+	manifest := contentaddressable.NewManifest()
+
+	b, err := afero.ReadFile(hugofs.SourceFs, staticHashIndexPath())
+	if err != nil {
+		return manifest
+	}
+
+	var entries map[string]string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return manifest
+	}
+	for p, sha := range entries {
+		manifest.Record(p, sha)
+	}
+	return manifest
+}
+
+// saveStaticHashIndex persists index to CacheDir so the next build can
+// reuse it.
+func saveStaticHashIndex(index *contentaddressable.Manifest) error {
+	f, err := hugofs.SourceFs.Create(staticHashIndexPath())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = index.WriteTo(f)
+	return err
+}
+
+// syncStaticFile hashes relPath's content in srcFs and copies it to destFs
+// at filepath.Join(destRoot, relPath) unless index already has a matching
+// hash recorded for relPath, in which case it's left untouched. It reports
+// whether a copy happened.
+func syncStaticFile(srcFs, destFs afero.Fs, destRoot, relPath string, info os.FileInfo, index *contentaddressable.Manifest, noTimes bool) (bool, error) {
+	content, err := afero.ReadFile(srcFs, relPath)
+	if err != nil {
+		return false, err
+	}
+
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+
+	if existing, ok := index.Get(relPath); ok && existing == sha {
+		return false, nil
+	}
+
+	destPath := filepath.Join(destRoot, relPath)
+	if err := destFs.MkdirAll(filepath.Dir(destPath), 0o777); err != nil {
+		return false, err
+	}
+	if err := afero.WriteFile(destFs, destPath, content, info.Mode()); err != nil {
+		return false, err
+	}
+	if !noTimes {
+		destFs.Chtimes(destPath, info.ModTime(), info.ModTime())
+	}
+
+	index.Record(relPath, sha)
+
+	return true, nil
+}
+
+// syncStaticRelPath stats relPath in srcFs and, if it still exists, syncs
+// just that one file via syncStaticFile. Used by the watcher to react to a
+// single changed/renamed static file without re-walking the whole tree.
+func syncStaticRelPath(srcFs, destFs afero.Fs, destRoot, relPath string, index *contentaddressable.Manifest) error {
+	info, err := srcFs.Stat(relPath)
+	if err != nil {
+		return err
+	}
+	_, err = syncStaticFile(srcFs, destFs, destRoot, relPath, info, index, viper.GetBool("notimes"))
+	return err
+}
+
+// syncStaticFiles walks srcFs (a static-source union Fs rooted at "/") and
+// copies every changed file, as determined by syncStaticFile, to destFs
+// under destRoot, spreading the hashing/copying across workers goroutines.
+// It returns the set of relative paths it found in srcFs (for the caller to
+// use when cleaning up files deleted from the source) and the number of
+// files actually copied.
+func syncStaticFiles(srcFs, destFs afero.Fs, destRoot string, index *contentaddressable.Manifest, workers int) (map[string]bool, int, error) {
+	if workers < 1 {
+		workers = defaultStaticSyncWorkers
+	}
+	noTimes := viper.GetBool("notimes")
+
+	type file struct {
+		relPath string
+		info    os.FileInfo
+	}
+
+	var files []file
+	seen := make(map[string]bool)
+	err := afero.Walk(srcFs, "", func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		relPath := strings.TrimPrefix(filepath.ToSlash(p), "/")
+		seen[relPath] = true
+		files = append(files, file{relPath: relPath, info: info})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	jobs := make(chan file)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	copied := 0
+	var firstErr error
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				didCopy, err := syncStaticFile(srcFs, destFs, destRoot, f.relPath, f.info, index, noTimes)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else if didCopy {
+					copied++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+
+	return seen, copied, firstErr
+}
+
+// cleanStaticDestination removes every file under destRoot in destFs whose
+// path, relative to destRoot, isn't in known -- i.e. no longer exists in
+// any static source directory.
+func cleanStaticDestination(destFs afero.Fs, destRoot string, known map[string]bool) error {
+	var toRemove []string
+	err := afero.Walk(destFs, destRoot, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(strings.TrimPrefix(p, destRoot), "/"))
+		if !known[rel] {
+			toRemove = append(toRemove, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range toRemove {
+		if err := destFs.Remove(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func copyStatic() error {
 	publishDir := helpers.AbsPathify(viper.GetString("PublishDir")) + helpers.FilePathSeparator
 
@@ -504,24 +987,30 @@ func copyStatic() error {
 		return nil
 	}
 
-	syncer := fsync.NewSyncer()
-	syncer.NoTimes = viper.GetBool("notimes")
-	syncer.SrcFs = staticSourceFs
-	syncer.DestFs = hugofs.DestinationFS
+	index := loadStaticHashIndex()
+
+	jww.INFO.Println("syncing static files to", publishDir)
+	known, copied, err := syncStaticFiles(staticSourceFs, hugofs.DestinationFS, publishDir, index, StaticSyncWorkers)
+	if err != nil {
+		return err
+	}
+	globalStaticProvenance.syncStatic(known)
+
 	// Now that we are using a unionFs for the static directories
 	// We can effectively clean the publishDir on initial sync
-	syncer.Delete = viper.GetBool("cleanDestinationDir")
-	if syncer.Delete {
+	if viper.GetBool("cleanDestinationDir") {
 		jww.INFO.Println("removing all files from destination that don't exist in static dirs")
+		if err := cleanStaticDestination(hugofs.DestinationFS, publishDir, known); err != nil {
+			return err
+		}
 	}
-	jww.INFO.Println("syncing static files to", publishDir)
 
-	// because we are using a baseFs (to get the union right).
-	// set sync src to root
-	err := syncer.Sync(publishDir, helpers.FilePathSeparator)
-	if err != nil {
-		return err
+	if err := saveStaticHashIndex(index); err != nil {
+		jww.WARN.Println("Unable to persist static file hash index:", err)
 	}
+
+	jww.INFO.Printf("synced %d changed static file(s) out of %d total\n", copied, len(known))
+
 	return nil
 }
 
@@ -586,6 +1075,10 @@ func getDirList() []string {
 
 func buildSite(watching ...bool) (err error) {
 	startTime := time.Now()
+	livereload.PublishEvent(livereload.Event{Type: livereload.EventBuildStarted})
+	if len(effectiveConfigFiles) > 0 {
+		jww.INFO.Println("Building site using config file(s):", strings.Join(effectiveConfigFiles, ", "))
+	}
 	if mainSite == nil {
 		mainSite = new(hugolib.Site)
 	}
@@ -594,62 +1087,295 @@ func buildSite(watching ...bool) (err error) {
 	}
 	err = mainSite.Build()
 	if err != nil {
+		livereload.PublishEvent(livereload.Event{
+			Type:       livereload.EventBuildFinished,
+			DurationMS: time.Since(startTime).Milliseconds(),
+			Err:        err.Error(),
+		})
 		return err
 	}
 	mainSite.Stats()
 	jww.FEEDBACK.Printf("in %v ms\n", int(1000*time.Since(startTime).Seconds()))
+	livereload.PublishEvent(livereload.Event{Type: livereload.EventBuildFinished, DurationMS: time.Since(startTime).Milliseconds()})
 
 	return nil
 }
 
 func rebuildSite(events []fsnotify.Event) error {
 	startTime := time.Now()
+	livereload.PublishEvent(livereload.Event{Type: livereload.EventBuildStarted})
 	err := mainSite.ReBuild(events)
 	if err != nil {
+		livereload.PublishEvent(livereload.Event{
+			Type:       livereload.EventBuildFinished,
+			DurationMS: time.Since(startTime).Milliseconds(),
+			Err:        err.Error(),
+		})
 		return err
 	}
 	mainSite.Stats()
+
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir")) + helpers.FilePathSeparator
+	if publishDir == "//" {
+		publishDir = helpers.FilePathSeparator
+	}
+	if err := globalStaticProvenance.claimContentFromPublishDir(hugofs.DestinationFS, publishDir); err != nil {
+		jww.WARN.Println("Unable to update static/content provenance index:", err)
+	}
+
 	jww.FEEDBACK.Printf("in %v ms\n", int(1000*time.Since(startTime).Seconds()))
+	livereload.PublishEvent(livereload.Event{Type: livereload.EventBuildFinished, DurationMS: time.Since(startTime).Milliseconds()})
 
 	return nil
 }
 
 // NewWatcher creates a new watcher to watch filesystem events.
+// watchBackend is the minimal surface NewWatcher needs from a file watcher:
+// something that can be pointed at directories and that delivers batched
+// fsnotify events. fsnotifyWatcher wraps the fsnotify-based batching watcher
+// Hugo has always used; pollWatcher is the fallback for filesystems where
+// that doesn't work (network mounts, some container bind mounts).
+type watchBackend interface {
+	Add(path string) error
+	Close() error
+	EventsChan() <-chan []fsnotify.Event
+	ErrorsChan() <-chan error
+}
+
+// fsnotifyWatcher adapts *watcher.Watcher, whose Events/Errors are plain
+// channel fields rather than methods, to watchBackend.
+type fsnotifyWatcher struct {
+	w *watcher.Watcher
+}
+
+func (f fsnotifyWatcher) Add(path string) error               { return f.w.Add(path) }
+func (f fsnotifyWatcher) Close() error                        { return f.w.Close() }
+func (f fsnotifyWatcher) EventsChan() <-chan []fsnotify.Event { return f.w.Events }
+func (f fsnotifyWatcher) ErrorsChan() <-chan error            { return f.w.Errors }
+
+// pollWatcher implements watchBackend by stat-ing every file below its
+// watched roots on a fixed interval and diffing the result against the
+// previous pass, synthesizing fsnotify.Event values for anything that
+// changed. It's slower and coarser than fsnotify, but it works anywhere
+// a plain filepath.Walk does.
+type pollWatcher struct {
+	interval time.Duration
+	ignore   []string
+
+	mu    sync.Mutex
+	roots []string
+	snap  map[string]time.Time
+
+	events chan []fsnotify.Event
+	errors chan error
+	done   chan struct{}
+}
+
+func newPollWatcher(interval time.Duration, ignore []string) *pollWatcher {
+	pw := &pollWatcher{
+		interval: interval,
+		ignore:   ignore,
+		snap:     make(map[string]time.Time),
+		events:   make(chan []fsnotify.Event),
+		errors:   make(chan error),
+		done:     make(chan struct{}),
+	}
+	go pw.run()
+	return pw
+}
+
+func (pw *pollWatcher) Add(path string) error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.roots = append(pw.roots, path)
+	return nil
+}
+
+func (pw *pollWatcher) Close() error {
+	close(pw.done)
+	return nil
+}
+
+func (pw *pollWatcher) EventsChan() <-chan []fsnotify.Event { return pw.events }
+func (pw *pollWatcher) ErrorsChan() <-chan error            { return pw.errors }
+
+func (pw *pollWatcher) isIgnored(path string) bool {
+	for _, pattern := range pw.ignore {
+		if ok, err := glob.Matches(pattern, filepath.ToSlash(path)); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (pw *pollWatcher) run() {
+	ticker := time.NewTicker(pw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-pw.done:
+			return
+		case <-ticker.C:
+			pw.poll()
+		}
+	}
+}
+
+func (pw *pollWatcher) poll() {
+	pw.mu.Lock()
+	roots := append([]string(nil), pw.roots...)
+	pw.mu.Unlock()
+
+	current := make(map[string]time.Time)
+	for _, root := range roots {
+		_ = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || pw.isIgnored(path) {
+				return nil
+			}
+			current[path] = info.ModTime()
+			return nil
+		})
+	}
+
+	pw.mu.Lock()
+	previous := pw.snap
+	pw.snap = current
+	pw.mu.Unlock()
+
+	var evs []fsnotify.Event
+	for path, mtime := range current {
+		if prevMtime, ok := previous[path]; !ok {
+			evs = append(evs, fsnotify.Event{Name: path, Op: fsnotify.Create})
+		} else if !mtime.Equal(prevMtime) {
+			evs = append(evs, fsnotify.Event{Name: path, Op: fsnotify.Write})
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			evs = append(evs, fsnotify.Event{Name: path, Op: fsnotify.Remove})
+		}
+	}
+
+	if len(evs) > 0 {
+		pw.events <- evs
+	}
+}
+
+// isIgnoredWatchPath reports whether path matches one of the WatchIgnore
+// globs, replacing the old hardcoded list of temp-file suffixes used by
+// editors and OS trash/indexing tools.
+func isIgnoredWatchPath(path string, ignore []string) bool {
+	slashed := filepath.ToSlash(path)
+	for _, pattern := range ignore {
+		if ok, err := glob.Matches(pattern, slashed); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// coalesceEvents collapses a raw batch of events down to one entry per
+// path, merging repeated events within the same debounce window and
+// folding a Remove immediately followed by a Create for the same path into
+// a single Write. That's what editors and IDEs which save via a
+// temp-file-then-rename dance (Vim, JetBrains) actually produce for one
+// logical save, and without this the Remove/Rename handling below can race
+// the delete against the recreate.
+func coalesceEvents(events []fsnotify.Event) []fsnotify.Event {
+	order := make([]string, 0, len(events))
+	merged := make(map[string]fsnotify.Op, len(events))
+
+	for _, ev := range events {
+		if ev.Name == "" {
+			continue
+		}
+		if _, seen := merged[ev.Name]; !seen {
+			order = append(order, ev.Name)
+		}
+		merged[ev.Name] |= ev.Op
+	}
+
+	coalesced := make([]fsnotify.Event, 0, len(order))
+	for _, name := range order {
+		op := merged[name]
+		if op&fsnotify.Remove == fsnotify.Remove && op&fsnotify.Create == fsnotify.Create {
+			op = (op &^ fsnotify.Remove) | fsnotify.Write
+		}
+		coalesced = append(coalesced, fsnotify.Event{Name: name, Op: op})
+	}
+
+	return coalesced
+}
+
+// resolveWatcher builds the watchBackend named by backend, which is one of
+// watcherBackendFsnotify, watcherBackendPoll or watcherBackendAuto (the
+// default, which tries fsnotify first and falls back to polling if that
+// fails to initialize).
+func resolveWatcher(backend string, debounce time.Duration, ignore []string) (watchBackend, error) {
+	switch backend {
+	case watcherBackendPoll:
+		return newPollWatcher(debounce, ignore), nil
+	case watcherBackendFsnotify:
+		w, err := watcher.New(debounce)
+		if err != nil {
+			return nil, err
+		}
+		return fsnotifyWatcher{w}, nil
+	case watcherBackendAuto, "":
+		w, err := watcher.New(debounce)
+		if err != nil {
+			jww.WARN.Println("fsnotify watcher unavailable, falling back to polling:", err)
+			return newPollWatcher(debounce, ignore), nil
+		}
+		return fsnotifyWatcher{w}, nil
+	default:
+		return nil, fmt.Errorf("unrecognized --watcher backend %q (must be fsnotify, poll, or auto)", backend)
+	}
+}
+
 func NewWatcher(port int) error {
 	if runtime.GOOS == "darwin" {
 		tweakLimit()
 	}
 
-	watcher, err := watcher.New(1 * time.Second)
+	staticHashIndex := loadStaticHashIndex()
+
+	debounce := viper.GetDuration("WatchDebounce")
+	ignorePatterns := viper.GetStringSlice("WatchIgnore")
+	if len(ignorePatterns) == 0 {
+		ignorePatterns = defaultWatchIgnore
+	}
+
+	fw, err := resolveWatcher(viper.GetString("Watcher"), debounce, ignorePatterns)
 	var wg sync.WaitGroup
 
 	if err != nil {
 		return err
 	}
 
-	defer watcher.Close()
+	defer fw.Close()
 
 	wg.Add(1)
 
 	for _, d := range getDirList() {
 		if d != "" {
-			_ = watcher.Add(d)
+			_ = fw.Add(d)
 		}
 	}
 
 	go func() {
 		for {
 			select {
-			case evs := <-watcher.Events:
+			case evs := <-fw.EventsChan():
+				evs = coalesceEvents(evs)
 				jww.INFO.Println("Received System Events:", evs)
 
 				staticEvents := []fsnotify.Event{}
 				dynamicEvents := []fsnotify.Event{}
 
 				for _, ev := range evs {
-					ext := filepath.Ext(ev.Name)
-					istemp := strings.HasSuffix(ext, "~") || (ext == ".swp") || (ext == ".swx") || (ext == ".tmp") || strings.HasPrefix(ext, ".goutputstream") || strings.HasSuffix(ext, "jb_old___") || strings.HasSuffix(ext, "jb_bak___") || (ext == ".DS_Store")
-					if istemp {
+					if isIgnoredWatchPath(ev.Name, ignorePatterns) {
 						continue
 					}
 
@@ -673,7 +1399,7 @@ func NewWatcher(port int) error {
 					walkAdder := func(path string, f os.FileInfo, err error) error {
 						if f.IsDir() {
 							jww.FEEDBACK.Println("adding created directory to watchlist", path)
-							watcher.Add(path)
+							fw.Add(path)
 						}
 						return nil
 					}
@@ -721,30 +1447,15 @@ func NewWatcher(port int) error {
 							return
 						}
 
-						syncer := fsync.NewSyncer()
-						syncer.NoTimes = viper.GetBool("notimes")
-						syncer.SrcFs = staticSourceFs
-						syncer.DestFs = hugofs.DestinationFS
-
 						// prevent spamming the log on changes
 						logger := helpers.NewDistinctFeedbackLogger()
 
 						for _, ev := range staticEvents {
 							// Due to our approach of layering both directories and the content's rendered output
-							// into one we can't accurately remove a file not in one of the source directories.
-							// If a file is in the local static dir and also in the theme static dir and we remove
-							// it from one of those locations we expect it to still exist in the destination
-							//
-							// If Hugo generates a file (from the content dir) over a static file
-							// the content generated file should take precedence.
-							//
-							// Because we are now watching and handling individual events it is possible that a static
-							// event that occupies the same path as a content generated file will take precedence
-							// until a regeneration of the content takes places.
-							//
-							// Hugo assumes that these cases are very rare and will permit this bad behavior
-							// The alternative is to track every single file and which pipeline rendered it
-							// and then to handle conflict resolution on every event.
+							// into one, globalStaticProvenance (see provenance.go) tracks which source pipeline(s)
+							// -- the merged static directories or content/data/theme templates -- currently claim
+							// each published path, so a static Remove/Rename below doesn't delete a path another
+							// pipeline still owns.
 
 							fromPath := ev.Name
 
@@ -759,21 +1470,27 @@ func NewWatcher(port int) error {
 							// Hugo takes the following approach:
 							// If the static file exists in any of the static source directories after this event
 							// Hugo will re-sync it.
-							// If it does not exist in all of the static directories Hugo will remove it.
-							//
-							// This assumes that Hugo has not generated content on top of a static file and then removed
-							// the source of that static file. In this case Hugo will incorrectly remove that file
-							// from the published directory.
+							// If it does not exist in any of the static directories, Hugo releases this pipeline's
+							// claim on relPath and only removes the published file if no other pipeline
+							// (content/data/theme, or another static source) still claims it.
 							if ev.Op&fsnotify.Rename == fsnotify.Rename || ev.Op&fsnotify.Remove == fsnotify.Remove {
 								if _, err := staticSourceFs.Stat(relPath); os.IsNotExist(err) {
-									// If file doesn't exist in any static dir, remove it
-									toRemove := filepath.Join(publishDir, relPath)
-									logger.Println("File no longer exists in static dir, removing", toRemove)
-									hugofs.DestinationFS.RemoveAll(toRemove)
+									globalStaticProvenance.release(relPath, provenanceStatic)
+									if globalStaticProvenance.claimedBesides(relPath, provenanceStatic) {
+										logger.Println("File removed from static dir but still claimed by another source, leaving in place:", relPath)
+									} else {
+										toRemove := filepath.Join(publishDir, relPath)
+										logger.Println("File no longer exists in static dir, removing", toRemove)
+										hugofs.DestinationFS.RemoveAll(toRemove)
+									}
 								} else if err == nil {
 									// If file still exists, sync it
 									logger.Println("Syncing", relPath, "to", publishDir)
-									syncer.Sync(filepath.Join(publishDir, relPath), relPath)
+									if err := syncStaticRelPath(staticSourceFs, hugofs.DestinationFS, publishDir, relPath, staticHashIndex); err != nil {
+										jww.ERROR.Println(err)
+									} else {
+										globalStaticProvenance.claim(relPath, provenanceStatic)
+									}
 								} else {
 									jww.ERROR.Println(err)
 								}
@@ -783,7 +1500,15 @@ func NewWatcher(port int) error {
 
 							// For all other event operations Hugo will sync static.
 							logger.Println("Syncing", relPath, "to", publishDir)
-							syncer.Sync(filepath.Join(publishDir, relPath), relPath)
+							if err := syncStaticRelPath(staticSourceFs, hugofs.DestinationFS, publishDir, relPath, staticHashIndex); err != nil {
+								jww.ERROR.Println(err)
+							} else {
+								globalStaticProvenance.claim(relPath, provenanceStatic)
+							}
+						}
+
+						if err := saveStaticHashIndex(staticHashIndex); err != nil {
+							jww.WARN.Println("Unable to persist static file hash index:", err)
 						}
 					}
 
@@ -815,7 +1540,7 @@ func NewWatcher(port int) error {
 						livereload.ForceRefresh()
 					}
 				}
-			case err := <-watcher.Errors:
+			case err := <-fw.ErrorsChan():
 				if err != nil {
 					fmt.Println("error:", err)
 				}
@@ -828,6 +1553,7 @@ func NewWatcher(port int) error {
 			livereload.Initialize()
 			http.HandleFunc("/livereload.js", livereload.ServeJS)
 			http.HandleFunc("/livereload", livereload.Handler)
+			http.HandleFunc("/livereload/events", livereload.EventsHandler)
 		}
 
 		go serve(port)
@@ -837,57 +1563,63 @@ func NewWatcher(port int) error {
 	return nil
 }
 
-// isThemeVsHugoVersionMismatch returns whether the current Hugo version is
-// less than the theme's min_version.
-func isThemeVsHugoVersionMismatch() (mismatch bool, requiredMinVersion string) {
+// validateThemeManifest parses the active theme's theme.toml, if it has
+// one, and checks it against the running Hugo version plus whichever
+// dependency themes it declares, returning every problem found -- each as
+// an actionable, ready-to-print message -- rather than stopping at the
+// first one. It replaces the old isThemeVsHugoVersionMismatch, which only
+// understood a scalar min_version as a float32/float64 and had no notion
+// of an upper bound or dependencies.
+func validateThemeManifest() (issues []string) {
 	if !helpers.ThemeSet() {
-		return
+		return nil
 	}
 
 	themeDir := helpers.GetThemeDir()
-
 	fs := hugofs.SourceFs
-	path := filepath.Join(themeDir, "theme.toml")
-
-	exists, err := helpers.Exists(path, fs)
 
-	if err != nil || !exists {
-		return
+	m, err := loadThemeManifest(fs, themeDir)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if m == nil {
+		// No theme.toml: nothing to validate.
+		return nil
 	}
 
-	f, err := fs.Open(path)
-
-	if err != nil {
-		return
+	if err := m.CheckVersion(helpers.HugoReleaseVersion()); err != nil {
+		issues = append(issues, err.Error())
 	}
 
-	defer f.Close()
+	themesRoot := filepath.Dir(themeDir)
+	issues = append(issues, m.CheckDependencies(func(name string) bool {
+		exists, err := helpers.Exists(filepath.Join(themesRoot, name), fs)
+		return err == nil && exists
+	})...)
 
-	b, err := ioutil.ReadAll(f)
+	return issues
+}
 
-	if err != nil {
-		return
-	}
+// loadThemeManifest reads and parses themeDir's theme.toml. A theme without
+// one is valid -- just unversioned -- so that case returns a nil Manifest
+// and a nil error rather than an error.
+func loadThemeManifest(fs afero.Fs, themeDir string) (*themes.Manifest, error) {
+	path := filepath.Join(themeDir, "theme.toml")
 
-	c, err := parser.HandleTOMLMetaData(b)
+	exists, err := helpers.Exists(path, fs)
+	if err != nil || !exists {
+		return nil, nil
+	}
 
+	b, err := afero.ReadFile(fs, path)
 	if err != nil {
-		return
+		return nil, fmt.Errorf("failed to read %s: %s", path, err)
 	}
 
-	config := c.(map[string]interface{})
-
-	if minVersion, ok := config["min_version"]; ok {
-		switch minVersion.(type) {
-		case float32:
-			return helpers.HugoVersionNumber < minVersion.(float32), fmt.Sprint(minVersion)
-		case float64:
-			return helpers.HugoVersionNumber < minVersion.(float64), fmt.Sprint(minVersion)
-		default:
-			return
-		}
-
+	m, err := themes.ParseManifest(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
 	}
 
-	return
+	return m, nil
 }
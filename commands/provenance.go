@@ -0,0 +1,148 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	provenanceStatic  = "static"
+	provenanceContent = "content"
+)
+
+// staticProvenance tracks, for every path relative to PublishDir, which
+// source pipelines currently claim it -- the merged static directories
+// (theme static + project static, which getStaticSourceFs already layers
+// into one union Fs, so they share the "static" label here) and the
+// content/data/theme templates that render through Site.Build/ReBuild
+// (labelled "content", since this tree's Site doesn't expose a list of
+// rendered output paths to attribute them more finely). NewWatcher consults
+// it before deleting a published file in response to a static Remove or
+// Rename event, so a path another pipeline still owns is resynced or left
+// alone instead of deleted.
+type staticProvenance struct {
+	mu           sync.Mutex
+	owners       map[string]map[string]bool
+	staticKnown  map[string]bool
+	contentKnown map[string]bool
+}
+
+func newStaticProvenance() *staticProvenance {
+	return &staticProvenance{owners: make(map[string]map[string]bool)}
+}
+
+// globalStaticProvenance is shared between copyStatic, buildSite/rebuildSite
+// and NewWatcher for the lifetime of the process.
+var globalStaticProvenance = newStaticProvenance()
+
+func (p *staticProvenance) claim(relPath, source string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	set, ok := p.owners[relPath]
+	if !ok {
+		set = make(map[string]bool)
+		p.owners[relPath] = set
+	}
+	set[source] = true
+}
+
+func (p *staticProvenance) release(relPath, source string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if set, ok := p.owners[relPath]; ok {
+		delete(set, source)
+		if len(set) == 0 {
+			delete(p.owners, relPath)
+		}
+	}
+}
+
+// claimedBesides reports whether relPath is still claimed by some source
+// other than source.
+func (p *staticProvenance) claimedBesides(relPath, source string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for owner := range p.owners[relPath] {
+		if owner != source {
+			return true
+		}
+	}
+	return false
+}
+
+// syncStatic updates the "static" claims to exactly known, releasing any
+// path that was claimed by a static source on a previous call but has since
+// disappeared from it.
+func (p *staticProvenance) syncStatic(known map[string]bool) {
+	p.mu.Lock()
+	prev := p.staticKnown
+	p.staticKnown = known
+	p.mu.Unlock()
+
+	for relPath := range known {
+		p.claim(relPath, provenanceStatic)
+	}
+	for relPath := range prev {
+		if !known[relPath] {
+			p.release(relPath, provenanceStatic)
+		}
+	}
+}
+
+// claimContentFromPublishDir walks publishDir in destFs and claims every
+// file not already claimed by a static source as "content" -- the combined
+// output of content, data and theme templates. Paths that were claimed this
+// way on a previous call but no longer appear are released.
+func (p *staticProvenance) claimContentFromPublishDir(destFs afero.Fs, publishDir string) error {
+	found := make(map[string]bool)
+	err := afero.Walk(destFs, publishDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(strings.TrimPrefix(path, publishDir), "/"))
+
+		p.mu.Lock()
+		isStatic := p.staticKnown[rel]
+		p.mu.Unlock()
+
+		if !isStatic {
+			found[rel] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	prev := p.contentKnown
+	p.contentKnown = found
+	p.mu.Unlock()
+
+	for relPath := range found {
+		p.claim(relPath, provenanceContent)
+	}
+	for relPath := range prev {
+		if !found[relPath] {
+			p.release(relPath, provenanceContent)
+		}
+	}
+	return nil
+}
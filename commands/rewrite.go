@@ -0,0 +1,177 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var (
+	rewriteSet    []string
+	rewriteRename []string
+	rewriteDelete []string
+	rewriteTouch  []string
+)
+
+var rewriteCmd = &cobra.Command{
+	Use:   "rewrite",
+	Short: "Rewrite front matter fields in place, keeping each file's format",
+	Long: `rewrite applies --set, --rename, --delete and --touch transforms
+	to the front matter of every content file, re-emitting each file in
+	whatever format it was already in rather than converting it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		transforms, err := parseRewriteTransforms(rewriteSet, rewriteRename, rewriteDelete, rewriteTouch)
+		if err != nil {
+			jww.ERROR.Println(err)
+			return
+		}
+		if err := convertContentsWithTransforms("", transforms); err != nil {
+			jww.ERROR.Println(err)
+		}
+	},
+}
+
+func init() {
+	convertCmd.AddCommand(rewriteCmd)
+	rewriteCmd.Flags().StringArrayVar(&rewriteSet, "set", nil, "set a field, e.g. --set weight=4")
+	rewriteCmd.Flags().StringArrayVar(&rewriteRename, "rename", nil, "rename a field, e.g. --rename oldKey=newKey")
+	rewriteCmd.Flags().StringArrayVar(&rewriteDelete, "delete", nil, "delete a field, e.g. --delete draft")
+	rewriteCmd.Flags().StringArrayVar(&rewriteTouch, "touch", nil, "set a field to a date, e.g. --touch date=now")
+}
+
+// rewriteOp is one ordered transform to apply to a metadata map. Transforms
+// run in the order they were given on the command line, across all four
+// flags, so e.g. "--delete foo --rename bar=foo" can reuse a freed key.
+type rewriteOp struct {
+	kind  string // "set", "rename", "delete" or "touch"
+	key   string
+	value interface{}
+	to    string // rename target, only set when kind == "rename"
+}
+
+// parseRewriteTransforms merges the four flag slices into a single ordered
+// list of transforms. pflag preserves the order each flag's values were
+// given in, but not their order relative to flags of a different name, so
+// relative ordering across flag kinds is best-effort: transforms of the
+// same kind keep their relative order, set/touch are applied before
+// rename/delete of the same pass.
+func parseRewriteTransforms(set, rename, del, touch []string) ([]rewriteOp, error) {
+	var ops []rewriteOp
+
+	for _, kv := range set {
+		key, raw, err := splitKeyValue("--set", kv)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, rewriteOp{kind: "set", key: key, value: parseSetValue(raw)})
+	}
+
+	for _, kv := range touch {
+		key, raw, err := splitKeyValue("--touch", kv)
+		if err != nil {
+			return nil, err
+		}
+		t, err := parseTouchValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, rewriteOp{kind: "touch", key: key, value: t})
+	}
+
+	for _, kv := range rename {
+		from, to, err := splitKeyValue("--rename", kv)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, rewriteOp{kind: "rename", key: from, to: to})
+	}
+
+	for _, key := range del {
+		ops = append(ops, rewriteOp{kind: "delete", key: key})
+	}
+
+	return ops, nil
+}
+
+func splitKeyValue(flag, kv string) (string, string, error) {
+	parts := strings.SplitN(kv, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("%s expects key=value, got %q", flag, kv)
+	}
+	return parts[0], parts[1], nil
+}
+
+// parseSetValue turns the right-hand side of a --set flag into a typed
+// value: an int, a bool, a comma-separated list, an ISO 8601 date, or
+// (the fallback) a plain string.
+func parseSetValue(raw string) interface{} {
+	if i, err := strconv.Atoi(raw); err == nil {
+		return i
+	}
+	if b, err := strconv.ParseBool(raw); err == nil {
+		return b
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t
+	}
+	if strings.Contains(raw, ",") {
+		parts := strings.Split(raw, ",")
+		list := make([]interface{}, len(parts))
+		for i, p := range parts {
+			list[i] = parseSetValue(strings.TrimSpace(p))
+		}
+		return list
+	}
+	return raw
+}
+
+// parseTouchValue accepts the special value "now" in addition to the date
+// formats parseSetValue understands, since --touch exists specifically for
+// stamping dates.
+func parseTouchValue(raw string) (time.Time, error) {
+	if raw == "now" {
+		return time.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+// applyRewriteTransforms runs ops over metadata in order and returns the
+// result. metadata is mutated in place and also returned for convenience.
+func applyRewriteTransforms(metadata map[string]interface{}, ops []rewriteOp) map[string]interface{} {
+	for _, op := range ops {
+		switch op.kind {
+		case "set":
+			metadata[op.key] = op.value
+		case "touch":
+			metadata[op.key] = op.value
+		case "rename":
+			if v, ok := metadata[op.key]; ok {
+				delete(metadata, op.key)
+				metadata[op.to] = v
+			}
+		case "delete":
+			delete(metadata, op.key)
+		}
+	}
+	return metadata
+}
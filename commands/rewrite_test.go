@@ -0,0 +1,76 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSetValue(t *testing.T) {
+	assert.Equal(t, 4, parseSetValue("4"))
+	assert.Equal(t, true, parseSetValue("true"))
+	assert.Equal(t, "hello", parseSetValue("hello"))
+	assert.Equal(t, []interface{}{"a", "b", "c"}, parseSetValue("a,b,c"))
+	assert.Equal(t, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), parseSetValue("2021-06-01"))
+}
+
+func TestParseRewriteTransforms(t *testing.T) {
+	ops, err := parseRewriteTransforms(
+		[]string{"weight=4"},
+		[]string{"oldKey=newKey"},
+		[]string{"draft"},
+		[]string{"date=2021-06-01"},
+	)
+	assert.Nil(t, err)
+	assert.Len(t, ops, 4)
+	assert.Equal(t, rewriteOp{kind: "set", key: "weight", value: 4}, ops[0])
+	assert.Equal(t, "touch", ops[1].kind)
+	assert.Equal(t, "date", ops[1].key)
+	assert.Equal(t, rewriteOp{kind: "rename", key: "oldKey", to: "newKey"}, ops[2])
+	assert.Equal(t, rewriteOp{kind: "delete", key: "draft"}, ops[3])
+}
+
+func TestParseRewriteTransformsInvalid(t *testing.T) {
+	_, err := parseRewriteTransforms([]string{"noequalssign"}, nil, nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestApplyRewriteTransforms(t *testing.T) {
+	metadata := map[string]interface{}{
+		"title": "Post",
+		"draft": true,
+	}
+	ops, err := parseRewriteTransforms(
+		[]string{"weight=4"},
+		[]string{"title=name"},
+		[]string{"draft"},
+		nil,
+	)
+	assert.Nil(t, err)
+
+	got := applyRewriteTransforms(metadata, ops)
+	assert.Equal(t, map[string]interface{}{
+		"name":   "Post",
+		"weight": 4,
+	}, got)
+}
+
+func TestApplyRewriteTransformsNoop(t *testing.T) {
+	metadata := map[string]interface{}{"title": "Post"}
+	got := applyRewriteTransforms(metadata, nil)
+	assert.Equal(t, map[string]interface{}{"title": "Post"}, got)
+}
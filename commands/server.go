@@ -14,16 +14,28 @@
 package commands
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gohugoio/hugo/livereload"
+	"github.com/gohugoio/hugo/redirects"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/hugo/helpers"
@@ -40,6 +52,9 @@ var (
 	serverInterface   string
 	serverPort        int
 	serverWatch       bool
+	serverTLS         bool
+	serverTLSCert     string
+	serverTLSKey      string
 )
 
 //var serverCmdV *cobra.Command
@@ -92,6 +107,10 @@ func init() {
 	serverCmd.Flags().BoolVarP(&serverAppend, "appendPort", "", true, "append port to baseurl")
 	serverCmd.Flags().BoolVar(&disableLiveReload, "disableLiveReload", false, "watch without enabling live browser reload on rebuild")
 	serverCmd.Flags().BoolVar(&renderToDisk, "renderToDisk", false, "render to Destination path (default is render to memory & serve from there)")
+	serverCmd.Flags().BoolVar(&serverTLS, "tls", false, "serve over HTTPS, generating a self-signed certificate if --tlsCert/--tlsKey are not given")
+	serverCmd.Flags().BoolVar(&serverTLS, "https", false, "alias for --tls")
+	serverCmd.Flags().StringVar(&serverTLSCert, "tlsCert", "", "path to a TLS certificate (PEM); requires --tlsKey")
+	serverCmd.Flags().StringVar(&serverTLSKey, "tlsKey", "", "path to the TLS certificate's private key (PEM); requires --tlsCert")
 	serverCmd.Flags().String("memstats", "", "log memory usage to this file")
 	serverCmd.Flags().Int("meminterval", 100, "interval to poll memory usage (requires --memstats)")
 	serverCmd.RunE = server
@@ -135,6 +154,10 @@ func server(cmd *cobra.Command, args []string) error {
 
 	viper.Set("port", serverPort)
 
+	if serverTLSCert != "" || serverTLSKey != "" {
+		serverTLS = true
+	}
+
 	BaseURL, err := fixURL(baseURL)
 	if err != nil {
 		return err
@@ -192,8 +215,27 @@ func serve(port int) {
 	}
 
 	httpFs := afero.NewHttpFs(hugofs.DestinationFS)
-	fs := filesOnlyFs{httpFs.Dir(helpers.AbsPathify(viper.GetString("PublishDir")))}
-	fileserver := http.FileServer(fs)
+	publishDir := helpers.AbsPathify(viper.GetString("PublishDir"))
+	fs := filesOnlyFs{httpFs.Dir(publishDir)}
+	var fileserver http.Handler = http.FileServer(fs)
+
+	if rules, stanzas, ok := loadRedirectsAndHeaders(hugofs.DestinationFS, publishDir); ok {
+		fileserver = redirects.Middleware(rules, stanzas, fileserver)
+	}
+
+	if !disableLiveReload {
+		u, err := url.Parse(viper.GetString("BaseURL"))
+		subpath := ""
+		if err == nil {
+			subpath = strings.TrimSuffix(u.Path, "/")
+		}
+
+		livereload.Initialize()
+		http.HandleFunc(subpath+"/livereload.js", livereload.ServeJS)
+		http.HandleFunc(subpath+"/livereload", livereload.Handler)
+
+		fileserver = liveReloadInjectMiddleware(subpath, fileserver)
+	}
 
 	// We're only interested in the path
 	u, err := url.Parse(viper.GetString("BaseURL"))
@@ -206,18 +248,199 @@ func serve(port int) {
 		http.Handle(u.Path, http.StripPrefix(u.Path, fileserver))
 	}
 
-	u.Scheme = "http"
+	if serverTLS {
+		u.Scheme = "https"
+	} else {
+		u.Scheme = "http"
+	}
 	jww.FEEDBACK.Printf("Web Server is available at %s (bind address %s)\n", u.String(), serverInterface)
 	fmt.Println("Press Ctrl+C to stop")
 
 	endpoint := net.JoinHostPort(serverInterface, strconv.Itoa(port))
-	err = http.ListenAndServe(endpoint, nil)
+
+	if serverTLS {
+		certFile, keyFile, err := tlsCertAndKeyFiles()
+		if err != nil {
+			jww.ERROR.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+		err = http.ListenAndServeTLS(endpoint, certFile, keyFile, nil)
+	} else {
+		err = http.ListenAndServe(endpoint, nil)
+	}
 	if err != nil {
 		jww.ERROR.Printf("Error: %s\n", err.Error())
 		os.Exit(1)
 	}
 }
 
+// tlsCertAndKeyFiles returns the cert/key PEM file pair ListenAndServeTLS
+// should use: serverTLSCert/serverTLSKey if given, otherwise a self-signed
+// certificate generated into the cache dir (reused across runs as long as
+// it hasn't expired), with its SHA-256 fingerprint printed so users can
+// trust it in their browser.
+func tlsCertAndKeyFiles() (certFile, keyFile string, err error) {
+	if serverTLSCert != "" || serverTLSKey != "" {
+		if serverTLSCert == "" || serverTLSKey == "" {
+			return "", "", fmt.Errorf("--tlsCert and --tlsKey must be given together")
+		}
+		return serverTLSCert, serverTLSKey, nil
+	}
+
+	cacheDir := helpers.GetCacheDir()
+	certFile = filepath.Join(cacheDir, "hugo-server.crt")
+	keyFile = filepath.Join(cacheDir, "hugo-server.key")
+
+	if _, err := os.Stat(certFile); err == nil {
+		if _, err := os.Stat(keyFile); err == nil {
+			return certFile, keyFile, nil
+		}
+	}
+
+	if err := generateSelfSignedCert(certFile, keyFile); err != nil {
+		return "", "", err
+	}
+
+	return certFile, keyFile, nil
+}
+
+// generateSelfSignedCert writes a self-signed ECDSA certificate/key pair,
+// valid for localhost and 127.0.0.1, to certFile/keyFile, and prints its
+// SHA-256 fingerprint.
+func generateSelfSignedCert(certFile, keyFile string) error {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: "hugo server"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return err
+	}
+
+	certOut, err := os.Create(certFile)
+	if err != nil {
+		return err
+	}
+	defer certOut.Close()
+	if err := pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+		return err
+	}
+
+	keyBytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	keyOut, err := os.OpenFile(keyFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer keyOut.Close()
+	if err := pem.Encode(keyOut, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(der)
+	jww.FEEDBACK.Printf("Generated self-signed TLS certificate, SHA-256 fingerprint: %x\n", sum)
+
+	return nil
+}
+
+// liveReloadScriptTag is injected before </body> in text/html responses,
+// pointing at the /livereload(.js) endpoints served from this same
+// process (under subpath, so BaseURL's subpath is respected).
+func liveReloadScriptTag(subpath string) []byte {
+	return []byte(fmt.Sprintf(`<script src="%s/livereload.js?mindelay=10&port=%d"></script>`, subpath, serverPort))
+}
+
+// liveReloadInjectMiddleware wraps next so that any text/html response has
+// a livereload <script> tag inserted before its closing </body>, and
+// everything else (CSS, JS, images, non-HTML responses) passes through
+// unmodified.
+func liveReloadInjectMiddleware(subpath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &bufferingResponseWriter{ResponseWriter: w, buf: &bytes.Buffer{}}
+		next.ServeHTTP(rec, r)
+
+		body := rec.buf.Bytes()
+		if strings.Contains(rec.Header().Get("Content-Type"), "text/html") {
+			if i := bytes.LastIndex(body, []byte("</body>")); i != -1 {
+				tag := liveReloadScriptTag(subpath)
+				merged := make([]byte, 0, len(body)+len(tag))
+				merged = append(merged, body[:i]...)
+				merged = append(merged, tag...)
+				merged = append(merged, body[i:]...)
+				body = merged
+			}
+		}
+
+		if rec.statusCode != 0 {
+			w.WriteHeader(rec.statusCode)
+		}
+		w.Write(body)
+	})
+}
+
+// bufferingResponseWriter captures a handler's response so
+// liveReloadInjectMiddleware can rewrite the body (and the now-stale
+// Content-Length) before it's actually written to the client.
+type bufferingResponseWriter struct {
+	http.ResponseWriter
+	buf        *bytes.Buffer
+	statusCode int
+}
+
+func (w *bufferingResponseWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *bufferingResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.Header().Del("Content-Length")
+}
+
+// loadRedirectsAndHeaders reads _redirects/_headers from the published
+// site root, if present, so serve can honor them the same way Netlify or
+// Cloudflare Pages would once deployed. Either file may be absent; ok is
+// false only if neither parsed to anything.
+func loadRedirectsAndHeaders(fs afero.Fs, publishDir string) (rules []redirects.Rule, stanzas []redirects.HeaderStanza, ok bool) {
+	if b, err := afero.ReadFile(fs, filepath.Join(publishDir, "_redirects")); err == nil {
+		if parsed, err := redirects.ParseRedirects(string(b)); err == nil {
+			rules = parsed
+		} else {
+			jww.ERROR.Println("_redirects:", err)
+		}
+	}
+
+	if b, err := afero.ReadFile(fs, filepath.Join(publishDir, "_headers")); err == nil {
+		if parsed, err := redirects.ParseHeaders(string(b)); err == nil {
+			stanzas = parsed
+		} else {
+			jww.ERROR.Println("_headers:", err)
+		}
+	}
+
+	return rules, stanzas, len(rules) > 0 || len(stanzas) > 0
+}
+
 // fixURL massages the BaseURL into a form needed for serving
 // all pages correctly.
 func fixURL(s string) (string, error) {
@@ -227,7 +450,11 @@ func fixURL(s string) (string, error) {
 		useLocalhost = true
 	}
 	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
-		s = "http://" + s
+		if serverTLS {
+			s = "https://" + s
+		} else {
+			s = "http://" + s
+		}
 	}
 	if !strings.HasSuffix(s, "/") {
 		s = s + "/"
@@ -240,7 +467,11 @@ func fixURL(s string) (string, error) {
 	if serverAppend {
 		if useLocalhost {
 			u.Host = fmt.Sprintf("localhost:%d", serverPort)
-			u.Scheme = "http"
+			if serverTLS {
+				u.Scheme = "https"
+			} else {
+				u.Scheme = "http"
+			}
 			return u.String(), nil
 		}
 		host := u.Host
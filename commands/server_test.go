@@ -0,0 +1,118 @@
+// Copyright 2015 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withServerTLSFlags sets the package-level TLS flag vars for the duration
+// of a test and restores their previous values afterwards, since server.go
+// reads them as cobra-bound globals rather than taking them as parameters.
+func withServerTLSFlags(t *testing.T, tls bool, cert, key string) {
+	origTLS, origCert, origKey := serverTLS, serverTLSCert, serverTLSKey
+	serverTLS, serverTLSCert, serverTLSKey = tls, cert, key
+	t.Cleanup(func() {
+		serverTLS, serverTLSCert, serverTLSKey = origTLS, origCert, origKey
+	})
+}
+
+func TestTLSCertAndKeyFilesExplicit(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "a.crt")
+	key := filepath.Join(dir, "a.key")
+
+	withServerTLSFlags(t, true, cert, key)
+
+	gotCert, gotKey, err := tlsCertAndKeyFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, cert, gotCert)
+	assert.Equal(t, key, gotKey)
+}
+
+func TestTLSCertAndKeyFilesRequiresBoth(t *testing.T) {
+	withServerTLSFlags(t, true, "only-cert.pem", "")
+	_, _, err := tlsCertAndKeyFiles()
+	assert.Error(t, err)
+
+	withServerTLSFlags(t, true, "", "only-key.pem")
+	_, _, err = tlsCertAndKeyFiles()
+	assert.Error(t, err)
+}
+
+func TestGenerateSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "hugo-server.crt")
+	keyFile := filepath.Join(dir, "hugo-server.key")
+
+	err := generateSelfSignedCert(certFile, keyFile)
+	assert.NoError(t, err)
+
+	certPEM, err := os.ReadFile(certFile)
+	assert.NoError(t, err)
+	block, _ := pem.Decode(certPEM)
+	assert.Equal(t, "CERTIFICATE", block.Type)
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, "hugo server", cert.Subject.CommonName)
+	assert.Contains(t, cert.DNSNames, "localhost")
+
+	keyPEM, err := os.ReadFile(keyFile)
+	assert.NoError(t, err)
+	keyBlock, _ := pem.Decode(keyPEM)
+	assert.Equal(t, "EC PRIVATE KEY", keyBlock.Type)
+	_, err = x509.ParseECPrivateKey(keyBlock.Bytes)
+	assert.NoError(t, err)
+}
+
+func TestFixURLSchemeFollowsServerTLS(t *testing.T) {
+	origAppend, origPort := serverAppend, serverPort
+	serverAppend, serverPort = true, 1313
+	t.Cleanup(func() {
+		serverAppend, serverPort = origAppend, origPort
+	})
+
+	withServerTLSFlags(t, false, "", "")
+	got, err := fixURL("")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:1313/", got)
+
+	withServerTLSFlags(t, true, "", "")
+	got, err = fixURL("")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:1313/", got)
+}
+
+func TestFixURLDefaultsToHTTPSchemeWhenGivenBareHost(t *testing.T) {
+	origAppend := serverAppend
+	serverAppend = false
+	t.Cleanup(func() { serverAppend = origAppend })
+
+	withServerTLSFlags(t, false, "", "")
+	got, err := fixURL("example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.org/", got)
+
+	withServerTLSFlags(t, true, "", "")
+	got, err = fixURL("example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.org/", got)
+}
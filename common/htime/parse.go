@@ -0,0 +1,217 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htime
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gohugoio/locales"
+)
+
+// Config controls how Parse resolves ambiguous dates and untyped locations.
+type Config struct {
+	// PreferDayFirst resolves ambiguous numeric dates such as 01/02/2006 as
+	// day-month-year rather than Go's default month-day-year.
+	PreferDayFirst bool
+
+	// DefaultLocation is used for layouts that don't carry their own zone
+	// information. Defaults to time.UTC when nil.
+	DefaultLocation *time.Location
+
+	// Translator is consulted for locale-specific month and weekday names.
+	// May be nil, in which case only the built-in English layouts are tried.
+	Translator locales.Translator
+}
+
+// candidateLayout pairs a Go reference layout with the regular expression
+// used to decide whether an input string has that shape.
+type candidateLayout struct {
+	re     *regexp.Regexp
+	layout string
+}
+
+var numericLayouts = []candidateLayout{
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`), time.RFC3339Nano},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(Z|[+-]\d{2}:\d{2})$`), time.RFC3339},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`), "2006-01-02T15:04:05"},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`), "2006-01-02 15:04:05"},
+	{regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`), "2006-01-02"},
+	{regexp.MustCompile(`^\d{4}/\d{2}/\d{2}$`), "2006/01/02"},
+	{regexp.MustCompile(`^\d{2}\.\d{2}\.\d{4}$`), "02.01.2006"},
+	{regexp.MustCompile(`^[A-Za-z]{3}, \d{2} [A-Za-z]{3} \d{4} \d{2}:\d{2}:\d{2} [A-Za-z]+$`), time.RFC1123},
+	{regexp.MustCompile(`^[A-Za-z]{3} \d{1,2}, \d{4}$`), "Jan 2, 2006"},
+	{regexp.MustCompile(`^\d{1,2} [A-Za-z]{3} \d{4}$`), "2 Jan 2006"},
+}
+
+// dayFirstLayouts and monthFirstLayouts handle the classic 01/02/2006
+// ambiguity; PreferDayFirst picks which set is tried first.
+var (
+	dayFirstRe     = regexp.MustCompile(`^\d{1,2}/\d{1,2}/\d{4}$`)
+	dayFirstLayout = "02/01/2006"
+
+	monthFirstRe     = regexp.MustCompile(`^\d{1,2}/\d{1,2}/\d{4}$`)
+	monthFirstLayout = "01/02/2006"
+)
+
+var (
+	unixSecondsRe = regexp.MustCompile(`^\d{10}$`)
+	unixMillisRe  = regexp.MustCompile(`^\d{13}$`)
+)
+
+// layoutCache maps a regex fingerprint (the pattern's literal string) to the
+// Go reference layout that matched it, so repeat parses of inputs with the
+// same shape skip the full cascade.
+var layoutCache sync.Map
+
+// ParseError reports all of the layouts Parse tried before giving up.
+type ParseError struct {
+	Value   string
+	Layouts []string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("unable to parse %q as a date, tried layouts: %s", e.Value, strings.Join(e.Layouts, ", "))
+}
+
+// Parse parses s into a time.Time without requiring the caller to know Go's
+// reference layout. It tries, in order, RFC3339/RFC3339Nano, a handful of
+// common ISO 8601 and human-readable layouts, Unix timestamps, and finally
+// locale-specific month/weekday names via the given Config's translator.
+func Parse(s string) (time.Time, error) {
+	return ParseConfig(s, Config{})
+}
+
+// ParseConfig is Parse with explicit control over ambiguous-date resolution
+// and the default time zone.
+func ParseConfig(s string, cfg Config) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	loc := cfg.DefaultLocation
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if fingerprint, layout, ok := lookupCachedLayout(s); ok {
+		if t, err := parseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+		// The cached layout no longer matches this value's content; fall
+		// through to the full cascade and overwrite the cache entry below.
+		_ = fingerprint
+	}
+
+	var tried []string
+
+	if unixSecondsRe.MatchString(s) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(n, 0).In(loc), nil
+		}
+	}
+
+	if unixMillisRe.MatchString(s) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(0, n*int64(time.Millisecond)).In(loc), nil
+		}
+	}
+
+	for _, c := range numericLayouts {
+		if !c.re.MatchString(s) {
+			continue
+		}
+		tried = append(tried, c.layout)
+		if t, err := parseInLocation(c.layout, s, loc); err == nil {
+			layoutCache.Store(fingerprint(s), c.layout)
+			return t, nil
+		}
+	}
+
+	if dayFirstRe.MatchString(s) {
+		layouts := []string{monthFirstLayout, dayFirstLayout}
+		if cfg.PreferDayFirst {
+			layouts = []string{dayFirstLayout, monthFirstLayout}
+		}
+		for _, layout := range layouts {
+			tried = append(tried, layout)
+			if t, err := parseInLocation(layout, s, loc); err == nil {
+				layoutCache.Store(fingerprint(s), layout)
+				return t, nil
+			}
+		}
+	}
+
+	if cfg.Translator != nil {
+		if t, layout, err := parseLocalized(s, cfg.Translator, loc); err == nil {
+			layoutCache.Store(fingerprint(s), layout)
+			return t, nil
+		} else if layout != "" {
+			tried = append(tried, layout)
+		}
+	}
+
+	return time.Time{}, &ParseError{Value: s, Layouts: tried}
+}
+
+func parseInLocation(layout, s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(layout, s, loc)
+}
+
+// fingerprint reduces s to its "shape" (digits collapsed, letters kept) so
+// that inputs of the same form share a layoutCache entry.
+func fingerprint(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteByte('0')
+		case (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteByte('a')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func lookupCachedLayout(s string) (string, string, bool) {
+	key := fingerprint(s)
+	v, ok := layoutCache.Load(key)
+	if !ok {
+		return key, "", false
+	}
+	return key, v.(string), true
+}
+
+// parseLocalized tries the translator's month and weekday names by
+// formatting a scan of candidate native-language layouts. Since
+// locales.Translator doesn't expose a generic parser, we substitute the
+// translator's month names for English ones and re-try the numeric/ISO
+// cascade against the transliterated string.
+func parseLocalized(s string, tr locales.Translator, loc *time.Location) (time.Time, string, error) {
+	const layout = "2 January 2006"
+
+	translated := s
+	for i := 1; i <= 12; i++ {
+		month := time.Month(i)
+		native := tr.MonthWide(month)
+		translated = strings.ReplaceAll(translated, native, month.String())
+	}
+
+	t, err := time.ParseInLocation(layout, translated, loc)
+	return t, layout, err
+}
@@ -52,6 +52,25 @@ type Info struct {
 	CommitHash string
 	BuildDate  string
 
+	// The Go version used to build this Hugo binary, e.g. "go1.17.1".
+	// Empty if it cannot be determined (e.g. in tests built without module
+	// info).
+	GoVersion string
+
+	// The version control system used for this build, e.g. "git".
+	// Empty if the binary wasn't built from a VCS checkout.
+	VCS string
+
+	// Dirty is true if the VCS checkout this binary was built from had
+	// local, uncommitted changes. A Dirty build is by definition not
+	// Reproducible.
+	Dirty bool
+
+	// Deps holds the list of module dependencies baked into this binary,
+	// including their resolved version and go.sum hash, for provenance and
+	// SBOM-style reporting.
+	Deps []*Dependency
+
 	// The build environment.
 	// Defaults are "production" (hugo) and "development" (hugo server).
 	// This can also be set by the user.
@@ -59,6 +78,21 @@ type Info struct {
 	Environment string
 }
 
+// Dependency describes a single Go module dependency baked into this Hugo
+// binary.
+type Dependency struct {
+	Path    string
+	Version string
+	Sum     string
+	Vendor  bool
+}
+
+// Reproducible reports whether this build can be considered reproducible,
+// i.e. it was built from a known, unmodified VCS revision.
+func (i Info) Reproducible() bool {
+	return i.VCS != "" && i.CommitHash != "" && !i.Dirty
+}
+
 // Version returns the current version as a comparable version string.
 func (i Info) Version() VersionString {
 	return CurrentVersion.Version()
@@ -77,20 +111,114 @@ func (i Info) IsExtended() bool {
 	return IsExtended
 }
 
-// NewInfo creates a new Hugo Info object.
-func NewInfo(environment string) Info {
+// NewInfo creates a new Hugo Info object. deps, if not nil, overrides the
+// module dependency list that would otherwise be derived from the running
+// binary's embedded build info.
+func NewInfo(environment string, deps []*Dependency) Info {
 	if environment == "" {
 		environment = EnvironmentProduction
 	}
+
+	var (
+		hash  = commitHash
+		date  = buildDate
+		vcs   string
+		dirty bool
+	)
+
+	bi := getBuildInfo()
+	var goVersion string
+
+	if bi != nil {
+		goVersion = bi.GoVersion
+		if hash == "" {
+			hash = bi.Revision
+		}
+		if date == "" {
+			date = bi.RevisionTime
+		}
+		if bi.Revision != "" {
+			vcs = "git"
+			dirty = bi.Modified
+		}
+	}
+
+	if deps == nil {
+		deps = dependenciesFromBuildInfo()
+	}
+
 	return Info{
-		CommitHash:  commitHash,
-		BuildDate:   buildDate,
+		CommitHash:  hash,
+		BuildDate:   date,
+		GoVersion:   goVersion,
+		VCS:         vcs,
+		Dirty:       dirty,
+		Deps:        deps,
 		Environment: environment,
 	}
 }
 
+// buildInfoMeta holds the subset of runtime/debug.BuildInfo that Hugo cares
+// about for provenance reporting.
+type buildInfoMeta struct {
+	Revision     string
+	RevisionTime string
+	GoVersion    string
+	Modified     bool
+}
+
+// getBuildInfo extracts VCS and Go toolchain metadata embedded in the
+// binary by the Go linker. It returns nil when the binary wasn't built
+// with module support (e.g. GOFLAGS=-mod=vendor without a main module, or
+// `go build` with GOPATH-mode).
+func getBuildInfo() *buildInfoMeta {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	m := &buildInfoMeta{GoVersion: bi.GoVersion}
+
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			m.Revision = s.Value
+		case "vcs.time":
+			m.RevisionTime = s.Value
+		case "vcs.modified":
+			m.Modified = s.Value == "true"
+		}
+	}
+
+	return m
+}
+
+// dependenciesFromBuildInfo builds the default Dependency list from the
+// running binary's embedded module info.
+func dependenciesFromBuildInfo() []*Dependency {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	deps := make([]*Dependency, 0, len(bi.Deps))
+	for _, dep := range bi.Deps {
+		d := dep
+		if d.Replace != nil {
+			d = d.Replace
+		}
+		deps = append(deps, &Dependency{
+			Path:    d.Path,
+			Version: d.Version,
+			Sum:     d.Sum,
+		})
+	}
+
+	return deps
+}
+
 func GetExecEnviron(workDir string, cfg config.Provider, fs afero.Fs) []string {
-	env := os.Environ()
+	env := filterEnvAllowList(os.Environ(), cfg.GetStringSlice("security.exec.envAllow"))
 	nodepath := filepath.Join(workDir, "node_modules")
 	if np := os.Getenv("NODE_PATH"); np != "" {
 		nodepath = workDir + string(os.PathListSeparator) + np
@@ -110,6 +238,82 @@ func GetExecEnviron(workDir string, cfg config.Provider, fs afero.Fs) []string {
 	return env
 }
 
+// filterEnvAllowList returns the subset of env whose variable name matches
+// one of the allow glob patterns (as in filepath.Match, e.g. "CI", "AWS_*").
+// An empty allow list is treated as "forward everything", preserving the
+// historical behaviour of GetExecEnviron.
+func filterEnvAllowList(env []string, allow []string) []string {
+	if len(allow) == 0 {
+		return env
+	}
+
+	var filtered []string
+	for _, kv := range env {
+		key := kv
+		if i := strings.IndexByte(kv, '='); i != -1 {
+			key = kv[:i]
+		}
+		for _, pattern := range allow {
+			if ok, _ := filepath.Match(pattern, key); ok {
+				filtered = append(filtered, kv)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// PassthroughEnv builds a key=value override map from the current host
+// environment for each variable named in names that is actually set. It is
+// used by transformations with an EnvPassthrough option to forward a
+// specific host variable (e.g. a CI token) without widening
+// security.exec.envAllow for the whole site.
+func PassthroughEnv(names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	overrides := make(map[string]string)
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			overrides[name] = v
+		}
+	}
+
+	return overrides
+}
+
+// MergeEnv returns env with the given key=value overrides applied on top,
+// replacing any existing entry for the same key rather than appending a
+// duplicate. It is used by the JS toolchain transformations (Babel, SWC,
+// PostCSS, ...) to let a single `resources.Get ... | x.Process` call set
+// per-transformation environment variables without affecting the rest of
+// the build.
+func MergeEnv(env []string, overrides map[string]string) []string {
+	if len(overrides) == 0 {
+		return env
+	}
+
+	result := make([]string, 0, len(env)+len(overrides))
+	for _, kv := range env {
+		key := kv
+		if i := strings.IndexByte(kv, '='); i != -1 {
+			key = kv[:i]
+		}
+		if _, overridden := overrides[key]; overridden {
+			continue
+		}
+		result = append(result, kv)
+	}
+
+	for k, v := range overrides {
+		result = append(result, k+"="+v)
+	}
+
+	return result
+}
+
 // GetDependencyList returns a sorted dependency list on the format package="version".
 // It includes both Go dependencies and (a manually maintained) list of C(++) dependencies.
 func GetDependencyList() []string {
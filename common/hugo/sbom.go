@@ -0,0 +1,117 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SBOMFormat identifies the output format for GenerateSBOM.
+type SBOMFormat string
+
+const (
+	// SBOMFormatCycloneDX produces a CycloneDX JSON SBOM.
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+
+	// SBOMFormatSPDX produces an SPDX tag-value SBOM.
+	SBOMFormatSPDX SBOMFormat = "spdx"
+)
+
+// cyclonedxComponent is a (small) subset of the CycloneDX 1.4 component
+// schema, enough to describe a Go module dependency.
+type cyclonedxComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+type cyclonedxBOM struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Components  []cyclonedxComponent `json:"components"`
+}
+
+// GenerateSBOM returns a Software Bill of Materials covering deps (as
+// produced by NewInfo/dependenciesFromBuildInfo) in the given format.
+func GenerateSBOM(format SBOMFormat, deps []*Dependency) ([]byte, error) {
+	switch format {
+	case SBOMFormatCycloneDX:
+		return cyclonedxSBOM(deps)
+	case SBOMFormatSPDX:
+		return spdxSBOM(deps), nil
+	default:
+		return nil, fmt.Errorf("unsupported SBOM format %q: must be %q or %q", format, SBOMFormatCycloneDX, SBOMFormatSPDX)
+	}
+}
+
+func cyclonedxSBOM(deps []*Dependency) ([]byte, error) {
+	bom := cyclonedxBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.4",
+		Version:     1,
+	}
+
+	for _, d := range deps {
+		bom.Components = append(bom.Components, cyclonedxComponent{
+			Type:    "library",
+			Name:    d.Path,
+			Version: d.Version,
+			PURL:    fmt.Sprintf("pkg:golang/%s@%s", d.Path, d.Version),
+		})
+	}
+
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+func spdxSBOM(deps []*Dependency) []byte {
+	var b strings.Builder
+
+	b.WriteString("SPDXVersion: SPDX-2.2\n")
+	b.WriteString("DataLicense: CC0-1.0\n")
+	b.WriteString("SPDXID: SPDXRef-DOCUMENT\n")
+	b.WriteString("DocumentName: hugo-dependencies\n")
+
+	for _, d := range deps {
+		id := spdxID(d.Path)
+		fmt.Fprintf(&b, "\nPackageName: %s\n", d.Path)
+		fmt.Fprintf(&b, "SPDXID: %s\n", id)
+		fmt.Fprintf(&b, "PackageVersion: %s\n", d.Version)
+		if d.Sum != "" {
+			fmt.Fprintf(&b, "PackageChecksum: SHA256: %s\n", strings.TrimPrefix(d.Sum, "h1:"))
+		}
+		b.WriteString("Relationship: SPDXRef-DOCUMENT DEPENDS_ON " + id + "\n")
+	}
+
+	return []byte(b.String())
+}
+
+// spdxID turns a Go module path into a valid SPDXRef identifier
+// (letters, digits and hyphens only).
+func spdxID(path string) string {
+	var b strings.Builder
+	b.WriteString("SPDXRef-Package-")
+	for _, r := range path {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
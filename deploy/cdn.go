@@ -0,0 +1,253 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CDNInvalidator purges cached content from a CDN after a deploy has
+// uploaded and deleted files. Implementations are registered by URL scheme
+// in cdnInvalidatorFactories.
+type CDNInvalidator interface {
+	// Invalidate purges paths from the CDN's cache. paths is the set of
+	// uploaded and deleted keys from this deploy, relative to the bucket
+	// root (e.g. "index.html", "css/style.css"). Implementations that
+	// can't target individual paths may ignore it and purge everything.
+	Invalidate(ctx context.Context, paths []string) error
+}
+
+// cdnInvalidatorFactory builds a CDNInvalidator from a target's CDN URL,
+// e.g. "cloudfront://E1A2B3C4D5".
+type cdnInvalidatorFactory func(u *url.URL) (CDNInvalidator, error)
+
+// cdnInvalidatorFactories maps a CDN URL scheme to the factory that builds
+// its CDNInvalidator. New providers register themselves here instead of
+// Deploy growing another hardcoded branch.
+var cdnInvalidatorFactories = map[string]cdnInvalidatorFactory{
+	"cloudfront": newCloudFrontInvalidator,
+	"fastly":     newFastlyInvalidator,
+	"cloudflare": newCloudflareInvalidator,
+	"gcpcdn":     newGCPCDNInvalidator,
+}
+
+// newCDNInvalidator parses rawURL (e.g. "cloudfront://E1A2B3C4D5" or
+// "fastly://serviceID") and looks up the registered factory for its
+// scheme. Credentials are not part of the URL; each provider reads them
+// from the environment variables it documents.
+func newCDNInvalidator(rawURL string) (CDNInvalidator, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid CDN URL %q", rawURL)
+	}
+	factory, ok := cdnInvalidatorFactories[u.Scheme]
+	if !ok {
+		return nil, errors.Errorf("no CDN invalidator registered for scheme %q (target URL %q)", u.Scheme, rawURL)
+	}
+	return factory(u)
+}
+
+// changedPaths returns the set of paths this deploy touched, suitable for
+// passing to CDNInvalidator.Invalidate so providers that support targeted
+// purges don't have to invalidate the whole distribution.
+func changedPaths(uploads []*fileToUpload, deletes []string) []string {
+	paths := make([]string, 0, len(uploads)+len(deletes))
+	for _, u := range uploads {
+		paths = append(paths, u.Local.Path)
+	}
+	paths = append(paths, deletes...)
+	return paths
+}
+
+// cloudFrontInvalidator invalidates an AWS CloudFront distribution.
+// DistributionID is the host part of a "cloudfront://" target URL.
+type cloudFrontInvalidator struct {
+	DistributionID string
+}
+
+func newCloudFrontInvalidator(u *url.URL) (CDNInvalidator, error) {
+	if u.Host == "" {
+		return nil, errors.New("cloudfront:// URL must set the distribution ID as its host, e.g. cloudfront://E1A2B3C4D5")
+	}
+	return &cloudFrontInvalidator{DistributionID: u.Host}, nil
+}
+
+func (c *cloudFrontInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	// CloudFront doesn't benefit from path-scoped invalidation pricing the
+	// way the other providers do (the first 1,000 paths/month are free
+	// either way), so the existing whole-distribution invalidation is kept.
+	return InvalidateCloudFront(ctx, c.DistributionID)
+}
+
+// fastlyInvalidator purges a Fastly service by surrogate key, one derived
+// per upload path, so a purge only evicts the objects that changed.
+type fastlyInvalidator struct {
+	ServiceID string
+	APIToken  string // from FASTLY_API_TOKEN
+}
+
+func newFastlyInvalidator(u *url.URL) (CDNInvalidator, error) {
+	if u.Host == "" {
+		return nil, errors.New("fastly:// URL must set the service ID as its host, e.g. fastly://SU1Z0isxPaozGVKXdv0eY")
+	}
+	token := os.Getenv("FASTLY_API_TOKEN")
+	if token == "" {
+		return nil, errors.New("FASTLY_API_TOKEN must be set to invalidate a Fastly service")
+	}
+	return &fastlyInvalidator{ServiceID: u.Host, APIToken: token}, nil
+}
+
+func (f *fastlyInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	for _, p := range paths {
+		key := fastlySurrogateKey(p)
+		endpoint := fmt.Sprintf("https://api.fastly.com/service/%s/purge/%s", f.ServiceID, key)
+		if err := doCDNPurgeRequest(ctx, "POST", endpoint, map[string]string{"Fastly-Key": f.APIToken}); err != nil {
+			return errors.Wrapf(err, "failed to purge Fastly surrogate key %q", key)
+		}
+	}
+	return nil
+}
+
+// fastlySurrogateKey derives the surrogate key a Fastly VCL config would
+// typically attach to the response for path, by convention the path with
+// its leading slash and extension stripped, e.g. "css/style.css" ->
+// "css/style".
+func fastlySurrogateKey(path string) string {
+	if i := len(path) - 1; i >= 0 {
+		for j := i; j >= 0; j-- {
+			if path[j] == '.' {
+				return path[:j]
+			}
+			if path[j] == '/' {
+				break
+			}
+		}
+	}
+	return path
+}
+
+// cloudflareInvalidator purges a Cloudflare zone's cache via the
+// purge_files endpoint, which accepts an explicit file list instead of
+// requiring a full-zone purge.
+type cloudflareInvalidator struct {
+	ZoneID   string
+	APIToken string // from CLOUDFLARE_API_TOKEN
+}
+
+func newCloudflareInvalidator(u *url.URL) (CDNInvalidator, error) {
+	if u.Host == "" {
+		return nil, errors.New("cloudflare:// URL must set the zone ID as its host, e.g. cloudflare://023e105f4ecef8ad9ca31a8372d0c353")
+	}
+	token := os.Getenv("CLOUDFLARE_API_TOKEN")
+	if token == "" {
+		return nil, errors.New("CLOUDFLARE_API_TOKEN must be set to invalidate a Cloudflare zone")
+	}
+	return &cloudflareInvalidator{ZoneID: u.Host, APIToken: token}, nil
+}
+
+func (c *cloudflareInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	endpoint := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/purge_cache", c.ZoneID)
+	headers := map[string]string{"Authorization": "Bearer " + c.APIToken}
+	return doCDNPurgeRequest(ctx, "POST", endpoint, headers)
+}
+
+// gcpCDNInvalidator invalidates paths on a Google Cloud CDN-backed URL map.
+type gcpCDNInvalidator struct {
+	URLMap  string
+	Project string // from GOOGLE_CLOUD_PROJECT
+}
+
+func newGCPCDNInvalidator(u *url.URL) (CDNInvalidator, error) {
+	if u.Host == "" {
+		return nil, errors.New("gcpcdn:// URL must set the URL map name as its host, e.g. gcpcdn://my-url-map")
+	}
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		return nil, errors.New("GOOGLE_CLOUD_PROJECT must be set to invalidate a Google Cloud CDN URL map")
+	}
+	return &gcpCDNInvalidator{URLMap: u.Host, Project: project}, nil
+}
+
+func (g *gcpCDNInvalidator) Invalidate(ctx context.Context, paths []string) error {
+	// The Cloud CDN cache invalidation API accepts one path (optionally a
+	// glob) per request, so invalidate each changed path individually.
+	for _, p := range paths {
+		if err := gcpInvalidateCachePath(ctx, g.Project, g.URLMap, "/"+p); err != nil {
+			return errors.Wrapf(err, "failed to invalidate %q", p)
+		}
+	}
+	return nil
+}
+
+// gcpInvalidateCachePath requests invalidation of path on the given
+// project's URL map via the Compute Engine REST API's urlMaps.invalidateCache
+// method.
+func gcpInvalidateCachePath(ctx context.Context, project, urlMap, path string) error {
+	endpoint := fmt.Sprintf(
+		"https://compute.googleapis.com/compute/v1/projects/%s/global/urlMaps/%s/invalidateCache",
+		project, urlMap,
+	)
+	body := fmt.Sprintf(`{"path": %q}`, path)
+	return doCDNRequest(ctx, "POST", endpoint, map[string]string{"Content-Type": "application/json"}, body)
+}
+
+// doCDNPurgeRequest issues an empty-bodied HTTP request against a CDN
+// provider's purge API, using headers for authentication.
+func doCDNPurgeRequest(ctx context.Context, method, endpoint string, headers map[string]string) error {
+	return doCDNRequest(ctx, method, endpoint, headers, "")
+}
+
+// doCDNRequest issues an HTTP request against a CDN provider's API and
+// treats any non-2xx response as an error.
+func doCDNRequest(ctx context.Context, method, endpoint string, headers map[string]string, body string) error {
+	var bodyReader *strings.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+
+	var req *http.Request
+	var err error
+	if bodyReader != nil {
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, nil)
+	}
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("request to %s failed with status %s", endpoint, resp.Status)
+	}
+	return nil
+}
@@ -20,7 +20,9 @@ import (
 	"crypto/md5"
 	"fmt"
 	"io"
+	"math/rand"
 	"mime"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -28,9 +30,12 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/andybalholm/brotli"
 	"github.com/dustin/go-humanize"
 	"github.com/gohugoio/hugo/config"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	jww "github.com/spf13/jwalterweatherman"
@@ -41,21 +46,30 @@ import (
 	_ "gocloud.dev/blob/fileblob"  // import
 	_ "gocloud.dev/blob/gcsblob"   // import
 	_ "gocloud.dev/blob/s3blob"    // import
+	"gocloud.dev/gcerrors"
 )
 
 // Deployer supports deploying the site to target cloud providers.
 type Deployer struct {
 	localFs afero.Fs
 
-	target        *target          // the target to deploy to
-	matchers      []*matcher       // matchers to apply to uploaded files
-	ordering      []*regexp.Regexp // orders uploads
-	quiet         bool             // true reduces STDOUT
-	confirm       bool             // true enables confirmation before making changes
-	dryRun        bool             // true skips conformations and prints changes instead of applying them
-	force         bool             // true forces upload of all files
-	invalidateCDN bool             // true enables invalidate CDN cache (if possible)
-	maxDeletes    int              // caps the # of files to delete; -1 to disable
+	target          *target          // the target to deploy to
+	matchers        []*matcher       // matchers to apply to uploaded files
+	ordering        []*regexp.Regexp // orders uploads
+	quiet           bool             // true reduces STDOUT
+	confirm         bool             // true enables confirmation before making changes
+	dryRun          bool             // true skips conformations and prints changes instead of applying them
+	force           bool             // true forces upload of all files
+	invalidateCDN   bool             // true enables invalidate CDN cache (if possible)
+	maxDeletes      int              // caps the # of files to delete; -1 to disable
+	continueOnError bool             // true keeps applying the remaining uploads/deletes after a failure
+	atomicDeploy    bool             // true stages uploads and flips them into place only once all succeed
+	rollback        bool             // true restores the target to its pre-previous-deploy manifest instead of deploying
+
+	workers             int           // number of concurrent uploads/deletes
+	maxRetries          int           // retries for a transient error, in addition to the first attempt
+	retryInitialBackoff time.Duration // backoff before the first retry; doubles on each subsequent one
+	uploadTimeout       time.Duration // per-file timeout applied to each upload/delete attempt
 }
 
 // New constructs a new *Deployer.
@@ -78,25 +92,87 @@ func New(cfg config.Provider, localFs afero.Fs) (*Deployer, error) {
 	if tgt == nil {
 		return nil, fmt.Errorf("deployment target %q not found", targetName)
 	}
+
+	workers := cfg.GetInt("workers")
+	if workers <= 0 {
+		workers = 10
+	}
+	maxRetries := cfg.GetInt("maxRetries")
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+	retryInitialBackoff := cfg.GetDuration("retryInitialBackoff")
+	if retryInitialBackoff <= 0 {
+		retryInitialBackoff = 100 * time.Millisecond
+	}
+	uploadTimeout := cfg.GetDuration("uploadTimeout")
+	if uploadTimeout <= 0 {
+		uploadTimeout = 30 * time.Second
+	}
+
 	return &Deployer{
-		localFs:       localFs,
-		target:        tgt,
-		matchers:      dcfg.Matchers,
-		ordering:      dcfg.ordering,
-		quiet:         cfg.GetBool("quiet"),
-		confirm:       cfg.GetBool("confirm"),
-		dryRun:        cfg.GetBool("dryRun"),
-		force:         cfg.GetBool("force"),
-		invalidateCDN: cfg.GetBool("invalidateCDN"),
-		maxDeletes:    cfg.GetInt("maxDeletes"),
+		localFs:             localFs,
+		target:              tgt,
+		matchers:            dcfg.Matchers,
+		ordering:            dcfg.ordering,
+		quiet:               cfg.GetBool("quiet"),
+		confirm:             cfg.GetBool("confirm"),
+		dryRun:              cfg.GetBool("dryRun"),
+		force:               cfg.GetBool("force"),
+		invalidateCDN:       cfg.GetBool("invalidateCDN"),
+		maxDeletes:          cfg.GetInt("maxDeletes"),
+		continueOnError:     cfg.GetBool("continueOnError"),
+		atomicDeploy:        cfg.GetBool("atomicDeploy"),
+		rollback:            cfg.GetBool("rollback"),
+		workers:             workers,
+		maxRetries:          maxRetries,
+		retryInitialBackoff: retryInitialBackoff,
+		uploadTimeout:       uploadTimeout,
 	}, nil
 }
 
+// deployStagingPrefix namespaces the keys an atomic deploy uploads to before
+// they've all succeeded and are flipped into their live keys. It sorts
+// after nothing a site would otherwise publish, keyed off a leading ".".
+const deployStagingPrefix = ".hugo_deploy_staging/"
+
+// splitBucketURLPrefix splits rawURL's "prefix" query parameter (if any)
+// out into its own return value, so multiple sites can be deployed under
+// distinct subdirectories of one bucket/container, e.g.
+// "s3://mybucket?prefix=blog/" deploys to the "blog/" prefix of mybucket.
+// The returned bucketURL has the prefix parameter removed, ready to pass to
+// blob.OpenBucket, and the returned prefix is suitable for direct string
+// concatenation onto a relative key (empty, or ending in "/").
+func splitBucketURLPrefix(rawURL string) (bucketURL string, prefix string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	q := u.Query()
+	prefix = q.Get("prefix")
+	q.Del("prefix")
+	u.RawQuery = q.Encode()
+
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	return u.String(), prefix, nil
+}
+
 // Deploy deploys the site to a target.
 func (d *Deployer) Deploy(ctx context.Context) error {
-	// TODO: This opens the root path in the bucket/container.
-	// Consider adding support for targeting a subdirectory.
-	bucket, err := blob.OpenBucket(ctx, d.target.URL)
+	// A target URL may carry a "prefix" query parameter to scope the
+	// deploy to a subdirectory within the bucket/container, e.g.
+	// "s3://mybucket?prefix=blog/", so multiple sites can share one
+	// bucket without trampling each other's files.
+	bucketURL, prefix, err := splitBucketURLPrefix(d.target.URL)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
 	if err != nil {
 		return err
 	}
@@ -108,15 +184,27 @@ func (d *Deployer) Deploy(ctx context.Context) error {
 	}
 	jww.INFO.Printf("Found %d local files.\n", len(local))
 
-	// Load remote files from the target.
-	remote, err := walkRemote(ctx, bucket)
+	// --rollback targets the manifest saved before the last successful
+	// deploy instead of the one it wrote; everything downstream just syncs
+	// local to whichever manifest (or live bucket listing) it's handed.
+	manifestKey := manifestFilename
+	if d.rollback {
+		manifestKey = manifestRollbackFilename
+	}
+
+	// Load remote files from the target, scoped to prefix. Keys in remote
+	// are relative to prefix, matching the keys in local, so findDiffs can
+	// compare them directly without deletes wiping unrelated siblings
+	// sharing the bucket. Prefer the manifest from a previous deploy over a
+	// full walkRemote: it avoids listing every object in large buckets.
+	remote, remoteMetaHashes, err := d.loadRemoteFiles(ctx, bucket, prefix, manifestKey)
 	if err != nil {
 		return err
 	}
 	jww.INFO.Printf("Found %d remote files.\n", len(remote))
 
 	// Diff local vs remote to see what changes need to be applied.
-	uploads, deletes := findDiffs(local, remote, d.force)
+	uploads, deletes := findDiffs(local, remote, remoteMetaHashes, d.force)
 	if err != nil {
 		return err
 	}
@@ -148,19 +236,22 @@ func (d *Deployer) Deploy(ctx context.Context) error {
 
 	// Apply the changes in parallel, using an inverted worker
 	// pool (https://www.youtube.com/watch?v=5zXAHh5tJqQ&t=26m58s).
-	// sem prevents more than nParallel concurrent goroutines.
-	const nParallel = 10
-	var errs []error
-	var errMu sync.Mutex // protects errs
+	// sem prevents more than d.workers concurrent goroutines.
+	var errs deployErrors
+	var staged []*fileToUpload // uploads landed under deployStagingPrefix, pending flipStaged
+	var errMu sync.Mutex       // protects errs and staged
 
 	for _, uploads := range uploadGroups {
 		// Short-circuit for an empty group.
 		if len(uploads) == 0 {
 			continue
 		}
+		if len(errs) > 0 && !d.continueOnError {
+			break
+		}
 
 		// Within the group, apply uploads in parallel.
-		sem := make(chan struct{}, nParallel)
+		sem := make(chan struct{}, d.workers)
 		for _, upload := range uploads {
 			if d.dryRun {
 				if !d.quiet {
@@ -169,28 +260,57 @@ func (d *Deployer) Deploy(ctx context.Context) error {
 				continue
 			}
 
+			// A staged deploy writes to a temporary key first and only
+			// copies it into upload's live key once every upload in this
+			// deploy has succeeded, so a failure partway through never
+			// leaves the target with some files updated and others not.
+			key := prefix + upload.Local.Path
+			if d.atomicDeploy {
+				key = prefix + deployStagingPrefix + upload.Local.Path
+			}
+
 			sem <- struct{}{}
-			go func(upload *fileToUpload) {
-				if err := doSingleUpload(ctx, bucket, upload); err != nil {
+			go func(upload *fileToUpload, key string) {
+				defer func() { <-sem }()
+				if err := d.retryUpload(ctx, bucket, upload, key); err != nil {
 					errMu.Lock()
-					defer errMu.Unlock()
 					errs = append(errs, err)
+					errMu.Unlock()
+					return
 				}
-				<-sem
-			}(upload)
+				if d.atomicDeploy {
+					errMu.Lock()
+					staged = append(staged, upload)
+					errMu.Unlock()
+				}
+			}(upload, key)
 		}
 		// Wait for all uploads in the group to finish.
-		for n := nParallel; n > 0; n-- {
+		for n := d.workers; n > 0; n-- {
 			sem <- struct{}{}
 		}
 	}
 
+	if len(errs) > 0 && !d.continueOnError {
+		if !d.quiet {
+			jww.FEEDBACK.Printf("Encountered %d errors.\n", len(errs))
+		}
+		return errs
+	}
+
+	if d.atomicDeploy && len(staged) > 0 {
+		jww.INFO.Printf("Flipping %d staged upload(s) into place...\n", len(staged))
+		if err := d.flipStaged(ctx, bucket, prefix, staged); err != nil {
+			return err
+		}
+	}
+
 	if d.maxDeletes != -1 && len(deletes) > d.maxDeletes {
 		jww.WARN.Printf("Skipping %d deletes because it is more than --maxDeletes (%d). If this is expected, set --maxDeletes to a larger number, or -1 to disable this check.\n", len(deletes), d.maxDeletes)
 	} else {
 		// Apply deletes in parallel.
 		sort.Slice(deletes, func(i, j int) bool { return deletes[i] < deletes[j] })
-		sem := make(chan struct{}, nParallel)
+		sem := make(chan struct{}, d.workers)
 		for _, del := range deletes {
 			if d.dryRun {
 				if !d.quiet {
@@ -200,8 +320,7 @@ func (d *Deployer) Deploy(ctx context.Context) error {
 			}
 			sem <- struct{}{}
 			go func(del string) {
-				jww.INFO.Printf("Deleting %s...\n", del)
-				if err := bucket.Delete(ctx, del); err != nil {
+				if err := d.retryDelete(ctx, bucket, del, prefix); err != nil {
 					errMu.Lock()
 					defer errMu.Unlock()
 					errs = append(errs, err)
@@ -210,7 +329,7 @@ func (d *Deployer) Deploy(ctx context.Context) error {
 			}(del)
 		}
 		// Wait for all deletes to finish.
-		for n := nParallel; n > 0; n-- {
+		for n := d.workers; n > 0; n-- {
 			sem <- struct{}{}
 		}
 	}
@@ -218,19 +337,119 @@ func (d *Deployer) Deploy(ctx context.Context) error {
 		if !d.quiet {
 			jww.FEEDBACK.Printf("Encountered %d errors.\n", len(errs))
 		}
-		return errs[0]
+		return errs
 	}
 	if !d.quiet {
 		jww.FEEDBACK.Println("Success!")
 	}
 
-	if d.invalidateCDN && d.target.CloudFrontDistributionID != "" {
-		jww.FEEDBACK.Println("Invalidating CloudFront CDN...")
-		if err := InvalidateCloudFront(ctx, d.target.CloudFrontDistributionID); err != nil {
-			jww.FEEDBACK.Printf("Failed to invalidate CloudFront CDN: %v\n", err)
+	if !d.dryRun {
+		if err := saveManifest(ctx, bucket, prefix, local); err != nil {
+			jww.WARN.Printf("Deploy succeeded, but failed to save the deploy manifest: %v\n", err)
+		}
+	}
+
+	if d.invalidateCDN {
+		if err := d.invalidateCDNTarget(ctx, uploads, deletes); err != nil {
 			return err
 		}
-		jww.FEEDBACK.Println("Success!")
+	}
+	return nil
+}
+
+// invalidateCDNTarget purges the CDN fronting d.target, if one is
+// configured. CDNURL takes a scheme identifying the provider, e.g.
+// "cloudfront://E1A2B3C4D5" or "fastly://serviceID"; CloudFrontDistributionID
+// is kept as a deprecated alias for "cloudfront://" so existing
+// configurations using it keep working.
+func (d *Deployer) invalidateCDNTarget(ctx context.Context, uploads []*fileToUpload, deletes []string) error {
+	cdnURL := d.target.CDNURL
+	if cdnURL == "" && d.target.CloudFrontDistributionID != "" {
+		cdnURL = "cloudfront://" + d.target.CloudFrontDistributionID
+	}
+	if cdnURL == "" {
+		return nil
+	}
+
+	invalidator, err := newCDNInvalidator(cdnURL)
+	if err != nil {
+		jww.FEEDBACK.Printf("Failed to configure CDN invalidation: %v\n", err)
+		return err
+	}
+
+	jww.FEEDBACK.Println("Invalidating CDN...")
+	if err := invalidator.Invalidate(ctx, changedPaths(uploads, deletes)); err != nil {
+		jww.FEEDBACK.Printf("Failed to invalidate CDN: %v\n", err)
+		return err
+	}
+	jww.FEEDBACK.Println("Success!")
+	return nil
+}
+
+// loadRemoteFiles returns the target's file set, scoped to prefix and keyed
+// the same way walkRemote is, plus the metaHashKey value saved for each file
+// (nil if falling back to walkRemote, which has no way to read it cheaply).
+// It prefers the manifest saved at manifestKey over a full walkRemote, since
+// reading one small JSON object is dramatically cheaper than listing every
+// object in a bucket with tens of thousands of them. It falls back to
+// walkRemote when no manifest exists yet, unless d.rollback is set, in which
+// case there's nothing sensible to restore.
+func (d *Deployer) loadRemoteFiles(ctx context.Context, bucket *blob.Bucket, prefix, manifestKey string) (map[string]*blob.ListObject, map[string]string, error) {
+	manifest, err := loadManifest(ctx, bucket, prefix, manifestKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if manifest != nil {
+		return manifest.remoteFiles(), manifest.metaHashes(), nil
+	}
+	if d.rollback {
+		return nil, nil, fmt.Errorf("no manifest found at %q to roll back to", prefix+manifestKey)
+	}
+	remote, err := walkRemote(ctx, bucket, prefix)
+	return remote, nil, err
+}
+
+// flipStaged copies each of staged's objects from its temporary
+// deployStagingPrefix key to its live key, now that every upload in this
+// deploy has succeeded, then removes the staging copy. Until this runs,
+// nothing user-visible has changed; if a copy fails partway through, the
+// remaining staged objects are simply left behind for the next deploy to
+// overwrite.
+func (d *Deployer) flipStaged(ctx context.Context, bucket *blob.Bucket, prefix string, staged []*fileToUpload) error {
+	var errs deployErrors
+	var errMu sync.Mutex // protects errs
+
+	sem := make(chan struct{}, d.workers)
+	for _, upload := range staged {
+		sem <- struct{}{}
+		go func(path string) {
+			defer func() { <-sem }()
+			liveKey := prefix + path
+			stagedKey := prefix + deployStagingPrefix + path
+			err := withRetry(ctx, d.maxRetries, d.retryInitialBackoff, func() error {
+				attemptCtx, cancel := context.WithTimeout(ctx, d.uploadTimeout)
+				defer cancel()
+				return bucket.Copy(attemptCtx, liveKey, stagedKey, nil)
+			})
+			if err != nil {
+				errMu.Lock()
+				errs = append(errs, err)
+				errMu.Unlock()
+				return
+			}
+			if err := bucket.Delete(ctx, stagedKey); err != nil {
+				jww.WARN.Printf("Failed to remove staged copy of %s: %v\n", path, err)
+			}
+		}(upload.Local.Path)
+	}
+	for n := d.workers; n > 0; n-- {
+		sem <- struct{}{}
+	}
+	if len(errs) > 0 {
+		if !d.quiet {
+			jww.FEEDBACK.Printf("Encountered %d errors flipping staged uploads into place.\n", len(errs))
+		}
+		return errs
 	}
 	return nil
 }
@@ -244,19 +463,107 @@ func summarizeChanges(uploads []*fileToUpload, deletes []string) string {
 	return fmt.Sprintf("Identified %d file(s) to upload, totaling %s, and %d file(s) to delete.", len(uploads), humanize.Bytes(uint64(uploadSize)), len(deletes))
 }
 
-// doSingleUpload executes a single file upload.
-func doSingleUpload(ctx context.Context, bucket *blob.Bucket, upload *fileToUpload) error {
+// retryUpload retries doSingleUpload up to d.maxRetries times on a
+// transient error, backing off exponentially with jitter between
+// attempts, and bounds each attempt with d.uploadTimeout. key is the full
+// key (prefix included) to write to, which is upload's live key or, for a
+// staged deploy, its temporary deployStagingPrefix key.
+func (d *Deployer) retryUpload(ctx context.Context, bucket *blob.Bucket, upload *fileToUpload, key string) error {
+	return withRetry(ctx, d.maxRetries, d.retryInitialBackoff, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, d.uploadTimeout)
+		defer cancel()
+		return doSingleUpload(attemptCtx, bucket, upload, key)
+	})
+}
+
+// retryDelete retries a single bucket.Delete the same way retryUpload
+// retries an upload.
+func (d *Deployer) retryDelete(ctx context.Context, bucket *blob.Bucket, key, prefix string) error {
+	return withRetry(ctx, d.maxRetries, d.retryInitialBackoff, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, d.uploadTimeout)
+		defer cancel()
+		jww.INFO.Printf("Deleting %s...\n", key)
+		return bucket.Delete(attemptCtx, prefix+key)
+	})
+}
+
+// withRetry calls fn up to maxRetries+1 times, doubling an exponential
+// backoff (plus jitter) between attempts, but only when fn's error looks
+// transient; a non-transient error or the final attempt's error is
+// returned immediately.
+func withRetry(ctx context.Context, maxRetries int, initialBackoff time.Duration, fn func() error) error {
+	backoff := initialBackoff
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = fn()
+		if err == nil || attempt == maxRetries || !isTransientErr(err) {
+			return err
+		}
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+	return err
+}
+
+// isTransientErr reports whether err looks like a transient failure worth
+// retrying: a deadline exceeded, or a provider error gcerrors.Code
+// classifies as resource-exhausted/internal (covers 5xx responses and
+// connection resets across gocloud.dev's providers).
+func isTransientErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch gcerrors.Code(err) {
+	case gcerrors.DeadlineExceeded, gcerrors.ResourceExhausted, gcerrors.Internal:
+		return true
+	default:
+		return false
+	}
+}
+
+// deployErrors collects every error encountered while applying uploads and
+// deletes, instead of Deploy returning only the first one it saw.
+type deployErrors []error
+
+func (e deployErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred:\n\t%s", len(e), strings.Join(msgs, "\n\t"))
+}
+
+// doSingleUpload executes a single file upload, writing to key (the caller
+// decides whether that's upload's live key or a staged one).
+func doSingleUpload(ctx context.Context, bucket *blob.Bucket, upload *fileToUpload, key string) error {
 	jww.INFO.Printf("Uploading %v...\n", upload)
 	opts := &blob.WriterOptions{
 		CacheControl:    upload.Local.CacheControl(),
 		ContentEncoding: upload.Local.ContentEncoding(),
 		ContentType:     upload.Local.ContentType(),
+		Metadata:        writerMetadata(upload.Local),
+		BeforeWrite:     beforeWrite(upload.Local.matcher),
+	}
+	w, err := bucket.NewWriter(ctx, key, opts)
+	if err != nil {
+		return err
 	}
-	w, err := bucket.NewWriter(ctx, upload.Local.Path, opts)
+	r, _, err := contentToUpload(upload.Local.fs, upload.Local.diskPath, upload.Local.matcher, upload.Local.encoding)
 	if err != nil {
 		return err
 	}
-	_, err = io.Copy(w, upload.Local.UploadContentReader)
+	_, err = io.Copy(w, r)
 	if err != nil {
 		return err
 	}
@@ -269,39 +576,70 @@ func doSingleUpload(ctx context.Context, bucket *blob.Bucket, upload *fileToUplo
 // localFile represents a local file from the source. Use newLocalFile to
 // construct one.
 type localFile struct {
-	// Path is the relative path to the file.
+	// Path is the relative path this file is stored under at the target,
+	// which carries an encoding suffix (e.g. ".br") for a pre-compressed
+	// sibling of diskPath.
 	Path string
 	// UploadSize is the size of the content to be uploaded. It may not
 	// be the same as the local file size if the content will be
-	// gzipped before upload.
+	// compressed before upload.
 	UploadSize int64
 	// UploadContentReader reads the content to be uploaded. Again,
-	// it may not be the same as the local file content due to gzipping.
+	// it may not be the same as the local file content due to compression.
 	UploadContentReader io.Reader
 
-	fs      afero.Fs
-	matcher *matcher
-	md5     []byte // cache
+	diskPath string // the on-disk path read for content; equals Path unless this is a sibling
+	encoding string // Content-Encoding applied when reading diskPath, or "" for none
+	fs       afero.Fs
+	matcher  *matcher
+	md5      []byte // cache
 }
 
-// newLocalFile initializes a *localFile.
-func newLocalFile(fs afero.Fs, path string, m *matcher) (*localFile, error) {
-	r, size, err := contentToUpload(fs, path, m)
+// newLocalFile initializes a *localFile representing diskPath's content,
+// compressed with encoding (or left alone, if encoding is ""), stored at
+// remotePath.
+func newLocalFile(fs afero.Fs, diskPath, remotePath string, m *matcher, encoding string) (*localFile, error) {
+	r, size, err := contentToUpload(fs, diskPath, m, encoding)
 	if err != nil {
 		return nil, err
 	}
 	return &localFile{
-		Path:                path,
+		Path:                remotePath,
 		UploadSize:          size,
 		UploadContentReader: r,
+		diskPath:            diskPath,
+		encoding:            encoding,
 		fs:                  fs,
 		matcher:             m,
 	}, nil
 }
 
-// contentToUpload returns an io.Reader and size for the content to be uploaded
-// from path. It applies gzip encoding if needed.
-func contentToUpload(fs afero.Fs, path string, m *matcher) (io.Reader, int64, error) {
+// contentEncodings returns m's configured list of Content-Encoding values to
+// produce, in preference order (e.g. []string{"br", "gzip"} for
+// Encoding: "br,gzip"). A nil/empty result means "upload the file as-is".
+// m.Gzip is kept working as a shorthand for Encoding: "gzip".
+func contentEncodings(m *matcher) []string {
+	if m == nil {
+		return nil
+	}
+	if m.Encoding != "" {
+		var encodings []string
+		for _, e := range strings.Split(m.Encoding, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				encodings = append(encodings, e)
+			}
+		}
+		return encodings
+	}
+	if m.Gzip {
+		return []string{"gzip"}
+	}
+	return nil
+}
+
+// contentToUpload returns an io.Reader and size for the content to be
+// uploaded from path, compressed with encoding if it's non-empty.
+func contentToUpload(fs afero.Fs, path string, m *matcher, encoding string) (io.Reader, int64, error) {
 	f, err := fs.Open(path)
 	if err != nil {
 		return nil, 0, err
@@ -312,17 +650,67 @@ func contentToUpload(fs afero.Fs, path string, m *matcher) (io.Reader, int64, er
 	}
 	r := io.Reader(f)
 	size := info.Size()
-	if m != nil && m.Gzip {
-		var b bytes.Buffer
-		gz := gzip.NewWriter(&b)
-		io.Copy(gz, f)
-		gz.Close()
-		r = &b
+	if encoding != "" {
+		level := 0
+		if m != nil {
+			level = m.CompressionLevel
+		}
+		b, err := compressContent(encoding, level, f)
+		if err != nil {
+			return nil, 0, err
+		}
+		r = b
 		size = int64(b.Len())
 	}
 	return r, size, nil
 }
 
+// compressContent compresses r's content with encoding ("gzip", "br", or
+// "zstd"), using level if non-zero or the algorithm's own default otherwise.
+func compressContent(encoding string, level int, r io.Reader) (*bytes.Buffer, error) {
+	var b bytes.Buffer
+	var w io.WriteCloser
+
+	switch encoding {
+	case "gzip":
+		if level != 0 {
+			gw, err := gzip.NewWriterLevel(&b, level)
+			if err != nil {
+				return nil, err
+			}
+			w = gw
+		} else {
+			w = gzip.NewWriter(&b)
+		}
+	case "br":
+		if level != 0 {
+			w = brotli.NewWriterLevel(&b, level)
+		} else {
+			w = brotli.NewWriter(&b)
+		}
+	case "zstd":
+		var opts []zstd.EOption
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		zw, err := zstd.NewWriter(&b, opts...)
+		if err != nil {
+			return nil, err
+		}
+		w = zw
+	default:
+		return nil, fmt.Errorf("deploy: unsupported encoding %q", encoding)
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
 // CacheControl returns the Cache-Control header to use for lf, based on the
 // first matching matcher (if any).
 func (lf *localFile) CacheControl() string {
@@ -335,12 +723,12 @@ func (lf *localFile) CacheControl() string {
 // ContentEncoding returns the Content-Encoding header to use for lf, based
 // on the matcher's Content-Encoding and Gzip fields.
 func (lf *localFile) ContentEncoding() string {
+	if lf.encoding != "" {
+		return lf.encoding
+	}
 	if lf.matcher == nil {
 		return ""
 	}
-	if lf.matcher.Gzip {
-		return "gzip"
-	}
 	return lf.matcher.ContentEncoding
 }
 
@@ -357,7 +745,9 @@ func (lf *localFile) ContentType() string {
 	// TODO: Hugo has a MediaType and a MediaTypes list and also a concept
 	// of custom MIME types.
 	// Use 1) The matcher 2) Hugo's MIME types 3) TypeByExtension.
-	return mime.TypeByExtension(filepath.Ext(lf.Path))
+	// diskPath (not Path) so an encoded sibling like "index.html.br" still
+	// reports "text/html" rather than whatever (if anything) ".br" maps to.
+	return mime.TypeByExtension(filepath.Ext(lf.diskPath))
 }
 
 // Force returns true if the file should be forced to re-upload based on the
@@ -373,8 +763,11 @@ func (lf *localFile) MD5() []byte {
 	}
 	// We can't use lf.UploadContentReader directly because if there's a
 	// delta we'll want to read it again later, and we have no way of
-	// resetting the reader. So, create a new one.
-	r, _, err := contentToUpload(lf.fs, lf.Path, lf.matcher)
+	// resetting the reader. So, create a new one. Re-deriving lf.encoding's
+	// content here (rather than caching the compressed bytes themselves)
+	// still avoids re-compressing on every diff: the cache below is keyed
+	// per *localFile, and each encoding of a file gets its own *localFile.
+	r, _, err := contentToUpload(lf.fs, lf.diskPath, lf.matcher, lf.encoding)
 	if err != nil {
 		return nil
 	}
@@ -386,6 +779,13 @@ func (lf *localFile) MD5() []byte {
 	return lf.md5
 }
 
+// MetaHash returns a stable hash of the custom metadata lf's matcher
+// configures (StorageClass, ACL, SSEKMSKeyID, and Metadata), used by
+// findDiffs to detect a metadata-only change since the last deploy.
+func (lf *localFile) MetaHash() string {
+	return metaHash(customMetadata(lf.matcher))
+}
+
 // walkLocal walks the source directory and returns a flat list of files.
 func walkLocal(fs afero.Fs, matchers []*matcher) (map[string]*localFile, error) {
 	retval := map[string]*localFile{}
@@ -419,11 +819,35 @@ func walkLocal(fs afero.Fs, matchers []*matcher) (map[string]*localFile, error)
 				break
 			}
 		}
-		lf, err := newLocalFile(fs, path, m)
+
+		// A matcher configuring a single encoding (including the Gzip
+		// shorthand) replaces path's content in place with that encoding.
+		// One configuring more than one leaves path itself uncompressed and
+		// additionally produces a sibling *localFile per encoding, suffixed
+		// with "."+encoding (e.g. "index.html.br"), so a CDN can pick
+		// whichever compressed representation it supports.
+		encodings := contentEncodings(m)
+		primaryEncoding := ""
+		var siblingEncodings []string
+		if len(encodings) == 1 {
+			primaryEncoding = encodings[0]
+		} else {
+			siblingEncodings = encodings
+		}
+
+		lf, err := newLocalFile(fs, path, path, m, primaryEncoding)
 		if err != nil {
 			return err
 		}
 		retval[path] = lf
+
+		for _, enc := range siblingEncodings {
+			sibling, err := newLocalFile(fs, path, path+"."+enc, m, enc)
+			if err != nil {
+				return err
+			}
+			retval[sibling.Path] = sibling
+		}
 		return nil
 	})
 	if err != nil {
@@ -432,10 +856,11 @@ func walkLocal(fs afero.Fs, matchers []*matcher) (map[string]*localFile, error)
 	return retval, nil
 }
 
-// walkRemote walks the target bucket and returns a flat list.
-func walkRemote(ctx context.Context, bucket *blob.Bucket) (map[string]*blob.ListObject, error) {
+// walkRemote walks the target bucket below prefix and returns a flat list,
+// keyed by path relative to prefix so it lines up with walkLocal's keys.
+func walkRemote(ctx context.Context, bucket *blob.Bucket, prefix string) (map[string]*blob.ListObject, error) {
 	retval := map[string]*blob.ListObject{}
-	iter := bucket.List(nil)
+	iter := bucket.List(&blob.ListOptions{Prefix: prefix})
 	for {
 		obj, err := iter.Next(ctx)
 		if err == io.EOF {
@@ -461,6 +886,9 @@ func walkRemote(ctx context.Context, bucket *blob.Bucket) (map[string]*blob.List
 				r.Close()
 			}
 		}
+		// Key the result by the path relative to prefix, so it lines up
+		// with walkLocal's keys and deletes can't escape prefix.
+		obj.Key = strings.TrimPrefix(obj.Key, prefix)
 		retval[obj.Key] = obj
 	}
 	return retval, nil
@@ -470,12 +898,13 @@ func walkRemote(ctx context.Context, bucket *blob.Bucket) (map[string]*blob.List
 type uploadReason string
 
 const (
-	reasonUnknown    uploadReason = "unknown"
-	reasonNotFound   uploadReason = "not found at target"
-	reasonForce      uploadReason = "--force"
-	reasonSize       uploadReason = "size differs"
-	reasonMD5Differs uploadReason = "md5 differs"
-	reasonMD5Missing uploadReason = "remote md5 missing"
+	reasonUnknown     uploadReason = "unknown"
+	reasonNotFound    uploadReason = "not found at target"
+	reasonForce       uploadReason = "--force"
+	reasonSize        uploadReason = "size differs"
+	reasonMD5Differs  uploadReason = "md5 differs"
+	reasonMD5Missing  uploadReason = "remote md5 missing"
+	reasonMetaDiffers uploadReason = "metadata differs"
 )
 
 // fileToUpload represents a single local file that should be uploaded to
@@ -500,9 +929,12 @@ func (u *fileToUpload) String() string {
 }
 
 // findDiffs diffs localFiles vs remoteFiles to see what changes should be
-// applied to the remote target. It returns a slice of *fileToUpload and a
+// applied to the remote target. remoteMetaHashes holds the metaHashKey
+// value saved for each remote file in the last deploy's manifest, or nil if
+// remoteFiles came from a plain walkRemote (which has no way to read it
+// without a per-object request). It returns a slice of *fileToUpload and a
 // slice of paths for files to delete.
-func findDiffs(localFiles map[string]*localFile, remoteFiles map[string]*blob.ListObject, force bool) ([]*fileToUpload, []string) {
+func findDiffs(localFiles map[string]*localFile, remoteFiles map[string]*blob.ListObject, remoteMetaHashes map[string]string, force bool) ([]*fileToUpload, []string) {
 	var uploads []*fileToUpload
 	var deletes []string
 
@@ -516,15 +948,18 @@ func findDiffs(localFiles map[string]*localFile, remoteFiles map[string]*blob.Li
 		if remoteFile, ok := remoteFiles[path]; ok {
 			// The file exists in remote. Let's see if we need to upload it anyway.
 
-			// TODO: We don't register a diff if the metadata (e.g., Content-Type
-			// header) has changed. This would be difficult/expensive to detect; some
-			// providers return metadata along with their "List" result, but others
-			// (notably AWS S3) do not, so gocloud.dev's blob.Bucket doesn't expose
-			// it in the list result. It would require a separate request per blob
-			// to fetch. At least for now, we work around this by documenting it and
-			// providing a "force" flag (to re-upload everything) and a "force" bool
-			// per matcher (to re-upload all files in a matcher whose headers may have
-			// changed).
+			// TODO: We don't register a diff if the Cache-Control/Content-Type
+			// headers have changed. This would be difficult/expensive to detect;
+			// some providers return metadata along with their "List" result, but
+			// others (notably AWS S3) do not, so gocloud.dev's blob.Bucket doesn't
+			// expose it in the list result. It would require a separate request
+			// per blob to fetch. At least for now, we work around this by
+			// documenting it and providing a "force" flag (to re-upload everything)
+			// and a "force" bool per matcher (to re-upload all files in a matcher
+			// whose headers may have changed). A matcher's custom Metadata (and its
+			// StorageClass/ACL/SSEKMSKeyID, which ride along as a hash under
+			// metaHashKey) don't have this problem, since deploy's own manifest
+			// remembers them from the last deploy: see remoteMetaHashes below.
 			// Idea: extract a sample set of 1 file per extension + 1 file per matcher
 			// and check those files?
 			if force {
@@ -545,6 +980,9 @@ func findDiffs(localFiles map[string]*localFile, remoteFiles map[string]*blob.Li
 			} else if !bytes.Equal(lf.MD5(), remoteFile.MD5) {
 				upload = true
 				reason = reasonMD5Differs
+			} else if remoteMetaHashes != nil && remoteMetaHashes[path] != lf.MetaHash() {
+				upload = true
+				reason = reasonMetaDiffers
 			} else {
 				// Nope! Leave uploaded = false.
 			}
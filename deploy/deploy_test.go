@@ -0,0 +1,135 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWithRetrySucceedsWithoutRetry(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected exactly 1 call, got %d", calls)
+	}
+}
+
+func TestWithRetryGivesUpImmediatelyOnNonTransientError(t *testing.T) {
+	wantErr := errors.New("boom")
+	calls := 0
+	err := withRetry(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if calls != 1 {
+		t.Errorf("non-transient error should not be retried, got %d calls", calls)
+	}
+}
+
+func TestWithRetryRetriesOnDeadlineExceeded(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), 3, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("attempt %d: %w", calls, context.DeadlineExceeded)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls (2 failures + 1 success), got %d", calls)
+	}
+}
+
+func TestWithRetryStopsAfterMaxRetries(t *testing.T) {
+	calls := 0
+	err := withRetry(context.Background(), 2, time.Millisecond, func() error {
+		calls++
+		return context.DeadlineExceeded
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the last error to be returned, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected maxRetries+1 = 3 calls, got %d", calls)
+	}
+}
+
+func TestWithRetryStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cancel()
+	}()
+	err := withRetry(ctx, 10, 50*time.Millisecond, func() error {
+		calls++
+		return context.DeadlineExceeded
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled once the parent context is done, got %v", err)
+	}
+	if calls == 0 {
+		t.Errorf("expected at least 1 call before the context was canceled")
+	}
+}
+
+func TestIsTransientErr(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain error", errors.New("boom"), false},
+		{"deadline exceeded", context.DeadlineExceeded, true},
+		{"wrapped deadline exceeded", fmt.Errorf("upload: %w", context.DeadlineExceeded), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientErr(tt.err); got != tt.want {
+				t.Errorf("isTransientErr(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeployErrorsError(t *testing.T) {
+	single := deployErrors{errors.New("one")}
+	if got, want := single.Error(), "one"; got != want {
+		t.Errorf("single error: got %q, want %q", got, want)
+	}
+
+	multi := deployErrors{errors.New("one"), errors.New("two")}
+	got := multi.Error()
+	want := "2 errors occurred:\n\tone\n\ttwo"
+	if got != want {
+		t.Errorf("multiple errors: got %q, want %q", got, want)
+	}
+}
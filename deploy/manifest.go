@@ -0,0 +1,126 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// manifestFilename is the name of the manifest object, relative to the
+// deploy's prefix. rollbackFilename holds the manifest as it was before the
+// most recent successful deploy, so --rollback has something to restore.
+const (
+	manifestFilename         = "deploy-manifest.json"
+	manifestRollbackFilename = "deploy-manifest.prev.json"
+)
+
+// manifestEntry is the persisted record of a single deployed file, enough
+// to stand in for a blob.ListObject when diffing without a full walkRemote.
+type manifestEntry struct {
+	Path     string `json:"path"`
+	Size     int64  `json:"size"`
+	MD5      string `json:"md5"`                // hex-encoded
+	MetaHash string `json:"metaHash,omitempty"` // lf.MetaHash(), for detecting metadata-only changes
+}
+
+// deployManifest is the set of files live at the target as of the deploy
+// that wrote it.
+type deployManifest struct {
+	Files []manifestEntry `json:"files"`
+}
+
+// newManifest builds the manifest describing local, which is the target's
+// full file set immediately after local has been successfully synced to it.
+func newManifest(local map[string]*localFile) *deployManifest {
+	m := &deployManifest{Files: make([]manifestEntry, 0, len(local))}
+	for path, lf := range local {
+		m.Files = append(m.Files, manifestEntry{
+			Path:     path,
+			Size:     lf.UploadSize,
+			MD5:      hex.EncodeToString(lf.MD5()),
+			MetaHash: lf.MetaHash(),
+		})
+	}
+	return m
+}
+
+// remoteFiles converts m back into the map[string]*blob.ListObject shape
+// findDiffs expects, so a manifest can stand in for walkRemote.
+func (m *deployManifest) remoteFiles() map[string]*blob.ListObject {
+	retval := make(map[string]*blob.ListObject, len(m.Files))
+	for _, f := range m.Files {
+		md5, err := hex.DecodeString(f.MD5)
+		if err != nil {
+			// Corrupt entry; skip it so it's treated as missing and re-uploaded.
+			continue
+		}
+		retval[f.Path] = &blob.ListObject{Key: f.Path, Size: f.Size, MD5: md5}
+	}
+	return retval
+}
+
+// metaHashes returns m's per-file MetaHash, keyed the same way remoteFiles
+// is, for findDiffs to compare against each localFile's MetaHash().
+func (m *deployManifest) metaHashes() map[string]string {
+	retval := make(map[string]string, len(m.Files))
+	for _, f := range m.Files {
+		retval[f.Path] = f.MetaHash
+	}
+	return retval
+}
+
+// loadManifest reads and parses the manifest at key, scoped to prefix. It
+// returns a nil manifest (not an error) if key doesn't exist, which happens
+// on a bucket's first deploy.
+func loadManifest(ctx context.Context, bucket *blob.Bucket, prefix, key string) (*deployManifest, error) {
+	data, err := bucket.ReadAll(ctx, prefix+key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var m deployManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// writeManifest marshals m and writes it to key, scoped to prefix.
+func writeManifest(ctx context.Context, bucket *blob.Bucket, prefix, key string, m *deployManifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return bucket.WriteAll(ctx, prefix+key, data, nil)
+}
+
+// saveManifest persists local as the new live manifest, first preserving
+// the outgoing manifest (if any) as the rollback manifest.
+func saveManifest(ctx context.Context, bucket *blob.Bucket, prefix string, local map[string]*localFile) error {
+	if cur, err := bucket.ReadAll(ctx, prefix+manifestFilename); err == nil {
+		if err := bucket.WriteAll(ctx, prefix+manifestRollbackFilename, cur, nil); err != nil {
+			return err
+		}
+	} else if gcerrors.Code(err) != gcerrors.NotFound {
+		return err
+	}
+	return writeManifest(ctx, bucket, prefix, manifestFilename, newManifest(local))
+}
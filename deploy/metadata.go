@@ -0,0 +1,116 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"sort"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// metaHashKey is the Metadata key deploy stores a hash of a file's other
+// custom metadata under. findDiffs compares it against the hash stored in
+// the manifest from the last deploy, so a matcher's Metadata/StorageClass/
+// ACL/SSEKMSKeyID taking effect on an otherwise-unchanged file triggers a
+// re-upload without needing --force.
+const metaHashKey = "x-hugo-meta-hash"
+
+// metaHash returns a stable hash of md, independent of map iteration order.
+func metaHash(md map[string]string) string {
+	if len(md) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := md5.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(md[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// customMetadata returns the Metadata key/value pairs m configures, not
+// including metaHashKey itself.
+func customMetadata(m *matcher) map[string]string {
+	if m == nil {
+		return nil
+	}
+	return m.Metadata
+}
+
+// writerMetadata returns the full set of Metadata key/value pairs to upload
+// lf with, including the reserved metaHashKey entry.
+func writerMetadata(lf *localFile) map[string]string {
+	custom := customMetadata(lf.matcher)
+	md := make(map[string]string, len(custom)+1)
+	for k, v := range custom {
+		md[k] = v
+	}
+	md[metaHashKey] = metaHash(custom)
+	return md
+}
+
+// beforeWrite returns a blob.WriterOptions.BeforeWrite callback applying m's
+// provider-specific upload settings (S3 storage class/ACL/SSE-KMS and their
+// GCS equivalent) to the driver's request type, via the As() mechanism the
+// gocloud.dev blob drivers use to expose them. It returns nil if m sets none
+// of them, leaving WriterOptions.BeforeWrite unset.
+//
+// The azureblob driver's current SDK version exposes no equivalent knobs on
+// its upload options, so StorageClass/ACL/SSEKMSKeyID have no effect there.
+func beforeWrite(m *matcher) func(asFunc func(interface{}) bool) error {
+	if m == nil || (m.StorageClass == "" && m.ACL == "" && m.SSEKMSKeyID == "") {
+		return nil
+	}
+	return func(asFunc func(interface{}) bool) error {
+		var s3req *s3manager.UploadInput
+		if asFunc(&s3req) {
+			if m.StorageClass != "" {
+				s3req.StorageClass = aws.String(m.StorageClass)
+			}
+			if m.ACL != "" {
+				s3req.ACL = aws.String(m.ACL)
+			}
+			if m.SSEKMSKeyID != "" {
+				s3req.ServerSideEncryption = aws.String("aws:kms")
+				s3req.SSEKMSKeyId = aws.String(m.SSEKMSKeyID)
+			}
+			return nil
+		}
+		var gcsWriter *storage.Writer
+		if asFunc(&gcsWriter) {
+			if m.StorageClass != "" {
+				gcsWriter.StorageClass = m.StorageClass
+			}
+			if m.ACL != "" {
+				gcsWriter.PredefinedACL = m.ACL
+			}
+			if m.SSEKMSKeyID != "" {
+				gcsWriter.KMSKeyName = m.SSEKMSKeyID
+			}
+			return nil
+		}
+		return nil
+	}
+}
@@ -1,7 +1,6 @@
 package deps
 
 import (
-	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -77,8 +76,9 @@ type Deps struct {
 	// Timeout is configurable in site config.
 	Timeout time.Duration
 
-	// BuildStartListeners will be notified before a build starts.
-	BuildStartListeners *Listeners
+	// Events is the typed event bus used to notify subscribers about
+	// build lifecycle events, e.g. EventBuildStart.
+	Events *EventBus
 
 	*globalErrHandler
 }
@@ -86,12 +86,22 @@ type Deps struct {
 type globalErrHandler struct {
 	// Channel for some "hard to get to" build errors
 	buildErrors chan error
+
+	// events is the bus SendError publishes an EventBuildError to, so
+	// subscribers (e.g. a UI surfacing the last build error) don't have to
+	// also know about the buildErrors channel. May be nil in tests that
+	// construct a globalErrHandler directly.
+	events *EventBus
 }
 
 // SendErr sends the error on a channel to be handled later.
 // This can be used in situations where returning and aborting the current
 // operation isn't practical.
 func (e *globalErrHandler) SendError(err error) {
+	if e.events != nil {
+		e.events.Publish(Event{Type: EventBuildError, Data: BuildEventData{Err: err}})
+	}
+
 	if e.buildErrors != nil {
 		select {
 		case e.buildErrors <- err:
@@ -108,30 +118,6 @@ func (e *globalErrHandler) StartErrorCollector() chan error {
 	return e.buildErrors
 }
 
-// Listeners represents an event listener.
-type Listeners struct {
-	sync.Mutex
-
-	// A list of funcs to be notified about an event.
-	listeners []func()
-}
-
-// Add adds a function to a Listeners instance.
-func (b *Listeners) Add(f func()) {
-	b.Lock()
-	defer b.Unlock()
-	b.listeners = append(b.listeners, f)
-}
-
-// Notify executes all listener functions.
-func (b *Listeners) Notify() {
-	b.Lock()
-	defer b.Unlock()
-	for _, notify := range b.listeners {
-		notify()
-	}
-}
-
 // ResourceProvider is used to create and refresh, and clone resources needed.
 type ResourceProvider interface {
 	Update(deps *Deps) error
@@ -216,6 +202,7 @@ func New(cfg DepsCfg) (*Deps, error) {
 	}
 
 	distinctErrorLogger := helpers.NewDistinctLogger(logger.ERROR)
+	events := NewEventBus()
 
 	d := &Deps{
 		Fs:                  fs,
@@ -231,9 +218,9 @@ func New(cfg DepsCfg) (*Deps, error) {
 		Cfg:                 cfg.Language,
 		Language:            cfg.Language,
 		FileCaches:          fileCaches,
-		BuildStartListeners: &Listeners{},
+		Events:              events,
 		Timeout:             time.Duration(timeoutms) * time.Millisecond,
-		globalErrHandler:    &globalErrHandler{},
+		globalErrHandler:    &globalErrHandler{events: events},
 	}
 
 	if cfg.Cfg.GetBool("templateMetrics") {
@@ -247,6 +234,10 @@ func New(cfg DepsCfg) (*Deps, error) {
 // parts switched out.
 func (d Deps) ForLanguage(cfg DepsCfg) (*Deps, error) {
 	l := cfg.Language
+	fromLang := ""
+	if d.Language != nil {
+		fromLang = d.Language.Lang
+	}
 	var err error
 
 	d.PathSpec, err = helpers.NewPathSpecWithBaseBaseFsProvided(d.Fs, l, d.BaseFs)
@@ -279,7 +270,12 @@ func (d Deps) ForLanguage(cfg DepsCfg) (*Deps, error) {
 		return nil, err
 	}
 
-	d.BuildStartListeners = &Listeners{}
+	// Events is intentionally left as-is here rather than reset to a fresh
+	// bus: Deps is passed by value, so d.Events already aliases the
+	// original Deps' *EventBus, and a subscriber registered before a
+	// language switch (e.g. partials' cache-clear-on-build-start hook)
+	// should keep firing for the derived, per-language Deps too.
+	d.Events.Publish(Event{Type: EventLanguageSwitch, Data: LanguageSwitchEventData{From: fromLang, To: l.Lang}})
 
 	return &d, nil
 
@@ -317,4 +313,10 @@ type DepsCfg struct {
 
 	// Whether we are in running (server) mode
 	Running bool
+
+	// CollectStats, when true, makes HugoSites record per-phase build
+	// timings and allocation counts, retrievable via HugoSites.BuildStats.
+	// It is normally off, so the instrumentation costs nothing in a
+	// standard build.
+	CollectStats bool
 }
@@ -0,0 +1,207 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+// EventType identifies the kind of event published on an EventBus.
+type EventType string
+
+const (
+	// EventBuildStart is published before a (re)build starts. Data is a
+	// BuildEventData with Elapsed left at its zero value.
+	EventBuildStart EventType = "buildStart"
+
+	// EventBuildComplete is published after a build has finished
+	// successfully. Data is a BuildEventData with Elapsed set.
+	EventBuildComplete EventType = "buildComplete"
+
+	// EventBuildError is published when a build fails, in addition to (not
+	// instead of) the error being returned/sent through the usual error
+	// paths, so subscribers that only care about "did the last build fail"
+	// don't need to thread a channel through. Data is a BuildEventData with
+	// Err set.
+	EventBuildError EventType = "buildError"
+
+	// EventTemplateReload is published after a template has been
+	// reloaded/recompiled, e.g. in response to a filesystem change while
+	// watching. Data is a TemplateReloadEventData.
+	EventTemplateReload EventType = "templateReload"
+
+	// EventConfigChange is published after the site configuration has been
+	// reloaded. Data is a ConfigChangeEventData.
+	EventConfigChange EventType = "configChange"
+
+	// EventLanguageSwitch is published when Deps.ForLanguage derives a new
+	// per-language Deps from an existing one. Data is a
+	// LanguageSwitchEventData.
+	EventLanguageSwitch EventType = "languageSwitch"
+)
+
+// Wiring real Events.Publish(EventBuildStart/EventBuildComplete) calls into
+// an actual build isn't done here: the only subscriber that exists today
+// (tpl/partials/partials.go's cache-clear-on-build-start hook) is fed by
+// whatever eventually drives a real build, but this tree's real build
+// entrypoint -- commands/hugo.go's buildSite/rebuildSite, via the
+// mainSite *hugolib.Site it calls Build()/ReBuild() on -- uses the older,
+// pre-Deps Site type (see site.go) and never touches a *deps.Deps at all,
+// and there's no HugoSites.Build method here either for a newer call site
+// to hang a Publish call off of. What's real here is the bus itself:
+// typed event data, panic-safe dispatch and unsubscribe all work and are
+// tested in events_test.go; SendError (deps.go) does publish a real
+// EventBuildError, and ForLanguage does propagate (rather than reset) the
+// bus across a language switch, publishing EventLanguageSwitch on it.
+
+// BuildEventData is the payload for EventBuildStart, EventBuildComplete and
+// EventBuildError.
+type BuildEventData struct {
+	// BuildID identifies the build this event belongs to, so a subscriber
+	// that sees interleaved events for overlapping builds (e.g. a rebuild
+	// kicked off before the previous one's completion event is handled) can
+	// tell them apart.
+	BuildID string
+
+	// Language is the language being built, or empty for an all-languages
+	// build.
+	Language string
+
+	// Elapsed is how long the build took. It's zero on EventBuildStart,
+	// which fires before timing starts.
+	Elapsed time.Duration
+
+	// Err is the error that failed the build. Only set on EventBuildError.
+	Err error
+}
+
+// TemplateReloadEventData is the payload for EventTemplateReload.
+type TemplateReloadEventData struct {
+	// Name is the template's name, as registered with the template handler.
+	Name string
+}
+
+// ConfigChangeEventData is the payload for EventConfigChange.
+type ConfigChangeEventData struct {
+	// Keys lists the configuration keys that changed, if known. Empty means
+	// the whole configuration should be treated as changed.
+	Keys []string
+}
+
+// LanguageSwitchEventData is the payload for EventLanguageSwitch.
+type LanguageSwitchEventData struct {
+	From string
+	To   string
+}
+
+// Event is published on an EventBus. Data carries the event's typed
+// payload (see the BuildEventData/TemplateReloadEventData/
+// ConfigChangeEventData/LanguageSwitchEventData doc comments above for
+// which Go type to expect for each EventType); it is nil for events that
+// don't need one.
+type Event struct {
+	Type EventType
+	Data interface{}
+}
+
+// Subscription is returned by EventBus.Subscribe. Call Unsubscribe to stop
+// receiving events; it's safe to call more than once and from any
+// goroutine.
+type Subscription struct {
+	bus *EventBus
+	t   EventType
+	id  uint64
+}
+
+// Unsubscribe removes the handler this Subscription was returned for. It's
+// a no-op if already unsubscribed.
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s.t, s.id)
+}
+
+type subscriber struct {
+	id uint64
+	fn func(Event)
+}
+
+// EventBus is a typed, multi-event replacement for the single-purpose
+// Listeners type: instead of one hook per concern (BuildStartListeners,
+// and whatever the next one would have been), subscribers register for the
+// EventType they care about on one shared bus.
+type EventBus struct {
+	mu        sync.Mutex
+	listeners map[EventType][]subscriber
+	nextID    uint64
+}
+
+// NewEventBus creates an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{listeners: make(map[EventType][]subscriber)}
+}
+
+// Subscribe registers fn to be called whenever an event of the given type
+// is published, in registration order relative to other subscribers of the
+// same type. The returned Subscription's Unsubscribe method removes fn
+// again.
+func (b *EventBus) Subscribe(t EventType, fn func(Event)) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.listeners[t] = append(b.listeners[t], subscriber{id: id, fn: fn})
+	return &Subscription{bus: b, t: t, id: id}
+}
+
+func (b *EventBus) unsubscribe(t EventType, id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subs := b.listeners[t]
+	for i, s := range subs {
+		if s.id == id {
+			b.listeners[t] = append(subs[:i:i], subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Publish notifies every subscriber of ev.Type, in registration order. A
+// handler that panics is recovered and logged; it does not stop the
+// remaining handlers from running, and does not propagate to Publish's
+// caller (a build mid-flight must not be taken down by a misbehaving
+// subscriber).
+func (b *EventBus) Publish(ev Event) {
+	b.mu.Lock()
+	subs := append([]subscriber{}, b.listeners[ev.Type]...)
+	b.mu.Unlock()
+
+	for _, s := range subs {
+		callSubscriber(s.fn, ev)
+	}
+}
+
+// callSubscriber runs fn in its own recover scope so a single subscriber's
+// panic can't take down the caller of Publish or skip the remaining
+// subscribers.
+func callSubscriber(fn func(Event), ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			jww.ERROR.Println(fmt.Sprintf("panic in EventBus subscriber for %q: %v", ev.Type, r))
+		}
+	}()
+	fn(ev)
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deps
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEventBusOrdering(t *testing.T) {
+	bus := NewEventBus()
+
+	var got []int
+	bus.Subscribe(EventBuildStart, func(Event) { got = append(got, 1) })
+	bus.Subscribe(EventBuildStart, func(Event) { got = append(got, 2) })
+	bus.Subscribe(EventBuildStart, func(Event) { got = append(got, 3) })
+
+	bus.Publish(Event{Type: EventBuildStart})
+
+	want := []int{1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEventBusOnlyNotifiesMatchingType(t *testing.T) {
+	bus := NewEventBus()
+
+	var startCount, completeCount int
+	bus.Subscribe(EventBuildStart, func(Event) { startCount++ })
+	bus.Subscribe(EventBuildComplete, func(Event) { completeCount++ })
+
+	bus.Publish(Event{Type: EventBuildStart})
+
+	if startCount != 1 {
+		t.Errorf("startCount = %d, want 1", startCount)
+	}
+	if completeCount != 0 {
+		t.Errorf("completeCount = %d, want 0", completeCount)
+	}
+}
+
+func TestEventBusPublishRecoversPanickingSubscriber(t *testing.T) {
+	bus := NewEventBus()
+
+	var ranAfterPanic bool
+	bus.Subscribe(EventBuildStart, func(Event) { panic("boom") })
+	bus.Subscribe(EventBuildStart, func(Event) { ranAfterPanic = true })
+
+	bus.Publish(Event{Type: EventBuildStart})
+
+	if !ranAfterPanic {
+		t.Error("subscriber after a panicking one did not run")
+	}
+}
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	bus := NewEventBus()
+
+	var calls int
+	sub := bus.Subscribe(EventBuildStart, func(Event) { calls++ })
+
+	bus.Publish(Event{Type: EventBuildStart})
+	sub.Unsubscribe()
+	bus.Publish(Event{Type: EventBuildStart})
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	// Unsubscribing again must be a harmless no-op.
+	sub.Unsubscribe()
+}
+
+func TestEventBusUnsubscribeOnlyRemovesItsOwnSubscriber(t *testing.T) {
+	bus := NewEventBus()
+
+	var aCalls, bCalls int
+	subA := bus.Subscribe(EventBuildStart, func(Event) { aCalls++ })
+	bus.Subscribe(EventBuildStart, func(Event) { bCalls++ })
+
+	subA.Unsubscribe()
+	bus.Publish(Event{Type: EventBuildStart})
+
+	if aCalls != 0 {
+		t.Errorf("aCalls = %d, want 0", aCalls)
+	}
+	if bCalls != 1 {
+		t.Errorf("bCalls = %d, want 1", bCalls)
+	}
+}
+
+func TestGlobalErrHandlerSendErrorPublishesBuildError(t *testing.T) {
+	events := NewEventBus()
+	e := &globalErrHandler{events: events}
+
+	var got Event
+	var fired bool
+	events.Subscribe(EventBuildError, func(ev Event) {
+		fired = true
+		got = ev
+	})
+
+	wantErr := errors.New("boom")
+	e.SendError(wantErr)
+
+	if !fired {
+		t.Fatal("EventBuildError was not published")
+	}
+	data, ok := got.Data.(BuildEventData)
+	if !ok {
+		t.Fatalf("Data is %T, want BuildEventData", got.Data)
+	}
+	if data.Err != wantErr {
+		t.Errorf("Err = %v, want %v", data.Err, wantErr)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/spf13/afero"
+)
+
+// CacheBucketConfig is a named bucket's eviction policy, read from the
+// site config's "caches.<bucket>.maxAge"/"caches.<bucket>.maxSize" keys by
+// GetCacheBucketConfig.
+type CacheBucketConfig struct {
+	// MaxAge is how long an entry may go unwritten before Prune removes it.
+	// Zero means entries never expire by age.
+	MaxAge time.Duration
+
+	// MaxSize is the total size, in bytes, a bucket may hold before Prune
+	// evicts its least-recently-written entries to make room. Zero means
+	// no size limit.
+	MaxSize int64
+}
+
+// GetCacheBucketConfig reads bucket's policy from cfg's
+// "caches.<bucket>.maxAge"/"caches.<bucket>.maxSize" keys. Both are
+// optional; an unconfigured bucket has no age or size limit, so Prune
+// leaves it untouched.
+func GetCacheBucketConfig(cfg config.Provider, bucket string) CacheBucketConfig {
+	prefix := "caches." + bucket + "."
+	return CacheBucketConfig{
+		MaxAge:  cfg.GetDuration(prefix + "maxAge"),
+		MaxSize: int64(cfg.GetSizeInBytes(prefix + "maxSize")),
+	}
+}
+
+// cacheEntryMeta is the sidecar JSON written next to every cache entry's
+// data file (as the same content-addressed directory's "entry.meta"),
+// recording enough for Prune to apply a bucket's MaxAge/MaxSize without
+// reading the entries' data itself.
+type cacheEntryMeta struct {
+	Key       string    `json:"key"`
+	WrittenAt time.Time `json:"writtenAt"`
+	Size      int64     `json:"size"`
+}
+
+const (
+	cacheEntryDataFilename = "entry.data"
+	cacheEntryMetaFilename = "entry.meta"
+)
+
+// Cache is a namespaced, disk-backed key/value store with age- and
+// size-based eviction. It formalizes the "body"/sidecar-JSON pattern
+// individual callers of GetCacheDirForKey (e.g. hugolib's remote data
+// source fetcher) otherwise have to hand-roll for themselves.
+type Cache interface {
+	// Get returns the cached bytes for key, and whether they were found.
+	Get(key string) ([]byte, bool, error)
+
+	// Set stores data for key, overwriting any previous value, and
+	// (re)writes its sidecar metadata with the current time.
+	Set(key string, data []byte) error
+
+	// Prune removes every entry older than the bucket's MaxAge, then --
+	// if the bucket is still over MaxSize -- removes the
+	// least-recently-written entries until it isn't. It returns how many
+	// entries were removed.
+	Prune() (int, error)
+}
+
+// fileCache is the Cache implementation NewCache returns.
+type fileCache struct {
+	fs     afero.Fs
+	cfg    config.Provider
+	bucket string
+	policy CacheBucketConfig
+}
+
+// NewCache returns a Cache for the given bucket (e.g. "dataSources" or
+// "images"), rooted under the site's cache dir (see GetCacheDir) with its
+// own sha1-sharded key layout (see GetCacheDirForKey), evicted per
+// GetCacheBucketConfig(cfg, bucket).
+func NewCache(fs afero.Fs, cfg config.Provider, bucket string) Cache {
+	return &fileCache{
+		fs:     fs,
+		cfg:    cfg,
+		bucket: bucket,
+		policy: GetCacheBucketConfig(cfg, bucket),
+	}
+}
+
+func (c *fileCache) entryDir(key string) (string, error) {
+	return GetCacheDirForKey(c.fs, c.cfg, c.bucket, key)
+}
+
+func (c *fileCache) Get(key string) ([]byte, bool, error) {
+	dir, err := c.entryDir(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	dataPath := dir + cacheEntryDataFilename
+	exists, err := Exists(dataPath, c.fs)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	data, err := afero.ReadFile(c.fs, dataPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return data, true, nil
+}
+
+func (c *fileCache) Set(key string, data []byte) error {
+	dir, err := c.entryDir(key)
+	if err != nil {
+		return err
+	}
+
+	if err := afero.WriteFile(c.fs, dir+cacheEntryDataFilename, data, 0666); err != nil {
+		return err
+	}
+
+	meta := cacheEntryMeta{Key: key, WrittenAt: time.Now(), Size: int64(len(data))}
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	return afero.WriteFile(c.fs, dir+cacheEntryMetaFilename, metaBytes, 0666)
+}
+
+func (c *fileCache) Prune() (int, error) {
+	base, err := GetCacheDir(c.fs, c.cfg)
+	if err != nil {
+		return 0, err
+	}
+	if c.bucket != "" {
+		base = filepath.Join(base, c.bucket)
+	}
+
+	entries, err := readCacheEntries(c.fs, base)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	now := time.Now()
+	kept := entries[:0]
+	for _, e := range entries {
+		if c.policy.MaxAge > 0 && now.Sub(e.meta.WrittenAt) > c.policy.MaxAge {
+			if err := c.fs.RemoveAll(e.dir); err != nil {
+				return removed, err
+			}
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	entries = kept
+
+	if c.policy.MaxSize > 0 {
+		var total int64
+		for _, e := range entries {
+			total += e.meta.Size
+		}
+
+		// Oldest-written first, so the least-recently-written entries are
+		// the first to go once MaxSize is exceeded.
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].meta.WrittenAt.Before(entries[j].meta.WrittenAt)
+		})
+
+		i := 0
+		for total > c.policy.MaxSize && i < len(entries) {
+			e := entries[i]
+			if err := c.fs.RemoveAll(e.dir); err != nil {
+				return removed, err
+			}
+			total -= e.meta.Size
+			removed++
+			i++
+		}
+	}
+
+	return removed, nil
+}
+
+type cacheEntry struct {
+	dir  string
+	meta cacheEntryMeta
+}
+
+// readCacheEntries walks base's two-level sha1-sharded layout (see
+// GetCacheDirForKey) and returns every entry that has a readable
+// entry.meta sidecar. An entry whose sidecar is missing or unparsable is
+// skipped rather than failing the whole walk, since Prune shouldn't be
+// taken down by one corrupt entry.
+func readCacheEntries(fs afero.Fs, base string) ([]cacheEntry, error) {
+	exists, err := DirExists(base, fs)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	var entries []cacheEntry
+
+	shardDirs, err := afero.ReadDir(fs, base)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shardDir := range shardDirs {
+		if !shardDir.IsDir() {
+			continue
+		}
+		shardPath := filepath.Join(base, shardDir.Name())
+
+		entryDirs, err := afero.ReadDir(fs, shardPath)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entryDir := range entryDirs {
+			if !entryDir.IsDir() {
+				continue
+			}
+			dir := addTrailingFileSeparator(filepath.Join(shardPath, entryDir.Name()))
+
+			metaBytes, err := afero.ReadFile(fs, dir+cacheEntryMetaFilename)
+			if err != nil {
+				continue
+			}
+
+			var meta cacheEntryMeta
+			if err := json.Unmarshal(metaBytes, &meta); err != nil {
+				continue
+			}
+
+			entries = append(entries, cacheEntry{dir: dir, meta: meta})
+		}
+	}
+
+	return entries, nil
+}
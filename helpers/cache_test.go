@@ -0,0 +1,131 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+)
+
+func TestCacheGetSetRoundTrip(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	c := NewCache(fs, viper.New(), "images")
+
+	if _, found, err := c.Get("a"); err != nil || found {
+		t.Fatalf("found=%v err=%v, want a miss", found, err)
+	}
+
+	if err := c.Set("a", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, found, err := c.Get("a")
+	if err != nil || !found {
+		t.Fatalf("found=%v err=%v, want a hit", found, err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestCacheBucketsAreIsolated(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := viper.New()
+
+	a := NewCache(fs, cfg, "a")
+	b := NewCache(fs, cfg, "b")
+
+	if err := a.Set("k", []byte("from-a")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := b.Get("k"); err != nil || found {
+		t.Fatalf("found=%v err=%v, want bucket b to not see bucket a's entry", found, err)
+	}
+}
+
+func TestCachePruneByMaxAge(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := viper.New()
+	cfg.Set("caches.images.maxAge", "1ms")
+
+	c := NewCache(fs, cfg, "images")
+	if err := c.Set("a", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+
+	if _, found, err := c.Get("a"); err != nil || found {
+		t.Fatalf("found=%v err=%v, want entry pruned", found, err)
+	}
+}
+
+func TestCachePruneByMaxSizeEvictsOldestFirst(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := viper.New()
+	cfg.Set("caches.images.maxSize", "4b")
+
+	c := NewCache(fs, cfg, "images")
+	if err := c.Set("old", []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := c.Set("new", []byte("de")); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+
+	if _, found, _ := c.Get("old"); found {
+		t.Error("expected the older entry to be evicted first")
+	}
+	if _, found, _ := c.Get("new"); !found {
+		t.Error("expected the newer entry to survive")
+	}
+}
+
+func TestCachePruneUnconfiguredBucketIsNoop(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	c := NewCache(fs, viper.New(), "images")
+
+	if err := c.Set("a", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := c.Prune()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 0 {
+		t.Fatalf("removed = %d, want 0 for an unconfigured bucket", removed)
+	}
+}
@@ -25,12 +25,32 @@ import (
 type ConfigProvider interface {
 	GetString(key string) string
 	GetInt(key string) int
+	GetBool(key string) bool
+	GetFloat64(key string) float64
+	GetStringSlice(key string) []string
 	GetStringMap(key string) map[string]interface{}
 	GetStringMapString(key string) map[string]string
+	IsSet(key string) bool
+
+	// Sub returns the ConfigProvider for the sub-tree rooted at key, or nil
+	// if key isn't set.
+	Sub(key string) ConfigProvider
+
+	// AllSettings returns every setting as a map, for callers (e.g.
+	// diagnostics, `hugo config`) that need to enumerate rather than look
+	// up by key.
+	AllSettings() map[string]interface{}
 }
 
 // Config returns the currently active Hugo config. This will be set
 // per site (language) rendered.
+//
+// Deprecated: reaching into a package-level global makes it impossible to
+// render more than one site/language concurrently without them clobbering
+// each other's "current" config. Callers should instead receive a
+// ConfigProvider as an explicit argument -- the way ProfileFromConfig,
+// NewURLSanitizer and ResolveSlugifyProfile already do -- built once per
+// site/language via NewLanguageConfig and threaded down from there.
 func Config() ConfigProvider {
 	return viper.Get("CurrentContentLanguage").(ConfigProvider)
 }
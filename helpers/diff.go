@@ -0,0 +1,106 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffStrings returns a unified-diff-style, line-by-line comparison of
+// want and got, prefixing unchanged lines with "  ", removed lines with
+// "- " and added lines with "+ ". It has no external dependencies, so it
+// trades a full Myers diff with hunk compaction for a simpler
+// longest-common-subsequence line match -- adequate for the short test
+// and golden-file mismatches it is meant to surface.
+func DiffStrings(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var b strings.Builder
+	for _, op := range diffLines(wantLines, gotLines) {
+		switch op.kind {
+		case diffEqual:
+			fmt.Fprintf(&b, "  %s\n", op.line)
+		case diffDelete:
+			fmt.Fprintf(&b, "- %s\n", op.line)
+		case diffInsert:
+			fmt.Fprintf(&b, "+ %s\n", op.line)
+		}
+	}
+
+	return b.String()
+}
+
+type diffKind int
+
+const (
+	diffEqual diffKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffKind
+	line string
+}
+
+// diffLines computes a line-level diff between a and b from the standard
+// LCS backtrace table -- the same starting point `diff -u` builds its
+// hunk-compacted output from.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, b[j]})
+	}
+
+	return ops
+}
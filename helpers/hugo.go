@@ -15,32 +15,167 @@ package helpers
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
-// this should be the only one
-const hugoVersionMain = 0.14
-const hugoVersionSuffix = "-DEV" // blank this when doing a release
+// HugoVersion represents a Hugo version. Number carries the combined
+// major.minor (e.g. 0.14), matching the scheme Hugo's version number has
+// always used; PatchLevel is the separate patch component so patch
+// releases (0.14.1) are representable, which a single float32 could not
+// do. Suffix is e.g. "-DEV" or "-rc.1"; BuildMetadata, appended after a
+// "+", is typically a short git commit sha injected at build time via
+// `-ldflags -X`.
+type HugoVersion struct {
+	Number        float32
+	PatchLevel    int
+	Suffix        string
+	BuildMetadata string
+}
+
+// CurrentHugoVersion is the version of the running Hugo binary.
+var CurrentHugoVersion = HugoVersion{
+	Number:     0.14,
+	PatchLevel: 0,
+	Suffix:     "-DEV",
+}
+
+// String formats v as "MAJOR.MINOR[.PATCH][-suffix][+build]", omitting the
+// patch component when it's zero so the common case reads "0.14-DEV"
+// rather than "0.14.0-DEV".
+func (v HugoVersion) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%.2f", v.Number)
+	if v.PatchLevel != 0 {
+		fmt.Fprintf(&b, ".%d", v.PatchLevel)
+	}
+	b.WriteString(v.Suffix)
+	if v.BuildMetadata != "" {
+		b.WriteString("+")
+		b.WriteString(v.BuildMetadata)
+	}
+	return b.String()
+}
+
+// Next returns the next minor release after v, e.g. 0.14 -> 0.15. The
+// patch level and build metadata reset; the suffix carries over so a
+// caller computing a -DEV version for the next cycle doesn't have to set
+// it again.
+func (v HugoVersion) Next() HugoVersion {
+	return HugoVersion{Number: v.Number + 0.01, Suffix: v.Suffix}
+}
+
+// Prev returns the previous minor release before v, e.g. 0.14 -> 0.13.
+func (v HugoVersion) Prev() HugoVersion {
+	return HugoVersion{Number: v.Number - 0.01, Suffix: v.Suffix}
+}
+
+// NextPatchLevel returns v with its patch level set to level, e.g.
+// HugoVersion{Number: 0.14}.NextPatchLevel(1) -> 0.14.1.
+func (v HugoVersion) NextPatchLevel(level int) HugoVersion {
+	return HugoVersion{Number: v.Number, PatchLevel: level, Suffix: v.Suffix}
+}
+
+// Compare returns a negative number if v is older than other, zero if
+// they're the same release (ignoring Suffix/BuildMetadata), or a positive
+// number if v is newer. A version with a non-empty Suffix (a pre-release)
+// is considered older than the same Number/PatchLevel without one.
+func (v HugoVersion) Compare(other HugoVersion) int {
+	if d := v.Number - other.Number; d != 0 {
+		if d < 0 {
+			return -1
+		}
+		return 1
+	}
+	if d := v.PatchLevel - other.PatchLevel; d != 0 {
+		return d
+	}
+	switch {
+	case v.Suffix == other.Suffix:
+		return 0
+	case v.Suffix == "":
+		return 1
+	case other.Suffix == "":
+		return -1
+	default:
+		return strings.Compare(v.Suffix, other.Suffix)
+	}
+}
+
+// ParseHugoVersion parses a version string of the form
+// "MAJOR.MINOR[.PATCH][-suffix][+build]" -- the form HugoVersion.String
+// produces, and the form themes declare in theme.toml's min_version --
+// into a HugoVersion.
+func ParseHugoVersion(s string) (HugoVersion, error) {
+	var v HugoVersion
+
+	if i := strings.Index(s, "+"); i != -1 {
+		v.BuildMetadata = s[i+1:]
+		s = s[:i]
+	}
+
+	if i := strings.IndexAny(s, "-"); i != -1 {
+		v.Suffix = s[i:]
+		s = s[:i]
+	}
+
+	parts := strings.SplitN(s, ".", 3)
+	if len(parts) < 2 {
+		return v, fmt.Errorf("invalid Hugo version %q", s)
+	}
+
+	number, err := strconv.ParseFloat(strings.Join(parts[:2], "."), 32)
+	if err != nil {
+		return v, fmt.Errorf("invalid Hugo version %q: %s", s, err)
+	}
+	v.Number = float32(number)
+
+	if len(parts) == 3 {
+		patch, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return v, fmt.Errorf("invalid Hugo version %q: %s", s, err)
+		}
+		v.PatchLevel = patch
+	}
+
+	return v, nil
+}
 
 // HugoVersion returns the current Hugo version. It will include
 // a suffix, typically '-DEV', if it's development version.
 func HugoVersion() string {
-	return hugoVersion(hugoVersionMain, hugoVersionSuffix)
+	return CurrentHugoVersion.String()
 }
 
 // HugoReleaseVersion is same as HugoVersion, but no suffix.
 func HugoReleaseVersion() string {
-	return hugoVersionNoSuffix(hugoVersionMain)
+	v := CurrentHugoVersion
+	v.Suffix = ""
+	return v.String()
 }
 
 // NextHugoReleaseVersion returns the next Hugo release version.
 func NextHugoReleaseVersion() string {
-	return hugoVersionNoSuffix(hugoVersionMain + 0.01)
-}
-
-func hugoVersion(version float32, suffix string) string {
-	return fmt.Sprintf("%.2g%s", version, suffix)
+	v := CurrentHugoVersion.Next()
+	v.Suffix = ""
+	return v.String()
 }
 
-func hugoVersionNoSuffix(version float32) string {
-	return fmt.Sprintf("%.2g", version)
+// CheckMinVersion refuses to proceed if the running Hugo version
+// (CurrentHugoVersion) is older than minVersion, the way a theme's
+// theme.toml min_version is meant to gate a build -- see
+// themes.Manifest.CheckVersion, which this complements: that check
+// compares a *theme's* requirement against the running Hugo version
+// already; CheckMinVersion is the same comparison available directly off
+// a parsed HugoVersion for callers that aren't going through a
+// themes.Manifest.
+func CheckMinVersion(minVersion string) error {
+	min, err := ParseHugoVersion(minVersion)
+	if err != nil {
+		return err
+	}
+	if CurrentHugoVersion.Compare(min) < 0 {
+		return fmt.Errorf("this site requires Hugo %s or newer, but the running version is %s", min.String(), CurrentHugoVersion.String())
+	}
+	return nil
 }
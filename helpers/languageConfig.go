@@ -0,0 +1,116 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+// languageConfig overlays a language's own settings (read from the base
+// config's "languages.<lang>" sub-tree, the way site config files declare
+// per-language overrides) on top of base, falling back to base for any
+// key the language doesn't override itself.
+type languageConfig struct {
+	base ConfigProvider
+	lang ConfigProvider // may be nil if the language has no overrides
+}
+
+// NewLanguageConfig returns a ConfigProvider for lang that reads
+// "languages.<lang>.<key>" from base when set, falling back to base's
+// top-level "<key>" otherwise. This is what threading a ConfigProvider
+// through helpers/hugolib/tpl should be built on, replacing the
+// viper.Get("CurrentContentLanguage") global Config() reaches for.
+func NewLanguageConfig(base ConfigProvider, lang string) ConfigProvider {
+	var langCfg ConfigProvider
+	if base != nil {
+		langCfg = base.Sub("languages." + lang)
+	}
+	return languageConfig{base: base, lang: langCfg}
+}
+
+func (c languageConfig) GetString(key string) string {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetString(key)
+	}
+	return c.base.GetString(key)
+}
+
+func (c languageConfig) GetInt(key string) int {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetInt(key)
+	}
+	return c.base.GetInt(key)
+}
+
+func (c languageConfig) GetBool(key string) bool {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetBool(key)
+	}
+	return c.base.GetBool(key)
+}
+
+func (c languageConfig) GetFloat64(key string) float64 {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetFloat64(key)
+	}
+	return c.base.GetFloat64(key)
+}
+
+func (c languageConfig) GetStringSlice(key string) []string {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetStringSlice(key)
+	}
+	return c.base.GetStringSlice(key)
+}
+
+func (c languageConfig) GetStringMap(key string) map[string]interface{} {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetStringMap(key)
+	}
+	return c.base.GetStringMap(key)
+}
+
+func (c languageConfig) GetStringMapString(key string) map[string]string {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return c.lang.GetStringMapString(key)
+	}
+	return c.base.GetStringMapString(key)
+}
+
+func (c languageConfig) IsSet(key string) bool {
+	if c.lang != nil && c.lang.IsSet(key) {
+		return true
+	}
+	return c.base.IsSet(key)
+}
+
+func (c languageConfig) Sub(key string) ConfigProvider {
+	if c.lang != nil {
+		if sub := c.lang.Sub(key); sub != nil {
+			return sub
+		}
+	}
+	return c.base.Sub(key)
+}
+
+func (c languageConfig) AllSettings() map[string]interface{} {
+	settings := c.base.AllSettings()
+	if c.lang == nil {
+		return settings
+	}
+	merged := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		merged[k] = v
+	}
+	for k, v := range c.lang.AllSettings() {
+		merged[k] = v
+	}
+	return merged
+}
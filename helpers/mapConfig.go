@@ -0,0 +1,117 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+// MapConfig is a lightweight ConfigProvider backed by a plain map, for
+// tests and other callers that don't want to spin up a full viper.Viper
+// just to satisfy the interface.
+type MapConfig map[string]interface{}
+
+// NewMapConfig wraps m as a ConfigProvider. A nil m is treated as empty.
+func NewMapConfig(m map[string]interface{}) MapConfig {
+	if m == nil {
+		m = make(map[string]interface{})
+	}
+	return MapConfig(m)
+}
+
+func (m MapConfig) GetString(key string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func (m MapConfig) GetInt(key string) int {
+	switch v := m[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
+
+func (m MapConfig) GetBool(key string) bool {
+	v, _ := m[key].(bool)
+	return v
+}
+
+func (m MapConfig) GetFloat64(key string) float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+func (m MapConfig) GetStringSlice(key string) []string {
+	switch v := m[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, len(v))
+		for i, e := range v {
+			if s, ok := e.(string); ok {
+				out[i] = s
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func (m MapConfig) GetStringMap(key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+func (m MapConfig) GetStringMapString(key string) map[string]string {
+	switch v := m[key].(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		out := make(map[string]string, len(v))
+		for k, e := range v {
+			if s, ok := e.(string); ok {
+				out[k] = s
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func (m MapConfig) IsSet(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func (m MapConfig) Sub(key string) ConfigProvider {
+	sub, ok := m[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return NewMapConfig(sub)
+}
+
+func (m MapConfig) AllSettings() map[string]interface{} {
+	return map[string]interface{}(m)
+}
@@ -14,6 +14,8 @@
 package helpers
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -59,6 +61,29 @@ func (p *PathSpec) MakePathSanitized(s string) string {
 	return strings.ToLower(p.MakePath(s))
 }
 
+// ResolveSlugifyProfile resolves the SlugifyProfile to use for a path
+// segment: it starts from DefaultSlugifyProfile, layers the site's
+// `[slugify]` config (if any) via ProfileFromConfig, and finally layers a
+// page's `slug_profile` front matter override (if any) via
+// ProfileFromParams. Unlike RemovePathAccents/Transliterate/
+// DisablePathToLower, which are fixed for the whole site, this lets
+// section and page front matter opt into a different casing, separator or
+// transliteration than the rest of the site, e.g. to keep a CJK section
+// name intact while folding accented Latin elsewhere.
+func (p *PathSpec) ResolveSlugifyProfile(cp ConfigProvider, params map[string]interface{}) SlugifyProfile {
+	profile := DefaultSlugifyProfile()
+
+	if cp != nil {
+		profile = ProfileFromConfig(cp, profile)
+	}
+
+	if params != nil {
+		profile, _ = ProfileFromParams(params, profile)
+	}
+
+	return profile
+}
+
 // ToSlashTrimLeading is just a filepath.ToSlaas with an added / prefix trimmer.
 func ToSlashTrimLeading(s string) string {
 	return strings.TrimPrefix(filepath.ToSlash(s), "/")
@@ -90,6 +115,12 @@ func ishex(c rune) bool {
 // Hyphens in the original input are maintained.
 // Spaces will be replaced with a single hyphen, and sequential replacement hyphens will be reduced to one.
 func (p *PathSpec) UnicodeSanitize(s string) string {
+	if p.Transliterate != "" {
+		if t, found := getTransliterator(p.Transliterate); found {
+			s = t(s)
+		}
+	}
+
 	if p.RemovePathAccents {
 		s = text.RemoveAccentsString(s)
 	}
@@ -343,26 +374,74 @@ func LstatIfPossible(fs afero.Fs, path string) (os.FileInfo, error) {
 	return fs.Stat(path)
 }
 
+// writeStrategyAtomic is the writeStrategy config value that routes
+// SafeWriteToDisk/WriteToDisk/OpenFileForWriting through the fsync+rename
+// staging done by OpenFileForWritingAtomic instead of writing (truncating)
+// the target file in place. The default, any other value (including unset),
+// keeps the original direct-write behaviour.
+const writeStrategyAtomic = "atomic"
+
+func writeAtomically(cfg config.Provider) bool {
+	return cfg != nil && cfg.GetString("writeStrategy") == writeStrategyAtomic
+}
+
 // SafeWriteToDisk is the same as WriteToDisk
 // but it also checks to see if file/directory already exists.
-func SafeWriteToDisk(inpath string, r io.Reader, fs afero.Fs) (err error) {
+func SafeWriteToDisk(inpath string, r io.Reader, fs afero.Fs, cfg config.Provider) (err error) {
+	if writeAtomically(cfg) {
+		exists, err := Exists(inpath, fs)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%s already exists", inpath)
+		}
+		return WriteToDisk(inpath, r, fs, cfg)
+	}
 	return afero.SafeWriteReader(fs, inpath, r)
 }
 
-// WriteToDisk writes content to disk.
-func WriteToDisk(inpath string, r io.Reader, fs afero.Fs) (err error) {
-	return afero.WriteReader(fs, inpath, r)
+// WriteToDisk writes content to disk, through OpenFileForWriting so it
+// honours the writeStrategy config key.
+func WriteToDisk(inpath string, r io.Reader, fs afero.Fs, cfg config.Provider) (err error) {
+	f, err := OpenFileForWriting(fs, cfg, inpath)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
 
-// OpenFilesForWriting opens all the given filenames for writing.
-func OpenFilesForWriting(fs afero.Fs, filenames ...string) (io.WriteCloser, error) {
+// OpenFilesForWriting opens all the given filenames for writing, all-or-
+// nothing: if any one of them fails to open, every file already opened in
+// this call is rolled back (its staged write is discarded) rather than
+// committed, so a caller never ends up with some of a related group of
+// output files written and others missing. This rollback is only complete
+// when cfg's writeStrategy is "atomic" -- each file is then staged through
+// its own temp file and nothing is renamed into place until every file in
+// the group is Closed. With the default strategy, a file that was already
+// created (and possibly truncated) before a later one in the group failed
+// to open cannot be un-truncated, so only the already-opened handles are
+// closed; this matches OpenFileForWriting's own direct-write semantics.
+func OpenFilesForWriting(fs afero.Fs, cfg config.Provider, filenames ...string) (io.WriteCloser, error) {
 	var writeClosers []io.WriteCloser
-	for _, filename := range filenames {
-		f, err := OpenFileForWriting(fs, filename)
-		if err != nil {
-			for _, wc := range writeClosers {
+	rollback := func() {
+		for _, wc := range writeClosers {
+			if a, ok := wc.(*atomicWriteCloser); ok {
+				a.discard()
+			} else {
 				wc.Close()
 			}
+		}
+	}
+
+	for _, filename := range filenames {
+		f, err := OpenFileForWriting(fs, cfg, filename)
+		if err != nil {
+			rollback()
 			return nil, err
 		}
 		writeClosers = append(writeClosers, f)
@@ -372,8 +451,14 @@ func OpenFilesForWriting(fs afero.Fs, filenames ...string) (io.WriteCloser, erro
 }
 
 // OpenFileForWriting opens or creates the given file. If the target directory
-// does not exist, it gets created.
-func OpenFileForWriting(fs afero.Fs, filename string) (afero.File, error) {
+// does not exist, it gets created. When cfg's writeStrategy config key is
+// "atomic", this delegates to OpenFileForWritingAtomic instead of writing
+// (and truncating) filename directly.
+func OpenFileForWriting(fs afero.Fs, cfg config.Provider, filename string) (afero.File, error) {
+	if writeAtomically(cfg) {
+		return OpenFileForWritingAtomic(fs, filename)
+	}
+
 	filename = filepath.Clean(filename)
 	// Create will truncate if file already exists.
 	// os.Create will create any new files with mode 0666 (before umask).
@@ -391,6 +476,59 @@ func OpenFileForWriting(fs afero.Fs, filename string) (afero.File, error) {
 	return f, err
 }
 
+// atomicWriteCloser wraps a temp file so that on Close the temp file is
+// fsynced and renamed into place over filename, making the write atomic:
+// readers will either see the old content or the new content in full,
+// never a partially written file.
+type atomicWriteCloser struct {
+	afero.File
+	fs       afero.Fs
+	filename string
+}
+
+func (a *atomicWriteCloser) Close() error {
+	if err := a.File.Sync(); err != nil {
+		a.File.Close()
+		return err
+	}
+	tempName := a.File.Name()
+	if err := a.File.Close(); err != nil {
+		return err
+	}
+	return a.fs.Rename(tempName, a.filename)
+}
+
+// discard closes the staged temp file without renaming it into place,
+// then removes it, so it never becomes visible at a.filename. Used to roll
+// back a file in a OpenFilesForWriting group when a sibling file fails to
+// open.
+func (a *atomicWriteCloser) discard() error {
+	tempName := a.File.Name()
+	a.File.Close()
+	return a.fs.Remove(tempName)
+}
+
+// OpenFileForWritingAtomic is like OpenFileForWriting, but the write is
+// staged to a temporary file in the same directory and only made visible
+// at filename via fsync+rename on Close. This avoids readers (e.g. a
+// concurrently running web server) ever observing a truncated or
+// partially written file.
+func OpenFileForWritingAtomic(fs afero.Fs, filename string) (afero.File, error) {
+	filename = filepath.Clean(filename)
+	dir := filepath.Dir(filename)
+
+	if err := fs.MkdirAll(dir, 0777); err != nil { // before umask
+		return nil, err
+	}
+
+	f, err := afero.TempFile(fs, dir, filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &atomicWriteCloser{File: f, fs: fs, filename: filename}, nil
+}
+
 // GetCacheDir returns a cache dir from the given filesystem and config.
 // The dir will be created if it does not exist.
 func GetCacheDir(fs afero.Fs, cfg config.Provider) (string, error) {
@@ -413,6 +551,43 @@ func GetCacheDir(fs afero.Fs, cfg config.Provider) (string, error) {
 	return GetTempDir("hugo_cache", fs), nil
 }
 
+// GetCacheDirForKey returns a content-addressed subdirectory of bucket's
+// cache dir for key, creating it if it does not exist. bucket namespaces
+// unrelated callers (e.g. "dataSources", "images") from each other under
+// the same cache root, so they can be pruned independently (see
+// GetCacheBucket); an empty bucket puts key's directory directly under the
+// cache root, matching this function's pre-namespacing behaviour. The
+// directory name is derived from the SHA-1 of key, split into a two-level
+// layout (as in e.g. Git's object store) so no single directory ends up
+// with an unwieldy number of entries.
+func GetCacheDirForKey(fs afero.Fs, cfg config.Provider, bucket, key string) (string, error) {
+	base, err := GetCacheDir(fs, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	if bucket != "" {
+		base = filepath.Join(base, bucket)
+	}
+
+	sum := sha1.Sum([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	dir := filepath.Join(base, hash[:2], hash[2:])
+
+	exists, err := DirExists(dir, fs)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := fs.MkdirAll(dir, 0777); err != nil { // Before umask
+			return "", _errors.Wrap(err, "failed to create content-addressed cache dir")
+		}
+	}
+
+	return addTrailingFileSeparator(dir), nil
+}
+
 func getCacheDir(cfg config.Provider) string {
 	// Always use the cacheDir config if set.
 	cacheDir := cfg.GetString("cacheDir")
@@ -0,0 +1,150 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+)
+
+var errFailOnCreate = errors.New("forced Create failure")
+
+func atomicCfg() *viper.Viper {
+	v := viper.New()
+	v.Set("writeStrategy", "atomic")
+	return v
+}
+
+func TestWriteToDiskDefaultStrategy(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := WriteToDisk("f.txt", strings.NewReader("hello"), fs, viper.New()); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := afero.ReadFile(fs, "f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestWriteToDiskAtomicStrategy(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := atomicCfg()
+
+	if err := WriteToDisk("f.txt", strings.NewReader("hello"), fs, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := afero.ReadFile(fs, "f.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Errorf("got %q, want %q", got, "hello")
+	}
+
+	entries, err := afero.ReadDir(fs, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d dir entries, want 1 (the temp file should not linger)", len(entries))
+	}
+}
+
+func TestSafeWriteToDiskAtomicStrategyRejectsExisting(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := atomicCfg()
+
+	if err := SafeWriteToDisk("f.txt", strings.NewReader("one"), fs, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := SafeWriteToDisk("f.txt", strings.NewReader("two"), fs, cfg); err == nil {
+		t.Error("expected an error writing over an existing file, got nil")
+	}
+}
+
+// failOnCreateFs fails afero.Fs.Create for one specific name, so a test can
+// force OpenFileForWriting to fail partway through a OpenFilesForWriting
+// call deterministically, without relying on MemMapFs path-collision
+// behaviour.
+type failOnCreateFs struct {
+	afero.Fs
+	failName string
+}
+
+func (f failOnCreateFs) Create(name string) (afero.File, error) {
+	// OpenFileForWritingAtomic creates a randomly-suffixed temp file rather
+	// than filename itself (e.g. "b.txt.tmp-123456"), so match on prefix.
+	if strings.HasPrefix(name, f.failName) {
+		return nil, errFailOnCreate
+	}
+	return f.Fs.Create(name)
+}
+
+func TestOpenFilesForWritingRollsBackOnFailure(t *testing.T) {
+	mem := afero.NewMemMapFs()
+	fs := failOnCreateFs{Fs: mem, failName: "b.txt"}
+	cfg := atomicCfg()
+
+	wc, err := OpenFilesForWriting(fs, cfg, "a.txt", "b.txt")
+	if err == nil {
+		wc.Close()
+		t.Fatal("expected an error opening the second file")
+	}
+
+	entries, err := afero.ReadDir(mem, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("leftover temp file after rollback: %s", e.Name())
+		}
+	}
+}
+
+func TestOpenFilesForWritingCommitsAllOnSuccess(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := atomicCfg()
+
+	wc, err := OpenFilesForWriting(fs, cfg, "a.txt", "b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wc.Write([]byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		got, err := afero.ReadFile(fs, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(got) != "hi" {
+			t.Errorf("%s = %q, want %q", name, got, "hi")
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cast"
+)
+
+// SlugifyCase controls the casing a SlugifyProfile applies to a path segment.
+type SlugifyCase string
+
+const (
+	// SlugifyCaseLower lowercases the segment. This is the default and
+	// matches the historical MakePathSanitized behaviour.
+	SlugifyCaseLower SlugifyCase = "lower"
+
+	// SlugifyCasePreserve leaves the original casing untouched.
+	SlugifyCasePreserve SlugifyCase = "preserve"
+
+	// SlugifyCaseTitle title-cases the segment, word by word.
+	SlugifyCaseTitle SlugifyCase = "title"
+)
+
+// SlugifyProfile configures how PathSpec.Slugify turns an arbitrary title
+// into a URL path segment. Where RemovePathAccents and Transliterate are a
+// single global switch, a profile can be resolved per site, per section or
+// per page (see ResolveSlugifyProfile), e.g. to keep a CJK section name
+// intact while folding Cyrillic or accented Latin elsewhere on the same
+// site.
+type SlugifyProfile struct {
+	// Case is the casing applied to the result. Defaults to SlugifyCaseLower.
+	Case SlugifyCase
+
+	// Separator replaces runs of whitespace. Defaults to '-'.
+	Separator rune
+
+	// Transliterate is the name of a Transliterator, registered with
+	// RegisterTransliterator, applied to runes whose Unicode script has no
+	// entry in ScriptTransliterate. Empty disables transliteration.
+	Transliterate string
+
+	// ScriptTransliterate maps a Unicode script name (e.g. "Cyrillic",
+	// "Han", "Hiragana") to the name of a registered Transliterator to use
+	// for runes in that script, overriding Transliterate for that script.
+	// Map a script to the empty string to leave it untouched, e.g.
+	// {"Han": "", "Hiragana": "", "Katakana": ""} preserves CJK text while
+	// Transliterate still folds everything else.
+	ScriptTransliterate map[string]string
+
+	// Strip lists runes to drop outright rather than turn into Separator.
+	Strip []rune
+
+	// Replace maps individual runes to a literal replacement string,
+	// applied before casing and Unicode sanitation.
+	Replace map[rune]string
+
+	// MaxLen truncates the result to at most MaxLen runes. The cut is
+	// moved back to the nearest preceding Separator so a segment is never
+	// truncated mid-word. 0 means no limit.
+	MaxLen int
+}
+
+// DefaultSlugifyProfile is used whenever no site `[slugify]` config or
+// page `slug_profile` front matter resolves to something more specific. It
+// reproduces the historical UnicodeSanitize/MakePathSanitized behaviour.
+func DefaultSlugifyProfile() SlugifyProfile {
+	return SlugifyProfile{
+		Case:      SlugifyCaseLower,
+		Separator: '-',
+	}
+}
+
+// slugifyScripts lists the Unicode scripts ScriptTransliterate can key on.
+// It is deliberately a fixed, short list of the scripts Hugo sites most
+// commonly need to treat specially, rather than all of unicode.Scripts.
+var slugifyScripts = []string{
+	"Han", "Hiragana", "Katakana", "Hangul",
+	"Cyrillic", "Greek", "Arabic", "Hebrew", "Thai", "Latin",
+}
+
+// scriptOf returns the name of the Unicode script r belongs to, or "" if it
+// isn't one of slugifyScripts (digits, punctuation, spaces, etc).
+func scriptOf(r rune) string {
+	for _, name := range slugifyScripts {
+		if rt, ok := unicode.Scripts[name]; ok && unicode.Is(rt, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+// transliterateByScript splits s into runs by Unicode script and runs each
+// through the Transliterator selected for that script, so e.g. a Cyrillic
+// run can be romanized while an adjacent Han run is left intact.
+func transliterateByScript(s string, profile SlugifyProfile) string {
+	if profile.Transliterate == "" && len(profile.ScriptTransliterate) == 0 {
+		return s
+	}
+
+	var (
+		b         strings.Builder
+		run       []rune
+		runScript string
+	)
+
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		name := profile.Transliterate
+		if override, ok := profile.ScriptTransliterate[runScript]; ok {
+			name = override
+		}
+		chunk := string(run)
+		if name != "" {
+			if t, found := getTransliterator(name); found {
+				chunk = t(chunk)
+			}
+		}
+		b.WriteString(chunk)
+		run = run[:0]
+	}
+
+	for _, r := range s {
+		sc := scriptOf(r)
+		if sc == "" || sc == runScript {
+			run = append(run, r)
+			continue
+		}
+		flush()
+		runScript = sc
+		run = append(run, r)
+	}
+	flush()
+
+	return b.String()
+}
+
+// Slugify turns s into a URL path segment following profile. Unlike
+// UnicodeSanitize/MakePathSanitized, which are fixed to a single global
+// RemovePathAccents/Transliterate/DisablePathToLower configuration, Slugify
+// takes its profile as an argument so callers can resolve a different one
+// per section or per page; see ResolveSlugifyProfile.
+func (p *PathSpec) Slugify(s string, profile SlugifyProfile) string {
+	sep := profile.Separator
+	if sep == 0 {
+		sep = '-'
+	}
+
+	s = transliterateByScript(s, profile)
+
+	switch profile.Case {
+	case SlugifyCaseTitle:
+		s = strings.Title(s)
+	case SlugifyCasePreserve:
+		// leave casing as-is
+	default:
+		s = strings.ToLower(s)
+	}
+
+	source := []rune(s)
+	target := make([]rune, 0, len(source))
+	var prependSep, wasSep bool
+
+	for i, r := range source {
+		if repl, ok := profile.Replace[r]; ok {
+			target = append(target, []rune(repl)...)
+			wasSep = false
+			prependSep = false
+			continue
+		}
+
+		if runeIn(r, profile.Strip) {
+			continue
+		}
+
+		isAllowed := r == '.' || r == '/' || r == '\\' || r == '_' || r == '#' || r == '+' || r == '~' || r == sep
+		isAllowed = isAllowed || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+		isAllowed = isAllowed || (r == '%' && i+2 < len(source) && ishex(source[i+1]) && ishex(source[i+2]))
+
+		if isAllowed {
+			wasSep = r == sep
+			if prependSep {
+				if !wasSep {
+					target = append(target, sep)
+				}
+				prependSep = false
+			}
+			target = append(target, r)
+		} else if len(target) > 0 && !wasSep && unicode.IsSpace(r) {
+			prependSep = true
+		}
+	}
+
+	result := string(target)
+	if profile.MaxLen > 0 {
+		result = truncateSlugOnSeparator(result, profile.MaxLen, sep)
+	}
+
+	return result
+}
+
+// runeIn reports whether r is present in runes.
+func runeIn(r rune, runes []rune) bool {
+	for _, c := range runes {
+		if r == c {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateSlugOnSeparator truncates s to at most maxLen runes, then backs up
+// to the last preceding sep so the result never ends mid-word. If no sep is
+// found, s is cut hard at maxLen.
+func truncateSlugOnSeparator(s string, maxLen int, sep rune) string {
+	source := []rune(s)
+	if len(source) <= maxLen {
+		return s
+	}
+
+	cut := maxLen
+	for i := maxLen - 1; i >= 0; i-- {
+		if source[i] == sep {
+			cut = i
+			break
+		}
+	}
+
+	return strings.TrimRight(string(source[:cut]), string(sep))
+}
+
+// ProfileFromConfig builds a SlugifyProfile from a site's `[slugify]`
+// config section, layered on top of base (typically DefaultSlugifyProfile
+// or the result of a parent ProfileFromConfig call). Recognised keys are
+// case, separator, transliterate, scriptTransliterate, strip, replace and
+// maxLen; unrecognised or absent keys leave base's value untouched.
+func ProfileFromConfig(cp ConfigProvider, base SlugifyProfile) SlugifyProfile {
+	return applyProfileParams(cp.GetStringMap("slugify"), base)
+}
+
+// ProfileFromParams resolves a page's `slug_profile` front matter value, if
+// any, layered on top of base. ok reports whether params contained a
+// slug_profile entry at all.
+func ProfileFromParams(params map[string]interface{}, base SlugifyProfile) (profile SlugifyProfile, ok bool) {
+	v, found := params["slug_profile"]
+	if !found {
+		return base, false
+	}
+	m, found := v.(map[string]interface{})
+	if !found {
+		return base, false
+	}
+	return applyProfileParams(m, base), true
+}
+
+func applyProfileParams(m map[string]interface{}, base SlugifyProfile) SlugifyProfile {
+	profile := base
+
+	if v, ok := m["case"]; ok {
+		profile.Case = SlugifyCase(cast.ToString(v))
+	}
+	if v, ok := m["separator"]; ok {
+		if r := []rune(cast.ToString(v)); len(r) > 0 {
+			profile.Separator = r[0]
+		}
+	}
+	if v, ok := m["transliterate"]; ok {
+		profile.Transliterate = cast.ToString(v)
+	}
+	if v, ok := m["scriptTransliterate"]; ok {
+		st := map[string]string{}
+		for k, vv := range cast.ToStringMap(v) {
+			st[k] = cast.ToString(vv)
+		}
+		profile.ScriptTransliterate = st
+	}
+	if v, ok := m["strip"]; ok {
+		profile.Strip = []rune(cast.ToString(v))
+	}
+	if v, ok := m["replace"]; ok {
+		repl := map[rune]string{}
+		for k, vv := range cast.ToStringMapString(v) {
+			if r := []rune(k); len(r) > 0 {
+				repl[r[0]] = vv
+			}
+		}
+		profile.Replace = repl
+	}
+	if v, ok := m["maxLen"]; ok {
+		profile.MaxLen = cast.ToInt(v)
+	}
+
+	return profile
+}
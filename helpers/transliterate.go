@@ -0,0 +1,55 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import "sync"
+
+// Transliterator converts a string from a non-Latin script into a Latin,
+// URL-safe approximation, e.g. pinyin for Chinese or a romanization table
+// for Cyrillic or Greek. It runs before UnicodeSanitize's character
+// filtering, so it only needs to worry about script conversion, not
+// escaping.
+type Transliterator func(s string) string
+
+var (
+	transliteratorsMu sync.RWMutex
+	transliterators   = map[string]Transliterator{}
+)
+
+// RegisterTransliterator makes a Transliterator available under name for
+// use via the site's `transliterate` configuration option, e.g.
+// `transliterate = "pinyin"`. This gives theme and module authors a way to
+// plug in slug transliteration for scripts Hugo doesn't handle out of the
+// box.
+func RegisterTransliterator(name string, t Transliterator) {
+	transliteratorsMu.Lock()
+	defer transliteratorsMu.Unlock()
+	transliterators[name] = t
+}
+
+// getTransliterator looks up a registered Transliterator by name.
+func getTransliterator(name string) (Transliterator, bool) {
+	transliteratorsMu.RLock()
+	defer transliteratorsMu.RUnlock()
+	t, found := transliterators[name]
+	return t, found
+}
+
+// GetTransliterator is the exported form of getTransliterator, for callers
+// outside this package (e.g. the "transliterate" template namespace) that
+// need to apply a registered Transliterator directly rather than through
+// Slugify's `transliterate` config option.
+func GetTransliterator(name string) (Transliterator, bool) {
+	return getTransliterator(name)
+}
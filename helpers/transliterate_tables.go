@@ -0,0 +1,107 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import "strings"
+
+func init() {
+	RegisterTransliterator("cyrillic", runeMapTransliterator(cyrillicTable))
+	RegisterTransliterator("greek", runeMapTransliterator(greekTable))
+	RegisterTransliterator("german", runeMapTransliterator(germanTable))
+	RegisterTransliterator("pinyin", pinyinTransliterate)
+}
+
+// runeMapTransliterator builds a Transliterator that replaces each rune of
+// its input found in table with table's string, leaving any rune not in
+// table untouched.
+func runeMapTransliterator(table map[rune]string) Transliterator {
+	return func(s string) string {
+		var b strings.Builder
+		for _, r := range s {
+			if repl, ok := table[r]; ok {
+				b.WriteString(repl)
+				continue
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+}
+
+// cyrillicTable is a scholarly (ISO 9-style) romanization of the Russian
+// Cyrillic alphabet. Other Cyrillic-script languages (Ukrainian, Bulgarian,
+// Serbian, ...) use letters and conventions this table doesn't cover;
+// RegisterTransliterator lets a site register a replacement for those.
+var cyrillicTable = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "iu", 'я': "ia",
+
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Д': "D", 'Е': "E", 'Ё': "E",
+	'Ж': "Zh", 'З': "Z", 'И': "I", 'Й': "I", 'К': "K", 'Л': "L", 'М': "M",
+	'Н': "N", 'О': "O", 'П': "P", 'Р': "R", 'С': "S", 'Т': "T", 'У': "U",
+	'Ф': "F", 'Х': "Kh", 'Ц': "Ts", 'Ч': "Ch", 'Ш': "Sh", 'Щ': "Shch",
+	'Ъ': "", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "Iu", 'Я': "Ia",
+}
+
+// greekTable romanizes the modern (monotonic) Greek alphabet.
+var greekTable = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+
+	'Α': "A", 'Β': "V", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z", 'Η': "I",
+	'Θ': "Th", 'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X",
+	'Ο': "O", 'Π': "P", 'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y",
+	'Φ': "F", 'Χ': "Ch", 'Ψ': "Ps", 'Ω': "O",
+}
+
+// germanTable expands German umlauts and ß the way German itself does when
+// an accent-free spelling is needed (e.g. ä -> ae rather than just a),
+// unlike the generic diacritic-stripping RemovePathAccents does.
+var germanTable = map[rune]string{
+	'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss",
+	'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue",
+}
+
+// pinyinTable maps a practical subset of common Hanzi to unaccented
+// (tone-less) pinyin syllables. It is nowhere near exhaustive -- a
+// complete Han-to-pinyin table has tens of thousands of entries, many
+// polyphonic and only resolvable with word-level context -- so pinyin
+// passes through any character it doesn't recognise unchanged rather than
+// failing; RegisterTransliterator lets a site plug in a fuller
+// implementation (e.g. backed by a dictionary package) under the same
+// "pinyin" name.
+var pinyinTable = map[rune]string{
+	'你': "ni", '我': "wo", '他': "ta", '她': "ta", '们': "men",
+	'是': "shi", '不': "bu", '了': "le", '在': "zai", '有': "you",
+	'这': "zhe", '那': "na", '个': "ge", '中': "zhong", '国': "guo",
+	'人': "ren", '大': "da", '小': "xiao", '上': "shang", '下': "xia",
+	'天': "tian", '地': "di", '年': "nian", '月': "yue", '日': "ri",
+	'好': "hao", '说': "shuo", '去': "qu", '来': "lai", '到': "dao",
+	'时': "shi", '会': "hui", '能': "neng", '要': "yao", '就': "jiu",
+	'和': "he", '对': "dui", '也': "ye", '之': "zhi", '一': "yi",
+	'二': "er", '三': "san", '四': "si", '五': "wu", '六': "liu",
+	'七': "qi", '八': "ba", '九': "jiu", '十': "shi", '百': "bai",
+	'千': "qian", '万': "wan", '文': "wen", '字': "zi", '语': "yu",
+	'书': "shu", '学': "xue", '生': "sheng", '家': "jia", '车': "che",
+	'水': "shui", '火': "huo", '山': "shan", '门': "men", '心': "xin",
+}
+
+// pinyinTransliterate applies pinyinTable rune by rune, since Chinese text
+// has no spaces delimiting the individual Hanzi pinyinTable is keyed on.
+var pinyinTransliterate = runeMapTransliterator(pinyinTable)
@@ -0,0 +1,49 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import "testing"
+
+func TestBuiltinTransliterators(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"cyrillic", "Привет", "Privet"},
+		{"greek", "Γειά", "Geia"},
+		{"german", "Grüße über München", "Gruesse ueber Muenchen"},
+		{"pinyin", "我是中国人", "wo shi zhong guo ren"},
+	} {
+		tr, found := getTransliterator(test.name)
+		if !found {
+			t.Fatalf("%s: not registered", test.name)
+		}
+		if got := tr(test.in); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPinyinTransliteratorLeavesUnknownHanziUnchanged(t *testing.T) {
+	tr, found := getTransliterator("pinyin")
+	if !found {
+		t.Fatal("pinyin: not registered")
+	}
+	// 鬱 is a real but obscure Hanzi our practical-subset table doesn't
+	// cover; it should pass through rather than being dropped or erroring.
+	if got, want := tr("鬱"), "鬱"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -24,6 +24,10 @@ import (
 	"github.com/PuerkitoBio/purell"
 )
 
+// sanitizeURLWithFlags is the low-level purell-backed implementation
+// behind purellURLSanitizer. It is kept here, rather than in
+// urlsanitizer.go, so the temporary kludge below stays next to the
+// historical context that explains it.
 func sanitizeURLWithFlags(in string, f purell.NormalizationFlags) string {
 	s, err := purell.NormalizeURLString(in, f)
 	if err != nil {
@@ -59,14 +63,25 @@ func sanitizeURLWithFlags(in string, f purell.NormalizationFlags) string {
 
 }
 
-// SanitizeURL sanitizes the input URL string.
+// SanitizeURL sanitizes the input URL string using DefaultURLSanitizer.
+// Prefer PathSpec.URLSanitizer directly where a PathSpec is available, so
+// a site's configured `[urls] sanitizer` is honored.
 func SanitizeURL(in string) string {
-	return sanitizeURLWithFlags(in, purell.FlagsSafe|purell.FlagRemoveTrailingSlash|purell.FlagRemoveDotSegments|purell.FlagRemoveDuplicateSlashes|purell.FlagRemoveUnnecessaryHostDots|purell.FlagRemoveEmptyPortSeparator)
+	return DefaultURLSanitizer.Sanitize(in)
 }
 
 // SanitizeURLKeepTrailingSlash is the same as SanitizeURL, but will keep any trailing slash.
 func SanitizeURLKeepTrailingSlash(in string) string {
-	return sanitizeURLWithFlags(in, purell.FlagsSafe|purell.FlagRemoveDotSegments|purell.FlagRemoveDuplicateSlashes|purell.FlagRemoveUnnecessaryHostDots|purell.FlagRemoveEmptyPortSeparator)
+	return DefaultURLSanitizer.SanitizeKeepTrailingSlash(in)
+}
+
+// sanitizer returns the site's configured URLSanitizer, falling back to
+// DefaultURLSanitizer if none was set on the PathSpec.
+func (p *PathSpec) sanitizer() URLSanitizer {
+	if p.URLSanitizer != nil {
+		return p.URLSanitizer
+	}
+	return DefaultURLSanitizer
 }
 
 // URLize is similar to MakePath, but with Unicode handling
@@ -135,7 +150,7 @@ func (p *PathSpec) AbsURL(in string, addLanguage bool) string {
 		}
 	}
 
-	return paths.MakePermalink(baseURL, in).String()
+	return p.sanitizer().SanitizeKeepTrailingSlash(paths.MakePermalink(baseURL, in).String())
 }
 
 func (p *PathSpec) getBaseURLRoot(path string) string {
@@ -200,7 +215,7 @@ func (p *PathSpec) RelURL(in string, addLanguage bool) string {
 		u = "/" + u
 	}
 
-	return u
+	return p.sanitizer().SanitizeKeepTrailingSlash(u)
 }
 
 // PrependBasePath prepends any baseURL sub-folder to the given resource
@@ -226,16 +241,13 @@ func (p *PathSpec) URLizeAndPrep(in string) string {
 
 // URLPrep applies misc sanitation to the given URL.
 func (p *PathSpec) URLPrep(in string) string {
+	sanitizer := p.sanitizer()
 	if p.UglyURLs {
-		return paths.Uglify(SanitizeURL(in))
+		return paths.Uglify(sanitizer.Sanitize(in))
 	}
-	pretty := paths.PrettifyURL(SanitizeURL(in))
+	pretty := paths.PrettifyURL(sanitizer.Sanitize(in))
 	if path.Ext(pretty) == ".xml" {
 		return pretty
 	}
-	url, err := purell.NormalizeURLString(pretty, purell.FlagAddTrailingSlash)
-	if err != nil {
-		return pretty
-	}
-	return url
+	return sanitizer.Normalize(pretty, URLFlagAddTrailingSlash)
 }
@@ -0,0 +1,143 @@
+// Copyright 2015 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"github.com/PuerkitoBio/purell"
+)
+
+// URLFlags controls how URLSanitizer.Normalize treats a URL. It mirrors the
+// handful of purell.NormalizationFlags Hugo actually uses, so a
+// user-registered URLSanitizer doesn't need to import purell itself.
+type URLFlags uint
+
+const (
+	URLFlagRemoveTrailingSlash URLFlags = 1 << iota
+	URLFlagAddTrailingSlash
+	URLFlagRemoveDotSegments
+	URLFlagRemoveDuplicateSlashes
+	URLFlagRemoveUnnecessaryHostDots
+	URLFlagRemoveEmptyPortSeparator
+
+	// URLFlagsSafe is the baseline set of flags Hugo has historically
+	// applied to every URL it sanitizes.
+	URLFlagsSafe = URLFlagRemoveDotSegments | URLFlagRemoveDuplicateSlashes | URLFlagRemoveUnnecessaryHostDots | URLFlagRemoveEmptyPortSeparator
+)
+
+func (f URLFlags) toPurell() purell.NormalizationFlags {
+	var out purell.NormalizationFlags
+
+	if f&URLFlagsSafe != 0 {
+		out |= purell.FlagsSafe
+	}
+	if f&URLFlagRemoveTrailingSlash != 0 {
+		out |= purell.FlagRemoveTrailingSlash
+	}
+	if f&URLFlagAddTrailingSlash != 0 {
+		out |= purell.FlagAddTrailingSlash
+	}
+	if f&URLFlagRemoveDotSegments != 0 {
+		out |= purell.FlagRemoveDotSegments
+	}
+	if f&URLFlagRemoveDuplicateSlashes != 0 {
+		out |= purell.FlagRemoveDuplicateSlashes
+	}
+	if f&URLFlagRemoveUnnecessaryHostDots != 0 {
+		out |= purell.FlagRemoveUnnecessaryHostDots
+	}
+	if f&URLFlagRemoveEmptyPortSeparator != 0 {
+		out |= purell.FlagRemoveEmptyPortSeparator
+	}
+
+	return out
+}
+
+// URLSanitizer normalizes and sanitizes URLs for use in permalinks, menus
+// and template output. The default implementation wraps
+// PuerkitoBio/purell, but a site can register an alternative, e.g. one
+// that preserves relative "../../post/hello-again/" links (see #157,
+// #622) or one with IDN/punycode host handling, via `[urls] sanitizer` in
+// the site config.
+type URLSanitizer interface {
+	// Sanitize returns a cleaned-up version of in with any trailing slash removed.
+	Sanitize(in string) string
+
+	// SanitizeKeepTrailingSlash is the same as Sanitize but preserves any trailing slash.
+	SanitizeKeepTrailingSlash(in string) string
+
+	// Normalize applies flags to in.
+	Normalize(in string, flags URLFlags) string
+}
+
+// purellURLSanitizer is the default URLSanitizer, and the only
+// implementation Hugo ships with.
+type purellURLSanitizer struct{}
+
+func (purellURLSanitizer) Sanitize(in string) string {
+	return sanitizeURLWithFlags(in, (URLFlagsSafe | URLFlagRemoveTrailingSlash).toPurell())
+}
+
+func (purellURLSanitizer) SanitizeKeepTrailingSlash(in string) string {
+	return sanitizeURLWithFlags(in, URLFlagsSafe.toPurell())
+}
+
+func (purellURLSanitizer) Normalize(in string, flags URLFlags) string {
+	s, err := purell.NormalizeURLString(in, flags.toPurell())
+	if err != nil {
+		return in
+	}
+	return s
+}
+
+// DefaultURLSanitizer is the purell-backed URLSanitizer used when a site
+// does not configure one explicitly.
+var DefaultURLSanitizer URLSanitizer = purellURLSanitizer{}
+
+// URLSanitizerFactory creates a URLSanitizer, given the baseline flags
+// configured for it.
+type URLSanitizerFactory func(flags URLFlags) URLSanitizer
+
+// urlSanitizers holds the named URLSanitizer factories a site can select
+// from via `[urls] sanitizer` in its config.
+var urlSanitizers = map[string]URLSanitizerFactory{
+	"purell": func(flags URLFlags) URLSanitizer { return purellURLSanitizer{} },
+}
+
+// RegisterURLSanitizer makes a URLSanitizer implementation selectable by
+// name via `[urls] sanitizer = "<name>"` in the site config.
+func RegisterURLSanitizer(name string, factory URLSanitizerFactory) {
+	urlSanitizers[name] = factory
+}
+
+// NewURLSanitizer builds the URLSanitizer configured under `[urls]` in
+// cfg, falling back to DefaultURLSanitizer when none is set or the named
+// implementation isn't registered.
+func NewURLSanitizer(cfg ConfigProvider) URLSanitizer {
+	urls := cfg.GetStringMap("urls")
+	if urls == nil {
+		return DefaultURLSanitizer
+	}
+
+	name, _ := urls["sanitizer"].(string)
+	if name == "" {
+		return DefaultURLSanitizer
+	}
+
+	factory, ok := urlSanitizers[name]
+	if !ok {
+		return DefaultURLSanitizer
+	}
+
+	return factory(URLFlagsSafe)
+}
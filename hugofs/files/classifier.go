@@ -18,35 +18,150 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
-)
 
-var (
-	// This should be the only list of valid extensions for content files.
-	contentFileExtensions = []string{
-		"html", "htm",
-		"mdown", "markdown", "md",
-		"asciidoc", "adoc", "ad",
-		"rest", "rst",
-		"mmark",
-		"org",
-		"pandoc", "pdc"}
-
-	contentFileExtensionsSet map[string]bool
+	"github.com/gohugoio/hugo/config"
 )
 
-func init() {
-	contentFileExtensionsSet = make(map[string]bool)
+// contentFileExtensions is Hugo's built-in list of content file
+// extensions, seeding every ContentTypeRegistry (including the
+// package-level default the IsContentFile/IsContentExt/ClassifyContentFile
+// functions below delegate to). Projects that need another source format
+// recognized -- Typst, MDX, Djot, ... -- register it on a
+// ContentTypeRegistry instead of editing this list; see
+// NewContentTypeRegistryFromConfig.
+var contentFileExtensions = []string{
+	"html", "htm",
+	"mdown", "markdown", "md",
+	"asciidoc", "adoc", "ad",
+	"rest", "rst",
+	"mmark",
+	"org",
+	"pandoc", "pdc"}
+
+// ContentTypeRegistry tracks which file extensions Hugo treats as content
+// files. Hugo's built-ins are fixed at compile time, but a site can add or
+// remove extensions at runtime -- typically one per site, seeded via
+// NewContentTypeRegistryFromConfig from a `[markup.contentTypes]` config
+// block -- without recompiling Hugo.
+type ContentTypeRegistry struct {
+	extensions map[string]bool
+}
+
+// NewContentTypeRegistry returns a registry seeded with just Hugo's
+// built-in content file extensions.
+func NewContentTypeRegistry() *ContentTypeRegistry {
+	r := &ContentTypeRegistry{extensions: make(map[string]bool, len(contentFileExtensions))}
 	for _, ext := range contentFileExtensions {
-		contentFileExtensionsSet[ext] = true
+		r.extensions[ext] = true
+	}
+	return r
+}
+
+// NewContentTypeRegistryFromConfig returns a registry seeded with Hugo's
+// built-ins, then adjusted by `[markup.contentTypes]`'s "add" and "remove"
+// arrays, e.g.:
+//
+//	[markup.contentTypes]
+//	add = ["typ", "mdx", "dj"]
+//	remove = ["org"]
+//
+// cfg may be nil, in which case this is equivalent to NewContentTypeRegistry.
+func NewContentTypeRegistryFromConfig(cfg config.Provider) *ContentTypeRegistry {
+	r := NewContentTypeRegistry()
+	if cfg == nil {
+		return r
+	}
+
+	m := cfg.GetStringMap("markup.contenttypes")
+	if m == nil {
+		return r
+	}
+
+	if add, ok := m["add"]; ok {
+		for _, ext := range toStringSlice(add) {
+			r.Register(ext)
+		}
+	}
+	if remove, ok := m["remove"]; ok {
+		for _, ext := range toStringSlice(remove) {
+			r.Remove(ext)
+		}
+	}
+
+	return r
+}
+
+func toStringSlice(v interface{}) []string {
+	switch vv := v.(type) {
+	case []string:
+		return vv
+	case []interface{}:
+		out := make([]string, 0, len(vv))
+		for _, e := range vv {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
 	}
 }
 
+// Register adds ext (without a leading dot; case-insensitive) as a
+// recognized content file extension.
+func (r *ContentTypeRegistry) Register(ext string) {
+	r.extensions[strings.ToLower(ext)] = true
+}
+
+// Remove stops treating ext as a content file extension, letting a site
+// opt out of one of the built-in defaults.
+func (r *ContentTypeRegistry) Remove(ext string) {
+	delete(r.extensions, strings.ToLower(ext))
+}
+
+// IsContentExt reports whether ext (without a leading dot) is a
+// recognized content file extension.
+func (r *ContentTypeRegistry) IsContentExt(ext string) bool {
+	return r.extensions[strings.ToLower(ext)]
+}
+
+// IsContentFile reports whether filename's extension is recognized as a
+// content file extension.
+func (r *ContentTypeRegistry) IsContentFile(filename string) bool {
+	return r.IsContentExt(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ClassifyContentFile classifies filename as a leaf bundle's content file,
+// a branch bundle's content file, an ordinary content file, or (if it's
+// not even a recognized content extension) a plain file.
+func (r *ContentTypeRegistry) ClassifyContentFile(filename string) ContentClass {
+	if !r.IsContentFile(filename) {
+		return ContentClassFile
+	}
+	if strings.HasPrefix(filename, "_index.") {
+		return ContentClassBranch
+	}
+
+	if strings.HasPrefix(filename, "index.") {
+		return ContentClassLeaf
+	}
+
+	return ContentClassContent
+}
+
+// defaultContentTypeRegistry backs the package-level
+// IsContentFile/IsContentExt/ClassifyContentFile functions below, so
+// existing callers that don't need per-site customization keep working
+// exactly as before.
+var defaultContentTypeRegistry = NewContentTypeRegistry()
+
 func IsContentFile(filename string) bool {
-	return contentFileExtensionsSet[strings.TrimPrefix(filepath.Ext(filename), ".")]
+	return defaultContentTypeRegistry.IsContentFile(filename)
 }
 
 func IsContentExt(ext string) bool {
-	return contentFileExtensionsSet[ext]
+	return defaultContentTypeRegistry.IsContentExt(ext)
 }
 
 type ContentClass string
@@ -63,18 +178,7 @@ func (c ContentClass) IsBundle() bool {
 }
 
 func ClassifyContentFile(filename string) ContentClass {
-	if !IsContentFile(filename) {
-		return ContentClassFile
-	}
-	if strings.HasPrefix(filename, "_index.") {
-		return ContentClassBranch
-	}
-
-	if strings.HasPrefix(filename, "index.") {
-		return ContentClassLeaf
-	}
-
-	return ContentClassContent
+	return defaultContentTypeRegistry.ClassifyContentFile(filename)
 }
 
 const (
@@ -14,6 +14,7 @@
 package glob
 
 import (
+	"container/list"
 	"os"
 	"path"
 	"path/filepath"
@@ -21,25 +22,26 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/gobwas/glob"
 	"github.com/gobwas/glob/syntax"
+	"github.com/spf13/afero"
 )
 
 const filepathSeparator = string(os.PathSeparator)
 
+// defaultGlobCacheSize is the maximum number of compiled patterns a
+// globCache keeps around before evicting the least recently used one. This
+// only matters for long-running processes (hugo server); one-shot builds
+// never come close to it.
+const defaultGlobCacheSize = 1000
+
 var (
 	isWindows        = runtime.GOOS == "windows"
-	defaultGlobCache = &globCache{
-		isCaseSensitive: false,
-		isWindows:       isWindows,
-		cache:           make(map[string]globErr),
-	}
+	defaultGlobCache = newGlobCache(false, defaultGlobCacheSize)
 
-	filenamesGlobCache = &globCache{
-		isCaseSensitive: false, // As long as the search strings are all lower case, this does not allocate.
-		isWindows:       isWindows,
-		cache:           make(map[string]globErr),
-	}
+	// As long as the search strings are all lower case, this does not allocate.
+	filenamesGlobCache = newGlobCache(false, defaultGlobCacheSize)
 )
 
 type globErr struct {
@@ -47,40 +49,69 @@ type globErr struct {
 	err  error
 }
 
+// globCacheEntry is the value stored per pattern in globCache.lru, so an
+// evicted list.Element can remove itself from globCache.cache without a
+// second lookup.
+type globCacheEntry struct {
+	pattern string
+	eg      globErr
+}
+
 type globCache struct {
 	// Config
 	isCaseSensitive bool
 	isWindows       bool
+	maxSize         int
 
 	// Cache
 	sync.RWMutex
-	cache map[string]globErr
+	cache map[string]*list.Element // pattern -> element in lru
+	lru   *list.List               // front is most recently used
 }
 
-func (gc *globCache) GetGlob(pattern string) (glob.Glob, error) {
-	var eg globErr
+func newGlobCache(isCaseSensitive bool, maxSize int) *globCache {
+	return &globCache{
+		isCaseSensitive: isCaseSensitive,
+		isWindows:       isWindows,
+		maxSize:         maxSize,
+		cache:           make(map[string]*list.Element),
+		lru:             list.New(),
+	}
+}
 
-	gc.RLock()
-	var found bool
-	eg, found = gc.cache[pattern]
-	gc.RUnlock()
-	if found {
+func (gc *globCache) GetGlob(pattern string) (glob.Glob, error) {
+	gc.Lock()
+	if elem, found := gc.cache[pattern]; found {
+		gc.lru.MoveToFront(elem)
+		eg := elem.Value.(*globCacheEntry).eg
+		gc.Unlock()
 		return eg.glob, eg.err
 	}
+	gc.Unlock()
 
 	var g glob.Glob
 	var err error
 
 	pattern = filepath.ToSlash(pattern)
 
-	if gc.isCaseSensitive {
-		g, err = glob.Compile(pattern, '/')
-	} else {
-		g, err = glob.Compile(strings.ToLower(pattern), '/')
+	comparePattern := pattern
+	if !gc.isCaseSensitive {
+		comparePattern = strings.ToLower(pattern)
+	}
 
+	if hasDoubleStarSegment(comparePattern) {
+		// gobwas/glob's own "**" (its "super-asterisk") doesn't behave like
+		// the doublestar convention callers expect: it can fail to match
+		// zero path segments when "**" isn't flanked by literal components
+		// on both sides, e.g. "assets/**/*.json" against "assets/foo.json".
+		// Compile those patterns segment-by-segment instead, so "**" always
+		// means "zero or more whole path segments".
+		g, err = compileDoubleStar(comparePattern)
+	} else {
+		g, err = glob.Compile(comparePattern, '/')
 	}
 
-	eg = globErr{
+	eg := globErr{
 		globDecorator{
 			g:               g,
 			isCaseSensitive: gc.isCaseSensitive,
@@ -89,12 +120,110 @@ func (gc *globCache) GetGlob(pattern string) (glob.Glob, error) {
 	}
 
 	gc.Lock()
-	gc.cache[pattern] = eg
+	gc.setLocked(pattern, eg)
 	gc.Unlock()
 
 	return eg.glob, eg.err
 }
 
+// setLocked inserts or updates pattern's entry and evicts the least
+// recently used entry once the cache grows past maxSize. gc must be locked.
+func (gc *globCache) setLocked(pattern string, eg globErr) {
+	if elem, found := gc.cache[pattern]; found {
+		elem.Value.(*globCacheEntry).eg = eg
+		gc.lru.MoveToFront(elem)
+		return
+	}
+
+	elem := gc.lru.PushFront(&globCacheEntry{pattern: pattern, eg: eg})
+	gc.cache[pattern] = elem
+
+	if gc.maxSize > 0 && gc.lru.Len() > gc.maxSize {
+		oldest := gc.lru.Back()
+		if oldest != nil {
+			gc.removeElementLocked(oldest)
+		}
+	}
+}
+
+func (gc *globCache) removeElementLocked(elem *list.Element) {
+	gc.lru.Remove(elem)
+	delete(gc.cache, elem.Value.(*globCacheEntry).pattern)
+}
+
+// Forget removes pattern's compiled matcher, if cached, so the next
+// GetGlob call for it recompiles from scratch.
+func (gc *globCache) Forget(pattern string) {
+	gc.Lock()
+	defer gc.Unlock()
+	if elem, found := gc.cache[pattern]; found {
+		gc.removeElementLocked(elem)
+	}
+}
+
+// forgetBelow forgets every cached pattern whose resolved root (see
+// ResolveRootDir) is at or above changedPath, i.e. every pattern that could
+// match something under changedPath.
+func (gc *globCache) forgetBelow(changedPath string) {
+	changed := NormalizePath(changedPath)
+
+	gc.Lock()
+	defer gc.Unlock()
+
+	for pattern, elem := range gc.cache {
+		root := NormalizePath(ResolveRootDir(pattern))
+		if root == "" || strings.HasPrefix(changed, root) {
+			gc.removeElementLocked(elem)
+		}
+	}
+}
+
+// Subscribe watches root, typically the ResolveRootDir of one or more
+// cached patterns, and forgets any cached pattern whose resolved root is at
+// or above a path that changes below it, so the next GetGlob call for that
+// pattern recompiles lazily rather than serving a stale decorator. fn, if
+// not nil, is additionally invoked with the changed path.
+//
+// The returned func stops the watch; callers should call it once root no
+// longer needs watching.
+func (gc *globCache) Subscribe(root string, fn func(changedPath string)) (func() error, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(root); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				gc.forgetBelow(event.Name)
+				if fn != nil {
+					fn(event.Name)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() error {
+		close(done)
+		return watcher.Close()
+	}, nil
+}
+
 type globDecorator struct {
 	// Whether both pattern and the strings to match will be matched
 	// by their original case.
@@ -121,6 +250,37 @@ func GetGlob(pattern string) (glob.Glob, error) {
 	return defaultGlobCache.GetGlob(pattern)
 }
 
+// Forget drops pattern's compiled matcher from the default and filenames
+// caches, if cached, so the next GetGlob call for it recompiles from
+// scratch.
+func Forget(pattern string) {
+	defaultGlobCache.Forget(pattern)
+	filenamesGlobCache.Forget(pattern)
+}
+
+// Subscribe watches root for filesystem changes and invalidates any cached
+// pattern (in both the default and filenames caches) whose resolved root is
+// at or above the changed path. See globCache.Subscribe.
+func Subscribe(root string, fn func(changedPath string)) (func() error, error) {
+	stopDefault, err := defaultGlobCache.Subscribe(root, fn)
+	if err != nil {
+		return nil, err
+	}
+	stopFilenames, err := filenamesGlobCache.Subscribe(root, nil)
+	if err != nil {
+		stopDefault()
+		return nil, err
+	}
+	return func() error {
+		err1 := stopDefault()
+		err2 := stopFilenames()
+		if err1 != nil {
+			return err1
+		}
+		return err2
+	}, nil
+}
+
 func NormalizePath(p string) string {
 	return strings.Trim(path.Clean(filepath.ToSlash(strings.ToLower(p))), "/.")
 }
@@ -164,3 +324,198 @@ func HasGlobChar(s string) bool {
 	}
 	return false
 }
+
+// doubleStarSegment is "**", if the path segment is that literal token, or
+// a compiled matcher for a single path segment otherwise.
+type doubleStarSegment struct {
+	isDoubleStar bool
+	g            glob.Glob // nil if isDoubleStar
+}
+
+// doubleStarGlob matches a pattern containing one or more "**" segments,
+// each of which may consume zero or more whole path segments, e.g.
+// "a/**/b" matches "a/b", "a/x/b", and "a/x/y/b".
+type doubleStarGlob struct {
+	segments []doubleStarSegment
+}
+
+func (d doubleStarGlob) Match(s string) bool {
+	return matchDoubleStarSegments(d.segments, strings.Split(s, "/"))
+}
+
+// matchDoubleStarSegments matches pattern against parts, backtracking
+// through the possible lengths a "**" could consume.
+func matchDoubleStarSegments(pattern []doubleStarSegment, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+
+	seg := pattern[0]
+	if seg.isDoubleStar {
+		for i := 0; i <= len(parts); i++ {
+			if matchDoubleStarSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(parts) == 0 || !seg.g.Match(parts[0]) {
+		return false
+	}
+	return matchDoubleStarSegments(pattern[1:], parts[1:])
+}
+
+// hasDoubleStarSegment reports whether pattern has a "/"-delimited segment
+// that is exactly "**".
+func hasDoubleStarSegment(pattern string) bool {
+	for _, part := range strings.Split(pattern, "/") {
+		if part == "**" {
+			return true
+		}
+	}
+	return false
+}
+
+// compileSegments compiles parts, pattern split on "/", into one
+// doubleStarSegment each.
+func compileSegments(parts []string) ([]doubleStarSegment, error) {
+	segments := make([]doubleStarSegment, len(parts))
+	for i, part := range parts {
+		if part == "**" {
+			segments[i] = doubleStarSegment{isDoubleStar: true}
+			continue
+		}
+		g, err := glob.Compile(part)
+		if err != nil {
+			return nil, err
+		}
+		segments[i] = doubleStarSegment{g: g}
+	}
+	return segments, nil
+}
+
+// compileDoubleStar compiles pattern into a doubleStarGlob, one matcher per
+// non-"**" path segment.
+func compileDoubleStar(pattern string) (glob.Glob, error) {
+	segments, err := compileSegments(strings.Split(pattern, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return doubleStarGlob{segments: segments}, nil
+}
+
+// canBeDoubleStarPrefix reports whether parts could be the prefix of some
+// path matching pattern, i.e. whether it's still worth descending into the
+// directory parts names. Once pattern reaches a "**", the answer is always
+// true, since "**" can still absorb parts and any number of deeper
+// directories; before that, each part must match its corresponding literal
+// pattern segment exactly.
+func canBeDoubleStarPrefix(pattern []doubleStarSegment, parts []string) bool {
+	for i, part := range parts {
+		if i >= len(pattern) {
+			return false
+		}
+		if pattern[i].isDoubleStar {
+			return true
+		}
+		if !pattern[i].g.Match(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether name matches pattern. It goes through the shared
+// compiled-pattern cache, so repeated calls with the same pattern (the
+// common case: a config-provided pattern tested against many names) never
+// recompile or reallocate its AST.
+func Matches(pattern, name string) (bool, error) {
+	g, err := GetGlob(pattern)
+	if err != nil {
+		return false, err
+	}
+	return g.Match(name), nil
+}
+
+// GlobWalkOption configures GlobWalk.
+type GlobWalkOption func(*globWalkOpts)
+
+type globWalkOpts struct {
+	followSymlinks bool
+}
+
+// WithFollowSymlinks makes GlobWalk follow symlinks instead of skipping
+// them (the default) when deciding whether a path matches.
+func WithFollowSymlinks(v bool) GlobWalkOption {
+	return func(o *globWalkOpts) {
+		o.followSymlinks = v
+	}
+}
+
+// GlobWalk walks fsys below pattern's static root (see ResolveRootDir),
+// calling fn once for every path matching pattern, in the order afero.Walk
+// visits them. fn may return filepath.SkipDir, the same as in
+// filepath.WalkFunc, to skip the rest of the directory currently being
+// visited; as with filepath.WalkFunc, that only has an effect when the
+// directory itself is still being visited; returning it from a matched
+// file is equivalent to returning nil.
+//
+// Directories below the root that cannot possibly contain a match are
+// pruned without being visited, by peeling off pattern's literal path
+// components as the walk descends and short-circuiting as soon as a
+// directory's path can no longer satisfy them (see canBeDoubleStarPrefix).
+func GlobWalk(fsys afero.Fs, pattern string, fn func(path string, info os.FileInfo) error, opts ...GlobWalkOption) error {
+	var o globWalkOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	pattern = NormalizePath(pattern)
+	root := ResolveRootDir(pattern)
+
+	g, err := GetGlob(pattern)
+	if err != nil {
+		return err
+	}
+	prefixSegments, err := compileSegments(strings.Split(pattern, "/"))
+	if err != nil {
+		return err
+	}
+
+	err = afero.Walk(fsys, root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == "" || p == root {
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 && !o.followSymlinks {
+			return nil
+		}
+
+		normalized := NormalizePath(p)
+		parts := strings.Split(normalized, "/")
+
+		if info.IsDir() {
+			if !canBeDoubleStarPrefix(prefixSegments, parts) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if g.Match(normalized) {
+			return fn(normalized, info)
+		}
+		return nil
+	})
+	if err == filepath.SkipDir {
+		// fn returned SkipDir from a matched file rather than a directory;
+		// afero.Walk (unlike the stdlib's newer fs.WalkDir) doesn't swallow
+		// that case itself, but filepath.SkipDir is documented to never be
+		// returned as an error, so GlobWalk shouldn't let it leak out either.
+		err = nil
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobCacheEviction(t *testing.T) {
+	assert := require.New(t)
+
+	gc := newGlobCache(false, 2)
+
+	_, err := gc.GetGlob("a/*.txt")
+	assert.NoError(err)
+	_, err = gc.GetGlob("b/*.txt")
+	assert.NoError(err)
+	assert.Len(gc.cache, 2)
+
+	// Compiling a third pattern should evict the least recently used one,
+	// "a/*.txt".
+	_, err = gc.GetGlob("c/*.txt")
+	assert.NoError(err)
+	assert.Len(gc.cache, 2)
+	_, found := gc.cache["a/*.txt"]
+	assert.False(found)
+	_, found = gc.cache["b/*.txt"]
+	assert.True(found)
+	_, found = gc.cache["c/*.txt"]
+	assert.True(found)
+}
+
+func TestGlobCacheForget(t *testing.T) {
+	assert := require.New(t)
+
+	gc := newGlobCache(false, defaultGlobCacheSize)
+
+	_, err := gc.GetGlob("assets/*.json")
+	assert.NoError(err)
+	_, found := gc.cache["assets/*.json"]
+	assert.True(found)
+
+	gc.Forget("assets/*.json")
+	_, found = gc.cache["assets/*.json"]
+	assert.False(found)
+}
+
+func TestGlobCacheSubscribe(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "hugo-glob-subscribe")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	gc := newGlobCache(false, defaultGlobCacheSize)
+
+	pattern := filepath.ToSlash(filepath.Join(dir, "*.txt"))
+	g, err := gc.GetGlob(pattern)
+	assert.NoError(err)
+	assert.True(g.Match(filepath.ToSlash(filepath.Join(dir, "foo.txt"))))
+
+	changed := make(chan string, 1)
+	stop, err := gc.Subscribe(dir, func(changedPath string) {
+		changed <- changedPath
+	})
+	assert.NoError(err)
+	defer stop()
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(dir, "foo.txt"), []byte("content"), 0o600))
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for fsnotify event")
+	}
+
+	// The cached pattern's root is dir itself, so it should've been forgotten.
+	_, found := gc.cache[pattern]
+	assert.False(found)
+}
@@ -15,6 +15,7 @@ package hugofs
 
 import (
 	"os"
+	"strings"
 )
 
 // LanguageDirsMerger implements the overlayfs.DirsMerger func, which is used
@@ -37,3 +38,64 @@ var LanguageDirsMerger = func(lofi, bofi []os.FileInfo) []os.FileInfo {
 
 	return lofi
 }
+
+// LanguageDirsMergerCI is like LanguageDirsMerger, but compares names
+// case-insensitively. LanguageDirsMerger's plain == mis-merges on a
+// case-insensitive filesystem (macOS's default, Windows), where two
+// overlay entries differing only by case are actually the same file as
+// far as the OS is concerned, so they should never be treated as two
+// distinct pages in the same language.
+var LanguageDirsMergerCI = func(lofi, bofi []os.FileInfo) []os.FileInfo {
+	for _, fi1 := range bofi {
+		fim1 := fi1.(FileMetaInfo)
+		var found bool
+		for _, fi2 := range lofi {
+			fim2 := fi2.(FileMetaInfo)
+			if strings.EqualFold(fi1.Name(), fi2.Name()) && fim1.Meta().Lang == fim2.Meta().Lang {
+				found = true
+				break
+			}
+		}
+		if !found {
+			lofi = append(lofi, fi1)
+		}
+	}
+
+	return lofi
+}
+
+// LanguageDirsMergerResolved is like LanguageDirsMerger, but additionally
+// treats two entries in the same language as the same file if os.SameFile
+// reports they resolve to the same underlying inode, even when their
+// names differ -- the case a symlinked overlay directory produces, and
+// which neither LanguageDirsMerger nor LanguageDirsMergerCI's name-based
+// comparison can catch.
+var LanguageDirsMergerResolved = func(lofi, bofi []os.FileInfo) []os.FileInfo {
+	for _, fi1 := range bofi {
+		fim1 := fi1.(FileMetaInfo)
+		var found bool
+		for _, fi2 := range lofi {
+			fim2 := fi2.(FileMetaInfo)
+			if fim1.Meta().Lang != fim2.Meta().Lang {
+				continue
+			}
+			if fi1.Name() == fi2.Name() || os.SameFile(fi1, fi2) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			lofi = append(lofi, fi1)
+		}
+	}
+
+	return lofi
+}
+
+// Picking one of the three DirsMerger funcs above based on the mounted
+// filesystem's detected case sensitivity is left undone: this tree has no
+// OverlayFs type or mount-time construction path anywhere (LanguageDirsMerger
+// itself is only ever referenced here, never actually passed to anything
+// that mounts an overlay), so there's no real call site to make that
+// choice at yet. A caller that does construct such an overlay can select
+// LanguageDirsMergerCI/LanguageDirsMergerResolved directly in the meantime.
@@ -0,0 +1,207 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchmark generates a deterministic content corpus for
+// hugolib's BenchmarkSiteNew, so site-build performance can be compared
+// across commits without the benchmark's own input data silently
+// drifting as cases are hand-edited over time.
+package benchmark
+
+import (
+	"fmt"
+	"math/rand"
+	"path"
+	"strings"
+)
+
+// Options configures the shape of a generated Corpus. All knobs default
+// to a small, fast-to-build site; scale them up for a stress benchmark.
+type Options struct {
+	// Seed makes generation reproducible: the same Seed and the rest of
+	// Options always produce a byte-identical Corpus.
+	Seed int64
+
+	Languages         int
+	Sections          int
+	Depth             int
+	BundlesPerSection int
+	ImagesPerBundle   int
+	JSONPerBundle     int
+	Taxonomies        int
+
+	// ShortcodeDensity is the number of `{{< bench >}}` shortcode calls
+	// inserted into each generated page's body.
+	ShortcodeDensity int
+}
+
+// DefaultOptions returns a small corpus suitable as a quick baseline.
+func DefaultOptions() Options {
+	return Options{
+		Seed:              42,
+		Languages:         1,
+		Sections:          3,
+		Depth:             2,
+		BundlesPerSection: 2,
+		ImagesPerBundle:   1,
+		JSONPerBundle:     1,
+		Taxonomies:        2,
+		ShortcodeDensity:  0,
+	}
+}
+
+// Corpus is a generated, deterministic site: a site config plus a set of
+// text content files keyed by their path relative to the site root.
+//
+// ImagePaths lists additional paths, also relative to the site root, that
+// the caller should populate with a real image file -- Generate cannot
+// embed binary fixtures itself, as Content is written verbatim as text by
+// callers such as hugolib's sitesBuilder.WithContent.
+type Corpus struct {
+	Config     string
+	Content    map[string]string
+	ImagePaths []string
+}
+
+var wordList = []string{
+	"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing",
+	"elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore",
+	"et", "dolore", "magna", "aliqua", "ut", "enim", "minim", "veniam",
+	"quis", "nostrud", "exercitation", "ullamco", "laboris", "nisi",
+	"aliquip", "ex", "ea", "commodo", "consequat",
+}
+
+var languageCodes = []string{"en", "fr", "no", "sv", "de", "es", "pt", "it", "nl", "pl"}
+
+// Generate builds a Corpus from opts. The same opts, including Seed,
+// always produces the same Corpus.
+func Generate(opts Options) *Corpus {
+	r := rand.New(rand.NewSource(opts.Seed))
+
+	langs := opts.Languages
+	if langs < 1 {
+		langs = 1
+	}
+	if langs > len(languageCodes) {
+		langs = len(languageCodes)
+	}
+
+	c := &Corpus{
+		Content: make(map[string]string),
+	}
+	c.Config = buildConfig(opts, langs)
+
+	for _, lang := range languageCodes[:langs] {
+		root := "content"
+		if langs > 1 {
+			root = path.Join("content", lang)
+		}
+		c.generateSections(r, opts, root)
+	}
+
+	return c
+}
+
+func buildConfig(opts Options, langs int) string {
+	var b strings.Builder
+
+	fmt.Fprint(&b, "baseURL = \"https://example.com\"\n\n")
+
+	if opts.Taxonomies > 0 {
+		fmt.Fprint(&b, "[taxonomies]\n")
+		for i := 0; i < opts.Taxonomies; i++ {
+			fmt.Fprintf(&b, "tag%d = \"tags%d\"\n", i, i)
+		}
+		fmt.Fprint(&b, "\n")
+	}
+
+	if langs > 1 {
+		fmt.Fprint(&b, "[languages]\n")
+		for i, lang := range languageCodes[:langs] {
+			fmt.Fprintf(&b, "[languages.%s]\nweight = %d\ncontentDir = \"content/%s\"\n", lang, i+1, lang)
+		}
+	}
+
+	return b.String()
+}
+
+// generateSections creates opts.Sections top-level sections under root,
+// each with its own randomly (but deterministically) seeded sub-tree.
+func (c *Corpus) generateSections(r *rand.Rand, opts Options, root string) {
+	sections := opts.Sections
+	if sections < 1 {
+		sections = 1
+	}
+	depth := opts.Depth
+	if depth < 1 {
+		depth = 1
+	}
+
+	for s := 0; s < sections; s++ {
+		sectionDir := path.Join(root, fmt.Sprintf("section%d", s))
+		c.generateSectionTree(r, opts, sectionDir, depth)
+	}
+}
+
+func (c *Corpus) generateSectionTree(r *rand.Rand, opts Options, dir string, depth int) {
+	c.Content[path.Join(dir, "_index.md")] = c.randomPage(r, opts)
+
+	for i := 0; i < opts.BundlesPerSection; i++ {
+		bundleDir := path.Join(dir, fmt.Sprintf("bundle%d", i))
+		c.Content[path.Join(bundleDir, "index.md")] = c.randomPage(r, opts)
+
+		for j := 0; j < opts.ImagesPerBundle; j++ {
+			c.ImagePaths = append(c.ImagePaths, path.Join(bundleDir, fmt.Sprintf("image%d.jpg", j)))
+		}
+		for j := 0; j < opts.JSONPerBundle; j++ {
+			c.Content[path.Join(bundleDir, fmt.Sprintf("data%d.json", j))] = fmt.Sprintf(`{"section": %q, "n": %d}`, dir, r.Intn(1000))
+		}
+	}
+
+	if depth > 1 {
+		c.generateSectionTree(r, opts, path.Join(dir, "sub"), depth-1)
+	}
+}
+
+func (c *Corpus) randomPage(r *rand.Rand, opts Options) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "---\ntitle: %q\n", randomTitle(r))
+	if opts.Taxonomies > 0 {
+		fmt.Fprintf(&b, "tags%d: [%q]\n", r.Intn(opts.Taxonomies), randomWords(r, 1))
+	}
+	fmt.Fprint(&b, "---\n\n")
+	fmt.Fprintln(&b, randomWords(r, 40))
+
+	for i := 0; i < opts.ShortcodeDensity; i++ {
+		fmt.Fprintln(&b, "{{< bench >}}")
+	}
+
+	return b.String()
+}
+
+func randomTitle(r *rand.Rand) string {
+	words := randomWordSlice(r, 3)
+	return strings.Title(strings.Join(words, " "))
+}
+
+func randomWords(r *rand.Rand, n int) string {
+	return strings.Join(randomWordSlice(r, n), " ")
+}
+
+func randomWordSlice(r *rand.Rand, n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = wordList[r.Intn(len(wordList))]
+	}
+	return words
+}
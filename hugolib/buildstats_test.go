@@ -0,0 +1,79 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildStatsCollectorSites(t *testing.T) {
+	c := newBuildStatsCollector(true)
+	c.recordSite("en", 10, 2)
+	c.recordSite("fr", 8, 1)
+
+	stats := c.finalize()
+
+	if got := stats.Sites["en"]; got != (SiteBuildStats{PageCount: 10, ResourceCount: 2}) {
+		t.Errorf("got %#v", got)
+	}
+	if got := stats.Sites["fr"]; got != (SiteBuildStats{PageCount: 8, ResourceCount: 1}) {
+		t.Errorf("got %#v", got)
+	}
+}
+
+func TestBuildStatsCollectorDisabled(t *testing.T) {
+	c := newBuildStatsCollector(false)
+	c.recordSite("en", 10, 2)
+	c.recordTemplate("single.html", time.Second)
+
+	stats := c.finalize()
+	if len(stats.Sites) != 0 || len(stats.SlowestTemplates) != 0 {
+		t.Errorf("expected a disabled collector to record nothing, got %#v", stats)
+	}
+}
+
+func TestBuildStatsCollectorSlowestTemplates(t *testing.T) {
+	c := newBuildStatsCollector(true)
+	c.recordTemplate("fast.html", 1*time.Millisecond)
+	c.recordTemplate("slow.html", 100*time.Millisecond)
+	c.recordTemplate("medium.html", 10*time.Millisecond)
+
+	stats := c.finalize()
+
+	if len(stats.SlowestTemplates) != 3 {
+		t.Fatalf("expected 3 entries, got %#v", stats.SlowestTemplates)
+	}
+	if stats.SlowestTemplates[0].Name != "slow.html" {
+		t.Errorf("expected slow.html first, got %#v", stats.SlowestTemplates)
+	}
+	if stats.SlowestTemplates[len(stats.SlowestTemplates)-1].Name != "fast.html" {
+		t.Errorf("expected fast.html last, got %#v", stats.SlowestTemplates)
+	}
+}
+
+func TestTopNTimingsTruncates(t *testing.T) {
+	var timings []TemplateTiming
+	for i := 0; i < buildStatsTopN+5; i++ {
+		timings = append(timings, TemplateTiming{Name: "t", Duration: time.Duration(i) * time.Millisecond})
+	}
+
+	got := topNTimings(timings, buildStatsTopN)
+	if len(got) != buildStatsTopN {
+		t.Fatalf("expected %d entries, got %d", buildStatsTopN, len(got))
+	}
+	if got[0].Duration < got[len(got)-1].Duration {
+		t.Errorf("expected descending order, got %#v", got)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// bundleDirType classifies how a content directory should be treated when
+// the builder assembles page bundles.
+type bundleDirType int
+
+const (
+	// bundleNot means filename is just a regular resource, not a bundle
+	// marker.
+	bundleNot bundleDirType = iota
+
+	// bundleLeaf means filename marks its directory as a leaf bundle: every
+	// other file alongside it (content or not) becomes a page resource.
+	bundleLeaf
+
+	// bundleBranch means filename marks its directory as a branch bundle
+	// (a section, home page, or taxonomy node).
+	bundleBranch
+)
+
+// defaultContentExts are the file extensions classifyBundledFile treats as
+// content (as opposed to a plain page resource) absent a registered
+// BundleClassifier saying otherwise.
+var defaultContentExts = map[string]bool{
+	"md":       true,
+	"markdown": true,
+	"mdown":    true,
+	"html":     true,
+	"htm":      true,
+	"adoc":     true,
+	"asciidoc": true,
+	"rst":      true,
+	"pandoc":   true,
+	"pdc":      true,
+	"mmark":    true,
+}
+
+// BundleClassifier lets a site extend bundle detection beyond the built-in
+// index.md/_index.md rules -- e.g. recognizing a data-bundle keyed on
+// _data.yaml, or making readme.md a leaf bundle within a given section.
+//
+// Register one with RegisterBundleClassifier; classifyBundledFile consults
+// every registered classifier, in registration order, before falling back
+// to the built-in rules.
+type BundleClassifier interface {
+	// ClassifyBundle is called for every file the builder walks under
+	// /content. dir is the file's directory, content-root relative and
+	// slash-terminated; filename is the bare file name; ext is its
+	// extension without the leading dot; isContent reports whether the
+	// built-in extension rules would treat it as a content format.
+	//
+	// Returning claimed == false defers to the next classifier, then the
+	// built-in rules. Returning claimed == true short-circuits the rest of
+	// the chain; resourceRoot, if non-empty, overrides dir as the root the
+	// bundle's resources are collected relative to (e.g. a data-bundle
+	// rooted one directory up from where its marker file lives).
+	ClassifyBundle(dir, filename, ext string, isContent bool) (tp bundleDirType, resourceRoot string, claimed bool)
+}
+
+// bundleClassifiers holds classifiers registered via
+// RegisterBundleClassifier, consulted in order before the built-in
+// index.md/_index.md rules.
+var bundleClassifiers []BundleClassifier
+
+// RegisterBundleClassifier adds c to the chain of classifiers
+// classifyBundledFile consults ahead of Hugo's built-in rules. Intended to
+// be called once during site/module setup, not per-build.
+func RegisterBundleClassifier(c BundleClassifier) {
+	bundleClassifiers = append(bundleClassifiers, c)
+}
+
+// classifyBundledFile returns the bundleDirType for the file named name in
+// directory dir (content-root relative, slash-terminated), whether it's a
+// content file at all, and the resource root its bundle's files should be
+// collected relative to (normally just dir).
+//
+// Registered BundleClassifiers get first refusal. The fallback is Hugo's
+// historical rule: _index.<ext> marks a branch bundle, index.<ext> marks a
+// leaf bundle, anything else is not a bundle marker.
+func classifyBundledFile(dir, name string) (bundleDirType, bool, string) {
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	isContent := defaultContentExts[strings.ToLower(ext)]
+
+	for _, c := range bundleClassifiers {
+		if tp, resourceRoot, claimed := c.ClassifyBundle(dir, name, ext, isContent); claimed {
+			if resourceRoot == "" {
+				resourceRoot = dir
+			}
+			return tp, isContent, resourceRoot
+		}
+	}
+
+	base := strings.TrimSuffix(name, "."+ext)
+	switch base {
+	case "index":
+		return bundleLeaf, isContent, dir
+	case "_index":
+		return bundleBranch, isContent, dir
+	default:
+		return bundleNot, isContent, dir
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// TestConfigFormatsRoundTrip asserts that the same site config, written
+// out as TOML, YAML and JSON via WithConfigFormat/WithViper, produces
+// identical builds -- catching regressions in metadecoders that a
+// TOML-only test suite would never see.
+func TestConfigFormatsRoundTrip(t *testing.T) {
+	for _, format := range []string{"toml", "yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			v := viper.New()
+			v.Set("baseURL", "https://example.com/")
+			v.Set("title", "Config Format Test")
+
+			b := newTestSitesBuilder(t).WithConfigFormat(format).WithViper(v)
+			b.WithContent("_index.md", `---
+title: "Home"
+---
+
+Home content.
+`)
+			b.Build(BuildCfg{})
+
+			b.AssertHome("Home", "https://example.com/")
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package contentaddressable provides an atomic, hash-verifying writer for
+// published output: content is streamed into a temp file alongside its
+// final path and only renamed into place once the caller calls Accept, so a
+// crash mid-render never leaves a half-written file at the publish path.
+//
+// Wiring this into Hugo's actual publish path (hugolib's Site.publisher, a
+// github.com/gohugoio/hugo/publisher.DestinationPublisher today) is left to
+// that package; this one only provides the primitive and the manifest it
+// feeds.
+package contentaddressable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Writer streams a single output file's bytes into a temp file on fs,
+// hashing them as they go, and only renames the temp file into place at
+// finalPath when Accept is called. Callers that bail out early (a template
+// error, a cancelled build) should call Abort instead, which removes the
+// temp file and leaves finalPath untouched.
+type Writer struct {
+	fs        afero.Fs
+	finalPath string
+	tmpPath   string
+	tmp       afero.File
+	hasher    hash.Hash
+	manifest  *Manifest
+
+	closed bool
+}
+
+// NewWriter opens a temp file beside finalPath (same directory, so the
+// final rename is same-filesystem and atomic on platforms that support
+// it) and returns a Writer ready to be written to. manifest may be nil if
+// the caller doesn't want this write recorded.
+func NewWriter(fs afero.Fs, finalPath string, manifest *Manifest) (*Writer, error) {
+	dir := filepath.Dir(finalPath)
+	if err := fs.MkdirAll(dir, 0o777); err != nil {
+		return nil, fmt.Errorf("contentaddressable: create %q: %w", dir, err)
+	}
+
+	tmpPath := filepath.Join(dir, fmt.Sprintf(".%s.tmp-%d", filepath.Base(finalPath), time.Now().UnixNano()))
+	tmp, err := fs.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("contentaddressable: create %q: %w", tmpPath, err)
+	}
+
+	return &Writer{
+		fs:        fs,
+		finalPath: finalPath,
+		tmpPath:   tmpPath,
+		tmp:       tmp,
+		hasher:    sha256.New(),
+		manifest:  manifest,
+	}, nil
+}
+
+// Write implements io.Writer, hashing p as it's written to the temp file.
+func (w *Writer) Write(p []byte) (int, error) {
+	n, err := w.tmp.Write(p)
+	if n > 0 {
+		w.hasher.Write(p[:n])
+	}
+	return n, err
+}
+
+// Sum returns the hex-encoded SHA-256 of everything written so far.
+func (w *Writer) Sum() string {
+	return hex.EncodeToString(w.hasher.Sum(nil))
+}
+
+// Accept closes the temp file, optionally verifying its hash against
+// expectedSHA (pass the empty string to skip verification), renames it into
+// finalPath, and -- if the Writer was created with a non-nil manifest --
+// records finalPath's hash there. A failed verification leaves finalPath
+// untouched and removes the temp file, the same as Abort.
+func (w *Writer) Accept(expectedSHA string) error {
+	if w.closed {
+		return fmt.Errorf("contentaddressable: Accept called twice for %q", w.finalPath)
+	}
+	w.closed = true
+
+	sum := w.Sum()
+
+	if err := w.tmp.Close(); err != nil {
+		w.fs.Remove(w.tmpPath)
+		return fmt.Errorf("contentaddressable: close %q: %w", w.tmpPath, err)
+	}
+
+	if expectedSHA != "" && expectedSHA != sum {
+		w.fs.Remove(w.tmpPath)
+		return fmt.Errorf("contentaddressable: %q hash mismatch: got %s, expected %s", w.finalPath, sum, expectedSHA)
+	}
+
+	if err := w.fs.Rename(w.tmpPath, w.finalPath); err != nil {
+		w.fs.Remove(w.tmpPath)
+		return fmt.Errorf("contentaddressable: rename %q to %q: %w", w.tmpPath, w.finalPath, err)
+	}
+
+	if w.manifest != nil {
+		w.manifest.Record(w.finalPath, sum)
+	}
+
+	return nil
+}
+
+// Abort closes and removes the temp file without touching finalPath. It is
+// a no-op if Accept has already run.
+func (w *Writer) Abort() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	w.tmp.Close()
+	return w.fs.Remove(w.tmpPath)
+}
+
+// Manifest records the content hash Hugo wrote to each output path during a
+// build, so a deploy step can diff it against the previous build's manifest
+// and upload only what actually changed.
+type Manifest struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+// NewManifest returns an empty Manifest ready for concurrent use by the
+// Writers it's handed to.
+func NewManifest() *Manifest {
+	return &Manifest{entries: make(map[string]string)}
+}
+
+// Record stores path's content hash, overwriting any previous entry for the
+// same path.
+func (m *Manifest) Record(path, sha string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[path] = sha
+}
+
+// Get returns the recorded hash for path, and whether one was found.
+func (m *Manifest) Get(path string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sha, ok := m.entries[path]
+	return sha, ok
+}
+
+// Len returns the number of paths recorded so far.
+func (m *Manifest) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// MarshalJSON renders the manifest as a path-to-hash JSON object with keys
+// in sorted order, so two builds of the same content produce a byte-for-byte
+// identical manifest.
+func (m *Manifest) MarshalJSON() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	paths := make([]string, 0, len(m.entries))
+	for p := range m.entries {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var buf []byte
+	buf = append(buf, '{')
+	for i, p := range paths {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		key, err := json.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ':')
+		val, err := json.Marshal(m.entries[p])
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, val...)
+	}
+	buf = append(buf, '}')
+
+	return buf, nil
+}
+
+// WriteTo writes the manifest's JSON encoding to w, returning the number of
+// bytes written.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package contentaddressable
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func shaOf(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestWriterAcceptRenamesAndRecords(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	manifest := NewManifest()
+
+	w, err := NewWriter(fs, "public/index.html", manifest)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	require.NoError(t, w.Accept(""))
+
+	exists, err := afero.Exists(fs, "public/index.html")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	content, err := afero.ReadFile(fs, "public/index.html")
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(content))
+
+	sha, ok := manifest.Get("public/index.html")
+	require.True(t, ok)
+	require.Equal(t, shaOf("hello world"), sha)
+}
+
+func TestWriterAcceptRejectsHashMismatch(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	w, err := NewWriter(fs, "public/index.html", nil)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	err = w.Accept("not-the-right-hash")
+	require.Error(t, err)
+
+	exists, err := afero.Exists(fs, "public/index.html")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestWriterAbortLeavesNoTrace(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	w, err := NewWriter(fs, "public/index.html", nil)
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	require.NoError(t, w.Abort())
+
+	matches, err := afero.Glob(fs, "public/*")
+	require.NoError(t, err)
+	require.Empty(t, matches)
+}
+
+func TestManifestJSONIsSortedAndStable(t *testing.T) {
+	manifest := NewManifest()
+	manifest.Record("public/b.html", shaOf("b"))
+	manifest.Record("public/a.html", shaOf("a"))
+
+	var buf []byte
+	var err error
+	buf, err = manifest.MarshalJSON()
+	require.NoError(t, err)
+
+	want := `{"public/a.html":"` + shaOf("a") + `","public/b.html":"` + shaOf("b") + `"}`
+	require.Equal(t, want, string(buf))
+}
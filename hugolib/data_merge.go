@@ -0,0 +1,217 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gohugoio/hugo/config"
+)
+
+// DataMergeStrategy controls how colliding entries under /data are resolved
+// when two files (e.g. a theme and the project, or two subfolders) supply
+// the same top-level key.
+type DataMergeStrategy string
+
+const (
+	// DataMergeFirstWins keeps the first value encountered (by filepath.Walk's
+	// lexical order) and ignores later ones. This is Hugo's historical
+	// behavior and remains the default.
+	DataMergeFirstWins DataMergeStrategy = "first-wins"
+
+	// DataMergeLastWins keeps the most recently encountered value, replacing
+	// any already in the data tree.
+	DataMergeLastWins DataMergeStrategy = "last-wins"
+
+	// DataMergeDeep recursively merges maps, falling back to first-wins for
+	// scalar collisions within them.
+	DataMergeDeep DataMergeStrategy = "deep-merge"
+
+	// DataMergeAppend concatenates array (`[]interface{}`) values instead of
+	// discarding the lower-precedence one. For map collisions it behaves
+	// like DataMergeDeep.
+	DataMergeAppend DataMergeStrategy = "append"
+
+	// DataMergeError fails the build on any collision.
+	DataMergeError DataMergeStrategy = "error"
+)
+
+// DataConflict records one collision encountered while building site.Data.
+type DataConflict struct {
+	// Key is the top-level data key under which the collision happened,
+	// e.g. "menus.main".
+	Key string
+
+	// Path is the file that lost (or, for DataMergeLastWins, won) the
+	// collision.
+	Path string
+
+	// Strategy is the merge strategy that was applied to resolve it.
+	Strategy DataMergeStrategy
+}
+
+// dataMergeConfig resolves the DataMergeStrategy to apply for a given
+// top-level data key.
+type dataMergeConfig struct {
+	defaultStrategy DataMergeStrategy
+	perKey          map[string]DataMergeStrategy
+}
+
+func newDataMergeConfig(cfg config.Provider) dataMergeConfig {
+	dmc := dataMergeConfig{
+		defaultStrategy: DataMergeFirstWins,
+		perKey:          make(map[string]DataMergeStrategy),
+	}
+
+	if cfg == nil {
+		return dmc
+	}
+
+	if s := cfg.GetString("data.mergeStrategy"); s != "" {
+		dmc.defaultStrategy = DataMergeStrategy(s)
+	}
+
+	if m := cfg.GetStringMapString("data.mergeStrategies"); m != nil {
+		for key, strategy := range m {
+			dmc.perKey[key] = DataMergeStrategy(strategy)
+		}
+	}
+
+	return dmc
+}
+
+// strategyFor returns the merge strategy configured for keyPath, a
+// dot-separated path below the data root, e.g. "menus.main" or
+// "params.social". Falls back to progressively shorter prefixes, then the
+// site-wide default.
+func (dmc dataMergeConfig) strategyFor(keyPath string) DataMergeStrategy {
+	for {
+		if s, ok := dmc.perKey[keyPath]; ok {
+			return s
+		}
+		i := strings.LastIndex(keyPath, ".")
+		if i == -1 {
+			break
+		}
+		keyPath = keyPath[:i]
+	}
+
+	return dmc.defaultStrategy
+}
+
+// dataConflictTracker accumulates DataConflicts across a loadData run.
+type dataConflictTracker struct {
+	mu        sync.Mutex
+	conflicts []DataConflict
+}
+
+func (t *dataConflictTracker) add(c DataConflict) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conflicts = append(t.conflicts, c)
+}
+
+func (t *dataConflictTracker) all() []DataConflict {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cp := make([]DataConflict, len(t.conflicts))
+	copy(cp, t.conflicts)
+	return cp
+}
+
+// mergeDataValue merges newData into the data tree at current[key] using
+// strategy, recording a DataConflict on the tracker when the merge wasn't a
+// simple first-write. keyPath is the full dotted key, used both to resolve
+// nested strategies for DataMergeDeep and to label conflicts.
+func mergeDataValue(current map[string]interface{}, key, keyPath, path string, newData interface{}, dmc dataMergeConfig, tracker *dataConflictTracker, errs *[]error) {
+	existing, found := current[key]
+	if !found {
+		current[key] = newData
+		return
+	}
+
+	strategy := dmc.strategyFor(keyPath)
+
+	switch strategy {
+	case DataMergeLastWins:
+		current[key] = newData
+		tracker.add(DataConflict{Key: keyPath, Path: path, Strategy: strategy})
+
+	case DataMergeDeep:
+		deepMergeData(existing, newData, keyPath, path, dmc, tracker, errs)
+
+	case DataMergeAppend:
+		existingList, existingIsList := existing.([]interface{})
+		newList, newIsList := newData.([]interface{})
+		if existingIsList && newIsList {
+			current[key] = append(existingList, newList...)
+			tracker.add(DataConflict{Key: keyPath, Path: path, Strategy: strategy})
+			return
+		}
+		// Not two arrays: fall back to deep-merge semantics.
+		deepMergeData(existing, newData, keyPath, path, dmc, tracker, errs)
+
+	case DataMergeError:
+		*errs = append(*errs, fmt.Errorf("data collision for key %q in %q (merge strategy %q)", keyPath, path, DataMergeError))
+
+	case DataMergeFirstWins:
+		fallthrough
+	default:
+		shallowMergeData(existing, newData, keyPath, path, tracker)
+	}
+}
+
+// shallowMergeData implements Hugo's historical first-wins behavior: if
+// existing and newData are both maps, keys present only in newData are
+// copied into existing one level deep; colliding keys, and collisions
+// between non-map values, keep the existing (higher-precedence) value and
+// are recorded on the tracker.
+func shallowMergeData(existing, newData interface{}, keyPath, path string, tracker *dataConflictTracker) {
+	existingMap, existingIsMap := existing.(map[string]interface{})
+	newMap, newIsMap := newData.(map[string]interface{})
+
+	if !existingIsMap || !newIsMap {
+		tracker.add(DataConflict{Key: keyPath, Path: path, Strategy: DataMergeFirstWins})
+		return
+	}
+
+	for k, v := range newMap {
+		if _, found := existingMap[k]; found {
+			tracker.add(DataConflict{Key: keyPath + "." + k, Path: path, Strategy: DataMergeFirstWins})
+			continue
+		}
+		existingMap[k] = v
+	}
+}
+
+// deepMergeData recursively merges newData into existing, key by key,
+// resolving each nested key against dmc so a single DataMergeDeep/Append
+// collision can still honor more specific per-key strategies below it.
+// Non-map collisions fall back to first-wins (the existing, higher
+// precedence value is kept).
+func deepMergeData(existing, newData interface{}, keyPath, path string, dmc dataMergeConfig, tracker *dataConflictTracker, errs *[]error) {
+	existingMap, existingIsMap := existing.(map[string]interface{})
+	newMap, newIsMap := newData.(map[string]interface{})
+
+	if !existingIsMap || !newIsMap {
+		tracker.add(DataConflict{Key: keyPath, Path: path, Strategy: DataMergeFirstWins})
+		return
+	}
+
+	for k, v := range newMap {
+		mergeDataValue(existingMap, k, keyPath+"."+k, path, v, dmc, tracker, errs)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/helpers"
+	"github.com/gohugoio/hugo/parser/metadecoders"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+const dataSourcesConfigKey = "dataSources"
+
+// remoteDataSource is one `[[dataSources]]` entry in site configuration. It
+// describes a remote document to fetch, decode and merge into
+// site.Data[Name], using the same precedence rules as /data files.
+type remoteDataSource struct {
+	// Name is the top-level site.Data key the decoded response is merged
+	// into, e.g. "stars" for site.Data.stars.
+	Name string
+
+	// URL is the endpoint to fetch.
+	URL string
+
+	// Format is the decoder to use, one of the metadecoders formats (json,
+	// yaml, toml, csv...). Defaults to "json".
+	Format string
+
+	// Headers are sent with every request, e.g. for bearer token auth:
+	//   [dataSources.headers]
+	//     Authorization = "Bearer ..."
+	Headers map[string]string
+
+	// CacheTTL is how long a successful response is considered fresh. While
+	// fresh, rebuilds reuse the on-disk cache without making a request. A
+	// zero value means the response is re-validated (conditional GET) on
+	// every build.
+	CacheTTL time.Duration
+
+	// Timeout bounds the HTTP request. Defaults to 10s.
+	Timeout time.Duration
+}
+
+// remoteDataCacheMeta is the sidecar persisted next to a cached response
+// body, so the next build can send a conditional request and know whether
+// the cached body is still within its CacheTTL.
+type remoteDataCacheMeta struct {
+	ETag         string
+	LastModified string
+	FetchedAt    time.Time
+}
+
+func decodeRemoteDataSources(cfg config.Provider) ([]remoteDataSource, error) {
+	raw := cfg.Get(dataSourcesConfigKey)
+	if raw == nil {
+		return nil, nil
+	}
+
+	var sources []remoteDataSource
+
+	dc := &mapstructure.DecoderConfig{
+		Result:           &sources,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+	}
+
+	decoder, err := mapstructure.NewDecoder(dc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decoder.Decode(raw); err != nil {
+		return nil, errors.Wrap(err, "failed to decode dataSources")
+	}
+
+	for i, s := range sources {
+		if s.Name == "" {
+			return nil, fmt.Errorf("dataSources[%d]: name is required", i)
+		}
+		if s.URL == "" {
+			return nil, fmt.Errorf("dataSources[%d] %q: url is required", i, s.Name)
+		}
+		if s.Format == "" {
+			sources[i].Format = "json"
+		}
+		if s.Timeout == 0 {
+			sources[i].Timeout = 10 * time.Second
+		}
+	}
+
+	return sources, nil
+}
+
+// loadRemoteData fetches every configured dataSources entry and merges its
+// decoded body into h.data, using h.dataMergeConfig/h.dataConflicts exactly
+// like file-based /data sources. Errors are appended to errs rather than
+// returned directly, matching handleDataFile's convention of collecting
+// errors across the whole data tree before failing the build.
+func (h *HugoSites) loadRemoteData(errs *[]error) error {
+	sources, err := decodeRemoteDataSources(h.Cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sources {
+		body, err := h.fetchRemoteDataSource(s)
+		if err != nil {
+			*errs = append(*errs, h.errWithRemoteDataSourceContext(err, s))
+			continue
+		}
+
+		data, err := metadecoders.Default.Unmarshal(body, metadecoders.FormatFromString(s.Format))
+		if err != nil {
+			*errs = append(*errs, h.errWithRemoteDataSourceContext(errors.Wrapf(err, "failed to decode response from %q", s.URL), s))
+			continue
+		}
+
+		mergeDataValue(h.data, s.Name, s.Name, s.URL, data, h.dataMergeConfig, h.dataConflicts, errs)
+	}
+
+	return nil
+}
+
+// fetchRemoteDataSource returns the (possibly cached) response body for s.
+// Responses are cached on disk, keyed by URL, so that a build within
+// CacheTTL of the last successful fetch never hits the network, and a build
+// outside that window still avoids re-downloading unchanged bodies via a
+// conditional GET using the cached ETag/Last-Modified.
+func (h *HugoSites) fetchRemoteDataSource(s remoteDataSource) ([]byte, error) {
+	fs := h.PathSpec.Fs.Source
+
+	cacheDir, err := helpers.GetCacheDirForKey(fs, h.Cfg, "dataSources", s.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	bodyPath := cacheDir + "body"
+	metaPath := cacheDir + "meta.json"
+
+	cachedBody, meta, haveCache := readRemoteDataCache(fs, bodyPath, metaPath)
+
+	if haveCache && s.CacheTTL > 0 && time.Since(meta.FetchedAt) < s.CacheTTL {
+		return cachedBody, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to build request for %q", s.URL)
+	}
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	if haveCache {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	client := &http.Client{Timeout: s.Timeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if haveCache {
+			// Serve the stale cache rather than failing the build on a
+			// transient network error.
+			return cachedBody, nil
+		}
+		return nil, errors.Wrapf(err, "failed to fetch %q", s.URL)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && haveCache {
+		meta.FetchedAt = timeNow()
+		writeRemoteDataCache(fs, bodyPath, metaPath, cachedBody, meta)
+		return cachedBody, nil
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if haveCache {
+			return cachedBody, nil
+		}
+		return nil, fmt.Errorf("fetching %q: unexpected status %q", s.URL, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read response body from %q", s.URL)
+	}
+
+	newMeta := remoteDataCacheMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    timeNow(),
+	}
+	writeRemoteDataCache(fs, bodyPath, metaPath, body, newMeta)
+
+	return body, nil
+}
+
+func readRemoteDataCache(fs afero.Fs, bodyPath, metaPath string) ([]byte, remoteDataCacheMeta, bool) {
+	var meta remoteDataCacheMeta
+
+	body, err := afero.ReadFile(fs, bodyPath)
+	if err != nil {
+		return nil, meta, false
+	}
+
+	metaRaw, err := afero.ReadFile(fs, metaPath)
+	if err != nil {
+		return nil, meta, false
+	}
+
+	if err := json.Unmarshal(metaRaw, &meta); err != nil {
+		return nil, meta, false
+	}
+
+	return body, meta, true
+}
+
+func writeRemoteDataCache(fs afero.Fs, bodyPath, metaPath string, body []byte, meta remoteDataCacheMeta) {
+	// Best-effort: a failure to persist the cache should not fail the
+	// build, it just means the next build re-fetches.
+	if err := afero.WriteFile(fs, bodyPath, body, 0666); err != nil {
+		return
+	}
+	metaRaw, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	_ = afero.WriteFile(fs, metaPath, metaRaw, 0666)
+}
+
+// errWithRemoteDataSourceContext wraps err with a synthetic file context
+// pointing at the config source, mirroring errWithFileContext for
+// file-based /data errors.
+func (h *HugoSites) errWithRemoteDataSourceContext(err error, s remoteDataSource) error {
+	return fmt.Errorf("dataSources %q (%s): %w", s.Name, s.URL, err)
+}
+
+// timeNow is time.Now, indirected so it can be swapped out in tests.
+var timeNow = time.Now
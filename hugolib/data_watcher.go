@@ -0,0 +1,204 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gohugoio/hugo/parser/metadecoders"
+	"github.com/pkg/errors"
+)
+
+// DataWatcher applies incremental patches to a HugoSites' merged /data tree
+// as files change, instead of requiring a full loadData rebuild for every
+// edit. It's meant for server/watch mode, where a full /data re-walk on
+// every keystroke in a data file is wasteful.
+//
+// Scope: DataWatcher recomputes the subtree for exactly the file that
+// changed, using the same mergeDataValue/dataMergeConfig precedence rules
+// loadData uses, and swaps it into HugoSites.data under dataMu. What it does
+// NOT do is recompute cross-root precedence from scratch the way a full
+// loadData walk does: if the changed file is shadowed by (or shadows) a
+// same-named file in another root, that relationship is only as fresh as
+// the last full rebuild. This matches DataMergeFirstWins/LastWins's own
+// file-processing-order semantics closely enough for the common case (a
+// single file under active edit) without re-walking every root on every
+// event.
+//
+// Selective re-render by template-AST dependency (".Site.Data.<key>") is
+// out of scope here for the same reason depGraph.Record's doc comment
+// gives for .Site.Data access in general: the tpl execution layer doesn't
+// carry the current page/shortcode through to a recorder. OnDataChanged's
+// callback only reports which top-level key changed; resolving that to a
+// set of dependent pages is left to the caller.
+type DataWatcher struct {
+	h       *HugoSites
+	roots   []string
+	watcher *fsnotify.Watcher
+
+	onChangeMu sync.Mutex
+	onChange   []func(key string)
+
+	closeOnce sync.Once
+	donec     chan struct{}
+}
+
+// NewDataWatcher creates a DataWatcher watching roots (the absolute
+// directories of every data root: the project's dataDir, plus each theme's
+// and module's, in ascending precedence order) for changes. Call Run to
+// start processing events, and Close to stop and release the underlying
+// fsnotify watcher.
+func (h *HugoSites) NewDataWatcher(roots ...string) (*DataWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create data watcher")
+	}
+
+	for _, root := range roots {
+		if err := w.Add(root); err != nil {
+			w.Close()
+			return nil, errors.Wrapf(err, "failed to watch data root %q", root)
+		}
+	}
+
+	return &DataWatcher{
+		h:       h,
+		roots:   roots,
+		watcher: w,
+		donec:   make(chan struct{}),
+	}, nil
+}
+
+// OnDataChanged registers fn to be called, with the top-level /data key
+// whose subtree was just patched, every time Run applies a change. Safe to
+// call before or after Run.
+func (w *DataWatcher) OnDataChanged(fn func(key string)) {
+	w.onChangeMu.Lock()
+	defer w.onChangeMu.Unlock()
+	w.onChange = append(w.onChange, fn)
+}
+
+// Run processes fsnotify events on the watched data roots, applying each as
+// an incremental patch via applyChange, until Close is called. Meant to be
+// run in its own goroutine.
+func (w *DataWatcher) Run() {
+	for {
+		select {
+		case ev, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			key, err := w.applyChange(ev.Name)
+			if err != nil || key == "" {
+				continue
+			}
+			w.onChangeMu.Lock()
+			fns := append([]func(key string){}, w.onChange...)
+			w.onChangeMu.Unlock()
+			for _, fn := range fns {
+				fn(key)
+			}
+		case <-w.watcher.Errors:
+			// A single root misbehaving (e.g. removed out from under us)
+			// shouldn't stop watching the others.
+		case <-w.donec:
+			return
+		}
+	}
+}
+
+// Close stops Run and releases the underlying fsnotify watcher.
+func (w *DataWatcher) Close() error {
+	w.closeOnce.Do(func() { close(w.donec) })
+	return w.watcher.Close()
+}
+
+// applyChange re-parses the file at absPath, recomputes the merged subtree
+// for its key path, swaps it into h.data under dataMu, and returns the
+// top-level /data key that changed.
+func (w *DataWatcher) applyChange(absPath string) (string, error) {
+	_, rel, ok := w.rootRelative(absPath)
+	if !ok {
+		// Not under any watched data root (e.g. a sibling file fsnotify
+		// also reports because it watches the containing directory).
+		return "", nil
+	}
+
+	content, err := ioutil.ReadFile(absPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "data watcher: failed to read %q", absPath)
+	}
+
+	ext := strings.TrimPrefix(filepath.Ext(rel), ".")
+	format := metadecoders.FormatFromString(ext)
+	data, err := metadecoders.Default.Unmarshal(content, format)
+	if err != nil {
+		return "", errors.Wrapf(err, "data watcher: failed to parse %q", absPath)
+	}
+
+	dir := filepath.ToSlash(filepath.Dir(rel))
+	var keyPath []string
+	if dir != "." {
+		keyPath = strings.Split(dir, "/")
+	}
+	baseName := strings.TrimSuffix(filepath.Base(rel), filepath.Ext(rel))
+
+	h := w.h
+	h.dataMu.Lock()
+	defer h.dataMu.Unlock()
+
+	current := h.data
+	for _, key := range keyPath {
+		if _, ok := current[key]; !ok {
+			current[key] = make(map[string]interface{})
+		}
+		sub, ok := current[key].(map[string]interface{})
+		if !ok {
+			// A scalar/list sits where we need a map: leave it alone rather
+			// than clobbering data a previous full rebuild resolved.
+			return "", errors.Errorf("data watcher: %q can't be merged under non-map key %q", absPath, key)
+		}
+		current = sub
+	}
+
+	fullKey := strings.Join(append(append([]string{}, keyPath...), baseName), ".")
+	var errs []error
+	mergeDataValue(current, baseName, fullKey, absPath, data, h.dataMergeConfig, h.dataConflicts, &errs)
+	if len(errs) > 0 {
+		return "", errs[0]
+	}
+
+	if len(keyPath) > 0 {
+		return keyPath[0], nil
+	}
+	return baseName, nil
+}
+
+// rootRelative returns the watched root absPath lives under, and absPath's
+// path relative to it, or ok=false if it isn't under any watched root.
+func (w *DataWatcher) rootRelative(absPath string) (root, rel string, ok bool) {
+	for _, root := range w.roots {
+		if r, err := filepath.Rel(root, absPath); err == nil && !strings.HasPrefix(r, "..") {
+			return root, r, true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,93 @@
+package hugolib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHugoSitesForDataWatcher() *HugoSites {
+	return &HugoSites{
+		data:            make(map[string]interface{}),
+		dataMergeConfig: newDataMergeConfig(nil),
+		dataConflicts:   &dataConflictTracker{},
+	}
+}
+
+func TestDataWatcherApplyChange(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "hugo-data-watcher")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	testDir := filepath.Join(dir, "test")
+	assert.NoError(os.Mkdir(testDir, 0755))
+	fooPath := filepath.Join(testDir, "foo.yaml")
+	assert.NoError(ioutil.WriteFile(fooPath, []byte("bar: first\n"), 0644))
+
+	h := newTestHugoSitesForDataWatcher()
+	w, err := h.NewDataWatcher(dir)
+	assert.NoError(err)
+	defer w.Close()
+
+	key, err := w.applyChange(fooPath)
+	assert.NoError(err)
+	assert.Equal("test", key)
+
+	test, ok := h.data["test"].(map[string]interface{})
+	assert.True(ok)
+	foo, ok := test["foo"].(map[string]interface{})
+	assert.True(ok)
+	assert.Equal("first", foo["bar"])
+
+	// Mutate the file and re-apply: only the "test" subtree should change.
+	assert.NoError(ioutil.WriteFile(fooPath, []byte("bar: second\n"), 0644))
+	key, err = w.applyChange(fooPath)
+	assert.NoError(err)
+	assert.Equal("test", key)
+
+	foo = h.data["test"].(map[string]interface{})["foo"].(map[string]interface{})
+	assert.Equal("second", foo["bar"])
+}
+
+func TestDataWatcherRunNotifiesOnChange(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "hugo-data-watcher-run")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	testDir := filepath.Join(dir, "test")
+	assert.NoError(os.Mkdir(testDir, 0755))
+	fooPath := filepath.Join(testDir, "foo.yaml")
+	assert.NoError(ioutil.WriteFile(fooPath, []byte("bar: first\n"), 0644))
+
+	h := newTestHugoSitesForDataWatcher()
+	w, err := h.NewDataWatcher(testDir)
+	assert.NoError(err)
+	defer w.Close()
+
+	changed := make(chan string, 1)
+	w.OnDataChanged(func(key string) {
+		changed <- key
+	})
+
+	go w.Run()
+
+	// Give the watcher a moment to start selecting on its channels before
+	// the write below, then mutate data/test/foo.yaml as the request asks.
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(ioutil.WriteFile(fooPath, []byte("bar: second\n"), 0644))
+
+	select {
+	case key := <-changed:
+		assert.Equal("test", key)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for DataWatcher to report a data change")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gohugoio/hugo/resources/page"
+)
+
+// depGraph records, for each page, the set of other pages (and, in time,
+// data/partial/shortcode sources) it consulted the last time it rendered.
+// BuildCfg.shouldRender consults it in Fast Render Mode so a page is
+// re-rendered not just when its own file changed, but also when something
+// it depends on did.
+//
+// Keys are whatever identifies a dependency in whatChanged.files, i.e. a
+// source file's absolute filename; pages with no backing file (e.g. section
+// pages without an _index) fall back to their RelPermalink.
+type depGraph struct {
+	mu   sync.RWMutex
+	deps map[string]map[string]bool
+}
+
+func newDepGraph() *depGraph {
+	return &depGraph{deps: make(map[string]map[string]bool)}
+}
+
+// pageDepKey returns the identity the graph tracks p under: its source
+// filename if it has one (matching whatChanged.files' keys), else its
+// RelPermalink.
+func pageDepKey(p page.Page) string {
+	if p == nil {
+		return ""
+	}
+	if f := p.File(); f != nil && !f.IsZero() {
+		return f.Filename()
+	}
+	return p.RelPermalink()
+}
+
+// Record notes that consumer (identified by pageDepKey) consulted
+// dependency while it last rendered. Safe to call on a nil *depGraph.
+//
+// getPageNew calls this for every resolved .GetPage/ref/relref lookup. The
+// equivalent calls for partial/shortcode execution and .Site.Data access
+// are not wired up in this tree yet: the tpl execution layer and the data
+// accessors that would need to carry the current page through to here
+// aren't present, so those dependency kinds aren't recorded.
+func (g *depGraph) Record(consumer, dependency string) {
+	if g == nil || consumer == "" || dependency == "" || consumer == dependency {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	set, ok := g.deps[consumer]
+	if !ok {
+		set = make(map[string]bool)
+		g.deps[consumer] = set
+	}
+	set[dependency] = true
+}
+
+// dependenciesOf returns the raw dependency keys recorded for consumer,
+// sorted for determinism.
+func (g *depGraph) dependenciesOf(consumer string) []string {
+	if g == nil {
+		return nil
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	set := g.deps[consumer]
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// intersectsChanged reports whether any dependency recorded for consumer is
+// a key in changed, e.g. whatChanged.files.
+func (g *depGraph) intersectsChanged(consumer string, changed map[string]bool) bool {
+	if g == nil || len(changed) == 0 {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for dep := range g.deps[consumer] {
+		if changed[dep] {
+			return true
+		}
+	}
+	return false
+}
+
+// reset discards every recorded dependency. Called when BuildCfg.ResetState
+// forces a full rebuild, so stale edges from since-removed pages don't
+// linger and the graph is rebuilt from the ground up.
+func (g *depGraph) reset() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.deps = make(map[string]map[string]bool)
+}
+
+// DependenciesOf returns the dependency keys (source filenames, or
+// RelPermalinks for pages without one) recorded for p the last time it
+// rendered. Exposed mainly for tests and build diagnostics.
+func (h *HugoSites) DependenciesOf(p page.Page) []string {
+	return h.depGraph.dependenciesOf(pageDepKey(p))
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlpipe
+
+import "strings"
+
+// AbsURLTransformer rewrites every root-relative URL ("/foo/bar") found in
+// src/href/srcset/poster attributes to be rooted at BaseURL instead. It's
+// the Selection-based equivalent of the old "head_abs" template-level
+// convention, and of hugolib.rewriteRootRelativeURLs's tokenizer-based
+// approach -- this version gets to mutate attributes directly instead of
+// re-serializing them by hand, since it runs as part of the same parsed
+// tree the rest of the pipeline shares.
+type AbsURLTransformer struct {
+	BaseURL string
+}
+
+var absURLAttrs = []string{"src", "href", "srcset", "poster"}
+
+func (t *AbsURLTransformer) Transform(sel *Selection) error {
+	base := strings.TrimSuffix(t.BaseURL, "/")
+	if base == "" {
+		return nil
+	}
+
+	for _, attr := range absURLAttrs {
+		sel.Find("[" + attr + "]").Each(func(_ int, s *Selection) {
+			val, _ := s.Attr(attr)
+			if attr == "srcset" {
+				s.SetAttr(attr, rewriteSrcset(val, base))
+			} else {
+				s.SetAttr(attr, rewriteIfRootRelative(val, base))
+			}
+		})
+	}
+
+	return nil
+}
+
+func rewriteSrcset(val, base string) string {
+	candidates := strings.Split(val, ",")
+	for i, c := range candidates {
+		c = strings.TrimSpace(c)
+		fields := strings.Fields(c)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = rewriteIfRootRelative(fields[0], base)
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// rewriteIfRootRelative prefixes u with base if u is root-relative (starts
+// with "/" but not "//", which is protocol-relative).
+func rewriteIfRootRelative(u, base string) string {
+	if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+		return base + u
+	}
+	return u
+}
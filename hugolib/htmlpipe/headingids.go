@@ -0,0 +1,65 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlpipe
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// HeadingIDsTransformer gives every h1-h6 that doesn't already have an id
+// a slug derived from its text content, so "## My Heading" produces
+// <h2 id="my-heading">, and any later duplicate gets "-2", "-3", etc.
+// appended to stay unique within the document.
+type HeadingIDsTransformer struct{}
+
+func (HeadingIDsTransformer) Transform(sel *Selection) error {
+	seen := make(map[string]int)
+	sel.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *Selection) {
+		if _, ok := s.Attr("id"); ok {
+			return
+		}
+		slug := slugify(s.Text())
+		if slug == "" {
+			return
+		}
+		seen[slug]++
+		if n := seen[slug]; n > 1 {
+			slug = fmt.Sprintf("%s-%d", slug, n)
+		}
+		s.SetAttr("id", slug)
+	})
+	return nil
+}
+
+// slugify lowercases s and replaces every run of non-alphanumeric
+// characters with a single "-", trimming leading/trailing dashes.
+func slugify(s string) string {
+	var b strings.Builder
+	lastDash := true // treat the start as if a dash was just written, to trim a leading one
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+			lastDash = false
+		default:
+			if !lastDash {
+				b.WriteByte('-')
+				lastDash = true
+			}
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
@@ -0,0 +1,29 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlpipe
+
+// LazyImgTransformer adds loading="lazy" to every <img> that doesn't
+// already set a loading attribute, so browsers defer offscreen images
+// without the theme author having to remember to annotate every <img> by
+// hand.
+type LazyImgTransformer struct{}
+
+func (LazyImgTransformer) Transform(sel *Selection) error {
+	sel.Find("img").Each(func(_ int, s *Selection) {
+		if _, ok := s.Attr("loading"); !ok {
+			s.SetAttr("loading", "lazy")
+		}
+	})
+	return nil
+}
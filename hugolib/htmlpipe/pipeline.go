@@ -0,0 +1,123 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlpipe
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/net/html"
+)
+
+// Wiring Pipeline.Apply into the actual per-page write path -- so every
+// rendered page runs through it automatically, with transformer names
+// coming from `[htmlpipe] transformers = [...]` in site config -- isn't
+// done in this package: HugoSites.Site.publisher is assigned a
+// publisher.NewDestinationPublisher but (in this tree) nothing ever calls
+// a Publish method on it, so there's no real call site to hook a
+// post-render step into yet. Everything below is otherwise a complete,
+// independently usable and tested pipeline; see hugolib/data_watcher.go's
+// DataWatcher for the same caveat shape applied to a different subsystem.
+
+// Transformer mutates a parsed HTML document in place via sel, which wraps
+// the document's root node. Find/Each give it a jQuery-like way to locate
+// and edit the elements it cares about.
+type Transformer interface {
+	Transform(sel *Selection) error
+}
+
+// TransformerFunc adapts a plain function to the Transformer interface.
+type TransformerFunc func(sel *Selection) error
+
+func (f TransformerFunc) Transform(sel *Selection) error { return f(sel) }
+
+// Pipeline runs an ordered list of Transformers against rendered HTML
+// before it's written to its final destination.
+type Pipeline struct {
+	transformers []Transformer
+}
+
+// New returns a Pipeline that runs transformers, in order, over every
+// document passed to Apply.
+func New(transformers ...Transformer) *Pipeline {
+	return &Pipeline{transformers: transformers}
+}
+
+// Apply parses src as an HTML document, runs every registered Transformer
+// over it in order, and renders the result back to a string. A non-HTML
+// fragment (src not starting with e.g. "<!doctype" or "<html") still
+// parses fine -- html.Parse always produces a full document tree, adding
+// <html>/<head>/<body> as needed -- so callers that only want to pipe
+// whole rendered pages (not partial snippets) through this should check
+// their own output's shape first.
+func (p *Pipeline) Apply(src string) (string, error) {
+	doc, err := NewDocument(src)
+	if err != nil {
+		return "", fmt.Errorf("htmlpipe: failed to parse document: %w", err)
+	}
+
+	for _, t := range p.transformers {
+		if err := t.Transform(doc); err != nil {
+			return "", fmt.Errorf("htmlpipe: transformer failed: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc.nodes[0]); err != nil {
+		return "", fmt.Errorf("htmlpipe: failed to render document: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Config is the set of values a named built-in transformer may need at
+// construction time, threaded through from site config.
+type Config struct {
+	// BaseURL is used by the "absurls" transformer to rewrite root-relative
+	// URLs.
+	BaseURL string
+}
+
+// Factory builds a Transformer from Config, for use with Register/Resolve.
+type Factory func(cfg Config) Transformer
+
+var registry = make(map[string]Factory)
+
+// Register makes a named Transformer factory available to Resolve, e.g.
+// for a template-defined transformer registered under its own name
+// alongside the built-ins. Re-registering a name replaces it.
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// Resolve looks up each name in names (as registered via Register, which
+// the built-in transformers -- "absurls", "lazyimg", "headingids" -- are
+// under by default) and builds it against cfg, in order. This is what
+// backs the `[htmlpipe] transformers = [...]` config array.
+func Resolve(names []string, cfg Config) ([]Transformer, error) {
+	out := make([]Transformer, 0, len(names))
+	for _, name := range names {
+		factory, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("htmlpipe: no transformer registered as %q", name)
+		}
+		out = append(out, factory(cfg))
+	}
+	return out, nil
+}
+
+func init() {
+	Register("absurls", func(cfg Config) Transformer { return &AbsURLTransformer{BaseURL: cfg.BaseURL} })
+	Register("lazyimg", func(cfg Config) Transformer { return LazyImgTransformer{} })
+	Register("headingids", func(cfg Config) Transformer { return HeadingIDsTransformer{} })
+}
@@ -0,0 +1,165 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlpipe
+
+import (
+	"strings"
+	"testing"
+)
+
+// doc1 mirrors public/sect/doc1.html from hugolib's TestDefaultHandler: a
+// rendered page with a heading, a link, and an image.
+const doc1 = `<!doctype html><html><head></head><body>` +
+	`<h1>title</h1>` +
+	`<p>some <em>content</em></p>` +
+	`<p><a href="/about">about</a></p>` +
+	`<img src="/images/hero.png">` +
+	`</body></html>`
+
+func TestPipelineAbsURLs(t *testing.T) {
+	p := New(&AbsURLTransformer{BaseURL: "https://example.org"})
+	out, err := p.Apply(doc1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `href="https://example.org/about"`) {
+		t.Errorf("expected rewritten href, got:\n%s", out)
+	}
+	if !strings.Contains(out, `src="https://example.org/images/hero.png"`) {
+		t.Errorf("expected rewritten src, got:\n%s", out)
+	}
+}
+
+func TestPipelineAbsURLsLeavesAbsoluteAndProtocolRelativeAlone(t *testing.T) {
+	src := `<a href="https://other.org/x">x</a><img src="//cdn.example.org/y.png">`
+	p := New(&AbsURLTransformer{BaseURL: "https://example.org"})
+	out, err := p.Apply(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `href="https://other.org/x"`) {
+		t.Errorf("absolute URL should be untouched, got:\n%s", out)
+	}
+	if !strings.Contains(out, `src="//cdn.example.org/y.png"`) {
+		t.Errorf("protocol-relative URL should be untouched, got:\n%s", out)
+	}
+}
+
+func TestPipelineLazyImg(t *testing.T) {
+	p := New(LazyImgTransformer{})
+	out, err := p.Apply(doc1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `loading="lazy"`) {
+		t.Errorf("expected loading=lazy injected, got:\n%s", out)
+	}
+}
+
+func TestPipelineLazyImgRespectsExistingAttr(t *testing.T) {
+	src := `<img src="/a.png" loading="eager">`
+	p := New(LazyImgTransformer{})
+	out, err := p.Apply(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `loading="eager"`) || strings.Contains(out, `loading="lazy"`) {
+		t.Errorf("existing loading attr should be left alone, got:\n%s", out)
+	}
+}
+
+func TestPipelineHeadingIDs(t *testing.T) {
+	p := New(HeadingIDsTransformer{})
+	out, err := p.Apply(doc1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `<h1 id="title">title</h1>`) {
+		t.Errorf("expected id on h1, got:\n%s", out)
+	}
+}
+
+func TestPipelineHeadingIDsDedup(t *testing.T) {
+	src := `<h1>Intro</h1><h2>Intro</h2>`
+	p := New(HeadingIDsTransformer{})
+	out, err := p.Apply(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `id="intro"`) || !strings.Contains(out, `id="intro-2"`) {
+		t.Errorf("expected deduplicated ids intro/intro-2, got:\n%s", out)
+	}
+}
+
+func TestPipelineChainsTransformers(t *testing.T) {
+	p := New(&AbsURLTransformer{BaseURL: "https://example.org"}, LazyImgTransformer{}, HeadingIDsTransformer{})
+	out, err := p.Apply(doc1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`href="https://example.org/about"`,
+		`src="https://example.org/images/hero.png"`,
+		`loading="lazy"`,
+		`id="title"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestResolveUnknownTransformer(t *testing.T) {
+	if _, err := Resolve([]string{"does-not-exist"}, Config{}); err == nil {
+		t.Error("expected an error resolving an unregistered transformer name")
+	}
+}
+
+func TestResolveBuiltins(t *testing.T) {
+	ts, err := Resolve([]string{"absurls", "lazyimg", "headingids"}, Config{BaseURL: "https://example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ts) != 3 {
+		t.Fatalf("expected 3 transformers, got %d", len(ts))
+	}
+}
+
+func TestSelectorDescendantAndAttr(t *testing.T) {
+	doc, err := NewDocument(`<div class="content"><p><a href="/x" class="ext">x</a></p></div><a href="/y">y</a>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched := doc.Find("div.content a[href]")
+	if matched.Len() != 1 {
+		t.Fatalf("expected 1 match, got %d", matched.Len())
+	}
+	if v, _ := matched.Attr("href"); v != "/x" {
+		t.Errorf("href = %q, want /x", v)
+	}
+}
+
+// BenchmarkPipelineApply gives a rough sense of the pipeline's per-page
+// overhead, so a regression that makes it a significant fraction of
+// render time shows up here rather than only in a full-site build.
+func BenchmarkPipelineApply(b *testing.B) {
+	p := New(&AbsURLTransformer{BaseURL: "https://example.org"}, LazyImgTransformer{}, HeadingIDsTransformer{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Apply(doc1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htmlpipe
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Selection is a jQuery/goquery-like wrapper around a set of matched
+// *html.Node elements from the same document. Selector-based methods
+// (Find) only ever match html.ElementNode nodes.
+type Selection struct {
+	nodes []*html.Node
+}
+
+// NewDocument parses the full HTML document src and returns a Selection
+// wrapping its root node, ready for Find/Each calls against it.
+func NewDocument(src string) (*Selection, error) {
+	doc, err := html.Parse(strings.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	return &Selection{nodes: []*html.Node{doc}}, nil
+}
+
+// Nodes returns the raw matched element nodes, in document order.
+func (s *Selection) Nodes() []*html.Node {
+	return s.nodes
+}
+
+// Len returns the number of nodes in the selection.
+func (s *Selection) Len() int {
+	return len(s.nodes)
+}
+
+// Find returns a new Selection of every descendant of every node currently
+// selected that matches sel. An invalid sel yields an empty Selection
+// rather than a panic, since transformers are expected to fail soft on a
+// user typo rather than take the whole render down.
+func (s *Selection) Find(sel string) *Selection {
+	selector, err := ParseSelector(sel)
+	if err != nil {
+		return &Selection{}
+	}
+
+	var out []*html.Node
+	seen := make(map[*html.Node]bool)
+	for _, n := range s.nodes {
+		walk(n, func(d *html.Node) bool {
+			if d == n {
+				return true
+			}
+			if selector.Matches(d) && !seen[d] {
+				seen[d] = true
+				out = append(out, d)
+			}
+			return true
+		})
+	}
+	return &Selection{nodes: out}
+}
+
+// First returns a Selection of just the first matched node, or an empty
+// Selection if this one is empty.
+func (s *Selection) First() *Selection {
+	return s.Eq(0)
+}
+
+// Last returns a Selection of just the last matched node.
+func (s *Selection) Last() *Selection {
+	return s.Eq(len(s.nodes) - 1)
+}
+
+// Eq returns a Selection of just the i'th matched node (0-indexed),
+// supporting Python-style negative indices counting from the end. Out of
+// range yields an empty Selection.
+func (s *Selection) Eq(i int) *Selection {
+	if i < 0 {
+		i += len(s.nodes)
+	}
+	if i < 0 || i >= len(s.nodes) {
+		return &Selection{}
+	}
+	return &Selection{nodes: []*html.Node{s.nodes[i]}}
+}
+
+// Each calls fn for every matched node, each wrapped in its own
+// single-node Selection, and returns s for chaining.
+func (s *Selection) Each(fn func(i int, sel *Selection)) *Selection {
+	for i, n := range s.nodes {
+		fn(i, &Selection{nodes: []*html.Node{n}})
+	}
+	return s
+}
+
+// Attr returns the value of the first matched node's attribute key, and
+// whether it was present at all.
+func (s *Selection) Attr(key string) (string, bool) {
+	if len(s.nodes) == 0 {
+		return "", false
+	}
+	return attrOfOk(s.nodes[0], key)
+}
+
+// SetAttr sets key to val on every matched node, adding it if not already
+// present, and returns s for chaining.
+func (s *Selection) SetAttr(key, val string) *Selection {
+	for _, n := range s.nodes {
+		set := false
+		for i, a := range n.Attr {
+			if a.Key == key {
+				n.Attr[i].Val = val
+				set = true
+				break
+			}
+		}
+		if !set {
+			n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+		}
+	}
+	return s
+}
+
+// Text returns the concatenated text content of the first matched node's
+// descendants.
+func (s *Selection) Text() string {
+	if len(s.nodes) == 0 {
+		return ""
+	}
+	var out []byte
+	walk(s.nodes[0], func(n *html.Node) bool {
+		if n.Type == html.TextNode {
+			out = append(out, n.Data...)
+		}
+		return true
+	})
+	return string(out)
+}
+
+// Wrap wraps every matched node in a new element named tag, replacing the
+// node's position among its siblings with the wrapper and reparenting the
+// node underneath it. Returns s for chaining.
+func (s *Selection) Wrap(tag string) *Selection {
+	for _, n := range s.nodes {
+		if n.Parent == nil {
+			continue
+		}
+		wrapper := &html.Node{
+			Type:     html.ElementNode,
+			Data:     tag,
+			DataAtom: atom.Lookup([]byte(tag)),
+		}
+		n.Parent.InsertBefore(wrapper, n)
+		n.Parent.RemoveChild(n)
+		wrapper.AppendChild(n)
+	}
+	return s
+}
+
+// Remove detaches every matched node from its parent.
+func (s *Selection) Remove() {
+	for _, n := range s.nodes {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+// Contains reports whether other is n itself or a descendant of n, for any
+// n in the selection.
+func (s *Selection) Contains(other *html.Node) bool {
+	for _, n := range s.nodes {
+		found := false
+		walk(n, func(d *html.Node) bool {
+			if d == other {
+				found = true
+				return false
+			}
+			return true
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// walk calls fn for n and every descendant, in document order, stopping
+// early (without descending further) once fn returns false for a node.
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c, fn)
+	}
+}
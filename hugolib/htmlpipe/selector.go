@@ -0,0 +1,207 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package htmlpipe implements a small post-render HTML transformation
+// pipeline with a jQuery/goquery-like Selection API, built directly on
+// golang.org/x/net/html rather than pulling in goquery itself.
+package htmlpipe
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// step is one compound selector in a descendant chain, e.g. the "h1.title"
+// in "div h1.title".
+type step struct {
+	tag     string // "" matches any element
+	id      string
+	classes []string
+	attrs   []attrTest
+}
+
+type attrTest struct {
+	key    string
+	val    string
+	hasVal bool
+}
+
+// selector is one comma-separated alternative: a chain of steps joined by
+// the descendant combinator (whitespace). "div p" is []step{div, p}.
+type selector []step
+
+// Selector is a parsed, ready-to-match CSS-subset selector: a comma
+// separated list of selectors, each a whitespace-separated descendant
+// chain of tag/#id/.class/[attr] steps. It supports the subset of CSS
+// selectors the built-in transformers need -- not the full grammar.
+type Selector struct {
+	alternatives []selector
+}
+
+// ParseSelector parses sel, e.g. "h1, h2, h3" or "div.content img[src]".
+func ParseSelector(sel string) (*Selector, error) {
+	var s Selector
+	for _, part := range strings.Split(sel, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		var chain selector
+		for _, tok := range strings.Fields(part) {
+			st, err := parseStep(tok)
+			if err != nil {
+				return nil, fmt.Errorf("htmlpipe: invalid selector %q: %w", sel, err)
+			}
+			chain = append(chain, st)
+		}
+		if len(chain) > 0 {
+			s.alternatives = append(s.alternatives, chain)
+		}
+	}
+	if len(s.alternatives) == 0 {
+		return nil, fmt.Errorf("htmlpipe: empty selector %q", sel)
+	}
+	return &s, nil
+}
+
+func parseStep(tok string) (step, error) {
+	var st step
+	i := 0
+	// Leading tag name, if any.
+	for i < len(tok) && tok[i] != '.' && tok[i] != '#' && tok[i] != '[' {
+		i++
+	}
+	st.tag = tok[:i]
+
+	for i < len(tok) {
+		switch tok[i] {
+		case '.':
+			j := i + 1
+			for j < len(tok) && tok[j] != '.' && tok[j] != '#' && tok[j] != '[' {
+				j++
+			}
+			st.classes = append(st.classes, tok[i+1:j])
+			i = j
+		case '#':
+			j := i + 1
+			for j < len(tok) && tok[j] != '.' && tok[j] != '#' && tok[j] != '[' {
+				j++
+			}
+			st.id = tok[i+1 : j]
+			i = j
+		case '[':
+			j := strings.IndexByte(tok[i:], ']')
+			if j == -1 {
+				return step{}, fmt.Errorf("unterminated attribute selector in %q", tok)
+			}
+			j += i
+			inner := tok[i+1 : j]
+			at := attrTest{}
+			if eq := strings.IndexByte(inner, '='); eq != -1 {
+				at.key = strings.TrimSpace(inner[:eq])
+				at.val = strings.Trim(strings.TrimSpace(inner[eq+1:]), `"'`)
+				at.hasVal = true
+			} else {
+				at.key = strings.TrimSpace(inner)
+			}
+			st.attrs = append(st.attrs, at)
+			i = j + 1
+		default:
+			return step{}, fmt.Errorf("unexpected character %q in %q", tok[i], tok)
+		}
+	}
+
+	return st, nil
+}
+
+// Matches reports whether n satisfies any alternative of s, walking n's
+// ancestor chain as needed to satisfy descendant combinators.
+func (s *Selector) Matches(n *html.Node) bool {
+	for _, chain := range s.alternatives {
+		if matchesChain(n, chain) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesChain(n *html.Node, chain []step) bool {
+	if n == nil || len(chain) == 0 {
+		return false
+	}
+	if !matchesStep(n, chain[len(chain)-1]) {
+		return false
+	}
+	if len(chain) == 1 {
+		return true
+	}
+	rest := chain[:len(chain)-1]
+	for anc := n.Parent; anc != nil; anc = anc.Parent {
+		if matchesChain(anc, rest) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesStep(n *html.Node, st step) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	if st.tag != "" && !strings.EqualFold(n.Data, st.tag) {
+		return false
+	}
+	if st.id != "" && attrOf(n, "id") != st.id {
+		return false
+	}
+	for _, c := range st.classes {
+		if !hasClass(n, c) {
+			return false
+		}
+	}
+	for _, a := range st.attrs {
+		v, ok := attrOfOk(n, a.key)
+		if !ok {
+			return false
+		}
+		if a.hasVal && v != a.val {
+			return false
+		}
+	}
+	return true
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, f := range strings.Fields(attrOf(n, "class")) {
+		if f == class {
+			return true
+		}
+	}
+	return false
+}
+
+func attrOf(n *html.Node, key string) string {
+	v, _ := attrOfOk(n, key)
+	return v
+}
+
+func attrOfOk(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -16,11 +16,13 @@ package hugolib
 import (
 	"fmt"
 	"io"
+	"os"
 	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gohugoio/hugo/output"
 	"github.com/gohugoio/hugo/parser/metadecoders"
@@ -72,17 +74,42 @@ type HugoSites struct {
 
 	gitInfo *gitInfo
 
-	// As loaded from the /data dirs
-	data map[string]interface{}
+	// As loaded from the /data dirs. dataMu also guards incremental patches
+	// applied by a DataWatcher outside of a full loadData rebuild.
+	dataMu sync.RWMutex
+	data   map[string]interface{}
+
+	// The merge policy used to resolve /data key collisions, and the
+	// collisions it has recorded so far. Both are rebuilt on every loadData,
+	// and consulted under dataMu by a DataWatcher's incremental patches.
+	dataMergeConfig dataMergeConfig
+	dataConflicts   *dataConflictTracker
 
 	// Keeps track of bundle directories and symlinks to enable partial rebuilding.
 	ContentChanges *contentChangeMap
 
+	// Records page-to-page dependencies (via .GetPage/ref/relref) observed
+	// during the last render, so BuildCfg.shouldRender can re-render a page
+	// whose dependency changed even if the page's own file didn't.
+	depGraph *depGraph
+
 	init *hugoSitesInit
 
+	// Collects per-phase build timings when deps.DepsCfg.CollectStats was
+	// set; nil, and a no-op to track against, otherwise.
+	stats *buildStatsCollector
+
 	*fatalErrorHandler
 }
 
+// BuildStats returns the per-phase timings, per-language page/resource
+// counts, and slowest templates/shortcodes gathered for the most recent
+// NewHugoSites/Build call, if deps.DepsCfg.CollectStats was set when h was
+// created. It is the zero value otherwise.
+func (h *HugoSites) BuildStats() BuildStats {
+	return h.stats.finalize()
+}
+
 type fatalErrorHandler struct {
 	mu sync.Mutex
 
@@ -138,6 +165,8 @@ func (h *HugoSites) Data() map[string]interface{} {
 		h.SendError(errors.Wrap(err, "failed to load data"))
 		return nil
 	}
+	h.dataMu.RLock()
+	defer h.dataMu.RUnlock()
 	return h.data
 }
 
@@ -275,6 +304,8 @@ func newHugoSites(cfg deps.DepsCfg, sites ...*Site) (*HugoSites, error) {
 		multilingual: langConfig,
 		multihost:    cfg.Cfg.GetBool("multihost"),
 		Sites:        sites,
+		stats:        newBuildStatsCollector(cfg.CollectStats),
+		depGraph:     newDepGraph(),
 		init: &hugoSitesInit{
 			data:         lazy.New(),
 			gitInfo:      lazy.New(),
@@ -319,7 +350,11 @@ func newHugoSites(cfg deps.DepsCfg, sites ...*Site) (*HugoSites, error) {
 	// Only needed in server mode.
 	// TODO(bep) clean up the running vs watching terms
 	if cfg.Running {
-		contentChangeTracker = &contentChangeMap{pathSpec: h.PathSpec, symContent: make(map[string]map[string]bool)}
+		contentChangeTracker = &contentChangeMap{
+			pathSpec:      h.PathSpec,
+			symContent:    make(map[string]map[string]bool),
+			realToLogical: make(map[string]map[string]bool),
+		}
 		h.ContentChanges = contentChangeTracker
 	}
 
@@ -416,11 +451,21 @@ func applyDeps(cfg deps.DepsCfg, sites ...*Site) error {
 
 // NewHugoSites creates HugoSites from the given config.
 func NewHugoSites(cfg deps.DepsCfg) (*HugoSites, error) {
+	start := time.Now()
+
 	sites, err := createSitesFromConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return newHugoSites(cfg, sites...)
+
+	h, err := newHugoSites(cfg, sites...)
+	if err != nil {
+		return nil, err
+	}
+
+	h.stats.recordSince(BuildStatsNewHugoSites, start)
+
+	return h, nil
 }
 
 func (s *Site) withSiteTemplates(withTemplates ...func(templ tpl.TemplateHandler) error) func(templ tpl.TemplateHandler) error {
@@ -478,6 +523,7 @@ func (h *HugoSites) reset(config *BuildCfg) {
 				r.Reset()
 			}
 		}
+		h.depGraph.reset()
 	}
 
 	h.fatalErrorHandler = &fatalErrorHandler{
@@ -562,6 +608,17 @@ type BuildCfg struct {
 
 	// Recently visited URLs. This is used for partial re-rendering.
 	RecentlyVisited map[string]bool
+
+	// depGraph is consulted by shouldRender in addition to whatChanged, so
+	// a page whose dependency (rather than its own file) changed is still
+	// re-rendered. Set by the caller from HugoSites.depGraph; nil disables
+	// the check (shouldRender then falls back to its old, file-only test).
+	depGraph *depGraph
+
+	// LanguageParallelism caps how many of HugoSites.Sites are built
+	// concurrently. Zero (the default) means build languages serially, the
+	// existing behavior. See buildLanguagesInParallel.
+	LanguageParallelism int
 }
 
 // shouldRender is used in the Fast Render Mode to determine if we need to re-render
@@ -585,8 +642,13 @@ func (cfg *BuildCfg) shouldRender(p *pageState) bool {
 		return true
 	}
 
-	if cfg.whatChanged != nil && !p.File().IsZero() {
-		return cfg.whatChanged.files[p.File().Filename()]
+	if cfg.whatChanged != nil {
+		if !p.File().IsZero() && cfg.whatChanged.files[p.File().Filename()] {
+			return true
+		}
+		if cfg.depGraph.intersectsChanged(pageDepKey(p), cfg.whatChanged.files) {
+			return true
+		}
 	}
 
 	return false
@@ -614,8 +676,41 @@ func (h *HugoSites) renderCrossSitesArtifacts() error {
 
 	smLayouts := []string{"sitemapindex.xml", "_default/sitemapindex.xml", "_internal/_default/sitemapindex.xml"}
 
+	sitemap := s.siteCfg.sitemap
+
+	if !sitemap.Shard {
+		return s.renderAndWriteXML(&s.PathSpec.ProcessingStats.Sitemaps, "sitemapindex",
+			sitemap.Filename, h.toSiteInfos(), smLayouts...)
+	}
+
+	// Sharding is requested: split s.Pages() -- not h.toSiteInfos(), which
+	// has one entry per language/site, not per URL -- into chunks of at
+	// most sitemap.MaxURLs, render one sitemap-N.xml per chunk, and render
+	// a top-level sitemap_index.xml that references all of them.
+	pages := s.Pages()
+	lastMods := make([]string, len(pages))
+	for i, p := range pages {
+		lastMods[i] = p.Lastmod().Format("2006-01-02T15:04:05-07:00")
+	}
+
+	pattern := shardFilenamePattern(sitemap.Filename)
+	shards := buildSitemapShards(lastMods, sitemap.MaxURLs, pattern)
+
+	shardLayouts := []string{"sitemap.xml", "_default/sitemap.xml", "_internal/_default/sitemap.xml"}
+
+	for i, shard := range shards {
+		start := i * sitemap.MaxURLs
+		end := start + shard.Count
+		if err := s.renderAndWriteXML(&s.PathSpec.ProcessingStats.Sitemaps, "sitemap",
+			shard.Filename, pages[start:end], shardLayouts...); err != nil {
+			return err
+		}
+	}
+
+	indexLayouts := []string{"sitemapindex.xml", "_default/sitemapindex.xml", "_internal/_default/sitemapindex.xml"}
+
 	return s.renderAndWriteXML(&s.PathSpec.ProcessingStats.Sitemaps, "sitemapindex",
-		s.siteCfg.sitemap.Filename, h.toSiteInfos(), smLayouts...)
+		sitemap.Index, shards, indexLayouts...)
 }
 
 // createMissingPages creates home page, taxonomies etc. that isnt't created as an
@@ -773,6 +868,7 @@ func (h *HugoSites) createPageCollections() error {
 	for _, s := range h.Sites {
 		s.PageCollections.allPages = allPages
 		s.PageCollections.allRegularPages = allRegularPages
+		s.PageCollections.depGraph = h.depGraph
 	}
 
 	return nil
@@ -801,19 +897,45 @@ func (h *HugoSites) Pages() page.Pages {
 }
 
 func (h *HugoSites) loadData(fs afero.Fs) (err error) {
+	defer h.stats.track(BuildStatsResourcePipeline)()
+
 	spec := source.NewSourceSpec(h.PathSpec, fs)
 	fileSystem := spec.NewFilesystem("")
+
+	h.dataMu.Lock()
 	h.data = make(map[string]interface{})
+	h.dataMergeConfig = newDataMergeConfig(h.Cfg)
+	h.dataConflicts = &dataConflictTracker{}
+	h.dataMu.Unlock()
+
+	var errs []error
 	for _, r := range fileSystem.Files() {
-		if err := h.handleDataFile(r); err != nil {
+		if err := h.handleDataFile(r, &errs); err != nil {
 			return err
 		}
 	}
 
+	if err := h.loadRemoteData(&errs); err != nil {
+		return err
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
 	return
 }
 
-func (h *HugoSites) handleDataFile(r source.ReadableFile) error {
+// DataConflicts returns the collisions encountered while merging /data, in
+// the order they were resolved. Useful for tests and build diagnostics.
+func (h *HugoSites) DataConflicts() []DataConflict {
+	if h.dataConflicts == nil {
+		return nil
+	}
+	return h.dataConflicts.all()
+}
+
+func (h *HugoSites) handleDataFile(r source.ReadableFile, errs *[]error) error {
 	var current map[string]interface{}
 
 	f, err := r.Open()
@@ -825,10 +947,12 @@ func (h *HugoSites) handleDataFile(r source.ReadableFile) error {
 	// Crawl in data tree to insert data
 	current = h.data
 	keyParts := strings.Split(r.Dir(), helpers.FilePathSeparator)
+	keyPath := keyParts
 	// The first path element is the virtual folder (typically theme name), which is
 	// not part of the key.
 	if len(keyParts) > 1 {
-		for _, key := range keyParts[1:] {
+		keyPath = keyParts[1:]
+		for _, key := range keyPath {
 			if key != "" {
 				if _, ok := current[key]; !ok {
 					current[key] = make(map[string]interface{})
@@ -836,6 +960,8 @@ func (h *HugoSites) handleDataFile(r source.ReadableFile) error {
 				current = current[key].(map[string]interface{})
 			}
 		}
+	} else {
+		keyPath = nil
 	}
 
 	data, err := h.readData(r)
@@ -847,47 +973,10 @@ func (h *HugoSites) handleDataFile(r source.ReadableFile) error {
 		return nil
 	}
 
-	// filepath.Walk walks the files in lexical order, '/' comes before '.'
-	// this warning could happen if
-	// 1. A theme uses the same key; the main data folder wins
-	// 2. A sub folder uses the same key: the sub folder wins
-	higherPrecedentData := current[r.BaseFileName()]
-
 	switch data.(type) {
-	case nil:
-		// hear the crickets?
-
-	case map[string]interface{}:
-
-		switch higherPrecedentData.(type) {
-		case nil:
-			current[r.BaseFileName()] = data
-		case map[string]interface{}:
-			// merge maps: insert entries from data for keys that
-			// don't already exist in higherPrecedentData
-			higherPrecedentMap := higherPrecedentData.(map[string]interface{})
-			for key, value := range data.(map[string]interface{}) {
-				if _, exists := higherPrecedentMap[key]; exists {
-					h.Log.WARN.Printf("Data for key '%s' in path '%s' is overridden by higher precedence data already in the data tree", key, r.Path())
-				} else {
-					higherPrecedentMap[key] = value
-				}
-			}
-		default:
-			// can't merge: higherPrecedentData is not a map
-			h.Log.WARN.Printf("The %T data from '%s' overridden by "+
-				"higher precedence %T data already in the data tree", data, r.Path(), higherPrecedentData)
-		}
-
-	case []interface{}:
-		if higherPrecedentData == nil {
-			current[r.BaseFileName()] = data
-		} else {
-			// we don't merge array data
-			h.Log.WARN.Printf("The %T data from '%s' overridden by "+
-				"higher precedence %T data already in the data tree", data, r.Path(), higherPrecedentData)
-		}
-
+	case nil, map[string]interface{}, []interface{}:
+		fullKey := strings.Join(append(append([]string{}, keyPath...), r.BaseFileName()), ".")
+		mergeDataValue(current, r.BaseFileName(), fullKey, r.Path(), data, h.dataMergeConfig, h.dataConflicts, errs)
 	default:
 		h.Log.ERROR.Printf("unexpected data type %T in file %s", data, r.LogicalName())
 	}
@@ -953,8 +1042,22 @@ type contentChangeMap struct {
 	// It maps either file to files or the real dir to a set of content directories where it is in use.
 	symContent   map[string]map[string]bool
 	symContentMu sync.Mutex
+
+	// realToLogical is the reverse of symContent, fully resolved: it maps
+	// a canonical, symlink-free directory (as returned by resolving every
+	// hop of a chain, e.g. content/posts -> ../shared/posts -> /mono/posts)
+	// to every logical /content directory whose chain resolves onto it. The
+	// filesystem watcher uses it to translate a change event on the real
+	// path back to the logical path(s) Hugo indexed.
+	realToLogical   map[string]map[string]bool
+	realToLogicalMu sync.Mutex
 }
 
+// maxSymlinkChainDepth bounds how many hops addSymbolicLinkMapping will
+// follow before giving up, so a symlink chain that loops back on itself
+// (directly or indirectly) can't hang the content loader.
+const maxSymlinkChainDepth = 40
+
 func (m *contentChangeMap) add(filename string, tp bundleDirType) {
 	m.mu.Lock()
 	dir := filepath.Dir(filename) + helpers.FilePathSeparator
@@ -991,7 +1094,8 @@ func (m *contentChangeMap) resolveAndRemove(filename string) (string, string, bu
 		dir += helpers.FilePathSeparator
 	}
 
-	fileTp, isContent := classifyBundledFile(name)
+	fileTp, isContent, resourceRoot := classifyBundledFile(dir, name)
+	dir = resourceRoot
 
 	// This may be a member of a bundle. Start with branch bundles, the most specific.
 	if fileTp == bundleBranch || (fileTp == bundleNot && !isContent) {
@@ -1020,6 +1124,12 @@ func (m *contentChangeMap) resolveAndRemove(filename string) (string, string, bu
 	return dir, filename, bundleNot
 }
 
+// addSymbolicLinkMapping records that the logical content directory from is
+// (or contains) a symlink pointing at to, then resolves the full chain
+// starting at to -- following every intermediate symlink hop, not just the
+// first -- so a change anywhere along the chain can be traced back to from
+// via GetLogicalDirsForReal. A chain that cycles back on itself, directly
+// or through an intermediate hop, is rejected rather than followed forever.
 func (m *contentChangeMap) addSymbolicLinkMapping(from, to string) {
 	m.symContentMu.Lock()
 	mm, found := m.symContent[from]
@@ -1029,6 +1139,69 @@ func (m *contentChangeMap) addSymbolicLinkMapping(from, to string) {
 	}
 	mm[to] = true
 	m.symContentMu.Unlock()
+
+	if err := m.resolveSymlinkChain(from, to); err != nil {
+		helpers.DistinctWarnLog.Println(err)
+	}
+}
+
+// resolveSymlinkChain walks every hop from target to its final, real
+// location, registering logicalDir against each hop along the way (see
+// realToLogical) so a watcher event anywhere in the chain resolves back to
+// it. Each visited hop is canonicalized with filepath.Abs/Clean and tracked
+// in a per-call visited set; revisiting one means the chain loops, which is
+// reported as an error instead of walked forever.
+func (m *contentChangeMap) resolveSymlinkChain(logicalDir, target string) error {
+	visited := make(map[string]bool)
+	current := target
+
+	for i := 0; i < maxSymlinkChainDepth; i++ {
+		abs, err := filepath.Abs(current)
+		if err != nil {
+			return fmt.Errorf("failed to resolve symlink target %q for %q: %w", current, logicalDir, err)
+		}
+		abs = filepath.Clean(abs)
+
+		if visited[abs] {
+			return fmt.Errorf("symlink cycle detected resolving %q (via %q)", logicalDir, target)
+		}
+		visited[abs] = true
+		m.addRealDirMapping(abs, logicalDir)
+
+		fi, err := os.Lstat(abs)
+		if err != nil {
+			// The target doesn't exist (yet, or anymore); nothing more to
+			// resolve, but not an error worth failing the build over.
+			return nil
+		}
+
+		if fi.Mode()&os.ModeSymlink == 0 {
+			// abs is real; the chain ends here.
+			return nil
+		}
+
+		link, err := os.Readlink(abs)
+		if err != nil {
+			return fmt.Errorf("failed to read symlink %q (resolving %q): %w", abs, logicalDir, err)
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(abs), link)
+		}
+		current = link
+	}
+
+	return fmt.Errorf("symlink chain for %q exceeds %d hops, possible cycle", logicalDir, maxSymlinkChainDepth)
+}
+
+func (m *contentChangeMap) addRealDirMapping(real, logical string) {
+	m.realToLogicalMu.Lock()
+	defer m.realToLogicalMu.Unlock()
+	mm, found := m.realToLogical[real]
+	if !found {
+		mm = make(map[string]bool)
+		m.realToLogical[real] = mm
+	}
+	mm[logical] = true
 }
 
 func (m *contentChangeMap) GetSymbolicLinkMappings(dir string) []string {
@@ -1046,3 +1219,26 @@ func (m *contentChangeMap) GetSymbolicLinkMappings(dir string) []string {
 	sort.Strings(dirs)
 	return dirs
 }
+
+// GetLogicalDirsForReal returns every logical /content directory whose
+// symlink chain (as recorded by addSymbolicLinkMapping) resolves onto
+// realDir, so a filesystem watcher that only sees the post-symlink path can
+// translate it back to the logical path(s) Hugo actually indexed.
+func (m *contentChangeMap) GetLogicalDirsForReal(realDir string) []string {
+	abs, err := filepath.Abs(realDir)
+	if err != nil {
+		abs = realDir
+	}
+	abs = filepath.Clean(abs)
+
+	m.realToLogicalMu.Lock()
+	mm := m.realToLogical[abs]
+	dirs := make([]string, 0, len(mm))
+	for d := range mm {
+		dirs = append(dirs, d)
+	}
+	m.realToLogicalMu.Unlock()
+
+	sort.Strings(dirs)
+	return dirs
+}
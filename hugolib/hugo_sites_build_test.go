@@ -774,6 +774,129 @@ END
 	}
 }
 
+// TestContentStressTestMultilingual is the 4-languages x 500-pages variant
+// chunk31-2 (per-language parallel builds) asked for, to catch per-language
+// build time scaling linearly with language count as
+// BuildCfg.LanguageParallelism lands.
+//
+// It's skipped for now: buildLanguagesInParallel (see language_parallel.go)
+// is a standalone concurrency primitive, not yet wired into
+// HugoSites.Build's per-language stages -- that wiring needs
+// page.Page/page.Pages and the markup/converter package, neither of which
+// exist in this tree, so HugoSites.Build doesn't compile regardless of
+// this test. Once that wiring lands, un-skip this and it should assert:
+//   - correctness: every language's 500 pages render with that language's
+//     own content, using the same per-page matchers TestContentStressTest
+//     checks, once per language subtree (public/en/..., public/fr/...);
+//   - wall-clock: building with BuildCfg.LanguageParallelism > 1 is not
+//     slower, and on a multi-core runner is meaningfully faster, than
+//     LanguageParallelism == 1 for the same 4x500-page input -- e.g. by
+//     comparing elapsed time for both against a ratio threshold, the way
+//     existing benchmark-shaped tests in this package assert bounds
+//     rather than exact durations.
+func TestContentStressTestMultilingual(t *testing.T) {
+	t.Skip("blocked on buildLanguagesInParallel not being wired into HugoSites.Build yet; see comment above")
+
+	b := newTestSitesBuilder(t)
+
+	numPages := 500
+	languages := []string{"en", "fr", "nn", "nb"}
+
+	contentTempl := `
+---
+title: %q
+weight: %d
+---
+
+# Header
+
+CONTENT
+
+The End.
+`
+	contentTempl = strings.Replace(contentTempl, "CONTENT", strings.Repeat(`
+
+## Another header
+
+Some text. Some more text.
+
+`, 100), -1)
+
+	var content []string
+	for _, lang := range languages {
+		for i := 1; i <= numPages; i++ {
+			section := "s1"
+			if i%10 == 0 {
+				section = "s2"
+			}
+			content = append(content, []string{
+				fmt.Sprintf("%s/page%d.%s.md", section, i, lang),
+				fmt.Sprintf(contentTempl, fmt.Sprintf("Title %d", i), i),
+			}...)
+		}
+	}
+
+	b.WithConfigFile("toml", `
+baseURL = "http://example.com/"
+defaultContentLanguage = "en"
+
+[languages]
+[languages.en]
+weight = 1
+[languages.fr]
+weight = 2
+[languages.nn]
+weight = 3
+[languages.nb]
+weight = 4
+`)
+	b.WithTemplates("layouts/_default/single.html", `Single: {{ .Content }}|RelPermalink: {{ .RelPermalink }}`)
+	b.WithTemplates("layouts/_default/list.html", `List: {{ len .Paginator.Pages }}`)
+	b.WithContent(content...)
+
+	start := time.Now()
+	b.CreateSites().Build(BuildCfg{LanguageParallelism: len(languages)})
+	parallelElapsed := time.Since(start)
+
+	for _, lang := range languages {
+		for i := 1; i <= numPages; i++ {
+			section := "s1"
+			if i%10 == 0 {
+				section = "s2"
+			}
+			checkContent(b, fmt.Sprintf("public/%s/%s/page%d/index.html", lang, section, i),
+				"<h2 id=\"another-header\">Another header</h2>", "<p>The End.</p>")
+		}
+	}
+
+	b2 := newTestSitesBuilder(t)
+	b2.WithConfigFile("toml", `
+baseURL = "http://example.com/"
+defaultContentLanguage = "en"
+
+[languages]
+[languages.en]
+weight = 1
+[languages.fr]
+weight = 2
+[languages.nn]
+weight = 3
+[languages.nb]
+weight = 4
+`)
+	b2.WithTemplates("layouts/_default/single.html", `Single: {{ .Content }}|RelPermalink: {{ .RelPermalink }}`)
+	b2.WithTemplates("layouts/_default/list.html", `List: {{ len .Paginator.Pages }}`)
+	b2.WithContent(content...)
+
+	start = time.Now()
+	b2.CreateSites().Build(BuildCfg{LanguageParallelism: 1})
+	serialElapsed := time.Since(start)
+
+	if parallelElapsed > serialElapsed {
+		t.Errorf("expected LanguageParallelism > 1 to not be slower than serial: parallel=%s serial=%s", parallelElapsed, serialElapsed)
+	}
+}
+
 func checkContent(s *sitesBuilder, filename string, matches ...string) {
 	content := readDestination(s.T, s.Fs, filename)
 	for _, match := range matches {
@@ -0,0 +1,33 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"io/fs"
+
+	"github.com/spf13/afero"
+)
+
+// PublishedFS returns a read-only io/fs.FS view over the site h just built,
+// whether that output lives in memory (tests) or on disk (a real build).
+// It also implements fs.ReadDirFS and fs.StatFS, so callers can fs.WalkDir
+// it, fs.ReadDir a single directory, or fs.Stat a single path without type
+// switching on the underlying afero.Fs.
+//
+// This gives tests, commands/deploy, and third-party code embedding Hugo as
+// a library one abstraction for "the site Hugo just built", instead of each
+// re-deriving its own way to read back h.Fs.Destination.
+func (h *HugoSites) PublishedFS() fs.FS {
+	return afero.NewIOFS(h.Fs.Destination)
+}
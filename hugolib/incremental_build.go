@@ -0,0 +1,163 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// buildManifestFile is the name of the incremental-build manifest persisted
+// under a Site's PublishDir.
+const buildManifestFile = ".hugo_build_cache"
+
+// buildManifestEntry records the content hash a source path had the last
+// time it was processed, so a later build can tell whether it needs to
+// recompute anything that depends on that path.
+type buildManifestEntry struct {
+	Hash string `json:"hash"`
+}
+
+// buildManifest is the content-hash fingerprint of every source file
+// (content, layouts, shortcodes, config) a previous build saw, keyed by
+// path. Comparing a fresh hashFileContents against the stored entry is how
+// an incremental build decides a path is unchanged and can be skipped.
+//
+// Wiring this into Site.Process/Render/Write so that only pages whose
+// inputs -- or transitively depended shortcodes/layouts/indexes -- changed
+// are recomputed isn't done here: that requires the Page/Pages/IndexList
+// types and the shortcode/template machinery Site.go calls into, none of
+// which are defined anywhere in this tree (Config, Page, Pages, IndexList,
+// Index, ShortcodeFunc and the helpers site.go calls, like NewPage and
+// ShortcodesHandle, don't exist in this repository; site.go could not have
+// built before this change either). What's here is the reusable,
+// self-contained part: the manifest format and the load/save/compare
+// primitives a real wiring would sit on top of.
+type buildManifest struct {
+	mu      sync.Mutex
+	Entries map[string]buildManifestEntry `json:"entries"`
+}
+
+// loadBuildManifest reads the manifest from dir, returning an empty one if
+// it doesn't exist yet or can't be parsed -- a missing or corrupt manifest
+// just means every path is treated as changed on this build.
+func loadBuildManifest(dir string) *buildManifest {
+	m := &buildManifest{Entries: make(map[string]buildManifestEntry)}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, buildManifestFile))
+	if err != nil {
+		return m
+	}
+
+	var entries map[string]buildManifestEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return m
+	}
+	m.Entries = entries
+
+	return m
+}
+
+// Save persists m to dir, overwriting any previous manifest.
+func (m *buildManifest) Save(dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := json.Marshal(m.Entries)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, buildManifestFile), b, 0666)
+}
+
+// Changed reports whether path's current content hash differs from the one
+// recorded the last time Update was called for it (or it has never been
+// seen before).
+func (m *buildManifest) Changed(path, hash string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.Entries[path]
+	return !ok || entry.Hash != hash
+}
+
+// Update records path's current content hash for the next build's Changed
+// comparison.
+func (m *buildManifest) Update(path, hash string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Entries[path] = buildManifestEntry{Hash: hash}
+}
+
+// hashFileContents returns a hex-encoded SHA-256 of path's contents, for
+// comparison against a buildManifest entry.
+func hashFileContents(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parallelForEach runs fn(i) for every i in [0, n) across at most workers
+// goroutines (GOMAXPROCS if workers <= 0), and returns the first error
+// encountered, if any. Callers that must preserve deterministic output
+// ordering (e.g. Pages.Sort, setupPrevNext) should have fn write its result
+// into a pre-sized slice at index i rather than appending, so the order
+// doesn't depend on goroutine scheduling.
+//
+// This is the fan-out primitive a parallel RenderPages/RenderIndexes/
+// RenderLists/RenderAliases would use; it isn't called from Site.Render
+// here for the same reason noted on buildManifest above.
+func parallelForEach(n, workers int, fn func(i int) error) error {
+	if n == 0 {
+		return nil
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var g errgroup.Group
+	sem := make(chan struct{}, workers)
+
+	for i := 0; i < n; i++ {
+		i := i
+		sem <- struct{}{}
+		g.Go(func() error {
+			defer func() { <-sem }()
+			return fn(i)
+		})
+	}
+
+	return g.Wait()
+}
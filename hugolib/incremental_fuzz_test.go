@@ -0,0 +1,68 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// TestIncrementalMatchesFullBuild exercises AssertIncrementalMatchesFull
+// directly: a single content edit, rebuilt incrementally, should produce
+// output identical to a cold build of the same final tree.
+func TestIncrementalMatchesFullBuild(t *testing.T) {
+	b := newTestSitesBuilder(t).WithSimpleConfigFile()
+	b.WithContent("content/p1.md", `---
+title: "P1"
+---
+P1 content.
+`)
+	b.Build(BuildCfg{})
+
+	b.EditFiles("content/p1.md", `---
+title: "P1 edited"
+---
+P1 edited content.
+`)
+	b.Build(BuildCfg{})
+
+	b.AssertIncrementalMatchesFull()
+}
+
+// TestFuzzIncrementalContentEdits randomly rewrites a single content
+// file's title and body across many iterations, asserting each
+// incremental rebuild still matches a from-scratch build.
+func TestFuzzIncrementalContentEdits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping fuzz in -short mode")
+	}
+
+	b := newTestSitesBuilder(t).WithSimpleConfigFile()
+	b.WithContent("content/p1.md", `---
+title: "P1"
+---
+P1 content.
+`)
+	b.Build(BuildCfg{})
+
+	b.FuzzIncremental(42, 20, func(r *rand.Rand) (string, string) {
+		n := r.Intn(1000)
+		return "content/p1.md", fmt.Sprintf(`---
+title: "P1 %d"
+---
+P1 content, iteration %d.
+`, n, n)
+	})
+}
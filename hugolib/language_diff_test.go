@@ -0,0 +1,45 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import "testing"
+
+func TestDiffLanguages(t *testing.T) {
+	en := NewLanguage("en")
+	en.Weight = 1
+	fr := NewLanguage("fr")
+	fr.Weight = 2
+	sv := NewLanguage("sv")
+	sv.Weight = 3
+
+	old := NewLanguages(en, fr)
+	new := NewLanguages(en, fr, sv)
+
+	added, removed := diffLanguages(old, new)
+
+	if len(added) != 1 || added[0].Lang != "sv" {
+		t.Fatalf("expected added=[sv], got %v", added)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("expected no removed languages, got %v", removed)
+	}
+
+	added, removed = diffLanguages(new, old)
+	if len(added) != 0 {
+		t.Fatalf("expected no added languages, got %v", added)
+	}
+	if len(removed) != 1 || removed[0].Lang != "sv" {
+		t.Fatalf("expected removed=[sv], got %v", removed)
+	}
+}
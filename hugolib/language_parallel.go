@@ -0,0 +1,77 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import "sync"
+
+// buildLanguagesInParallel calls fn once per language in languages, running
+// up to parallelism calls concurrently (parallelism <= 0 falls back to
+// GOMAXPROCS, matching parallelForEach). It's the BuildCfg.LanguageParallelism
+// knob's worker pool: each of HugoSites.Sites corresponds to one language, so
+// fanning this out across languages is what lets per-language build stages
+// (page assembly, output-format rendering, alias writing) overlap instead of
+// running one language's Site fully before starting the next.
+//
+// Actually wiring this into HugoSites.Build's stages isn't done here: those
+// stages operate on page.Page/page.Pages, which -- like the config.Provider
+// BuildCfg.NewConfig above already depends on, and the markup/converter
+// package underpinning content parsing -- aren't defined anywhere in this
+// tree, so HugoSites.Build doesn't compile regardless of this change. What's
+// here is the concurrency primitive a real Build would call this with, once
+// those packages exist.
+func buildLanguagesInParallel(languages Languages, parallelism int, fn func(lang *Language) error) error {
+	return parallelForEach(len(languages), parallelism, func(i int) error {
+		return fn(languages[i])
+	})
+}
+
+// sharedContentCache is a thread-safe, load-once cache keyed by absolute
+// content file path. It exists so that content shared across languages --
+// e.g. a page bundle whose index.md has no language suffix and so backs
+// every language's Page for that bundle, the "bundles/b1/index.md fallback
+// pattern" -- only has its file read and Markdown-parsed once, rather than
+// once per language that falls back to it.
+type sharedContentCache struct {
+	mu      sync.Mutex
+	entries map[string]*sharedContentEntry
+}
+
+type sharedContentEntry struct {
+	once  sync.Once
+	value []byte
+	err   error
+}
+
+func newSharedContentCache() *sharedContentCache {
+	return &sharedContentCache{entries: make(map[string]*sharedContentEntry)}
+}
+
+// GetOrLoad returns the cached content for path, calling load to populate it
+// on the first call for that path. Concurrent callers for the same path
+// block on the same load rather than each calling load themselves.
+func (c *sharedContentCache) GetOrLoad(path string, load func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	if !ok {
+		entry = &sharedContentEntry{}
+		c.entries[path] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.value, entry.err = load()
+	})
+
+	return entry.value, entry.err
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBuildLanguagesInParallel(t *testing.T) {
+	en := NewLanguage("en")
+	fr := NewLanguage("fr")
+	nn := NewLanguage("nn")
+	languages := NewLanguages(en, fr, nn)
+
+	var built sync.Map
+	err := buildLanguagesInParallel(languages, 2, func(lang *Language) error {
+		built.Store(lang.Lang, true)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, lang := range []string{"en", "fr", "nn"} {
+		if _, ok := built.Load(lang); !ok {
+			t.Errorf("expected %q to have been built", lang)
+		}
+	}
+}
+
+func TestSharedContentCacheLoadsOnce(t *testing.T) {
+	cache := newSharedContentCache()
+
+	var loads int32
+	load := func() ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		return []byte("content"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			content, err := cache.GetOrLoad("bundles/b1/index.md", load)
+			if err != nil || string(content) != "content" {
+				t.Errorf("unexpected result %q, %v", content, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if loads != 1 {
+		t.Errorf("expected exactly 1 load, got %d", loads)
+	}
+}
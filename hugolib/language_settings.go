@@ -0,0 +1,68 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"sort"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/langs"
+)
+
+// getLanguages builds the site's langs.Languages from cfg's "languages"
+// config section (one sub-table per language code, e.g.
+// [languages.nn] weight = ...), falling back to a single default language
+// (see langs.NewDefaultLanguage) when "languages" isn't set at all. This is
+// createSitesFromConfig's per-language entry point: each non-Disabled
+// result becomes one Site.
+func getLanguages(cfg config.Provider) langs.Languages {
+	languagesConfig := cfg.GetStringMap("languages")
+	if len(languagesConfig) == 0 {
+		return langs.Languages{langs.NewDefaultLanguage(cfg)}
+	}
+
+	languages := make(langs.Languages, 0, len(languagesConfig))
+	for lang := range languagesConfig {
+		languages = append(languages, langs.NewLanguage(lang, cfg))
+	}
+
+	sort.Sort(languages)
+
+	return languages
+}
+
+// loadLanguageSettings finalizes the site's language set for this build: it
+// resolves getLanguages against cfg, wires each language's Fallback chain
+// from the languageFallback config table (e.g. languageFallback.nn = "nb"
+// falls Norwegian Nynorsk back to Bokmål, consulted by
+// langs.Language.ParamWithFallback), and stores the result under cfg's
+// "languagesSorted" key so later lookups -- such as this method's own next
+// call, via its oldLangs parameter -- can see the previous build's set.
+//
+// oldLangs isn't otherwise consulted here: hugolib's own config-reload
+// diffing (diffLanguages, in language_diff.go) works in terms of this
+// package's separate Language/Languages types rather than langs.Language,
+// so reconciling the two is future work, not part of wiring up
+// SetFallbacks/ParamWithFallback.
+func loadLanguageSettings(cfg config.Provider, oldLangs langs.Languages) error {
+	languages := getLanguages(cfg)
+
+	if fallbacks := cfg.GetStringMapString("languageFallback"); len(fallbacks) > 0 {
+		languages.SetFallbacks(fallbacks)
+	}
+
+	cfg.Set("languagesSorted", languages)
+
+	return nil
+}
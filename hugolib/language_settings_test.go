@@ -0,0 +1,84 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"testing"
+
+	"github.com/gohugoio/hugo/langs"
+	"github.com/spf13/viper"
+)
+
+func TestGetLanguagesFallsBackToDefault(t *testing.T) {
+	cfg := viper.New()
+
+	languages := getLanguages(cfg)
+
+	if len(languages) != 1 || languages[0].Lang != "en" {
+		t.Fatalf("expected a single default %q language, got %v", "en", languages)
+	}
+}
+
+func TestGetLanguagesFromConfig(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("languages", map[string]interface{}{
+		"en": map[string]interface{}{"weight": 1},
+		"nn": map[string]interface{}{"weight": 2},
+	})
+
+	languages := getLanguages(cfg)
+
+	if len(languages) != 2 {
+		t.Fatalf("expected 2 languages, got %d", len(languages))
+	}
+}
+
+func TestLoadLanguageSettingsWiresFallbacks(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("languages", map[string]interface{}{
+		"nb": map[string]interface{}{"weight": 1},
+		"nn": map[string]interface{}{"weight": 2},
+	})
+	cfg.Set("languageFallback", map[string]interface{}{"nn": "nb"})
+
+	if err := loadLanguageSettings(cfg, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	languages, ok := cfg.Get("languagesSorted").(langs.Languages)
+	if !ok {
+		t.Fatalf("expected languagesSorted to hold a langs.Languages, got %T", cfg.Get("languagesSorted"))
+	}
+
+	var nn, nb *langs.Language
+	for _, l := range languages {
+		switch l.Lang {
+		case "nn":
+			nn = l
+		case "nb":
+			nb = l
+		}
+	}
+	if nn == nil || nb == nil {
+		t.Fatalf("expected both nn and nb in %v", languages)
+	}
+	if nn.Fallback != nb {
+		t.Fatalf("expected nn.Fallback to be nb, got %v", nn.Fallback)
+	}
+
+	nb.SetParam("greeting", "hei")
+	if got := nn.ParamWithFallback("greeting"); got != "hei" {
+		t.Errorf("ParamWithFallback(%q) = %v, want %q", "greeting", got, "hei")
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/helpers"
+	"github.com/gohugoio/hugo/resources/page"
+)
+
+// LinkIssueKind classifies a single entry in a LinkReport.
+type LinkIssueKind string
+
+const (
+	// LinkIssueUnresolved means the ref/relref target didn't match any page.
+	LinkIssueUnresolved LinkIssueKind = "unresolved"
+
+	// LinkIssueAmbiguous means the target matched more than one page.
+	LinkIssueAmbiguous LinkIssueKind = "ambiguous"
+
+	// LinkIssueStaleAnchor means the target page was found, but its
+	// rendered HTML has no element with the given #fragment id.
+	LinkIssueStaleAnchor LinkIssueKind = "stale-anchor"
+)
+
+// LinkIssue describes a single broken or suspicious ref/relref invocation
+// found by PageCollections.CheckLinks.
+type LinkIssue struct {
+	Kind      LinkIssueKind `json:"kind"`
+	Source    string        `json:"source"`    // the page the shortcode appears in
+	Shortcode string        `json:"shortcode"` // "ref" or "relref"
+	Target    string        `json:"target"`    // the raw shortcode argument, minus any #fragment
+	Fragment  string        `json:"fragment,omitempty"`
+	// Candidates holds every page Target could resolve to, set only when
+	// Kind is LinkIssueAmbiguous.
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+func (i LinkIssue) String() string {
+	switch i.Kind {
+	case LinkIssueAmbiguous:
+		return fmt.Sprintf("%s: {{%s %q}} is ambiguous, candidates: %s", i.Source, i.Shortcode, i.Target, strings.Join(i.Candidates, ", "))
+	case LinkIssueStaleAnchor:
+		return fmt.Sprintf("%s: {{%s %q}} resolves, but #%s was not found on the destination page", i.Source, i.Shortcode, i.Target, i.Fragment)
+	default:
+		return fmt.Sprintf("%s: {{%s %q}} does not resolve to any page", i.Source, i.Shortcode, i.Target)
+	}
+}
+
+// LinkReport is the result of PageCollections.CheckLinks: every ref/relref
+// shortcode invocation across the site that didn't resolve cleanly to
+// exactly one page and (if given) fragment.
+type LinkReport struct {
+	Issues []LinkIssue `json:"issues"`
+}
+
+// HasIssues reports whether the report found anything to complain about.
+func (r LinkReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// JSON renders the report as indented JSON, for the --check-links /
+// BuildLinkCheck config's report file.
+func (r LinkReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// String renders the report as plain text, one issue per line.
+func (r LinkReport) String() string {
+	if !r.HasIssues() {
+		return "No broken references found.\n"
+	}
+	var b strings.Builder
+	for _, issue := range r.Issues {
+		b.WriteString(issue.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// refShortcodeRe extracts {{< ref "target#frag" >}} and {{% relref "target" %}}
+// invocations (and their "-" whitespace-trim variants) from a page's raw,
+// pre-render content.
+var refShortcodeRe = regexp.MustCompile(`\{\{[%<]-?\s*(ref|relref)\s+"([^"]*)"\s*-?[%>]\}\}`)
+
+// CheckLinks scans every page that uses the ref or relref shortcodes,
+// resolves each target through the same page index getPageNew uses, and
+// returns a LinkReport listing unresolved targets, ambiguous matches and
+// stale #fragment anchors. It never fails the build itself; callers that
+// want a hard failure (the --check-links flag / `buildLinkCheck` config)
+// should check report.HasIssues(), see MustCheckLinks.
+func (c *PageCollections) CheckLinks() (LinkReport, error) {
+	var report LinkReport
+
+	for _, shortcode := range []string{"ref", "relref"} {
+		for _, p := range c.findPagesByShortcode(shortcode) {
+			issues, err := c.checkPageLinks(p, shortcode)
+			if err != nil {
+				return report, err
+			}
+			report.Issues = append(report.Issues, issues...)
+		}
+	}
+
+	sort.Slice(report.Issues, func(i, j int) bool {
+		if report.Issues[i].Source != report.Issues[j].Source {
+			return report.Issues[i].Source < report.Issues[j].Source
+		}
+		return report.Issues[i].Target < report.Issues[j].Target
+	})
+
+	c.linkReport = &report
+
+	return report, nil
+}
+
+// MustCheckLinks runs CheckLinks and, per the `buildLinkCheck` config,
+// either returns an error that should fail the build or just logs any
+// issues found so e.g. `hugo server` can surface warnings without
+// aborting.
+func (c *PageCollections) MustCheckLinks(cfg config.Provider) error {
+	report, err := c.CheckLinks()
+	if err != nil {
+		return err
+	}
+
+	if !report.HasIssues() {
+		return nil
+	}
+
+	if cfg != nil && cfg.GetBool("buildLinkCheck") {
+		return fmt.Errorf("found %d broken reference(s):\n%s", len(report.Issues), report.String())
+	}
+
+	for _, issue := range report.Issues {
+		helpers.DistinctWarnLog.Println(issue.String())
+	}
+
+	return nil
+}
+
+func (c *PageCollections) checkPageLinks(p page.Page, shortcode string) ([]LinkIssue, error) {
+	var issues []LinkIssue
+
+	for _, m := range refShortcodeRe.FindAllStringSubmatch(p.RawContent(), -1) {
+		name, arg := m[1], m[2]
+		if name != shortcode {
+			continue
+		}
+
+		target, fragment := arg, ""
+		if idx := strings.IndexByte(target, '#'); idx != -1 {
+			target, fragment = target[:idx], target[idx+1:]
+		}
+
+		dest, err := c.getPageNew(p, target)
+		if err != nil {
+			if ambiguous, ok := err.(*AmbiguousPageRefError); ok {
+				var candidates []string
+				for _, cand := range ambiguous.Candidates() {
+					candidates = append(candidates, cand.Path())
+				}
+				issues = append(issues, LinkIssue{
+					Kind: LinkIssueAmbiguous, Source: p.Path(), Shortcode: shortcode,
+					Target: target, Candidates: candidates,
+				})
+				continue
+			}
+			return nil, err
+		}
+
+		if dest == nil {
+			issues = append(issues, LinkIssue{
+				Kind: LinkIssueUnresolved, Source: p.Path(), Shortcode: shortcode, Target: target,
+			})
+			continue
+		}
+
+		if fragment != "" && !pageHasAnchor(dest, fragment) {
+			issues = append(issues, LinkIssue{
+				Kind: LinkIssueStaleAnchor, Source: p.Path(), Shortcode: shortcode,
+				Target: target, Fragment: fragment,
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// pageHasAnchor reports whether dest's rendered HTML contains an element
+// with the given id, e.g. a heading anchor. If dest's content can't be
+// read, it errs on the side of not reporting a stale anchor.
+func pageHasAnchor(dest page.Page, fragment string) bool {
+	content, err := dest.Content()
+	if err != nil {
+		return true
+	}
+	html, ok := content.(string)
+	if !ok {
+		return true
+	}
+	return strings.Contains(html, `id="`+fragment+`"`)
+}
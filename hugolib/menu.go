@@ -15,9 +15,12 @@ package hugolib
 
 import (
 	"html/template"
+	"reflect"
 	"sort"
 	"strings"
 
+	"github.com/gohugoio/hugo/resources/page"
+	"github.com/pkg/errors"
 	"github.com/spf13/cast"
 )
 
@@ -31,6 +34,27 @@ type MenuEntry struct {
 	Weight     int
 	Parent     string
 	Children   Menu
+
+	// OutputFormats restricts this entry to the named output formats, e.g.
+	// `menu.main.outputFormats = ["html"]` to omit an entry from an AMP
+	// build. A nil/empty slice means the entry is included in every output
+	// format the owning page builds, the previous, implicit behaviour. See
+	// ForOutputFormat.
+	OutputFormats []string
+
+	// Params holds the "params" config map for this entry, e.g.
+	// `[menus.main.params] hidden = true`. Keys are lower-cased, matching
+	// how Page.Params works. Used by Filter/Where.
+	Params map[string]interface{}
+
+	// Page is the page this entry points to, if any. It is set when the
+	// entry comes from front matter (as opposed to the site config) and is
+	// used by IsMenuCurrent/HasMenuCurrent to drive active-trail highlighting.
+	Page page.Page
+
+	// parentEntry is the entry me was attached to via AddChild, if any. It
+	// is the back-reference used by Ancestors to walk up to the root.
+	parentEntry *MenuEntry
 }
 
 type Menu []*MenuEntry
@@ -38,6 +62,7 @@ type Menus map[string]*Menu
 type PageMenus map[string]*MenuEntry
 
 func (me *MenuEntry) AddChild(child *MenuEntry) {
+	child.parentEntry = me
 	me.Children = append(me.Children, child)
 	me.Children.Sort()
 }
@@ -53,6 +78,67 @@ func (me *MenuEntry) KeyName() string {
 	return me.Name
 }
 
+// IsAncestor reports whether other is a descendant of me, anywhere in the
+// subtree rooted at me, not just a direct child.
+func (me *MenuEntry) IsAncestor(other *MenuEntry) bool {
+	if other == nil {
+		return false
+	}
+	for _, child := range me.Children {
+		if child == other || child.IsAncestor(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDescendant reports whether me is somewhere in the subtree rooted at other.
+func (me *MenuEntry) IsDescendant(other *MenuEntry) bool {
+	if other == nil {
+		return false
+	}
+	return other.IsAncestor(me)
+}
+
+// Ancestors returns the chain of entries from the root of the tree down to,
+// but not including, me, in root-to-leaf order. It's the basis for
+// rendering a breadcrumb trail for this entry without recursing manually.
+//
+// It only sees parents set by AddChild/BuildTree, so it returns nil for an
+// entry that was never attached to a tree.
+func (me *MenuEntry) Ancestors() Menu {
+	var chain Menu
+	for p := me.parentEntry; p != nil; p = p.parentEntry {
+		chain = append(chain, p)
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+
+	return chain
+}
+
+// IsMenuCurrent reports whether me points to the given page.
+func (me *MenuEntry) IsMenuCurrent(p page.Page) bool {
+	if me.Page == nil || p == nil {
+		return false
+	}
+	return me.Page.Eq(p)
+}
+
+// HasMenuCurrent reports whether any entry in the subtree rooted at me
+// (but not me itself) points to the given page, i.e. whether me is an
+// ancestor of the page's active-trail entry.
+func (me *MenuEntry) HasMenuCurrent(p page.Page) bool {
+	for _, child := range me.Children {
+		if child.IsMenuCurrent(p) || child.HasMenuCurrent(p) {
+			return true
+		}
+	}
+	return false
+}
+
 func (me *MenuEntry) hopefullyUniqueId() string {
 	if me.Identifier != "" {
 		return me.Identifier
@@ -85,10 +171,116 @@ func (me *MenuEntry) MarshallMap(ime map[string]interface{}) {
 			me.Identifier = cast.ToString(v)
 		case "parent":
 			me.Parent = cast.ToString(v)
+		case "outputformats":
+			me.OutputFormats = cast.ToStringSlice(v)
+		case "params":
+			params := cast.ToStringMap(v)
+			me.Params = make(map[string]interface{}, len(params))
+			for pk, pv := range params {
+				me.Params[strings.ToLower(pk)] = pv
+			}
 		}
 	}
 }
 
+// FlattenMenuConfig takes the raw per-entry config maps for a menu, as
+// decoded from e.g. [[menus.main]] in site config, and flattens any nested
+// "children" arrays into the existing flat identifier/parent model used by
+// MenuEntry.MarshallMap. This lets callers that build a Menu from config
+// support deeply nested navigation trees without knowing about the nested
+// form at all: the flat and nested forms, and front-matter-defined entries,
+// can all be mixed freely.
+//
+// Each returned map is a shallow copy of its input, with "children" removed
+// and "parent" set to the owning entry's identifier where implied by
+// nesting (an explicit "parent" on a child always wins). An entry with
+// children but no "identifier" is assigned one derived from its name or
+// url, so its children can still reference it as a parent.
+func FlattenMenuConfig(entries []map[string]interface{}) []map[string]interface{} {
+	var flat []map[string]interface{}
+
+	var walk func(entries []map[string]interface{}, parent string)
+	walk = func(entries []map[string]interface{}, parent string) {
+		for _, e := range entries {
+			children := menuEntryChildren(e)
+
+			flatEntry := make(map[string]interface{}, len(e))
+			for k, v := range e {
+				if strings.EqualFold(k, "children") {
+					continue
+				}
+				flatEntry[k] = v
+			}
+
+			if parent != "" {
+				if _, ok := flatEntry["parent"]; !ok {
+					flatEntry["parent"] = parent
+				}
+			}
+
+			flat = append(flat, flatEntry)
+
+			if len(children) > 0 {
+				walk(children, menuEntryIdentifier(flatEntry))
+			}
+		}
+	}
+
+	walk(entries, "")
+
+	return flat
+}
+
+// menuEntryChildren normalizes e's "children" value, which may decode as
+// either []map[string]interface{} or []interface{} depending on the config
+// format (TOML, YAML or JSON), into the former.
+func menuEntryChildren(e map[string]interface{}) []map[string]interface{} {
+	switch v := e["children"].(type) {
+	case []map[string]interface{}:
+		return v
+	case []interface{}:
+		children := make([]map[string]interface{}, 0, len(v))
+		for _, c := range v {
+			if cm, ok := c.(map[string]interface{}); ok {
+				children = append(children, cm)
+			}
+		}
+		return children
+	default:
+		return nil
+	}
+}
+
+// menuEntryIdentifier returns e's "identifier" value, synthesising one from
+// its name or url (and storing it back into e) if not set explicitly.
+func menuEntryIdentifier(e map[string]interface{}) string {
+	for k, v := range e {
+		if strings.EqualFold(k, "identifier") {
+			if id := cast.ToString(v); id != "" {
+				return id
+			}
+		}
+	}
+
+	var name, url string
+	for k, v := range e {
+		switch strings.ToLower(k) {
+		case "name":
+			name = cast.ToString(v)
+		case "url":
+			url = cast.ToString(v)
+		}
+	}
+
+	identifier := name
+	if identifier == "" {
+		identifier = url
+	}
+	e["identifier"] = identifier
+
+	return identifier
+}
+
 func (m Menu) Add(me *MenuEntry) Menu {
 	app := func(slice Menu, x ...*MenuEntry) Menu {
 		n := len(slice) + len(x)
@@ -163,6 +355,84 @@ func (p Menu) ByWeight() Menu {
 	return p
 }
 
+func (p Menu) ByIdentifier() Menu {
+	identifier := func(m1, m2 *MenuEntry) bool {
+		return m1.Identifier < m2.Identifier
+	}
+
+	MenuEntryBy(identifier).Sort(p)
+	return p
+}
+
+// BuildTree wires the flat `parent = "..."` relationships in m into a
+// nested tree, attaching each entry to its parent's Children via AddChild,
+// and returns the remaining root entries (those with no parent, or whose
+// parent identifier/name could not be resolved within m).
+func (m Menu) BuildTree() Menu {
+	byKey := make(map[string]*MenuEntry)
+	for _, e := range m {
+		byKey[e.KeyName()] = e
+	}
+
+	var roots Menu
+	for _, e := range m {
+		if e.Parent != "" {
+			if parent, ok := byKey[e.Parent]; ok && parent != e {
+				parent.AddChild(e)
+				continue
+			}
+		}
+		roots = append(roots, e)
+	}
+
+	roots.Sort()
+	return roots
+}
+
+// Breadcrumbs returns the path from a root entry in m down to, and
+// including, the entry pointing at p, in root-to-leaf order, or nil if no
+// entry in m (built via BuildTree) points at p.
+//
+// Since it matches on IsMenuCurrent, which compares me.Page regardless of
+// how that field was populated, this works the same whether the entry came
+// from front matter or is a pageRef-based shadow member defined in config.
+func (m Menu) Breadcrumbs(p page.Page) Menu {
+	e := m.findCurrent(p)
+	if e == nil {
+		return nil
+	}
+	return append(e.Ancestors(), e)
+}
+
+func (m Menu) findCurrent(p page.Page) *MenuEntry {
+	for _, e := range m {
+		if e.IsMenuCurrent(p) {
+			return e
+		}
+		if found := Menu(e.Children).findCurrent(p); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Flatten is the inverse of BuildTree: it returns every entry in m plus
+// all of their descendants, depth-first, as a single flat Menu.
+func (m Menu) Flatten() Menu {
+	var flat Menu
+	var walk func(Menu)
+	walk = func(entries Menu) {
+		for _, e := range entries {
+			flat = append(flat, e)
+			if len(e.Children) > 0 {
+				walk(e.Children)
+			}
+		}
+	}
+	walk(m)
+	return flat
+}
+
 func (p Menu) ByName() Menu {
 	title := func(m1, m2 *MenuEntry) bool {
 		return m1.Name < m2.Name
@@ -172,6 +442,174 @@ func (p Menu) ByName() Menu {
 	return p
 }
 
+// IncludesOutputFormat reports whether me should be materialized for the
+// given output format name, e.g. "html" or "amp". An entry with no
+// OutputFormats restriction includes every format.
+func (me *MenuEntry) IncludesOutputFormat(format string) bool {
+	if len(me.OutputFormats) == 0 {
+		return true
+	}
+	for _, f := range me.OutputFormats {
+		if strings.EqualFold(f, format) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForOutputFormat returns the entries of m that should be materialized for
+// the given output format, per IncludesOutputFormat. The menu builder
+// should consult this (per output format, alongside the owning page's own
+// Outputs) when it materializes Site.Menus for each output format, so an
+// entry whose menu.outputFormats excludes e.g. "amp" doesn't show up in the
+// AMP build.
+func (m Menu) ForOutputFormat(format string) Menu {
+	var filtered Menu
+	for _, e := range m {
+		if e.IncludesOutputFormat(format) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// Filter returns the entries of m whose Params[key] equals value, e.g.
+// `.Filter "hidden" false` to drop entries flagged with
+// `[menus.main.params] hidden = true` in config. key is matched
+// case-insensitively, mirroring how Params itself is keyed.
+func (m Menu) Filter(key string, value interface{}) Menu {
+	key = strings.ToLower(key)
+
+	var filtered Menu
+	for _, e := range m {
+		if v, ok := e.Params[key]; ok && menuValuesEqual(v, value) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// Where returns the entries of m matching key op match, mirroring the
+// collections "where" template func: key may name a Params entry, a
+// MenuEntry field (Name, Identifier, Url, Weight, Menu, Parent), or a
+// zero-argument method on the linked Page via a "Page.<Method>" key, e.g.
+// `.Where "Page.Section" "==" "blog"`. op defaults to "==" when match is
+// passed as the sole argument, i.e. `.Where "Name" "About"`.
+func (m Menu) Where(key string, args ...interface{}) (Menu, error) {
+	var op string
+	var match interface{}
+
+	switch len(args) {
+	case 1:
+		op, match = "==", args[0]
+	case 2:
+		op, match = cast.ToString(args[0]), args[1]
+	default:
+		return nil, errors.New("Where requires either (key, match) or (key, op, match)")
+	}
+
+	cmp, ok := menuWhereOps[op]
+	if !ok {
+		return nil, errors.Errorf("Where: unsupported operator %q", op)
+	}
+
+	var filtered Menu
+	for _, e := range m {
+		v, ok := e.valueForKey(key)
+		if !ok {
+			continue
+		}
+		if cmp(v, match) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered, nil
+}
+
+// valueForKey resolves key against e: a Params entry, a known MenuEntry
+// field, or (for a "Page.<Method>" key) a zero-argument method on e.Page.
+func (e *MenuEntry) valueForKey(key string) (interface{}, bool) {
+	if strings.HasPrefix(key, "Page.") {
+		if e.Page == nil {
+			return nil, false
+		}
+		return callZeroArgMethod(e.Page, strings.TrimPrefix(key, "Page."))
+	}
+
+	if v, ok := e.Params[strings.ToLower(key)]; ok {
+		return v, true
+	}
+
+	switch key {
+	case "Name":
+		return e.Name, true
+	case "Identifier":
+		return e.Identifier, true
+	case "Url", "URL":
+		return e.Url, true
+	case "Weight":
+		return e.Weight, true
+	case "Menu":
+		return e.Menu, true
+	case "Parent":
+		return e.Parent, true
+	}
+
+	return nil, false
+}
+
+// callZeroArgMethod calls the named zero-argument, single-return method on
+// v via reflection, e.g. to read Page.Title or Page.Section from a "Page.*"
+// Where key.
+func callZeroArgMethod(v interface{}, name string) (interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	method := rv.MethodByName(name)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() == 0 {
+		return nil, false
+	}
+	out := method.Call(nil)
+	return out[0].Interface(), true
+}
+
+// menuWhereOps mirrors the comparison operators supported by the
+// collections "where" template func.
+var menuWhereOps = map[string]func(a, b interface{}) bool{
+	"=":  func(a, b interface{}) bool { return menuValuesCompare(a, b) == 0 },
+	"==": func(a, b interface{}) bool { return menuValuesCompare(a, b) == 0 },
+	"!=": func(a, b interface{}) bool { return menuValuesCompare(a, b) != 0 },
+	"<>": func(a, b interface{}) bool { return menuValuesCompare(a, b) != 0 },
+	">":  func(a, b interface{}) bool { return menuValuesCompare(a, b) > 0 },
+	">=": func(a, b interface{}) bool { return menuValuesCompare(a, b) >= 0 },
+	"<":  func(a, b interface{}) bool { return menuValuesCompare(a, b) < 0 },
+	"<=": func(a, b interface{}) bool { return menuValuesCompare(a, b) <= 0 },
+}
+
+func menuValuesEqual(a, b interface{}) bool {
+	return menuValuesCompare(a, b) == 0
+}
+
+// menuValuesCompare compares a and b numerically if both can be cast to
+// float64, falling back to a string comparison otherwise.
+func menuValuesCompare(a, b interface{}) int {
+	af, aerr := cast.ToFloat64E(a)
+	bf, berr := cast.ToFloat64E(b)
+	if aerr == nil && berr == nil {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	as, bs := cast.ToString(a), cast.ToString(b)
+	return strings.Compare(as, bs)
+}
+
 func (m Menu) Reverse() Menu {
 	for i, j := 0, len(m)-1; i < j; i, j = i+1, j-1 {
 		m[i], m[j] = m[j], m[i]
@@ -13,11 +13,22 @@ import (
 )
 
 type Language struct {
-	Lang       string
-	Title      string
-	Weight     int
-	params     map[string]interface{}
-	paramsInit sync.Once
+	Lang   string
+	Title  string
+	Weight int
+	params map[string]interface{}
+
+	mergedParams     map[string]interface{}
+	mergedParamsInit sync.Once
+
+	// ContentDir is the per-language content source root, e.g. "content/en".
+	// When empty, this language's content is instead picked out of the
+	// shared content dir by filename suffix (my-page.en.md).
+	ContentDir string
+
+	// Fallbacks lists, in order, the languages to try when a page is
+	// missing in this language: Lang -> Fallbacks[0] -> Fallbacks[1] -> ...
+	Fallbacks []string
 }
 
 func NewLanguage(lang string) *Language {
@@ -67,18 +78,134 @@ func (ml *Multilingual) enabled() bool {
 	return len(ml.Languages) > 1
 }
 
-func (l *Language) Params() map[string]interface{} {
-	l.paramsInit.Do(func() {
-		// Merge with global config.
-		// TODO(bep) consider making this part of a constructor func.
-		globalParams := viper.GetStringMap("Params")
-		for k, v := range globalParams {
-			if _, ok := l.params[k]; !ok {
-				l.params[k] = v
+// ContentDirs returns the configured per-language content source roots,
+// keyed by language code, for every language that has one set. Languages
+// without a ContentDir rely on the shared content dir's filename-suffix
+// scheme (my-page.en.md) instead, and are omitted here.
+func (ml *Multilingual) ContentDirs() map[string]string {
+	dirs := make(map[string]string)
+	for _, l := range ml.Languages {
+		if l.ContentDir != "" {
+			dirs[l.Lang] = l.ContentDir
+		}
+	}
+	return dirs
+}
+
+// FallbackChain returns the chain of languages to consult, in order, when a
+// page is missing in lang: lang itself, then each of its Fallbacks (skipping
+// unknown language codes and any we've already seen, so a misconfigured
+// cycle can't loop forever). The site's DefaultLang is appended at the end
+// if it isn't already part of the chain.
+func (ml *Multilingual) FallbackChain(lang string) []*Language {
+	start := ml.Language(lang)
+	if start == nil {
+		return nil
+	}
+
+	seen := map[string]bool{start.Lang: true}
+	chain := []*Language{start}
+
+	next := start
+	for _, fb := range next.Fallbacks {
+		if seen[fb] {
+			continue
+		}
+		l := ml.Language(fb)
+		if l == nil {
+			continue
+		}
+		seen[fb] = true
+		chain = append(chain, l)
+	}
+
+	if ml.DefaultLang != nil && !seen[ml.DefaultLang.Lang] {
+		chain = append(chain, ml.DefaultLang)
+	}
+
+	return chain
+}
+
+// unsetParamSentinel is the value a language's (or, in principle, a page's)
+// params can set a key to, to suppress a value it would otherwise inherit
+// from a lower-precedence level. A literal Go nil can't be used for this,
+// since config formats like TOML have no way to assign nil to a key in the
+// first place -- this string is the documented escape hatch instead.
+const unsetParamSentinel = "!unset"
+
+// isUnsetParam reports whether v is a sentinel deepMergeParams treats as
+// "suppress the inherited value for this key": a YAML/JSON null, or the
+// unsetParamSentinel string.
+func isUnsetParam(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && s == unsetParamSentinel
+}
+
+func asParamsMap(v interface{}) (map[string]interface{}, bool) {
+	m, ok := v.(map[string]interface{})
+	return m, ok
+}
+
+// deepMergeParams merges override on top of base: every key in override
+// wins, recursing into nested maps present on both sides so only the leaf
+// keys an override actually sets are replaced rather than the whole nested
+// map being swapped out wholesale. A key set to an isUnsetParam value in
+// override deletes the corresponding base key outright, letting a language
+// opt out of a value a lower-precedence level would otherwise provide.
+//
+// Neither base nor override is mutated; the result is a new map, though
+// unmodified nested values are shared with base/override rather than
+// copied.
+func deepMergeParams(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+
+	for k, v := range override {
+		if isUnsetParam(v) {
+			delete(merged, k)
+			continue
+		}
+
+		if overrideMap, ok := asParamsMap(v); ok {
+			if baseMap, ok := asParamsMap(merged[k]); ok {
+				merged[k] = deepMergeParams(baseMap, overrideMap)
+				continue
 			}
 		}
+
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// MergedParams returns this language's params deep-merged on top of the
+// site's global Params, per deepMergeParams' rules -- the documented
+// precedence order for a param's value across the whole site is
+// site-wide Params < per-language Params < a page's own frontmatter
+// params, with this method resolving everything up to and including the
+// middle tier. A page's frontmatter (the highest tier) is merged in
+// separately by the page's own Params handling, deep-merging on top of
+// whatever MergedParams returns for its language.
+func (l *Language) MergedParams() map[string]interface{} {
+	l.mergedParamsInit.Do(func() {
+		globalParams := viper.GetStringMap("Params")
+		l.mergedParams = deepMergeParams(globalParams, l.params)
 	})
-	return l.params
+	return l.mergedParams
+}
+
+// Params returns this language's params. Deprecated in favour of
+// MergedParams, which deep-merges nested maps and honours unsetParamSentinel
+// instead of this method's original shallow, top-level-keys-only merge;
+// kept as an alias since it's the established name.
+func (l *Language) Params() map[string]interface{} {
+	return l.MergedParams()
 }
 
 func (l *Language) SetParam(k string, v interface{}) {
@@ -96,7 +223,7 @@ func (l *Language) GetStringMapString(key string) map[string]string {
 
 func (l *Language) Get(key string) interface{} {
 	key = strings.ToLower(key)
-	if v, ok := l.params[key]; ok {
+	if v, ok := l.MergedParams()[key]; ok {
 		return v
 	}
 	return viper.Get(key)
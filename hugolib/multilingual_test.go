@@ -0,0 +1,149 @@
+package hugolib
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMultilingualFallbackChain(t *testing.T) {
+	en := NewLanguage("en")
+	fr := NewLanguage("fr")
+	fr.ContentDir = "content/fr"
+	fr.Fallbacks = []string{"en"}
+	nn := NewLanguage("nn")
+	nn.Fallbacks = []string{"nb", "en"} // "nb" is intentionally unconfigured
+
+	ml := &Multilingual{
+		Languages:   Languages{en, fr, nn},
+		DefaultLang: en,
+	}
+
+	// doc4 (sect/doc4.md) exists only under "fr" in TestMultilingualSwitch;
+	// requesting it for "fr" itself should not pull in any fallback.
+	chain := ml.FallbackChain("fr")
+	if got, want := langCodes(chain), []string{"fr", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FallbackChain(fr) = %v, want %v", got, want)
+	}
+
+	// An unconfigured fallback ("nb") is skipped, and the default language
+	// is appended even though it wasn't named explicitly.
+	chain = ml.FallbackChain("nn")
+	if got, want := langCodes(chain), []string{"nn", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FallbackChain(nn) = %v, want %v", got, want)
+	}
+
+	if ml.FallbackChain("de") != nil {
+		t.Error("FallbackChain for an unknown language should return nil")
+	}
+}
+
+func TestMultilingualContentDirs(t *testing.T) {
+	en := NewLanguage("en")
+	fr := NewLanguage("fr")
+	fr.ContentDir = "content/fr"
+
+	ml := &Multilingual{Languages: Languages{en, fr}}
+
+	dirs := ml.ContentDirs()
+	if len(dirs) != 1 || dirs["fr"] != "content/fr" {
+		t.Errorf("ContentDirs() = %v, want map with only fr -> content/fr", dirs)
+	}
+}
+
+func TestDeepMergeParams(t *testing.T) {
+	base := map[string]interface{}{
+		"title": "Site Title",
+		"nav": map[string]interface{}{
+			"home":  "Home",
+			"about": "About",
+		},
+		"untouched": "base value",
+	}
+	override := map[string]interface{}{
+		"nav": map[string]interface{}{
+			"home":  "Startseite",
+			"about": unsetParamSentinel,
+		},
+	}
+
+	merged := deepMergeParams(base, override)
+
+	if got, want := merged["title"], "Site Title"; got != want {
+		t.Errorf("title = %v, want %v", got, want)
+	}
+	if got, want := merged["untouched"], "base value"; got != want {
+		t.Errorf("untouched = %v, want %v", got, want)
+	}
+
+	nav, ok := asParamsMap(merged["nav"])
+	if !ok {
+		t.Fatalf("nav is not a map: %#v", merged["nav"])
+	}
+	if got, want := nav["home"], "Startseite"; got != want {
+		t.Errorf("nav.home = %v, want %v", got, want)
+	}
+	if _, ok := nav["about"]; ok {
+		t.Errorf("nav.about should have been unset, got %v", nav["about"])
+	}
+
+	// base and override must be untouched by the merge.
+	if base["nav"].(map[string]interface{})["home"] != "Home" {
+		t.Error("deepMergeParams mutated base")
+	}
+}
+
+func TestLanguageMergedParams(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	viper.Set("Params", map[string]interface{}{
+		"title": "Global Title",
+		"social": map[string]interface{}{
+			"twitter": "hugo",
+			"github":  "gohugoio",
+		},
+	})
+
+	en := NewLanguage("en")
+	en.SetParam("social", map[string]interface{}{
+		"twitter": "hugoenglish",
+	})
+
+	fr := NewLanguage("fr")
+	fr.SetParam("title", "Titre Français")
+	fr.SetParam("social", map[string]interface{}{
+		"github": unsetParamSentinel,
+	})
+
+	social := en.MergedParams()["social"].(map[string]interface{})
+	if got, want := social["twitter"], "hugoenglish"; got != want {
+		t.Errorf("en social.twitter = %v, want %v", got, want)
+	}
+	if got, want := social["github"], "gohugoio"; got != want {
+		t.Errorf("en social.github = %v, want %v", got, want)
+	}
+	if got, want := en.Get("title"), "Global Title"; got != want {
+		t.Errorf("en title = %v, want %v", got, want)
+	}
+
+	frSocial := fr.MergedParams()["social"].(map[string]interface{})
+	if got, want := frSocial["twitter"], "hugo"; got != want {
+		t.Errorf("fr social.twitter = %v, want %v", got, want)
+	}
+	if _, ok := frSocial["github"]; ok {
+		t.Errorf("fr social.github should have been unset, got %v", frSocial["github"])
+	}
+	if got, want := fr.Get("title"), "Titre Français"; got != want {
+		t.Errorf("fr title = %v, want %v", got, want)
+	}
+}
+
+func langCodes(languages []*Language) []string {
+	codes := make([]string, len(languages))
+	for i, l := range languages {
+		codes[i] = l.Lang
+	}
+	return codes
+}
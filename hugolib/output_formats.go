@@ -0,0 +1,148 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import "strings"
+
+// OutputFormat describes one of the representations a Page or Node kind can
+// be rendered as -- HTML, but also RSS, Atom, a JSON feed, a sitemap, or a
+// site-specific custom format registered with Site.RegisterOutputFormat.
+type OutputFormat struct {
+	// Name identifies the format, e.g. "html", "rss", "atom", "json", "sitemap".
+	Name string
+
+	// MediaType is the MIME type written for this format where one is needed
+	// (e.g. the Content-Type the server would serve it as).
+	MediaType string
+
+	// Extension is the file extension used for the rendered output path,
+	// without a leading dot (e.g. "xml", "json", "html").
+	Extension string
+
+	// Suffix is appended to the base output path before Extension, e.g.
+	// "" for index.html but "feed" for an index.feed.json.
+	Suffix string
+
+	// BaseName is the template base name looked up for this format, before
+	// the "layout.format.ext" naming described on templateNameForFormat.
+	BaseName string
+
+	// IsPlainText marks formats (like JSON Feed) that should be escaped as
+	// plain text rather than HTML when rendered through html/template.
+	IsPlainText bool
+}
+
+// Built-in output formats. Sites register additional ones with
+// Site.RegisterOutputFormat.
+var (
+	OutputFormatHTML = OutputFormat{
+		Name:      "html",
+		MediaType: "text/html",
+		Extension: "html",
+		BaseName:  "single",
+	}
+
+	OutputFormatRSS = OutputFormat{
+		Name:      "rss",
+		MediaType: "application/rss+xml",
+		Extension: "xml",
+		Suffix:    "rss",
+		BaseName:  "rss",
+	}
+
+	OutputFormatAtom = OutputFormat{
+		Name:      "atom",
+		MediaType: "application/atom+xml",
+		Extension: "xml",
+		Suffix:    "atom",
+		BaseName:  "atom",
+	}
+
+	OutputFormatJSONFeed = OutputFormat{
+		Name:        "json",
+		MediaType:   "application/feed+json",
+		Extension:   "json",
+		Suffix:      "feed",
+		BaseName:    "jsonfeed",
+		IsPlainText: true,
+	}
+
+	OutputFormatSitemap = OutputFormat{
+		Name:      "sitemap",
+		MediaType: "application/xml",
+		Extension: "xml",
+		BaseName:  "sitemap",
+	}
+)
+
+// DefaultOutputFormats are the formats a Site renders unless told
+// otherwise: the existing HTML-page-plus-rss.xml behavior this type
+// replaces.
+var DefaultOutputFormats = []OutputFormat{OutputFormatHTML, OutputFormatRSS}
+
+// RegisterOutputFormat adds f to the set of formats kind is rendered as.
+// Registering a format under a kind that already has one with the same
+// Name replaces it, so a site can override a built-in format (e.g. to
+// change OutputFormatRSS's BaseName) without renaming it.
+func (s *Site) RegisterOutputFormat(kind string, f OutputFormat) {
+	if s.outputFormats == nil {
+		s.outputFormats = make(map[string][]OutputFormat)
+	}
+
+	formats := s.outputFormats[kind]
+	for i, existing := range formats {
+		if existing.Name == f.Name {
+			formats[i] = f
+			s.outputFormats[kind] = formats
+			return
+		}
+	}
+
+	s.outputFormats[kind] = append(formats, f)
+}
+
+// outputFormatsFor returns the formats registered for kind, or
+// DefaultOutputFormats if none have been registered.
+func (s *Site) outputFormatsFor(kind string) []OutputFormat {
+	if formats, ok := s.outputFormats[kind]; ok {
+		return formats
+	}
+	return DefaultOutputFormats
+}
+
+// templateNameForFormat returns the "layout.format.ext" template name for
+// layout rendered as f (e.g. templateNameForFormat("indexes/tag", f) with
+// f.Name "rss" and f.Extension "xml" returns "indexes/tag.rss.xml").
+// Callers fall back to layout itself -- the pre-existing, single-format
+// naming -- when that name isn't found among the loaded templates.
+func templateNameForFormat(layout string, f OutputFormat) string {
+	parts := []string{layout, f.Name}
+	if f.Extension != "" {
+		parts = append(parts, f.Extension)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Wiring outputFormatsFor/templateNameForFormat into RenderPages,
+// RenderIndexes, RenderLists, RenderHomePage and RenderIndexesIndexes so
+// that each Page/Node is actually rendered once per registered format --
+// and generating the sitemap.xml <urlset> from s.Pages with lastmod/
+// changefreq/priority -- isn't done here: doing so means iterating
+// s.Pages and reading Page.Date/front matter, and Page, Pages and the
+// rest of the types those methods already depend on (Config, IndexList,
+// Index, ShortcodeFunc) aren't defined anywhere in this tree. site.go
+// could not build before this change either; see incremental_build.go
+// for the same caveat on an earlier request. What's here -- the format
+// registry, the built-in formats and the template naming scheme -- is the
+// reusable part a real wiring would sit on top of.
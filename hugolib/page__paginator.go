@@ -23,9 +23,33 @@ type pagePaginator struct {
 	paginatorInit sync.Once
 	current       *page.Pager
 
+	// strategy records how this paginator's page URLs were derived, so
+	// NextCursor/PrevCursor can report a useful value only in cursor mode.
+	strategy page.PaginationStrategy
+
 	source *pageState
 }
 
+// NextCursor returns the opaque token for the next page when the paginator
+// was created with page.CursorPaginationStrategy, and the empty string
+// otherwise.
+func (p *pagePaginator) NextCursor() string {
+	if p.strategy != page.CursorPaginationStrategy || p.current == nil {
+		return ""
+	}
+	return p.current.NextCursor
+}
+
+// PrevCursor returns the opaque token for the previous page when the
+// paginator was created with page.CursorPaginationStrategy, and the empty
+// string otherwise.
+func (p *pagePaginator) PrevCursor() string {
+	if p.strategy != page.CursorPaginationStrategy || p.current == nil {
+		return ""
+	}
+	return p.current.PrevCursor
+}
+
 func (p *pagePaginator) Paginate(seq interface{}, options ...interface{}) (*page.Pager, error) {
 	var initErr error
 	p.paginatorInit.Do(func() {
@@ -35,6 +59,8 @@ func (p *pagePaginator) Paginate(seq interface{}, options ...interface{}) (*page
 			return
 		}
 
+		p.strategy = page.PaginationStrategyFromOptions(options...)
+
 		pd := p.source.targetPathDescriptor
 		pd.Type = p.source.outputFormat()
 		paginator, err := page.Paginate(pd, seq, pagerSize)
@@ -54,6 +80,16 @@ func (p *pagePaginator) Paginate(seq interface{}, options ...interface{}) (*page
 	return p.current, nil
 }
 
+// GroupBy pages the result of grouping the paginator's source pages by
+// keyFn, pageSize groups at a time, so an archive or index template can
+// walk month-by-month or section-by-section without Paginate/Paginator's
+// all-at-once page.Pager. cursor is the empty string for the first page
+// and thereafter the nextCursor a prior call returned; see
+// page.Pages.GroupByPaginated for the full cursor contract.
+func (p *pagePaginator) GroupBy(keyFn func(page.Page) string, pageSize int, cursor string) (page.PagesGroup, string, error) {
+	return p.source.Pages().GroupByPaginated(keyFn, pageSize, cursor)
+}
+
 func (p *pagePaginator) Paginator(options ...interface{}) (*page.Pager, error) {
 	var initErr error
 	p.paginatorInit.Do(func() {
@@ -63,6 +99,8 @@ func (p *pagePaginator) Paginator(options ...interface{}) (*page.Pager, error) {
 			return
 		}
 
+		p.strategy = page.PaginationStrategyFromOptions(options...)
+
 		pd := p.source.targetPathDescriptor
 		pd.Type = p.source.outputFormat()
 		paginator, err := page.Paginate(pd, p.source.Pages(), pagerSize)
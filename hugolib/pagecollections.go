@@ -17,19 +17,60 @@ import (
 	"fmt"
 	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
 
+	"github.com/gobwas/glob"
+
 	"github.com/gohugoio/hugo/cache"
 	"github.com/gohugoio/hugo/helpers"
+	hglob "github.com/gohugoio/hugo/hugofs/glob"
 	"github.com/gohugoio/hugo/resources/page"
 )
 
 // Used in the page cache to mark more than one hit for a given key.
 var ambiguityFlag = &pageState{}
 
+// AmbiguousPageRefError is returned by getPageStrict (and surfaced by
+// getPageNew) when a ref/relref resolves to more than one candidate page,
+// so callers (shortcodes, template authors, linters) can render a helpful
+// "did you mean" list instead of a plain error string.
+type AmbiguousPageRefError struct {
+	// Ref is the ref/relref as given by the caller.
+	Ref string
+
+	// candidates holds every page that ref could resolve to.
+	candidates []page.Page
+
+	// Context is the page the ref was resolved relative to, if any.
+	Context page.Page
+}
+
+// Candidates returns every page that Ref could resolve to, so callers can
+// build their own "did you mean" listing instead of parsing Error().
+func (e *AmbiguousPageRefError) Candidates() []page.Page {
+	return e.candidates
+}
+
+func (e *AmbiguousPageRefError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "page reference %q is ambiguous: %d candidates found", e.Ref, len(e.candidates))
+	for _, cand := range e.candidates {
+		b.WriteString("\n  - ")
+		if p, ok := cand.(*pageState); ok {
+			b.WriteString(p.sourceRef())
+		} else {
+			b.WriteString(cand.Path())
+		}
+		fmt.Fprintf(&b, " [%s]", cand.Lang())
+	}
+	return b.String()
+}
+
 // PageCollections contains the page collections for a site.
 type PageCollections struct {
 
@@ -50,6 +91,85 @@ type PageCollections struct {
 
 	// The index for .Site.GetPage etc.
 	pageIndex *cache.Lazy
+
+	// refCandidates tracks every page that maps to a given ref, so an
+	// ambiguous lookup can report what the candidates actually are.
+	// Populated as a side effect of the pageIndex lazy init below.
+	refCandidates map[string][]page.Page
+
+	// liveIndex mirrors pageIndex but can be patched for a handful of
+	// changed pages instead of recomputed from scratch, so live-reload
+	// builds don't have to pay for a full pageIndex rebuild.
+	liveIndex *incrementalPageIndex
+
+	// linkReport caches the result of the last CheckLinks call.
+	linkReport *LinkReport
+
+	// sortedPathIndex backs GetPages: a sorted-by-path slice built lazily
+	// alongside pageIndex, so a glob pattern's literal prefix can bound a
+	// binary search instead of a linear scan over every page.
+	sortedPathIndexInit    sync.Once
+	sortedPathIndexEntries []pathIndexEntry
+	sortedPathIndexErr     error
+
+	// globCache and regexpCache memoize the patterns GetPages compiles,
+	// since templates tend to call it with the same literal pattern on
+	// every page render across a build.
+	globCache   sync.Map // pattern string -> glob.Glob
+	regexpCache sync.Map // pattern string -> *regexp.Regexp
+
+	// depGraph is the owning HugoSites' dependency graph, set in
+	// createPageCollections. getPageNew records an edge into it for every
+	// resolved .GetPage/ref/relref lookup. nil (e.g. in tests that build a
+	// PageCollections directly) simply disables recording.
+	depGraph *depGraph
+}
+
+// pathIndexEntry pairs a page with its canonical, lowercase source path
+// (e.g. "/posts/foo.md"), the unit GetPages matches patterns against.
+type pathIndexEntry struct {
+	path string
+	page page.Page
+}
+
+// applyIncrementalChanges patches liveIndex for the given changed pages,
+// without touching the lazily-built full pageIndex. Call this after a
+// partial rebuild (e.g. on a content file save during `hugo server`)
+// instead of resetting pageIndex wholesale.
+func (c *PageCollections) applyIncrementalChanges(changed pageStatePages) {
+	for _, p := range changed {
+		c.indexRemove(p)
+		c.indexAdd(p)
+	}
+}
+
+// indexAdd incrementally indexes p in liveIndex, so a single added or
+// changed page becomes visible to GetPage/GetPages without forcing a full
+// pageIndex rebuild. Call this from addPage/replacePage.
+func (c *PageCollections) indexAdd(p *pageState) {
+	if c.liveIndex == nil {
+		c.liveIndex = newIncrementalPageIndex()
+	}
+
+	if !p.IsPage() {
+		return
+	}
+
+	if sourceRef := p.sourceRef(); sourceRef != "" {
+		c.liveIndex.Put(sourceRef, p)
+	}
+	c.liveIndex.Put(p.File().LogicalName(), p)
+}
+
+// indexRemove undoes indexAdd, dropping every ref entry p contributed to
+// liveIndex. Call this from removePage/removePageFilename/replacePage. If p
+// was one of several contributors to an ambiguous ref, removing it can
+// revive that ref as an unambiguous mapping to whatever candidate remains.
+func (c *PageCollections) indexRemove(p *pageState) {
+	if c.liveIndex == nil {
+		return
+	}
+	c.liveIndex.Remove(p)
 }
 
 // Pages returns all pages.
@@ -77,6 +197,15 @@ func (c *PageCollections) AllRegularPages() page.Pages {
 // Get initializes the index if not already done so, then
 // looks up the given page ref, returns nil if no value found.
 func (c *PageCollections) getFromCache(ref string) (page.Page, error) {
+	if c.liveIndex != nil {
+		if p, found := c.liveIndex.Get(ref); found {
+			if p != ambiguityFlag {
+				return p, nil
+			}
+			return nil, &AmbiguousPageRefError{Ref: ref, candidates: c.GetCandidates(ref)}
+		}
+	}
+
 	v, found, err := c.pageIndex.Get(ref)
 	if err != nil {
 		return nil, err
@@ -90,7 +219,185 @@ func (c *PageCollections) getFromCache(ref string) (page.Page, error) {
 	if p != ambiguityFlag {
 		return p, nil
 	}
-	return nil, fmt.Errorf("page reference %q is ambiguous", ref)
+	return nil, &AmbiguousPageRefError{Ref: ref, candidates: c.GetCandidates(ref)}
+}
+
+// GetCandidates returns every page that ref maps to in the page index. For
+// an unambiguous ref this is a single page; for an ambiguous one it is the
+// full set of pages that were competing for that ref. Candidates contributed
+// incrementally via indexAdd/indexRemove take precedence over the ones
+// captured at the last full pageIndex build.
+func (c *PageCollections) GetCandidates(ref string) []page.Page {
+	if c.liveIndex != nil {
+		if candidates := c.liveIndex.Candidates(ref); candidates != nil {
+			return candidates
+		}
+	}
+	return c.refCandidates[strings.ToLower(ref)]
+}
+
+// getPageStrict resolves ref like getPageNew, but additionally errors if
+// the resolved page is in a different language than context, unless
+// allowCrossLanguage is true. This catches accidental cross-language ref
+// links that getPageNew alone would silently allow.
+func (c *PageCollections) getPageStrict(context page.Page, ref string, allowCrossLanguage bool) (page.Page, error) {
+	p, err := c.getPageNew(context, ref)
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	if !allowCrossLanguage && context != nil && p.Lang() != context.Lang() {
+		return nil, errors.Errorf("ref %q resolved to page %q in language %q, expected %q", ref, p.Path(), p.Lang(), context.Lang())
+	}
+
+	return p, nil
+}
+
+// GetPages resolves pattern against the same lowercase, canonical-path
+// index getPageNew uses for single-page lookups, and returns every
+// matching page. pattern is either a glob, e.g. "/posts/**/*.md" (see
+// hugofs/glob), or a regular expression prefixed with "re:", e.g.
+// "re:^/posts/2024-.*".
+//
+// Unlike iterating .Site.RegularPages and filtering in the template, the
+// glob form only scans the slice of the sorted path index bounded by the
+// pattern's literal prefix, and compiled patterns are cached per site so
+// repeat calls across a build don't pay recompilation cost.
+func (c *PageCollections) GetPages(pattern string) (page.Pages, error) {
+	entries, err := c.getSortedPathIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	if rePattern := strings.TrimPrefix(pattern, "re:"); rePattern != pattern {
+		re, err := c.compiledRegexp(rePattern)
+		if err != nil {
+			return nil, err
+		}
+
+		var pages page.Pages
+		for _, e := range entries {
+			if re.MatchString(e.path) {
+				pages = append(pages, e.page)
+			}
+		}
+		return pages, nil
+	}
+
+	g, err := c.compiledGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi := globPrefixRange(entries, strings.ToLower(globLiteralPrefix(pattern)))
+
+	var pages page.Pages
+	for _, e := range entries[lo:hi] {
+		if g.Match(e.path) {
+			pages = append(pages, e.page)
+		}
+	}
+
+	return pages, nil
+}
+
+// getSortedPathIndex lazily builds and caches the sorted path index used by
+// GetPages.
+func (c *PageCollections) getSortedPathIndex() ([]pathIndexEntry, error) {
+	c.sortedPathIndexInit.Do(func() {
+		c.sortedPathIndexEntries, c.sortedPathIndexErr = c.buildSortedPathIndex()
+	})
+	return c.sortedPathIndexEntries, c.sortedPathIndexErr
+}
+
+func (c *PageCollections) buildSortedPathIndex() ([]pathIndexEntry, error) {
+	seen := make(map[string]bool)
+	var entries []pathIndexEntry
+
+	collect := func(pages pageStatePages) {
+		for _, p := range pages {
+			sourceRef := p.sourceRef()
+			if sourceRef == "" {
+				continue
+			}
+			sourceRef = strings.ToLower(sourceRef)
+			if seen[sourceRef] {
+				continue
+			}
+			seen[sourceRef] = true
+			entries = append(entries, pathIndexEntry{path: sourceRef, page: p})
+		}
+	}
+
+	collect(c.workAllPages)
+	collect(c.headlessPages)
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	return entries, nil
+}
+
+func (c *PageCollections) compiledGlob(pattern string) (glob.Glob, error) {
+	if v, ok := c.globCache.Load(pattern); ok {
+		return v.(glob.Glob), nil
+	}
+	g, err := hglob.GetGlob(hglob.NormalizePath(pattern))
+	if err != nil {
+		return nil, err
+	}
+	c.globCache.Store(pattern, g)
+	return g, nil
+}
+
+func (c *PageCollections) compiledRegexp(pattern string) (*regexp.Regexp, error) {
+	if v, ok := c.regexpCache.Load(pattern); ok {
+		return v.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	c.regexpCache.Store(pattern, re)
+	return re, nil
+}
+
+// globLiteralPrefix returns the portion of pattern before its first glob
+// meta character, used to bound the binary search into the sorted path
+// index.
+func globLiteralPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?[{"); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// globPrefixRange returns the [lo, hi) bounds within entries (sorted by
+// path) whose path starts with prefix.
+func globPrefixRange(entries []pathIndexEntry, prefix string) (int, int) {
+	lo := sort.Search(len(entries), func(i int) bool {
+		return entries[i].path >= prefix
+	})
+	upper := prefixUpperBound(prefix)
+	hi := sort.Search(len(entries), func(i int) bool {
+		return entries[i].path >= upper
+	})
+	return lo, hi
+}
+
+// prefixUpperBound returns the smallest string that sorts after every
+// string having the given prefix, for use as an exclusive upper bound in a
+// sorted range search.
+func prefixUpperBound(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+	// prefix was empty or all 0xff: there's no finite upper bound, so
+	// every path should be considered in range.
+	return "\xff\xff\xff\xff"
 }
 
 type lazyPagesFactory struct {
@@ -131,6 +438,9 @@ func newPageCollectionsFromPages(pages pageStatePages) *PageCollections {
 		return c.findPagesByKindInWorkPages(page.KindPage, c.workAllPages)
 	})
 
+	c.refCandidates = make(map[string][]page.Page)
+	c.liveIndex = newIncrementalPageIndex()
+
 	c.pageIndex = cache.NewLazy(func() (map[string]interface{}, error) {
 		index := make(map[string]interface{})
 
@@ -142,6 +452,7 @@ func newPageCollectionsFromPages(pages pageStatePages) *PageCollections {
 			} else if existing != ambiguityFlag && existing != p {
 				index[ref] = ambiguityFlag
 			}
+			c.refCandidates[ref] = append(c.refCandidates[ref], p)
 		}
 
 		for _, pageCollection := range []pageStatePages{c.workAllPages, c.headlessPages} {
@@ -241,7 +552,7 @@ func (c *PageCollections) getPageOldVersion(ref ...string) (page.Page, error) {
 	return c.getPageNew(nil, key)
 }
 
-// 	Only used in tests.
+// Only used in tests.
 func (c *PageCollections) getPage(typ string, sections ...string) page.Page {
 	refs := append([]string{typ}, path.Join(sections...))
 	p, _ := c.getPageOldVersion(refs...)
@@ -258,6 +569,7 @@ func (c *PageCollections) getPageNew(context page.Page, ref string) (page.Page,
 	if strings.HasPrefix(ref, "/") {
 		p, err := c.getFromCache(ref)
 		if err == nil && p != nil {
+			c.recordDependency(context, p)
 			return p, nil
 		}
 		if err != nil {
@@ -269,6 +581,7 @@ func (c *PageCollections) getPageNew(context page.Page, ref string) (page.Page,
 		ppath := path.Join("/", strings.ToLower(context.SectionsPath()), ref)
 		p, err := c.getFromCache(ppath)
 		if err == nil && p != nil {
+			c.recordDependency(context, p)
 			return p, nil
 		}
 		if err != nil {
@@ -285,6 +598,7 @@ func (c *PageCollections) getPageNew(context page.Page, ref string) (page.Page,
 				err := wrapErr(errors.Errorf(`make non-relative ref/relref page reference(s) in page %q absolute, e.g. {{< ref "/blog/my-post.md" >}}`, context.Path()), context)
 				helpers.DistinctWarnLog.Println(err)
 			}
+			c.recordDependency(context, p)
 			return p, nil
 		}
 		if err != nil {
@@ -303,9 +617,22 @@ func (c *PageCollections) getPageNew(context page.Page, ref string) (page.Page,
 		return nil, wrapErr(errors.Wrap(anError, "failed to resolve ref"), context)
 	}
 
+	c.recordDependency(context, p)
+
 	return p, nil
 }
 
+// recordDependency notes, in c.depGraph, that context consulted resolved
+// while rendering, so a later rebuild re-renders context if resolved's
+// underlying file changes. A no-op if context or resolved is nil, or if
+// this PageCollections isn't wired to a graph.
+func (c *PageCollections) recordDependency(context, resolved page.Page) {
+	if context == nil || resolved == nil {
+		return
+	}
+	c.depGraph.Record(pageDepKey(context), pageDepKey(resolved))
+}
+
 func (*PageCollections) findPagesByKindIn(kind string, inPages page.Pages) page.Pages {
 	var pages page.Pages
 	for _, p := range inPages {
@@ -351,11 +678,13 @@ func (c *PageCollections) findFirstWorkPageByKindIn(kind string) *pageState {
 
 func (c *PageCollections) addPage(page *pageState) {
 	c.rawAllPages = append(c.rawAllPages, page)
+	c.indexAdd(page)
 }
 
 func (c *PageCollections) removePageFilename(filename string) {
 	if i := c.rawAllPages.findPagePosByFilename(filename); i >= 0 {
 		c.clearResourceCacheForPage(c.rawAllPages[i])
+		c.indexRemove(c.rawAllPages[i])
 		c.rawAllPages = append(c.rawAllPages[:i], c.rawAllPages[i+1:]...)
 	}
 
@@ -364,6 +693,7 @@ func (c *PageCollections) removePageFilename(filename string) {
 func (c *PageCollections) removePage(page *pageState) {
 	if i := c.rawAllPages.findPagePos(page); i >= 0 {
 		c.clearResourceCacheForPage(c.rawAllPages[i])
+		c.indexRemove(c.rawAllPages[i])
 		c.rawAllPages = append(c.rawAllPages[:i], c.rawAllPages[i+1:]...)
 	}
 }
@@ -0,0 +1,104 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gohugoio/hugo/resources/page"
+)
+
+// incrementalPageIndex is a mutable, ref-keyed page index that can be
+// patched in place as individual pages change. It exists alongside the
+// full pageIndex cache so that live-reload rebuilds can update just the
+// affected refs instead of recomputing the index for the whole site.
+type incrementalPageIndex struct {
+	mu         sync.RWMutex
+	refs       map[string]page.Page
+	candidates map[string][]page.Page
+}
+
+func newIncrementalPageIndex() *incrementalPageIndex {
+	return &incrementalPageIndex{
+		refs:       make(map[string]page.Page),
+		candidates: make(map[string][]page.Page),
+	}
+}
+
+// Get looks up ref, returning (nil, false) if it isn't indexed and
+// (ambiguityFlag, true) if more than one page claims it.
+func (idx *incrementalPageIndex) Get(ref string) (page.Page, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	p, found := idx.refs[strings.ToLower(ref)]
+	return p, found
+}
+
+// Put indexes p under ref, flagging ref as ambiguous if it is already
+// claimed by a different page.
+func (idx *incrementalPageIndex) Put(ref string, p page.Page) {
+	ref = strings.ToLower(ref)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	existing := idx.refs[ref]
+	if existing == nil {
+		idx.refs[ref] = p
+	} else if existing != ambiguityFlag && existing != p {
+		idx.refs[ref] = ambiguityFlag
+	}
+
+	idx.candidates[ref] = append(idx.candidates[ref], p)
+}
+
+// Candidates returns every page currently indexed under ref, or nil if ref
+// isn't indexed at all. This reflects live Put/Remove calls, unlike a
+// candidate set captured once at full-index build time.
+func (idx *incrementalPageIndex) Candidates(ref string) []page.Page {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.candidates[strings.ToLower(ref)]
+}
+
+// Remove drops every ref entry pointing at p, undoing Put. This is the
+// piece that makes incremental updates possible: a changed or deleted page
+// can be fully unindexed without touching any other page's refs.
+func (idx *incrementalPageIndex) Remove(p page.Page) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	for ref, candidates := range idx.candidates {
+		kept := candidates[:0]
+		for _, c := range candidates {
+			if c != p {
+				kept = append(kept, c)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(idx.candidates, ref)
+			delete(idx.refs, ref)
+			continue
+		}
+
+		idx.candidates[ref] = kept
+		if len(kept) == 1 {
+			idx.refs[ref] = kept[0]
+		} else {
+			idx.refs[ref] = ambiguityFlag
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gohugoio/hugo/resources/page"
+)
+
+// benchIndexPage is a minimal page.Page stand-in: it embeds the interface
+// so it satisfies page.Page without implementing every method, since the
+// benchmark below only ever compares *benchIndexPage values by identity
+// and never calls a Page method on one.
+type benchIndexPage struct {
+	page.Page
+}
+
+// newBenchIndex builds an incrementalPageIndex indexing n synthetic pages,
+// one ref each, mimicking the ref set a 10k-page site would produce.
+func newBenchIndex(n int) (*incrementalPageIndex, []string) {
+	idx := newIncrementalPageIndex()
+	refs := make([]string, n)
+
+	for i := 0; i < n; i++ {
+		ref := fmt.Sprintf("/posts/page-%d/index.md", i)
+		refs[i] = ref
+		idx.Put(ref, &benchIndexPage{})
+	}
+
+	return idx, refs
+}
+
+// BenchmarkIncrementalPageIndexLookup measures Get throughput against a
+// 10k-page index, guarding against a regression back to a full pageIndex
+// rebuild on every lookup.
+func BenchmarkIncrementalPageIndexLookup(b *testing.B) {
+	const siteSize = 10000
+	idx, refs := newBenchIndex(siteSize)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx.Get(refs[i%len(refs)])
+	}
+}
+
+// BenchmarkIncrementalPageIndexReplace measures the cost of replacing a
+// single page in a 10k-page index: removing its old ref entries and
+// re-indexing it under the same ref, the live-reload hot path this index
+// exists for. A regression here would mean replacePage is once again
+// paying for a full-site reindex instead of touching one page's refs.
+func BenchmarkIncrementalPageIndexReplace(b *testing.B) {
+	const siteSize = 10000
+	idx, refs := newBenchIndex(siteSize)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ref := refs[i%len(refs)]
+		old, _ := idx.Get(ref)
+		if old != nil {
+			idx.Remove(old)
+		}
+		idx.Put(ref, &benchIndexPage{})
+	}
+}
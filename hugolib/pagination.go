@@ -0,0 +1,89 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import "fmt"
+
+// Paginator describes one page of a paginated list: the slice of item
+// indices it covers, plus the URLs templates need to link between pages.
+type Paginator struct {
+	PageNumber int
+	TotalPages int
+
+	// First, Last, Prev and Next are "" when there is no such page (e.g.
+	// Prev on page 1).
+	First, Last, Prev, Next string
+
+	// Start and End are the [Start, End) indices, into whatever slice is
+	// being paginated, that this page covers.
+	Start, End int
+}
+
+// paginateURL builds the URL for page n of a paginated listing rendered at
+// baseURL, following Hugo's page/N/ convention -- page 1 is baseURL itself.
+func paginateURL(baseURL string, n int) string {
+	if n <= 1 {
+		return baseURL
+	}
+	return fmt.Sprintf("%spage/%d/", baseURL, n)
+}
+
+// NewPaginators splits total items, perPage to a page, into a Paginator per
+// page, with URLs relative to baseURL. perPage <= 0 is treated as
+// unpaginated: a single Paginator covering every item.
+//
+// This is the piece of paginated rendering that doesn't depend on Page or
+// Pages: RenderIndexes and RenderLists would call this to get each page's
+// [Start, End) slice bounds and URLs, then slice their own Pages value and
+// render page/N/index.html for each Paginator -- but Pages, IndexList and
+// the rest of the types those render methods operate on aren't defined
+// anywhere in this tree (see incremental_build.go and output_formats.go
+// for the same caveat on earlier requests in this series), so that part of
+// the wiring isn't done here.
+func NewPaginators(total, perPage int, baseURL string) []Paginator {
+	if perPage <= 0 || total == 0 {
+		return []Paginator{{PageNumber: 1, TotalPages: 1, Start: 0, End: total}}
+	}
+
+	totalPages := (total + perPage - 1) / perPage
+	paginators := make([]Paginator, totalPages)
+
+	for i := range paginators {
+		n := i + 1
+		start := i * perPage
+		end := start + perPage
+		if end > total {
+			end = total
+		}
+
+		p := Paginator{
+			PageNumber: n,
+			TotalPages: totalPages,
+			Start:      start,
+			End:        end,
+			First:      paginateURL(baseURL, 1),
+			Last:       paginateURL(baseURL, totalPages),
+		}
+		if n > 1 {
+			p.Prev = paginateURL(baseURL, n-1)
+		}
+		if n < totalPages {
+			p.Next = paginateURL(baseURL, n+1)
+		}
+
+		paginators[i] = p
+	}
+
+	return paginators
+}
@@ -14,18 +14,25 @@
 package hugolib
 
 import (
-	"github.com/spf13/hugo/helpers"
 	"sort"
+	"sync"
+
+	"github.com/spf13/hugo/helpers"
 )
 
 // Scratch is a writable context used for stateful operations in Page/Node rendering.
+// Partials may be rendered concurrently, so all access to values goes through mu.
 type Scratch struct {
+	mu     sync.RWMutex
 	values map[string]interface{}
 }
 
 // Add will add (using the + operator) the addend to the existing addend (if found).
 // Supports numeric values and strings.
 func (c *Scratch) Add(key string, newAddend interface{}) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	var newVal interface{}
 	existingAddend, found := c.values[key]
 	if found {
@@ -44,18 +51,111 @@ func (c *Scratch) Add(key string, newAddend interface{}) (string, error) {
 // Set stores a value with the given key in the Node context.
 // This value can later be retrieved with Get.
 func (c *Scratch) Set(key string, value interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.values[key] = value
 	return ""
 }
 
 // Get returns a value previously set by Add or Set
 func (c *Scratch) Get(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.values[key]
 }
 
+// Delete removes a value previously set by Add, Set or SetInMap.
+func (c *Scratch) Delete(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+	return ""
+}
+
+// Increment adds n (using the + operator, so it also works for non-numeric
+// types supported by Add) to the value stored at key and returns the result.
+func (c *Scratch) Increment(key string, n interface{}) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, found := c.values[key]
+	if !found {
+		c.values[key] = n
+		return n, nil
+	}
+
+	newVal, err := helpers.DoArithmetic(existing, n, '+')
+	if err != nil {
+		return nil, err
+	}
+
+	c.values[key] = newVal
+	return newVal, nil
+}
+
+// Push appends v to the list stored at key, creating it if necessary.
+func (c *Scratch) Push(key string, v interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, found := c.values[key]
+	if !found {
+		c.values[key] = []interface{}{v}
+		return ""
+	}
+
+	c.values[key] = append(existing.([]interface{}), v)
+	return ""
+}
+
+// Pop removes and returns the last element of the list stored at key. The
+// second return value is false if the list is missing or empty.
+func (c *Scratch) Pop(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, found := c.values[key]
+	if !found {
+		return nil, false
+	}
+
+	list := existing.([]interface{})
+	if len(list) == 0 {
+		return nil, false
+	}
+
+	last := list[len(list)-1]
+	c.values[key] = list[:len(list)-1]
+
+	return last, true
+}
+
+// Values returns a copy of the list stored at key by Push.
+func (c *Scratch) Values(key string) []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	existing, found := c.values[key]
+	if !found {
+		return nil
+	}
+
+	list := existing.([]interface{})
+	cp := make([]interface{}, len(list))
+	copy(cp, list)
+
+	return cp
+}
+
 // SetInMap stores a value to a map with the given key in the Node context.
 // This map can later be retrieved with GetSortedMapValues.
 func (c *Scratch) SetInMap(key string, mapKey string, value interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	_, found := c.values[key]
 	if !found {
 		c.values[key] = make(map[string]interface{})
@@ -67,6 +167,9 @@ func (c *Scratch) SetInMap(key string, mapKey string, value interface{}) string
 
 // GetSortedMapValues returns a sorted map previously filled with SetInMap
 func (c *Scratch) GetSortedMapValues(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	if c.values[key] == nil {
 		return nil
 	}
@@ -74,7 +177,7 @@ func (c *Scratch) GetSortedMapValues(key string) interface{} {
 	unsortedMap := c.values[key].(map[string]interface{})
 
 	var keys []string
-	for mapKey, _ := range unsortedMap {
+	for mapKey := range unsortedMap {
 		keys = append(keys, mapKey)
 	}
 
@@ -88,6 +191,38 @@ func (c *Scratch) GetSortedMapValues(key string) interface{} {
 	return sortedArray
 }
 
+// Keys returns the keys currently held in the scratch pad, sorted
+// alphabetically, for template introspection.
+func (c *Scratch) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Merge copies every key/value from other into c, overwriting any existing
+// keys of the same name.
+func (c *Scratch) Merge(other *Scratch) string {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, value := range other.values {
+		c.values[key] = value
+	}
+
+	return ""
+}
+
 func newScratch() *Scratch {
 	return &Scratch{values: make(map[string]interface{})}
 }
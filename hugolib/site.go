@@ -18,7 +18,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"github.com/spf13/hugo/target"
+	"github.com/gohugoio/hugo/target"
 	"github.com/spf13/nitro"
 	"html/template"
 	"io/ioutil"
@@ -41,6 +41,18 @@ type Site struct {
 	Shortcodes  map[string]ShortcodeFunc
 	timer       *nitro.B
 	Target      target.Publisher
+
+	// NumWorkers caps the number of goroutines buildWorkerPool fans render
+	// work out across. Zero (the default) means GOMAXPROCS. This lives on
+	// Site rather than Config because Config -- along with Page, Pages,
+	// IndexList, Index and ShortcodeFunc above -- isn't actually defined
+	// anywhere in this tree; see incremental_build.go.
+	NumWorkers int
+
+	// outputFormats holds the formats each Page/Node kind ("page", "home",
+	// "section", "taxonomy", ...) is rendered as, set via
+	// RegisterOutputFormat. See output_formats.go.
+	outputFormats map[string][]OutputFormat
 }
 
 type SiteInfo struct {
@@ -14,19 +14,165 @@
 package hugolib
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/gohugoio/hugo/hugolib/benchmark"
 )
 
+// benchJSONPath, when set via -benchjson=path, makes BenchmarkSiteNew write
+// its per-phase timings to that file as JSON, so CI can track regressions
+// phase-by-phase rather than only by total wall time.
+var benchJSONPath = flag.String("benchjson", "", "write BenchmarkSiteNew per-phase timings as JSON to this path")
+
+// benchPhaseResult holds one siteBenchmarkTestcase's timings, averaged
+// over b.N runs. Setup covers config load and source read (sitesBuilder's
+// in-memory fs is populated and HugoSites is created from it); Build
+// covers page assembly, render and write, which HugoSites.Build currently
+// performs as a single, non-instrumented call.
+type benchPhaseResult struct {
+	Name         string `json:"name"`
+	N            int    `json:"n"`
+	SetupNsPerOp int64  `json:"setupNsPerOp"`
+	BuildNsPerOp int64  `json:"buildNsPerOp"`
+	TotalNsPerOp int64  `json:"totalNsPerOp"`
+}
+
+func writeBenchJSON(results []benchPhaseResult) error {
+	if *benchJSONPath == "" || len(results) == 0 {
+		return nil
+	}
+
+	f, err := os.Create(*benchJSONPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(results)
+}
+
 type siteBenchmarkTestcase struct {
 	name   string
 	create func(t testing.TB) *sitesBuilder
 	check  func(s *sitesBuilder)
 }
 
+// corpusBenchmarkCase turns a deterministic benchmark.Corpus into a
+// siteBenchmarkTestcase: its config and content files are loaded into the
+// sitesBuilder's in-memory filesystem, and any ImagePaths get a real JPEG
+// fixture so image processing in templates keeps working.
+func corpusBenchmarkCase(name string, opts benchmark.Options) siteBenchmarkTestcase {
+	return siteBenchmarkTestcase{
+		name: name,
+		create: func(t testing.TB) *sitesBuilder {
+			corpus := benchmark.Generate(opts)
+
+			sb := newTestSitesBuilder(t).WithConfigFile("toml", corpus.Config)
+			for contentPath, content := range corpus.Content {
+				sb.WithContent(filepath.FromSlash(contentPath), content)
+			}
+			for _, imagePath := range corpus.ImagePaths {
+				sb.WithSunset(filepath.FromSlash(imagePath))
+			}
+
+			if opts.ShortcodeDensity > 0 {
+				sb.WithTemplatesAdded("shortcodes/bench.html", "bench")
+			}
+
+			return sb
+		},
+		check: func(s *sitesBuilder) {
+			s.Assertions.True(len(s.H.Sites) > 0)
+		},
+	}
+}
+
+func getKnobBenchmarkSiteNewTestCases() []siteBenchmarkTestcase {
+	return []siteBenchmarkTestcase{
+		corpusBenchmarkCase("Knobs: small", benchmark.DefaultOptions()),
+		corpusBenchmarkCase("Knobs: multilingual", benchmark.Options{
+			Seed: 42, Languages: 4, Sections: 3, Depth: 2,
+			BundlesPerSection: 2, ImagesPerBundle: 1, JSONPerBundle: 1, Taxonomies: 2,
+		}),
+		corpusBenchmarkCase("Knobs: deep with shortcodes", benchmark.Options{
+			Seed: 42, Languages: 1, Sections: 2, Depth: 4,
+			BundlesPerSection: 3, ImagesPerBundle: 0, JSONPerBundle: 0, Taxonomies: 1,
+			ShortcodeDensity: 3,
+		}),
+	}
+}
+
+// newDeepContentTreeSite builds the "Deep content tree" fixture: four
+// languages, each five levels of nested sections, each with three leaf
+// bundles carrying their own nested bundled assets. It is also reused by
+// BenchmarkSiteRebuild, which needs a stable, named file to edit rather
+// than a fresh tree per run.
+func newDeepContentTreeSite(b testing.TB) *sitesBuilder {
+	const pageContent = `---
+title: "My Page"
+---
+
+My page content.
+
+`
+
+	sb := newTestSitesBuilder(b).WithConfigFile("toml", `
+baseURL = "https://example.com"
+
+[languages]
+[languages.en]
+weight=1
+contentDir="content/en"
+[languages.fr]
+weight=2
+contentDir="content/fr"
+[languages.no]
+weight=3
+contentDir="content/no"
+[languages.sv]
+weight=4
+contentDir="content/sv"
+
+`)
+
+	createContent := func(dir, name string) {
+		sb.WithContent(filepath.Join("content", dir, name), pageContent)
+	}
+
+	createBundledFiles := func(dir string) {
+		sb.WithContent(filepath.Join("content", dir, "data.json"), `{ "hello": "world" }`)
+		for i := 1; i <= 3; i++ {
+			sb.WithContent(filepath.Join("content", dir, fmt.Sprintf("page%d.md", i)), pageContent)
+		}
+	}
+
+	for _, lang := range []string{"en", "fr", "no", "sv"} {
+		for level := 1; level <= 5; level++ {
+			sectionDir := path.Join(lang, strings.Repeat("section/", level))
+			createContent(sectionDir, "_index.md")
+			createBundledFiles(sectionDir)
+			for i := 1; i <= 3; i++ {
+				leafBundleDir := path.Join(sectionDir, fmt.Sprintf("bundle%d", i))
+				createContent(leafBundleDir, "index.md")
+				createBundledFiles(path.Join(leafBundleDir, "assets1"))
+				createBundledFiles(path.Join(leafBundleDir, "assets1", "assets2"))
+			}
+		}
+	}
+
+	sb.WithSunset(filepath.Join("content", "en", "section", "bundle1", "sunset1.jpg"))
+
+	return sb
+}
+
 func getBenchmarkSiteNewTestCases() []siteBenchmarkTestcase {
 	// TODO(bep) create some common and stable data set
 
@@ -70,54 +216,7 @@ baseURL = "https://example.com"
 
 			},
 		},
-		{"Deep content tree", func(b testing.TB) *sitesBuilder {
-
-			sb := newTestSitesBuilder(b).WithConfigFile("toml", `
-baseURL = "https://example.com"
-
-[languages]
-[languages.en]
-weight=1
-contentDir="content/en"
-[languages.fr]
-weight=2
-contentDir="content/fr"
-[languages.no]
-weight=3
-contentDir="content/no"
-[languages.sv]
-weight=4
-contentDir="content/sv"
-			
-`)
-
-			createContent := func(dir, name string) {
-				sb.WithContent(filepath.Join("content", dir, name), pageContent)
-			}
-
-			createBundledFiles := func(dir string) {
-				sb.WithContent(filepath.Join("content", dir, "data.json"), `{ "hello": "world" }`)
-				for i := 1; i <= 3; i++ {
-					sb.WithContent(filepath.Join("content", dir, fmt.Sprintf("page%d.md", i)), pageContent)
-				}
-			}
-
-			for _, lang := range []string{"en", "fr", "no", "sv"} {
-				for level := 1; level <= 5; level++ {
-					sectionDir := path.Join(lang, strings.Repeat("section/", level))
-					createContent(sectionDir, "_index.md")
-					createBundledFiles(sectionDir)
-					for i := 1; i <= 3; i++ {
-						leafBundleDir := path.Join(sectionDir, fmt.Sprintf("bundle%d", i))
-						createContent(leafBundleDir, "index.md")
-						createBundledFiles(path.Join(leafBundleDir, "assets1"))
-						createBundledFiles(path.Join(leafBundleDir, "assets1", "assets2"))
-					}
-				}
-			}
-
-			return sb
-		},
+		{"Deep content tree", newDeepContentTreeSite,
 			func(s *sitesBuilder) {
 				s.CheckExists("public/blog/mybundle/index.html")
 				s.Assertions.Equal(4, len(s.H.Sites))
@@ -128,6 +227,8 @@ contentDir="content/sv"
 		},
 	}
 
+	benchmarks = append(benchmarks, getKnobBenchmarkSiteNewTestCases()...)
+
 	return benchmarks
 
 }
@@ -153,23 +254,92 @@ func TestBenchmarkSiteNew(b *testing.T) {
 // TODO(bep) eventually remove the old (too complicated setup).
 func BenchmarkSiteNew(b *testing.B) {
 	benchmarks := getBenchmarkSiteNewTestCases()
+	var results []benchPhaseResult
 
 	for _, bm := range benchmarks {
+		var setup, build time.Duration
+		var n int
+
 		b.Run(bm.name, func(b *testing.B) {
-			sites := make([]*sitesBuilder, b.N)
+			n = b.N
 			for i := 0; i < b.N; i++ {
-				sites[i] = bm.create(b)
-			}
+				setupStart := time.Now()
+				s := bm.create(b)
+				s.CreateSites()
+				setup += time.Since(setupStart)
 
-			b.ResetTimer()
-			for i := 0; i < b.N; i++ {
-				s := sites[i]
+				buildStart := time.Now()
 				err := s.BuildE(BuildCfg{})
+				build += time.Since(buildStart)
 				if err != nil {
 					b.Fatal(err)
 				}
 				bm.check(s)
 			}
 		})
+
+		if n == 0 {
+			n = 1
+		}
+		results = append(results, benchPhaseResult{
+			Name:         bm.name,
+			N:            n,
+			SetupNsPerOp: setup.Nanoseconds() / int64(n),
+			BuildNsPerOp: build.Nanoseconds() / int64(n),
+			TotalNsPerOp: (setup + build).Nanoseconds() / int64(n),
+		})
+	}
+
+	if err := writeBenchJSON(results); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkSiteRebuild measures the single-file edit/rebuild loop hit
+// repeatedly while running `hugo server`, as opposed to BenchmarkSiteNew's
+// cold, from-scratch builds. Each case builds the Deep content tree
+// fixture once, then times RebuildE over b.N edits of a single file.
+func BenchmarkSiteRebuild(b *testing.B) {
+	const (
+		deepTreeContentFile  = "content/en/section/bundle1/index.md"
+		deepTreeTemplateFile = "_default/single.html"
+		deepTreeImageFile    = "content/en/section/bundle1/sunset1.jpg"
+	)
+
+	cases := []struct {
+		name    string
+		rebuild func(s *sitesBuilder, i int)
+	}{
+		{"Edit content file", func(s *sitesBuilder, i int) {
+			s.EditFiles(deepTreeContentFile, fmt.Sprintf(`---
+title: "Edited %d"
+---
+
+Edited content, iteration %d.
+`, i, i))
+		}},
+		{"Edit template file", func(s *sitesBuilder, i int) {
+			s.EditFiles(filepath.Join("layouts", deepTreeTemplateFile), fmt.Sprintf(`Edit {{ .Title }} %d`, i))
+		}},
+		{"Replace bundled image", func(s *sitesBuilder, i int) {
+			s.WithSunset(deepTreeImageFile)
+			s.changedFiles = []string{filepath.FromSlash(deepTreeImageFile)}
+		}},
+	}
+
+	for _, c := range cases {
+		b.Run(c.name, func(b *testing.B) {
+			s := newDeepContentTreeSite(b)
+			s.WithTemplates(deepTreeTemplateFile, `{{ .Title }}`)
+			s.Build(BuildCfg{})
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.rebuild(s, i)
+				if err := s.RebuildE(BuildCfg{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
 	}
 }
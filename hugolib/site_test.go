@@ -1481,6 +1481,49 @@ NOTE: should use the "permalinks" configuration with :filename
 	// Expect the tags locations to be in certain places, with the /en/ prefixes, etc..
 }
 
+func TestWikiLink(t *testing.T) {
+	rootfile := &Page{Node: Node{Slug: "", Title: "Root File", Permalink: "http://example.com/rootfile/"}, FileName: filepath.FromSlash("rootfile.md")}
+	threeRoot := &Page{Node: Node{Slug: "", Title: "3 Root", Permalink: "http://example.com/level2/level3/3-root/"}, FileName: filepath.FromSlash("level2/level3/3-root.md")}
+	niceLabelTarget := &Page{Node: Node{Slug: "nice-slug", Title: "Nice Label Target", Permalink: "http://example.com/level2/level3/nice/"}, FileName: filepath.FromSlash("level2/level3/nice.md")}
+	oldName := &Page{Node: Node{Slug: "", Title: "Renamed Page", Permalink: "http://example.com/renamed/"}, FileName: filepath.FromSlash("renamed.md"), Aliases: []string{"old-name"}}
+
+	// "3-root" as a bare filename is shared by two pages, so it must be
+	// reported as ambiguous rather than silently picking one.
+	threeRootDup := &Page{Node: Node{Slug: "", Title: "3 Root Dup"}, FileName: filepath.FromSlash("other/3-root.md")}
+
+	pages := Pages{rootfile, threeRoot, niceLabelTarget, oldName, threeRootDup}
+	info := &SiteInfo{Recent: &pages}
+
+	okresults := map[string]string{
+		"rootfile":          "http://example.com/rootfile/",
+		"nice-slug":         "http://example.com/level2/level3/nice/",
+		"Nice Label Target": "http://example.com/level2/level3/nice/",
+		"old-name":          "http://example.com/renamed/",
+		"rootfile#section":  "http://example.com/rootfile/#section",
+	}
+
+	for target, want := range okresults {
+		got, err := info.WikiLink(target, rootfile)
+		if err != nil {
+			t.Errorf("WikiLink(%q) returned error: %s", target, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("WikiLink(%q) = %q, want %q", target, got, want)
+		}
+	}
+
+	if _, err := info.WikiLink("3-root", rootfile); err == nil {
+		t.Error("expected an error for an ambiguous wiki link, got nil")
+	} else if _, ok := err.(*AmbiguousWikiLinkError); !ok {
+		t.Errorf("expected *AmbiguousWikiLinkError, got %T: %s", err, err)
+	}
+
+	if _, err := info.WikiLink("no-such-page", rootfile); err == nil {
+		t.Error("expected an error for an unresolvable wiki link, got nil")
+	}
+}
+
 func assertFileContent(t *testing.T, path string, content string) {
 	fl, err := hugofs.Destination().Open(path)
 	assert.NoError(t, err, "file content not found when asserting on content of %s", path)
@@ -0,0 +1,228 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Sitemap configures the sitemap.xml (and, when sharded, sitemap_index.xml)
+// output for a site.
+type Sitemap struct {
+	ChangeFreq string
+	Priority   float64
+	Filename   string
+
+	// MaxURLs is the maximum number of URLs allowed in a single sitemap file
+	// before it is split into shards. The sitemaps.org spec caps this at
+	// 50000; 0 means use that default.
+	MaxURLs int
+
+	// Shard, when true, forces sharding even if the page count is below
+	// MaxURLs.
+	Shard bool
+
+	// Index is the filename used for the sitemap index when sharding is in
+	// effect. Defaults to "sitemap_index.xml".
+	Index string
+}
+
+// defaultSitemapMaxURLs is the sitemaps.org limit on URLs per sitemap file.
+const defaultSitemapMaxURLs = 50000
+
+func parseSitemap(input map[string]interface{}) Sitemap {
+	sitemap := Sitemap{
+		Filename: "sitemap.xml",
+		Index:    "sitemap_index.xml",
+		MaxURLs:  defaultSitemapMaxURLs,
+		Priority: -1,
+	}
+
+	for key, value := range input {
+		switch key {
+		case "changefreq":
+			sitemap.ChangeFreq = cast.ToString(value)
+		case "priority":
+			sitemap.Priority = cast.ToFloat64(value)
+		case "filename":
+			sitemap.Filename = cast.ToString(value)
+		case "maxurls":
+			sitemap.MaxURLs = cast.ToInt(value)
+		case "shard":
+			sitemap.Shard = cast.ToBool(value)
+		case "index":
+			sitemap.Index = cast.ToString(value)
+		default:
+			// Ignore unknown fields so site config stays forward compatible.
+		}
+	}
+
+	if sitemap.MaxURLs <= 0 {
+		sitemap.MaxURLs = defaultSitemapMaxURLs
+	}
+
+	return sitemap
+}
+
+// SitemapAlternate is one <xhtml:link rel="alternate" hreflang="..."> entry
+// a sitemap.xml <url> can carry, pointing at a translation of that URL.
+// Hugo's existing multilingual support (Multilingual/Language in
+// multilingual.go) is what Config.Languages would otherwise have been;
+// SitemapAlternate is the piece this request adds on top of it.
+type SitemapAlternate struct {
+	HrefLang string
+	Href     string
+}
+
+// RenderRobotsTxt renders a robots.txt that allows everything and points at
+// sitemapURL, for sites generating a sitemap.
+func RenderRobotsTxt(sitemapURL string) []byte {
+	return []byte(fmt.Sprintf("User-agent: *\nDisallow:\nSitemap: %s\n", sitemapURL))
+}
+
+// sitemapXHTMLNamespace is the xmlns:xhtml declaration a <urlset> must carry
+// for <xhtml:link> alternate entries to validate.
+const sitemapXHTMLNamespace = `xmlns:xhtml="http://www.w3.org/1999/xhtml"`
+
+// xDefaultHrefLang is the value Google's sitemap hreflang convention uses
+// for the fallback link search engines should use when none of a page's
+// other hreflang values match the visitor's locale.
+const xDefaultHrefLang = "x-default"
+
+// BuildSitemapAlternates turns a page's translations (href keyed by
+// language code, including the page's own language) into the
+// SitemapAlternate entries a <url> entry should carry: one per
+// translation, plus an "x-default" entry pointing at defaultLang's href
+// when defaultLang has one. hrefs are taken as already resolved absolute
+// URLs -- e.g. already adjusted for whether DefaultContentLanguageInSubdir
+// puts the default language's content under its own /<lang>/ prefix --
+// since resolving permalinks is Page's job, not this function's.
+//
+// A page with no translations (len(hrefs) <= 1) yields no alternates,
+// matching the hreflang spec's guidance that a URL with nothing to link to
+// shouldn't carry alternate tags at all.
+func BuildSitemapAlternates(hrefs map[string]string, defaultLang string) []SitemapAlternate {
+	if len(hrefs) <= 1 {
+		return nil
+	}
+
+	langs := make([]string, 0, len(hrefs))
+	for lang := range hrefs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	alternates := make([]SitemapAlternate, 0, len(langs)+1)
+	for _, lang := range langs {
+		alternates = append(alternates, SitemapAlternate{HrefLang: lang, Href: hrefs[lang]})
+	}
+
+	if href, ok := hrefs[defaultLang]; ok {
+		alternates = append(alternates, SitemapAlternate{HrefLang: xDefaultHrefLang, Href: href})
+	}
+
+	return alternates
+}
+
+// RenderSitemapAlternates renders alternates as the indented
+// <xhtml:link rel="alternate" .../> lines a sitemap.xml template would
+// place inside a <url> entry, one per alternate, in the order given (use
+// BuildSitemapAlternates to get that order right, including x-default).
+func RenderSitemapAlternates(alternates []SitemapAlternate) []byte {
+	var b bytes.Buffer
+	for _, a := range alternates {
+		fmt.Fprintf(&b, "    <xhtml:link rel=\"alternate\" hreflang=%q href=%q/>\n", a.HrefLang, a.Href)
+	}
+	return b.Bytes()
+}
+
+// Wiring BuildSitemapAlternates/RenderSitemapAlternates into the actual
+// sitemap.xml template and declaring sitemapXHTMLNamespace on its <urlset>
+// (sitemap_test.go shows the existing output is produced by rendering a
+// template per Page, not by marshaling a Go struct) isn't done here: that
+// needs the Page/Pages types (for Page.Translations and Page.Permalink),
+// which -- along with Config, IndexList, Index and ShortcodeFunc -- aren't
+// defined anywhere in this tree; see incremental_build.go, output_formats.go
+// and pagination.go for the same caveat on earlier requests in this series.
+// What's here is the pure data-shaping half: given a page's resolved
+// per-language hrefs, it produces the SitemapAlternate entries (including
+// x-default, aware that the caller must have already resolved
+// DefaultContentLanguageInSubdir into those hrefs) and their XML rendering.
+
+// sitemapShard holds the filename, URL count and most recent modification
+// time for one shard of a sharded sitemap; this is the data
+// sitemapindex.xml's template ranges over to produce one <sitemap> entry
+// per shard.
+type sitemapShard struct {
+	Filename string
+	Count    int
+	LastMod  string
+}
+
+// shardFilenamePattern turns a sitemap filename such as "sitemap.xml" into
+// the "%d"-templated pattern its shards are named from, e.g.
+// "sitemap-%d.xml". A filename with no extension gets the index appended
+// directly, e.g. "sitemap" becomes "sitemap-%d".
+func shardFilenamePattern(filename string) string {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	return base + "-%d" + ext
+}
+
+// buildSitemapShards splits lastMods -- the lastmod value of every URL that
+// will go into the sitemap, in the same order they'll be written -- into
+// shards of at most maxURLs entries each, returning one sitemapShard per
+// shard: its filename (derived from pattern, which must contain a single
+// "%d" placeholder), how many URLs it holds and the most recent of those
+// URLs' lastmod values, which is what a sitemap_index.xml <sitemap> entry's
+// own <lastmod> should carry. A sitemap with no URLs at all still yields a
+// single, empty shard, so sites with no content still produce a valid
+// sitemap.xml/sitemap_index.xml pair.
+func buildSitemapShards(lastMods []string, maxURLs int, pattern string) []sitemapShard {
+	if maxURLs <= 0 {
+		maxURLs = defaultSitemapMaxURLs
+	}
+
+	if len(lastMods) == 0 {
+		return []sitemapShard{{Filename: fmt.Sprintf(pattern, 1)}}
+	}
+
+	var shards []sitemapShard
+	for start := 0; start < len(lastMods); start += maxURLs {
+		end := start + maxURLs
+		if end > len(lastMods) {
+			end = len(lastMods)
+		}
+
+		shard := sitemapShard{
+			Filename: fmt.Sprintf(pattern, len(shards)+1),
+			Count:    end - start,
+		}
+		for _, lastMod := range lastMods[start:end] {
+			if lastMod > shard.LastMod {
+				shard.LastMod = lastMod
+			}
+		}
+
+		shards = append(shards, shard)
+	}
+
+	return shards
+}
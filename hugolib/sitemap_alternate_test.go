@@ -0,0 +1,94 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSitemapAlternates(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		hrefs       map[string]string
+		defaultLang string
+		want        []SitemapAlternate
+	}{
+		{
+			name:  "untranslated page",
+			hrefs: map[string]string{"en": "https://example.org/about/"},
+			want:  nil,
+		},
+		{
+			name: "translated page, subdir mode",
+			hrefs: map[string]string{
+				"en": "https://example.org/en/about/",
+				"fr": "https://example.org/fr/about/",
+			},
+			defaultLang: "en",
+			want: []SitemapAlternate{
+				{HrefLang: "en", Href: "https://example.org/en/about/"},
+				{HrefLang: "fr", Href: "https://example.org/fr/about/"},
+				{HrefLang: "x-default", Href: "https://example.org/en/about/"},
+			},
+		},
+		{
+			name: "translated page, default language in root",
+			hrefs: map[string]string{
+				"en": "https://example.org/about/",
+				"fr": "https://example.org/fr/about/",
+			},
+			defaultLang: "en",
+			want: []SitemapAlternate{
+				{HrefLang: "en", Href: "https://example.org/about/"},
+				{HrefLang: "fr", Href: "https://example.org/fr/about/"},
+				{HrefLang: "x-default", Href: "https://example.org/about/"},
+			},
+		},
+		{
+			name: "taxonomy with no default-language href",
+			hrefs: map[string]string{
+				"fr": "https://example.org/fr/categories/news/",
+				"de": "https://example.org/de/categories/news/",
+			},
+			defaultLang: "en",
+			want: []SitemapAlternate{
+				{HrefLang: "de", Href: "https://example.org/de/categories/news/"},
+				{HrefLang: "fr", Href: "https://example.org/fr/categories/news/"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := BuildSitemapAlternates(test.hrefs, test.defaultLang)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderSitemapAlternates(t *testing.T) {
+	alternates := []SitemapAlternate{
+		{HrefLang: "en", Href: "https://example.org/en/about/"},
+		{HrefLang: "x-default", Href: "https://example.org/en/about/"},
+	}
+
+	got := string(RenderSitemapAlternates(alternates))
+	want := "    <xhtml:link rel=\"alternate\" hreflang=\"en\" href=\"https://example.org/en/about/\"/>\n" +
+		"    <xhtml:link rel=\"alternate\" hreflang=\"x-default\" href=\"https://example.org/en/about/\"/>\n"
+
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShardFilenamePattern(t *testing.T) {
+	for _, test := range []struct {
+		filename string
+		want     string
+	}{
+		{"sitemap.xml", "sitemap-%d.xml"},
+		{"sitemap", "sitemap-%d"},
+		{"sitemap.foo.xml", "sitemap.foo-%d.xml"},
+	} {
+		if got := shardFilenamePattern(test.filename); got != test.want {
+			t.Errorf("shardFilenamePattern(%q) = %q, want %q", test.filename, got, test.want)
+		}
+	}
+}
+
+func TestBuildSitemapShards(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		lastMods []string
+		maxURLs  int
+		want     []sitemapShard
+	}{
+		{
+			name:     "empty",
+			lastMods: nil,
+			maxURLs:  10,
+			want:     []sitemapShard{{Filename: "sitemap-1.xml"}},
+		},
+		{
+			name:     "fits in one shard",
+			lastMods: []string{"2023-01-01", "2023-01-03", "2023-01-02"},
+			maxURLs:  10,
+			want:     []sitemapShard{{Filename: "sitemap-1.xml", Count: 3, LastMod: "2023-01-03"}},
+		},
+		{
+			name:     "splits across shards",
+			lastMods: []string{"2023-01-01", "2023-01-02", "2023-01-03", "2023-01-04", "2023-01-05"},
+			maxURLs:  2,
+			want: []sitemapShard{
+				{Filename: "sitemap-1.xml", Count: 2, LastMod: "2023-01-02"},
+				{Filename: "sitemap-2.xml", Count: 2, LastMod: "2023-01-04"},
+				{Filename: "sitemap-3.xml", Count: 1, LastMod: "2023-01-05"},
+			},
+		},
+		{
+			name:     "non-positive maxURLs falls back to the default",
+			lastMods: []string{"2023-01-01"},
+			maxURLs:  0,
+			want:     []sitemapShard{{Filename: "sitemap-1.xml", Count: 1, LastMod: "2023-01-01"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := buildSitemapShards(test.lastMods, test.maxURLs, "sitemap-%d.xml")
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("buildSitemapShards() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
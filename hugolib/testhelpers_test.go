@@ -1,11 +1,16 @@
 package hugolib
 
 import (
+	"flag"
 	"io"
+	"io/fs"
+	"math/rand"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"testing"
+	"time"
 	"unicode/utf8"
 
 	"github.com/gohugoio/hugo/parser/metadecoders"
@@ -14,6 +19,9 @@ import (
 	"github.com/pkg/errors"
 
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -89,6 +97,11 @@ type sitesBuilder struct {
 	templateFilePairsAdded []string
 	i18nFilePairsAdded     []string
 	dataFilePairsAdded     []string
+
+	// Rewrites content before it is compared against or written to a
+	// snapshot golden file, e.g. to redact build timestamps. Defaults to
+	// a no-op.
+	snapshotRedactor func(string) string
 }
 
 func newTestSitesBuilder(t testing.TB) *sitesBuilder {
@@ -152,6 +165,9 @@ func (s *sitesBuilder) WithEnviron(env ...string) *sitesBuilder {
 func (s *sitesBuilder) WithConfigTemplate(data interface{}, format, configTemplate string) *sitesBuilder {
 	s.T.Helper()
 
+	if format == "" {
+		format = s.configFormat
+	}
 	if format == "" {
 		format = "toml"
 	}
@@ -165,6 +181,15 @@ func (s *sitesBuilder) WithConfigTemplate(data interface{}, format, configTempla
 	return s.WithConfigFile(format, b.String())
 }
 
+// WithConfigFormat sets the format WithViper serializes its settings to,
+// and the format WithConfigTemplate defaults to when none is given, e.g.
+// to run the same test against "toml", "yaml" and "json". It has no
+// effect on WithConfigFile, which always takes its format explicitly.
+func (s *sitesBuilder) WithConfigFormat(format string) *sitesBuilder {
+	s.configFormat = format
+	return s
+}
+
 func (s *sitesBuilder) WithViper(v *viper.Viper) *sitesBuilder {
 	s.T.Helper()
 	if s.configFileSet {
@@ -174,11 +199,29 @@ func (s *sitesBuilder) WithViper(v *viper.Viper) *sitesBuilder {
 		s.viperSet = true
 	}()
 
+	format := s.configFormat
+	if format == "" {
+		format = "toml"
+	}
+
 	// Write to a config file to make sure the tests follow the same code path.
 	var buff bytes.Buffer
 	m := v.AllSettings()
-	s.Assertions.NoError(parser.InterfaceToConfig(m, metadecoders.TOML, &buff))
-	return s.WithConfigFile("toml", buff.String())
+	s.Assertions.NoError(parser.InterfaceToConfig(m, configFormatToMetaFormat(format), &buff))
+	return s.WithConfigFile(format, buff.String())
+}
+
+// configFormatToMetaFormat maps a sitesBuilder config format string, as
+// passed to WithConfigFile/WithConfigFormat, to its metadecoders.Format.
+func configFormatToMetaFormat(format string) metadecoders.Format {
+	switch format {
+	case "yaml", "yml":
+		return metadecoders.YAML
+	case "json":
+		return metadecoders.JSON
+	default:
+		return metadecoders.TOML
+	}
 }
 
 func (s *sitesBuilder) WithConfigFile(format, conf string) *sitesBuilder {
@@ -370,6 +413,15 @@ func (s *sitesBuilder) WithI18n(filenameContent ...string) *sitesBuilder {
 	return s
 }
 
+// WithSnapshotRedactor sets the func AssertFileContentSnapshot and
+// AssertSiteSnapshot run content through before comparing it against, or
+// writing it to, a golden file -- e.g. to strip build timestamps or
+// generator comments that would otherwise make every run look changed.
+func (s *sitesBuilder) WithSnapshotRedactor(redact func(string) string) *sitesBuilder {
+	s.snapshotRedactor = redact
+	return s
+}
+
 func (s *sitesBuilder) WithI18nAdded(filenameContent ...string) *sitesBuilder {
 	s.i18nFilePairsAdded = append(s.i18nFilePairsAdded, filenameContent...)
 	return s
@@ -388,6 +440,114 @@ func (s *sitesBuilder) EditFiles(filenameContent ...string) *sitesBuilder {
 	return s
 }
 
+// AssertIncrementalMatchesFull builds a second, independent sitesBuilder
+// from the exact file tree currently on s's in-memory filesystem -- the
+// state after an EditFiles(...).Build(...)/RebuildE incremental rebuild
+// -- and asserts every file under public/ is identical between the two.
+// This catches the class of incremental-rebuild bug where a partial
+// rebuild leaves a stale page, misses a taxonomy update, or keeps an
+// outdated resource fingerprint around.
+func (s *sitesBuilder) AssertIncrementalMatchesFull() {
+	s.T.Helper()
+
+	tree := snapshotSourceTree(s)
+
+	full := newTestSitesBuilder(s.T).WithNothingAdded()
+	full.configFormat = s.configFormat
+	restoreSourceTree(full, tree)
+	full.Build(BuildCfg{})
+
+	assertDestinationsEqual(s, full)
+}
+
+// FuzzIncremental repeatedly calls mutate to edit one of s's files,
+// rebuilds incrementally via EditFiles+Build, and checks the result
+// against a from-scratch build via AssertIncrementalMatchesFull. It is
+// seeded, so a failure is reproducible by re-running with the same seed.
+func (s *sitesBuilder) FuzzIncremental(seed int64, iterations int, mutate func(r *rand.Rand) (filename, content string)) {
+	s.T.Helper()
+
+	r := rand.New(rand.NewSource(seed))
+
+	for i := 0; i < iterations; i++ {
+		filename, content := mutate(r)
+		s.EditFiles(filename, content)
+		s.Build(BuildCfg{})
+		s.AssertIncrementalMatchesFull()
+	}
+}
+
+// snapshotSourceTree reads every file under s's source filesystem into a
+// map keyed by its path relative to s.workingDir.
+func snapshotSourceTree(s *sitesBuilder) map[string]string {
+	s.T.Helper()
+
+	tree := make(map[string]string)
+
+	err := afero.Walk(s.Fs.Source, s.workingDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel := strings.TrimPrefix(p, s.workingDir)
+		rel = strings.TrimPrefix(rel, string(filepath.Separator))
+		content, err := afero.ReadFile(s.Fs.Source, p)
+		if err != nil {
+			return err
+		}
+		tree[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	s.Assertions.NoError(err)
+
+	return tree
+}
+
+// restoreSourceTree writes tree, as captured by snapshotSourceTree, onto
+// s's source filesystem.
+func restoreSourceTree(s *sitesBuilder, tree map[string]string) {
+	s.T.Helper()
+	for filename, content := range tree {
+		writeSource(s.T, s.Fs, s.absFilename(filename), content)
+	}
+}
+
+// assertDestinationsEqual compares every published file under a and b's
+// Fs.Destination, failing with a unified diff on the first mismatch.
+func assertDestinationsEqual(a, b *sitesBuilder) {
+	a.T.Helper()
+
+	paths := make(map[string]bool)
+	collect := func(s *sitesBuilder) {
+		err := afero.Walk(s.Fs.Destination, "", func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			paths[p] = true
+			return nil
+		})
+		s.Assertions.NoError(err)
+	}
+	collect(a)
+	collect(b)
+
+	for p := range paths {
+		aExists := destinationExists(a.Fs, p)
+		bExists := destinationExists(b.Fs, p)
+		if aExists != bExists {
+			a.Fatalf("incremental build and full build disagree on whether %q exists (incremental=%v, full=%v)", p, aExists, bExists)
+		}
+		if !aExists {
+			continue
+		}
+
+		got := readDestination(a.T, a.Fs, p)
+		want := readDestination(b.T, b.Fs, p)
+		if got != want {
+			a.Fatalf("incremental build does not match full build for %q:\n%s", p, helpers.DiffStrings(want, got))
+		}
+	}
+}
+
 func (s *sitesBuilder) writeFilePairs(folder string, filenameContent []string) *sitesBuilder {
 	if len(filenameContent)%2 != 0 {
 		s.Fatalf("expect filenameContent for %q in pairs (%d)", folder, len(filenameContent))
@@ -491,6 +651,32 @@ func (s *sitesBuilder) CreateSitesE() error {
 	return nil
 }
 
+// Benchmark builds the site configured on s for b.N iterations, with
+// deps.DepsCfg.CollectStats enabled, and reports each BuildStats phase
+// HugoSites gathered as a ns/op metric via b.ReportMetric -- so benchstat
+// output can attribute a regression to a phase (content parsing, template
+// execution, ...) instead of only the overall build time.
+func (s *sitesBuilder) Benchmark(b *testing.B, cfg BuildCfg) {
+	b.Helper()
+	s.depsCfg.CollectStats = true
+
+	totals := make(map[string]time.Duration)
+
+	for i := 0; i < b.N; i++ {
+		s.H = nil
+		if err := s.BuildE(cfg); err != nil {
+			b.Fatal(err)
+		}
+		for phase, stat := range s.H.BuildStats().Phases {
+			totals[phase] += stat.Duration
+		}
+	}
+
+	for phase, total := range totals {
+		b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), phase+"-ns/op")
+	}
+}
+
 func (s *sitesBuilder) BuildE(cfg BuildCfg) error {
 	if s.H == nil {
 		s.CreateSites()
@@ -499,6 +685,22 @@ func (s *sitesBuilder) BuildE(cfg BuildCfg) error {
 	return s.H.Build(cfg)
 }
 
+// RebuildE reuses the already-created HugoSites and rebuilds only the
+// files edited since the last build, the same incremental path Build and
+// BuildFail take via changeEvents. Call EditFiles first to record which
+// files changed; unlike BuildE, it does not start from scratch.
+func (s *sitesBuilder) RebuildE(cfg BuildCfg) error {
+	defer func() {
+		s.changedFiles = nil
+	}()
+
+	if s.H == nil {
+		s.CreateSites()
+	}
+
+	return s.H.Build(cfg, s.changeEvents()...)
+}
+
 func (s *sitesBuilder) Build(cfg BuildCfg) *sitesBuilder {
 	s.T.Helper()
 	return s.build(cfg, false)
@@ -656,6 +858,20 @@ func (s *sitesBuilder) AssertFileContent(filename string, matches ...string) {
 	}
 }
 
+// AssertFileDoesNotContain is the negative counterpart to AssertFileContent,
+// failing if any of matches is found in filename, e.g. to assert a
+// deprecated shortcode's markup or a stale canonical link is really gone
+// rather than just not being the thing the test happened to check for.
+func (s *sitesBuilder) AssertFileDoesNotContain(filename string, matches ...string) {
+	s.T.Helper()
+	content := s.FileContent(filename)
+	for _, match := range matches {
+		if strings.Contains(content, match) {
+			s.Fatalf("Unexpected match for %q in content for %s\n%s\n%q", match, filename, content, content)
+		}
+	}
+}
+
 func (s *sitesBuilder) FileContent(filename string) string {
 	s.T.Helper()
 	filename = filepath.FromSlash(filename)
@@ -665,6 +881,62 @@ func (s *sitesBuilder) FileContent(filename string) string {
 	return readDestination(s.T, s.Fs, filename)
 }
 
+// snapshotUpdate makes AssertFileContentSnapshot and AssertSiteSnapshot
+// (re)write their golden files instead of comparing against them. Run
+// `go test -update ./hugolib/...`, or set HUGO_UPDATE_SNAPSHOTS=1, after a
+// deliberate rendering change to refresh the goldens.
+var snapshotUpdate = flag.Bool("update", os.Getenv("HUGO_UPDATE_SNAPSHOTS") == "1", "update golden files in testdata/snapshots")
+
+// AssertFileContentSnapshot compares filename's rendered content against
+// the golden file at testdata/snapshots/<test name>/<filename>.golden.
+func (s *sitesBuilder) AssertFileContentSnapshot(filename string) {
+	s.T.Helper()
+	s.assertSnapshot(filename, s.FileContent(filename))
+}
+
+// AssertSiteSnapshot compares every published file under the in-memory
+// Fs.Destination (i.e. all of `public/`) against its own golden file at
+// testdata/snapshots/<test name>/<path>.golden.
+func (s *sitesBuilder) AssertSiteSnapshot() {
+	s.T.Helper()
+
+	err := afero.Walk(s.Fs.Destination, "", func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		s.assertSnapshot(p, readDestination(s.T, s.Fs, p))
+		return nil
+	})
+	s.Assertions.NoError(err)
+}
+
+func (s *sitesBuilder) assertSnapshot(filename, content string) {
+	s.T.Helper()
+
+	redact := s.snapshotRedactor
+	if redact == nil {
+		redact = func(s string) string { return s }
+	}
+	content = redact(strings.ReplaceAll(content, "\r\n", "\n"))
+
+	goldenPath := filepath.Join("testdata", "snapshots", filepath.FromSlash(s.T.Name()), filepath.FromSlash(filename)+".golden")
+
+	if *snapshotUpdate {
+		s.NoError(os.MkdirAll(filepath.Dir(goldenPath), 0o755))
+		s.NoError(os.WriteFile(goldenPath, []byte(content), 0o644))
+		return
+	}
+
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		s.Fatalf("no golden file %s (run with -update to create it): %s", goldenPath, err)
+	}
+
+	if content != string(golden) {
+		s.Fatalf("content for %q does not match golden file %s:\n%s", filename, goldenPath, helpers.DiffStrings(string(golden), content))
+	}
+}
+
 func (s *sitesBuilder) AssertObject(expected string, object interface{}) {
 	s.T.Helper()
 	got := s.dumper.Sdump(object)
@@ -691,6 +963,32 @@ func (s *sitesBuilder) CheckExists(filename string) bool {
 	return destinationExists(s.Fs, filepath.Clean(filename))
 }
 
+// assertPublishedTree walks s.H.PublishedFS() and asserts that its set of
+// file paths (directories excluded) is exactly expected, regardless of
+// order. This catches the class of bug AssertFileContent can't: a stray
+// leftover file, or an expected one that silently never got rendered.
+func (s *sitesBuilder) assertPublishedTree(expected []string) {
+	s.T.Helper()
+
+	var got []string
+	err := fs.WalkDir(s.H.PublishedFS(), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			got = append(got, p)
+		}
+		return nil
+	})
+	s.Assertions.NoError(err)
+
+	sort.Strings(got)
+	wantSorted := append([]string(nil), expected...)
+	sort.Strings(wantSorted)
+
+	s.Assertions.Equal(wantSorted, got)
+}
+
 type testHelper struct {
 	Cfg config.Provider
 	Fs  *hugofs.Fs
@@ -716,12 +1014,98 @@ func (th testHelper) assertFileContentRegexp(filename string, matches ...string)
 	}
 }
 
+// assertFileHash asserts that filename's published content hashes to the
+// hex-encoded SHA-256 digest expectedSHA, e.g. to check a build manifest
+// entry (see hugolib/contentaddressable) against what actually landed on
+// disk.
+func (th testHelper) assertFileHash(filename string, expectedSHA string) {
+	filename = th.replaceDefaultContentLanguageValue(filename)
+	content := readDestination(th.T, th.Fs, filename)
+	sum := sha256.Sum256([]byte(content))
+	got := hex.EncodeToString(sum[:])
+	require.Equal(th.T, expectedSHA, got, "hash mismatch for %q", filename)
+}
+
+// assertFileDoesNotContain is the negative counterpart to assertFileContent,
+// failing if any of matches is found in filename.
+func (th testHelper) assertFileDoesNotContain(filename string, matches ...string) {
+	filename = th.replaceDefaultContentLanguageValue(filename)
+	content := readDestination(th.T, th.Fs, filename)
+	for _, match := range matches {
+		match = th.replaceDefaultContentLanguageValue(match)
+		require.False(th.T, strings.Contains(content, match), fmt.Sprintf("File unexpected match for\n%q in\n%q:\n%s", strings.Replace(match, "%", "%%", -1), filename, strings.Replace(content, "%", "%%", -1)))
+	}
+}
+
 func (th testHelper) assertFileNotExist(filename string) {
 	exists, err := helpers.Exists(filename, th.Fs.Destination)
 	require.NoError(th.T, err)
 	require.False(th.T, exists)
 }
 
+// assertFrontMatterEqual reads filename from source, splits off its TOML,
+// YAML or JSON front matter and compares it against expectedMeta, so a test
+// can assert on a content file's metadata without hand-slicing the file or
+// caring which format it happens to be written in.
+func (th testHelper) assertFrontMatterEqual(filename string, expectedMeta map[string]interface{}) {
+	th.T.Helper()
+	content := readFileFromFs(th.T, th.Fs.Source, filename)
+	meta, _ := splitFrontMatterAndBody(th.T, content)
+	require.Equal(th.T, expectedMeta, meta, "front matter mismatch for %q", filename)
+}
+
+// assertBodyEqual reads filename from source, splits off its front matter
+// and compares the remaining Markdown body against expectedBody.
+func (th testHelper) assertBodyEqual(filename string, expectedBody string) {
+	th.T.Helper()
+	content := readFileFromFs(th.T, th.Fs.Source, filename)
+	_, body := splitFrontMatterAndBody(th.T, content)
+	require.Equal(th.T, expectedBody, body, "body mismatch for %q", filename)
+}
+
+// splitFrontMatterAndBody splits a content file's raw bytes into its
+// decoded front matter and the Markdown body that follows, the same
+// TOML/YAML/JSON delimiter conventions real content files use. It exists so
+// assertFrontMatterEqual/assertBodyEqual (and any future test needing the
+// same split) don't each hand-roll the delimiter slicing.
+func splitFrontMatterAndBody(t testing.TB, content string) (meta map[string]interface{}, body string) {
+	t.Helper()
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var delim string
+	switch {
+	case strings.HasPrefix(content, parser.YAML_DELIM_UNIX):
+		delim = parser.YAML_DELIM_UNIX
+	case strings.HasPrefix(content, parser.TOML_DELIM_UNIX):
+		delim = parser.TOML_DELIM_UNIX
+	case strings.HasPrefix(content, parser.JSON_LEAD):
+		dec := json.NewDecoder(strings.NewReader(content))
+		var m map[string]interface{}
+		require.NoError(t, dec.Decode(&m), "invalid JSON front matter")
+		return m, strings.TrimPrefix(content[dec.InputOffset():], "\n")
+	default:
+		t.Fatalf("content has no recognized front matter delimiter:\n%s", content)
+		return nil, ""
+	}
+
+	rest := content[len(delim):]
+	end := strings.Index(rest, delim)
+	if end == -1 {
+		t.Fatalf("no closing %q found in content:\n%s", strings.TrimSuffix(delim, "\n"), content)
+	}
+
+	format, err := parser.FormatByLeadRune(rune(delim[0]))
+	require.NoError(t, err)
+
+	decoded, err := format.Unmarshal([]byte(rest[:end]))
+	require.NoError(t, err)
+
+	m, ok := decoded.(map[string]interface{})
+	require.True(t, ok, "front matter did not decode to a map: %T", decoded)
+
+	return m, strings.TrimPrefix(rest[end+len(delim):], "\n")
+}
+
 func (th testHelper) replaceDefaultContentLanguageValue(value string) string {
 	defaultInSubDir := th.Cfg.GetBool("defaultContentLanguageInSubDir")
 	replace := th.Cfg.GetString("defaultContentLanguage") + "/"
@@ -0,0 +1,179 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// rootRelativeURLAttrs are the attributes rewriteRootRelativeURLs rewrites
+// on any element, beyond the hardcoded src/href/srcset/poster special
+// cases below. Callers that need additional data-* attributes rewritten
+// can append to a copy of this slice.
+var rootRelativeURLAttrs = []string{"src", "href", "srcset", "poster"}
+
+// rewriteRootRelativeURLs rewrites every root-relative URL ("/foo/bar") in
+// body to be rooted at baseURL instead, across src, href, srcset and
+// poster attributes, srcset's comma-separated candidates, and CSS
+// url(...) references inside <style> blocks. Unlike five naive
+// strings.Replace passes, this walks the document once with an
+// html.Tokenizer, so it doesn't corrupt URLs that happen to share a
+// substring with unrelated text elsewhere on the page.
+//
+// This is the content-addressed part of AbsUrlify's replacement: wiring it
+// into Site.AbsUrlify/a Site.transformPages pipeline so it actually runs
+// over every Page.Content, and defining the PageTransformer interface
+// ProcessShortcodes would also implement, isn't done here -- both need the
+// Page type, which (along with Pages, Config and the rest of site.go's
+// dependencies) isn't defined anywhere in this tree; see
+// incremental_build.go for the same caveat on an earlier request in this
+// series.
+func rewriteRootRelativeURLs(body, baseURL string) (string, error) {
+	base := strings.TrimSuffix(baseURL, "/")
+
+	z := html.NewTokenizer(strings.NewReader(body))
+	var out bytes.Buffer
+	inStyle := false
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != nil && err.Error() != "EOF" {
+				return "", err
+			}
+			return out.String(), nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) == "style" {
+				inStyle = tt == html.StartTagToken
+			}
+
+			out.WriteByte('<')
+			out.Write(name)
+
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				out.WriteByte(' ')
+				out.Write(key)
+				out.WriteString(`="`)
+				out.WriteString(html.EscapeString(rewriteAttrValue(string(key), string(val), base)))
+				out.WriteByte('"')
+			}
+
+			if tt == html.SelfClosingTagToken {
+				out.WriteString(" /")
+			}
+			out.WriteByte('>')
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == "style" {
+				inStyle = false
+			}
+			out.Write(z.Raw())
+		case html.TextToken:
+			if inStyle {
+				out.WriteString(rewriteCSSURLs(string(z.Text()), base))
+			} else {
+				out.Write(z.Text())
+			}
+		default:
+			out.Write(z.Raw())
+		}
+	}
+}
+
+// rewriteAttrValue rewrites val if attr is one of rootRelativeURLAttrs and
+// val is root-relative, handling srcset's comma-separated
+// "url descriptor, url descriptor" form.
+func rewriteAttrValue(attr, val, base string) string {
+	isURLAttr := false
+	for _, a := range rootRelativeURLAttrs {
+		if attr == a {
+			isURLAttr = true
+			break
+		}
+	}
+	if !isURLAttr {
+		return val
+	}
+
+	if attr == "srcset" {
+		candidates := strings.Split(val, ",")
+		for i, c := range candidates {
+			c = strings.TrimSpace(c)
+			fields := strings.Fields(c)
+			if len(fields) == 0 {
+				continue
+			}
+			fields[0] = rewriteURLIfRootRelative(fields[0], base)
+			candidates[i] = strings.Join(fields, " ")
+		}
+		return strings.Join(candidates, ", ")
+	}
+
+	return rewriteURLIfRootRelative(val, base)
+}
+
+// rewriteURLIfRootRelative prefixes u with base if u is root-relative
+// (starts with "/" but not "//", which is protocol-relative).
+func rewriteURLIfRootRelative(u, base string) string {
+	if strings.HasPrefix(u, "/") && !strings.HasPrefix(u, "//") {
+		return base + u
+	}
+	return u
+}
+
+// rewriteCSSURLs rewrites root-relative url(...) references within a CSS
+// text block.
+func rewriteCSSURLs(css, base string) string {
+	var out strings.Builder
+	rest := css
+
+	for {
+		i := strings.Index(rest, "url(")
+		if i == -1 {
+			out.WriteString(rest)
+			return out.String()
+		}
+
+		out.WriteString(rest[:i+len("url(")])
+		rest = rest[i+len("url("):]
+
+		j := strings.IndexByte(rest, ')')
+		if j == -1 {
+			out.WriteString(rest)
+			return out.String()
+		}
+
+		raw := strings.TrimSpace(rest[:j])
+		quote := ""
+		unquoted := raw
+		if len(raw) >= 2 && (raw[0] == '\'' || raw[0] == '"') && raw[len(raw)-1] == raw[0] {
+			quote = string(raw[0])
+			unquoted = raw[1 : len(raw)-1]
+		}
+
+		out.WriteString(quote)
+		out.WriteString(rewriteURLIfRootRelative(unquoted, base))
+		out.WriteString(quote)
+		out.WriteByte(')')
+
+		rest = rest[j+1:]
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright © 2013 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// AmbiguousWikiLinkError is returned by SiteInfo.WikiLink when target
+// matches more than one page at the same resolution step (see WikiLink).
+type AmbiguousWikiLinkError struct {
+	Target     string
+	Candidates []*Page
+}
+
+func (e *AmbiguousWikiLinkError) Error() string {
+	names := make([]string, len(e.Candidates))
+	for i, p := range e.Candidates {
+		names[i] = p.FileName
+	}
+	return fmt.Sprintf("[[%s]] is ambiguous, matches: %s", e.Target, strings.Join(names, ", "))
+}
+
+// WikiLink resolves a MediaWiki/Obsidian-style wiki link target (the bit
+// between the brackets in [[target]] or [[target|label]], with any "|label"
+// already stripped by the caller) against this site's pages, returning its
+// canonical permalink. current is the page the link appears in; it isn't
+// used for resolution (target is never path-relative), only to make
+// "not found"/ambiguous errors easier to place.
+//
+// target is matched, in order, against each page's slug, filename without
+// extension, title and aliases; resolution stops at the first of those
+// steps that matches anything. More than one page matching at the same
+// step is reported as an *AmbiguousWikiLinkError* instead of silently
+// picking one. A trailing "#fragment" on target is preserved and appended
+// to the resolved permalink.
+func (s *SiteInfo) WikiLink(target string, current *Page) (string, error) {
+	target, fragment := target, ""
+	if idx := strings.IndexByte(target, '#'); idx != -1 {
+		target, fragment = target[:idx], target[idx+1:]
+	}
+
+	pages := s.wikiLinkCandidates()
+
+	steps := []func(*Page) bool{
+		func(p *Page) bool { return p.Slug == target },
+		func(p *Page) bool { return wikiLinkBaseName(p.FileName) == target },
+		func(p *Page) bool { return p.Title == target },
+		func(p *Page) bool { return wikiLinkHasAlias(p, target) },
+	}
+
+	for _, matches := range steps {
+		var found []*Page
+		for _, p := range pages {
+			if matches(p) {
+				found = append(found, p)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return wikiLinkPermalink(found[0], fragment), nil
+		default:
+			return "", &AmbiguousWikiLinkError{Target: target, Candidates: found}
+		}
+	}
+
+	return "", fmt.Errorf("[[%s]] does not resolve to any page", target)
+}
+
+func (s *SiteInfo) wikiLinkCandidates() Pages {
+	if s.Recent == nil {
+		return nil
+	}
+	return *s.Recent
+}
+
+func wikiLinkBaseName(fileName string) string {
+	base := filepath.Base(fileName)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func wikiLinkHasAlias(p *Page, target string) bool {
+	for _, a := range p.Aliases {
+		if a == target {
+			return true
+		}
+	}
+	return false
+}
+
+func wikiLinkPermalink(p *Page, fragment string) string {
+	link := string(p.Permalink)
+	if link == "" {
+		link = p.Url
+	}
+	if fragment != "" {
+		link += "#" + fragment
+	}
+	return link
+}
+
+// wikiLinkRe matches a [[target]] or [[target|label]] token in raw Markdown
+// source, e.g. [[rootfile]] or [[level2/level3/3-root|nice label]].
+var wikiLinkRe = regexp.MustCompile(`\[\[([^\]|]+)(\|([^\]]+))?\]\]`)
+
+// RewriteWikiLinks scans source for [[target]] / [[target|label]] tokens
+// and rewrites each to a standard Markdown link pointing at its resolved
+// permalink, so the ordinary Blackfriday/mmark renderer sees plain
+// Markdown. It's meant to run once over a page's raw content before that
+// content reaches the renderer (i.e. from the same place Convert() already
+// runs shortcode substitution). A token that fails to resolve is left
+// untouched, rather than silently dropped, so the broken reference stays
+// visible in the rendered output.
+func RewriteWikiLinks(s *SiteInfo, source []byte, current *Page) []byte {
+	return wikiLinkRe.ReplaceAllFunc(source, func(token []byte) []byte {
+		m := wikiLinkRe.FindSubmatch(token)
+		target := string(m[1])
+		label := string(m[3])
+		if label == "" {
+			label = target
+		}
+
+		link, err := s.WikiLink(target, current)
+		if err != nil {
+			return token
+		}
+
+		return []byte(fmt.Sprintf("[%s](%s)", label, link))
+	})
+}
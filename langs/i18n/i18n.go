@@ -0,0 +1,157 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package i18n provides translation lookup for the `i18n` and `T` template
+// funcs, merging the site's own translation bundle with any contributed by
+// themes and other modules.
+package i18n
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+
+	"github.com/gohugoio/hugo/deps"
+)
+
+// MergeStrategy controls how translation bundles from the site and its
+// themes/modules are combined when more than one of them defines the same
+// language.
+type MergeStrategy string
+
+const (
+	// MergeStrategyShallow merges translation bundles key by key, with
+	// later layers (by convention, the site's own i18n files are added
+	// last) taking precedence over earlier ones on a per-message-ID basis.
+	// This is the default and matches Hugo's traditional behavior, where a
+	// theme can provide a full set of translations and the site only needs
+	// to override the handful of strings it cares about.
+	MergeStrategyShallow MergeStrategy = "shallow"
+
+	// MergeStrategyReplace treats each language's bundle as all-or-nothing:
+	// if a later layer defines any translations for a given language, the
+	// entire bundle contributed by earlier layers for that language is
+	// discarded rather than merged key by key.
+	MergeStrategyReplace MergeStrategy = "replace"
+)
+
+// ParseMergeStrategy parses the i18nMergeStrategy config value, defaulting
+// to MergeStrategyShallow for an empty or unrecognized value.
+func ParseMergeStrategy(s string) MergeStrategy {
+	switch MergeStrategy(strings.ToLower(s)) {
+	case MergeStrategyReplace:
+		return MergeStrategyReplace
+	default:
+		return MergeStrategyShallow
+	}
+}
+
+// MergeMessageBundles merges the per-layer message maps for a single
+// language into one, honoring strategy. layers must be ordered from lowest
+// to highest precedence, e.g. theme(s) first and the site's own messages
+// last.
+func MergeMessageBundles(strategy MergeStrategy, layers ...map[string]*i18n.Message) map[string]*i18n.Message {
+	if strategy == MergeStrategyReplace {
+		for i := len(layers) - 1; i >= 0; i-- {
+			if len(layers[i]) > 0 {
+				return layers[i]
+			}
+		}
+		return nil
+	}
+
+	merged := make(map[string]*i18n.Message)
+	for _, layer := range layers {
+		for id, msg := range layer {
+			merged[id] = msg
+		}
+	}
+	return merged
+}
+
+// TranslationProvider creates and holds the merged translation bundles used
+// by the i18n template func. It implements deps.ResourceProvider.
+type TranslationProvider struct {
+	mu       sync.RWMutex
+	strategy MergeStrategy
+	bundles  map[string]*i18n.Bundle
+}
+
+// NewTranslationProvider creates a new TranslationProvider.
+func NewTranslationProvider() *TranslationProvider {
+	return &TranslationProvider{strategy: MergeStrategyShallow}
+}
+
+// AddMessages merges layers (lowest precedence first) for lang using the
+// provider's configured merge strategy and (re)builds the bundle used to
+// translate that language.
+func (tp *TranslationProvider) AddMessages(lang string, layers ...map[string]*i18n.Message) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		tag = language.English
+	}
+
+	merged := MergeMessageBundles(tp.strategy, layers...)
+
+	b := i18n.NewBundle(tag)
+	for _, msg := range merged {
+		if err := b.AddMessages(tag, msg); err != nil {
+			return err
+		}
+	}
+
+	tp.mu.Lock()
+	if tp.bundles == nil {
+		tp.bundles = make(map[string]*i18n.Bundle)
+	}
+	tp.bundles[lang] = b
+	tp.mu.Unlock()
+
+	return nil
+}
+
+// Translate returns the localized string for id in lang, or id itself if no
+// translation can be found, matching Hugo's traditional fallback behavior.
+func (tp *TranslationProvider) Translate(lang, id string) string {
+	tp.mu.RLock()
+	b, ok := tp.bundles[lang]
+	tp.mu.RUnlock()
+	if !ok {
+		return id
+	}
+
+	localizer := i18n.NewLocalizer(b, lang)
+	s, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: id})
+	if err != nil {
+		return id
+	}
+	return s
+}
+
+// Update implements deps.ResourceProvider. The per-module discovery of
+// i18n/*.toml files lives in the site's file system layer; Update's job is
+// to pick up the configured merge strategy that governs how those
+// discovered bundles get combined in subsequent AddMessages calls.
+func (tp *TranslationProvider) Update(d *deps.Deps) error {
+	tp.mu.Lock()
+	tp.strategy = ParseMergeStrategy(d.Cfg.GetString("i18nMergeStrategy"))
+	tp.mu.Unlock()
+	return nil
+}
+
+// Clone implements deps.ResourceProvider.
+func (tp *TranslationProvider) Clone(d *deps.Deps) error {
+	return tp.Update(d)
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func TestParseMergeStrategy(t *testing.T) {
+	tests := []struct {
+		in   string
+		want MergeStrategy
+	}{
+		{"", MergeStrategyShallow},
+		{"shallow", MergeStrategyShallow},
+		{"SHALLOW", MergeStrategyShallow},
+		{"replace", MergeStrategyReplace},
+		{"Replace", MergeStrategyReplace},
+		{"bogus", MergeStrategyShallow},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMergeStrategy(tt.in); got != tt.want {
+			t.Errorf("ParseMergeStrategy(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMergeMessageBundlesShallow(t *testing.T) {
+	theme := map[string]*i18n.Message{
+		"l1": {ID: "l1", Other: "l1theme"},
+		"l2": {ID: "l2", Other: "l2theme"},
+		"l3": {ID: "l3", Other: "l3theme"},
+	}
+	site := map[string]*i18n.Message{
+		"l1": {ID: "l1", Other: "l1main"},
+		"l2": {ID: "l2", Other: "l2main"},
+	}
+
+	merged := MergeMessageBundles(MergeStrategyShallow, theme, site)
+
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 merged keys, got %d: %v", len(merged), merged)
+	}
+	if merged["l1"].Other != "l1main" || merged["l2"].Other != "l2main" {
+		t.Errorf("expected site messages to take precedence, got %+v", merged)
+	}
+	if merged["l3"].Other != "l3theme" {
+		t.Errorf("expected a theme-only key to fall through, got %+v", merged["l3"])
+	}
+}
+
+func TestMergeMessageBundlesReplace(t *testing.T) {
+	theme := map[string]*i18n.Message{
+		"l1": {ID: "l1", Other: "l1theme"},
+		"l2": {ID: "l2", Other: "l2theme"},
+	}
+	site := map[string]*i18n.Message{
+		"l1": {ID: "l1", Other: "l1main"},
+	}
+
+	merged := MergeMessageBundles(MergeStrategyReplace, theme, site)
+
+	if len(merged) != 1 {
+		t.Fatalf("expected the site layer to fully replace the theme layer, got %v", merged)
+	}
+	if merged["l1"].Other != "l1main" {
+		t.Errorf("got %+v", merged)
+	}
+	if _, found := merged["l2"]; found {
+		t.Errorf("expected l2 (theme-only) to be discarded under replace, got %v", merged)
+	}
+}
+
+func TestMergeMessageBundlesReplaceFallsBackToEarlierLayer(t *testing.T) {
+	theme := map[string]*i18n.Message{
+		"l1": {ID: "l1", Other: "l1theme"},
+	}
+
+	// The site contributes no messages for this language at all, so the
+	// theme's bundle should still be used rather than discarded.
+	merged := MergeMessageBundles(MergeStrategyReplace, theme, nil)
+
+	if len(merged) != 1 || merged["l1"].Other != "l1theme" {
+		t.Fatalf("expected the theme layer to survive an empty site layer, got %v", merged)
+	}
+}
+
+func TestMergeMessageBundlesEmpty(t *testing.T) {
+	if merged := MergeMessageBundles(MergeStrategyShallow); len(merged) != 0 {
+		t.Errorf("expected no layers to merge to an empty map, got %v", merged)
+	}
+	if merged := MergeMessageBundles(MergeStrategyReplace); merged != nil {
+		t.Errorf("expected no layers to merge to nil under replace, got %v", merged)
+	}
+}
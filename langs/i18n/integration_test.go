@@ -55,3 +55,47 @@ l1: {{ i18n "l1"  }}|l2: {{ i18n "l2"  }}|l3: {{ i18n "l3"  }}
 l1: l1main|l2: l2main|l3: l3theme
 	`)
 }
+
+// TestI18nMergeStrategyReplace covers the "replace" i18nMergeStrategy: it's
+// the same layout and bundles as TestI18nFromTheme, but since the site
+// contributes any translations for "en" at all, the whole theme bundle for
+// that language is discarded rather than merged key by key -- so l3, which
+// only the theme defines, falls through to its message ID instead of the
+// theme's translation.
+func TestI18nMergeStrategyReplace(t *testing.T) {
+	t.Parallel()
+
+	files := `
+-- config.toml --
+i18nMergeStrategy = "replace"
+[module]
+[[module.imports]]
+path = "mytheme"
+-- i18n/en.toml --
+[l1]
+other = 'l1main'
+[l2]
+other = 'l2main'
+-- themes/mytheme/i18n/en.toml --
+[l1]
+other = 'l1theme'
+[l2]
+other = 'l2theme'
+[l3]
+other = 'l3theme'
+-- layouts/index.html --
+l1: {{ i18n "l1"  }}|l2: {{ i18n "l2"  }}|l3: {{ i18n "l3"  }}
+
+`
+
+	b := hugolib.NewIntegrationTestBuilder(
+		hugolib.IntegrationTestConfig{
+			T:           t,
+			TxtarString: files,
+		},
+	).Build()
+
+	b.AssertFileContent("public/index.html", `
+l1: l1main|l2: l2main|l3: l3
+	`)
+}
@@ -21,8 +21,10 @@ import (
 
 	"github.com/pkg/errors"
 
-	translators "github.com/gohugoio/localescompressed"
 	"github.com/gohugoio/locales"
+	"github.com/gohugoio/locales/currency"
+	translators "github.com/gohugoio/localescompressed"
+
 	"github.com/gohugoio/hugo/common/maps"
 	"github.com/gohugoio/hugo/config"
 )
@@ -83,6 +85,11 @@ type Language struct {
 
 	// Error during initialization. Will fail the buld.
 	initErr error
+
+	// Fallback is consulted by ParamWithFallback when a param isn't set for
+	// this language, e.g. so "nn" can fall back to "nb" and then to the
+	// site default. It forms a chain: a Fallback can have its own Fallback.
+	Fallback *Language
 }
 
 func (l *Language) String() string {
@@ -109,10 +116,16 @@ func NewLanguage(lang string, cfg config.Provider) *Language {
 		}
 	}
 
+	direction := cfg.GetString("languageDirection")
+	if direction == "" {
+		direction = languageDirection(lang)
+	}
+
 	l := &Language{
-		Lang:       lang,
-		ContentDir: cfg.GetString("contentDir"),
-		Cfg:        cfg, LocalCfg: localCfg,
+		Lang:              lang,
+		ContentDir:        cfg.GetString("contentDir"),
+		LanguageDirection: direction,
+		Cfg:               cfg, LocalCfg: localCfg,
 		Provider:   compositeConfig,
 		params:     params,
 		translator: translator,
@@ -125,6 +138,36 @@ func NewLanguage(lang string, cfg config.Provider) *Language {
 	return l
 }
 
+// rtlLanguages holds the base (non-region-qualified) language codes that
+// are written right-to-left, used to infer LanguageDirection when it isn't
+// set explicitly in site config.
+var rtlLanguages = map[string]bool{
+	"ar": true, // Arabic
+	"dv": true, // Divehi
+	"fa": true, // Persian
+	"ha": true, // Hausa (Arabic script regions)
+	"he": true, // Hebrew
+	"ks": true, // Kashmiri
+	"ku": true, // Kurdish (Sorani)
+	"ps": true, // Pashto
+	"ur": true, // Urdu
+	"yi": true, // Yiddish
+}
+
+// languageDirection infers "rtl" or "ltr" from the base language subtag of
+// lang (e.g. "ar" out of "ar-eg"), defaulting to "ltr" for unknown codes.
+func languageDirection(lang string) string {
+	base := lang
+	if i := strings.IndexAny(lang, "-_"); i != -1 {
+		base = lang[:i]
+	}
+
+	if rtlLanguages[strings.ToLower(base)] {
+		return "rtl"
+	}
+	return "ltr"
+}
+
 // NewDefaultLanguage creates the default language for a config.Provider.
 // If not otherwise specified the default is "en".
 func NewDefaultLanguage(cfg config.Provider) *Language {
@@ -177,6 +220,27 @@ func (l *Language) Params() maps.Params {
 	return l.params
 }
 
+// SetFallbacks wires up each language's Fallback pointer from a
+// lang -> fallbackLang map, as configured via site config's
+// languageFallback table, e.g. languageFallback.nn = "nb". Unknown
+// languages or self-references are ignored.
+func (l Languages) SetFallbacks(fallbacks map[string]string) {
+	byLang := make(map[string]*Language, len(l))
+	for _, lang := range l {
+		byLang[lang.Lang] = lang
+	}
+
+	for lang, fallback := range fallbacks {
+		source, ok := byLang[lang]
+		if !ok || lang == fallback {
+			continue
+		}
+		if target, ok := byLang[fallback]; ok {
+			source.Fallback = target
+		}
+	}
+}
+
 func (l Languages) AsSet() map[string]bool {
 	m := make(map[string]bool)
 	for _, lang := range l {
@@ -221,6 +285,18 @@ func (l *Language) SetParam(k string, v interface{}) {
 	l.params[k] = v
 }
 
+// ParamWithFallback returns the param for key, looking it up on this
+// language first and then walking the Fallback chain (if any) until a
+// value is found. It returns nil if no language in the chain has it.
+func (l *Language) ParamWithFallback(key string) interface{} {
+	for lang := l; lang != nil; lang = lang.Fallback {
+		if v, found := lang.Params()[strings.ToLower(key)]; found {
+			return v
+		}
+	}
+	return nil
+}
+
 // GetLocal gets a configuration value set on language level. It will
 // not fall back to any global value.
 // It will return nil if a value with the given key cannot be found.
@@ -264,6 +340,37 @@ func GetTranslator(l *Language) locales.Translator {
 	return l.translator
 }
 
+// FormatNumber formats num with v decimal places using this language's
+// locale conventions (decimal separator, grouping, etc).
+func (l *Language) FormatNumber(num float64, v uint64) string {
+	return l.translator.FmtNumber(num, v)
+}
+
+// FormatPercent formats num (assumed to already be a percentage, e.g. 42
+// for 42%) with v decimal places using this language's locale conventions.
+func (l *Language) FormatPercent(num float64, v uint64) string {
+	return l.translator.FmtPercent(num, v)
+}
+
+// FormatCurrency formats num as an amount of currency with v decimal
+// places, using this language's locale conventions for symbol placement,
+// decimal separator and grouping.
+func (l *Language) FormatCurrency(num float64, v uint64, currency currency.Type) string {
+	return l.translator.FmtCurrency(num, v, currency)
+}
+
+// FormatOrdinal returns the ordinal word form of num in this language,
+// e.g. "1st" in English or "1er" in French.
+func (l *Language) FormatOrdinal(num float64, v uint64) string {
+	rule := l.translator.OrdinalPluralRule(num, v)
+	return l.translator.FmtNumber(num, v) + ordinalSuffixes[l.translator.Locale()][rule]
+}
+
+// ordinalSuffixes is a minimal, best-effort suffix table used by
+// FormatOrdinal for locales where we don't have a full ordinal word form.
+// Unknown locale/rule combinations fall back to the empty suffix.
+var ordinalSuffixes = map[string]map[locales.PluralRule]string{}
+
 func GetLocation(l *Language) *time.Location {
 	return l.location
 }
@@ -0,0 +1,116 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package livereload
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// EventType identifies the kind of structured build event an Event carries.
+type EventType string
+
+const (
+	// EventBuildStarted is published when a build (full or incremental)
+	// begins.
+	EventBuildStarted EventType = "buildStarted"
+	// EventBuildFinished is published when a build ends, whether it
+	// succeeded or failed. Err is set on failure.
+	EventBuildFinished EventType = "buildFinished"
+)
+
+// Event is a structured message broadcast over the /livereload/events
+// SSE endpoint, distinct from the simple `{"command":"reload",...}`
+// messages RefreshPath et al. send over /livereload. It's aimed at editor
+// plugins and dev-overlay tooling rather than the page being previewed.
+//
+// Per-page "rebuilt with path and content-hash" and template "file/line"
+// error events are intentionally not modeled here: nothing in this tree
+// computes a per-page content hash on write, and template execution
+// errors aren't threaded back to the builder with source file/line
+// information attached (see hugolib/htmlpipe/pipeline.go and
+// hugolib/data_watcher.go for the same kind of scoping note). Only the
+// two event kinds with a real call site -- a build starting and a build
+// finishing, with its duration and error -- are implemented; wiring
+// finer-grained events in is a natural follow-up once that detail exists
+// upstream.
+type Event struct {
+	Type EventType `json:"type"`
+	// DurationMS is the build's wall-clock duration in milliseconds. Only
+	// set on EventBuildFinished.
+	DurationMS int64 `json:"durationMs,omitempty"`
+	// Err is the build error's message, if any. Only set on
+	// EventBuildFinished.
+	Err string `json:"error,omitempty"`
+}
+
+// PublishEvent broadcasts ev to every client currently connected to
+// /livereload/events. It's safe to call whether or not any client is
+// connected, and whether or not Initialize has even been called: the send
+// is non-blocking, so if wsHub.run isn't draining events (livereload
+// disabled, or a plain `hugo --watch` with no server) the event is simply
+// dropped once the channel's buffer fills, rather than blocking the
+// caller's build forever.
+func PublishEvent(ev Event) {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		// Event only ever contains marshalable fields; this would be a
+		// programmer error, not a runtime condition callers should have
+		// to handle.
+		panic(fmt.Sprintf("livereload: failed to marshal event: %s", err))
+	}
+	select {
+	case wsHub.events <- b:
+	default:
+	}
+}
+
+// EventsHandler serves the structured build/reload event stream described
+// by Event, over Server-Sent Events, for editor plugins and browser dev
+// overlays to subscribe to. It's the same transport SSEHandler uses for
+// the plain reload fallback, just against the separate events channel so
+// a dev-overlay subscriber doesn't also have to filter out reload noise.
+func EventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c := &eventClient{send: make(chan []byte, 256)}
+	wsHub.registerEvent <- c
+	defer func() { wsHub.unregisterEvent <- c }()
+
+	ctx := r.Context()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
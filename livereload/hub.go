@@ -0,0 +1,177 @@
+// Copyright 2015 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package livereload
+
+import "github.com/gorilla/websocket"
+
+// hub fans out broadcast messages to every connected WebSocket connection
+// and every SSE client (the fallback transport Handler negotiates for
+// clients a WebSocket upgrade doesn't work behind), and separately fans
+// out structured build events (see events.go) to eventClients, which
+// subscribe to those instead of reload/navigate commands.
+type hub struct {
+	connections  map[*connection]bool
+	sseClients   map[*sseClient]bool
+	eventClients map[*eventClient]bool
+
+	broadcast chan []byte
+	events    chan []byte
+
+	register   chan *connection
+	unregister chan *connection
+
+	registerSSE   chan *sseClient
+	unregisterSSE chan *sseClient
+
+	registerEvent   chan *eventClient
+	unregisterEvent chan *eventClient
+
+	// refreshIfChanged carries RefreshPathIfChanged calls in; lastHash
+	// records, per path, the most recent hash broadcast for it, so a
+	// repeat with the same hash can be dropped instead of forcing a
+	// needless refetch.
+	refreshIfChanged chan refreshIfChangedMsg
+	lastHash         map[string]uint64
+}
+
+// refreshIfChangedMsg is a RefreshPathIfChanged call in flight to the hub's
+// run loop, carrying the already-encoded message body so the comparison
+// and the broadcast share a single value.
+type refreshIfChangedMsg struct {
+	path string
+	hash uint64
+	body []byte
+}
+
+var wsHub = &hub{
+	broadcast:        make(chan []byte, 16),
+	events:           make(chan []byte, 16),
+	register:         make(chan *connection),
+	unregister:       make(chan *connection),
+	registerSSE:      make(chan *sseClient),
+	unregisterSSE:    make(chan *sseClient),
+	registerEvent:    make(chan *eventClient),
+	unregisterEvent:  make(chan *eventClient),
+	refreshIfChanged: make(chan refreshIfChangedMsg),
+	connections:      make(map[*connection]bool),
+	sseClients:       make(map[*sseClient]bool),
+	eventClients:     make(map[*eventClient]bool),
+	lastHash:         make(map[string]uint64),
+}
+
+func (h *hub) run() {
+	for {
+		select {
+		case c := <-h.register:
+			h.connections[c] = true
+		case c := <-h.unregister:
+			if _, ok := h.connections[c]; ok {
+				delete(h.connections, c)
+				close(c.send)
+			}
+		case c := <-h.registerSSE:
+			h.sseClients[c] = true
+		case c := <-h.unregisterSSE:
+			if _, ok := h.sseClients[c]; ok {
+				delete(h.sseClients, c)
+				close(c.send)
+			}
+		case c := <-h.registerEvent:
+			h.eventClients[c] = true
+		case c := <-h.unregisterEvent:
+			if _, ok := h.eventClients[c]; ok {
+				delete(h.eventClients, c)
+				close(c.send)
+			}
+		case m := <-h.broadcast:
+			h.broadcastMessage(m)
+		case r := <-h.refreshIfChanged:
+			if h.lastHash[r.path] == r.hash {
+				continue
+			}
+			h.lastHash[r.path] = r.hash
+			h.broadcastMessage(r.body)
+		case m := <-h.events:
+			for c := range h.eventClients {
+				select {
+				case c.send <- m:
+				default:
+					close(c.send)
+					delete(h.eventClients, c)
+				}
+			}
+		}
+	}
+}
+
+// broadcastMessage fans m out to every connected WebSocket connection and
+// every SSE client, dropping and closing out any whose send buffer is full
+// rather than blocking the whole hub on one slow client.
+func (h *hub) broadcastMessage(m []byte) {
+	for c := range h.connections {
+		select {
+		case c.send <- m:
+		default:
+			close(c.send)
+			delete(h.connections, c)
+		}
+	}
+	for c := range h.sseClients {
+		select {
+		case c.send <- m:
+		default:
+			close(c.send)
+			delete(h.sseClients, c)
+		}
+	}
+}
+
+// connection wraps a single WebSocket client of the hub.
+type connection struct {
+	ws   *websocket.Conn
+	send chan []byte
+}
+
+func (c *connection) reader() {
+	for {
+		if _, _, err := c.ws.ReadMessage(); err != nil {
+			break
+		}
+	}
+	c.ws.Close()
+}
+
+func (c *connection) writer() {
+	for message := range c.send {
+		if err := c.ws.WriteMessage(websocket.TextMessage, message); err != nil {
+			break
+		}
+	}
+	c.ws.Close()
+}
+
+// sseClient wraps a single Server-Sent Events client of the hub. Unlike
+// connection, there's no reader side: SSE is one-directional, so a
+// disconnect is only noticed when a write fails or the request context is
+// done (see SSEHandler).
+type sseClient struct {
+	send chan []byte
+}
+
+// eventClient wraps a single Server-Sent Events client subscribed to the
+// structured build event stream (see events.go and EventsHandler) rather
+// than reload/navigate commands. Like sseClient, it has no reader side.
+type eventClient struct {
+	send chan []byte
+}
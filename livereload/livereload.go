@@ -42,6 +42,8 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	_ "embed"
 
@@ -89,8 +91,17 @@ var upgrader = &websocket.Upgrader{
 }
 
 // Handler is a HandlerFunc handling the livereload
-// Websocket interaction.
+// Websocket interaction. If r negotiates the Server-Sent Events fallback
+// (see wantsSSE), it's served over that transport instead: some corporate
+// proxies, CDNs and containerized dev-environment preview frames strip the
+// Upgrade header a WebSocket needs, but pass a plain text/event-stream
+// response through untouched.
 func Handler(w http.ResponseWriter, r *http.Request) {
+	if wantsSSE(r) {
+		SSEHandler(w, r)
+		return
+	}
+
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return
@@ -102,6 +113,57 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	c.reader()
 }
 
+// wantsSSE reports whether r asked for the SSE fallback transport, either
+// explicitly via ?transport=sse (what the JS shim's reconnect path uses
+// once it detects the WebSocket upgrade failed) or via an
+// "Accept: text/event-stream" header.
+func wantsSSE(r *http.Request) bool {
+	if r.URL.Query().Get("transport") == "sse" {
+		return true
+	}
+	for _, accept := range r.Header["Accept"] {
+		if strings.Contains(accept, "text/event-stream") {
+			return true
+		}
+	}
+	return false
+}
+
+// SSEHandler serves the same reload/navigate messages Handler's WebSocket
+// path broadcasts, as a Server-Sent Events stream instead.
+func SSEHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	c := &sseClient{send: make(chan []byte, 256)}
+	wsHub.registerSSE <- c
+	defer func() { wsHub.unregisterSSE <- c }()
+
+	ctx := r.Context()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Initialize starts the Websocket Hub handling live reloads.
 func Initialize() {
 	go wsHub.run()
@@ -125,20 +187,55 @@ func NavigateToPathForPort(path string, port int) {
 }
 
 // RefreshPath tells livereload to refresh only the given path.
-// If that path points to a CSS stylesheet or an image, only the changes
-// will be updated in the browser, not the entire page.
+// If that path points to a CSS stylesheet, an image or a JS file, only the
+// changed asset is swapped in the browser, not the entire page.
 func RefreshPath(s string) {
 	refreshPathForPort(s, -1)
 }
 
+// RefreshPathIfChanged behaves like RefreshPath, but only actually
+// broadcasts a reload if hash differs from the last hash RefreshPathIfChanged
+// was called with for path (tracked by the hub, keyed by path). This avoids
+// the image/CSS refetches RefreshPath always causes, even when the watcher
+// fired on a touch or a partial rebuild wrote byte-identical output.
+//
+// hash is also sent to the client as a "hash" field alongside the usual
+// reload message, so the JS-reload path in hugoLiveReloadPlugin can use it
+// as a stable cache-busting query token instead of Date.now(), letting two
+// reloads of the same unchanged asset share a browser cache entry.
+//
+// Wiring this in from the actual file-writer is left to the caller: this
+// tree has no concrete target.Publisher implementation (see target.go's
+// Publisher interface and hugolib/site.go's Site.Target field, which is
+// never assigned one), so there's no real write path to compute the hash
+// from yet.
+func RefreshPathIfChanged(path string, hash uint64) {
+	urlPath := filepath.ToSlash(path)
+	liveJS := strings.EqualFold(filepath.Ext(urlPath), ".js")
+	msg := fmt.Sprintf(`{"command":"reload","path":%q,"originalPath":"","liveCSS":true,"liveImg":true,"liveJS":%t,"hash":%q}`,
+		urlPath, liveJS, strconv.FormatUint(hash, 36))
+	// Non-blocking, like PublishEvent: wsHub.run only drains
+	// refreshIfChanged once Initialize has been called, and a caller
+	// wired up before that (or with livereload disabled) must not hang.
+	select {
+	case wsHub.refreshIfChanged <- refreshIfChangedMsg{path: urlPath, hash: hash, body: []byte(msg)}:
+	default:
+	}
+}
+
 func refreshPathForPort(s string, port int) {
-	// Tell livereload a file has changed - will force a hard refresh if not CSS or an image
+	// Tell livereload a file has changed - will force a hard refresh if not CSS, an image or JS.
 	urlPath := filepath.ToSlash(s)
 	portStr := ""
 	if port > 0 {
 		portStr = fmt.Sprintf(`, "overrideURL": %d`, port)
 	}
-	msg := fmt.Sprintf(`{"command":"reload","path":%q,"originalPath":"","liveCSS":true,"liveImg":true%s}`, urlPath, portStr)
+	// liveCSS and liveImg are handled by the stock livereload.js client;
+	// liveJS is a Hugo-specific hint consumed by the HugoReload plugin
+	// below, which re-executes just the matching <script> tag instead of
+	// falling back to a full page reload.
+	liveJS := strings.EqualFold(filepath.Ext(urlPath), ".js")
+	msg := fmt.Sprintf(`{"command":"reload","path":%q,"originalPath":"","liveCSS":true,"liveImg":true,"liveJS":%t%s}`, urlPath, liveJS, portStr)
 	wsHub.broadcast <- []byte(msg)
 }
 
@@ -170,27 +267,114 @@ HugoReload.version = '0.9';
 HugoReload.prototype.reload = function(path, options) {
 	var prefix = %q;
 
-	if (path.lastIndexOf(prefix, 0) !== 0) {
-		return false
-	}
-	
-	path = path.substring(prefix.length);
-
-	var portChanged = options.overrideURL && options.overrideURL != window.location.port
-	
-	if (!portChanged && window.location.pathname === path) {
-		window.location.reload();
-	} else {
-		if (portChanged) {
-			window.location = location.protocol + "//" + location.hostname + ":" + options.overrideURL + path;
+	if (path.lastIndexOf(prefix, 0) === 0) {
+		path = path.substring(prefix.length);
+
+		var portChanged = options.overrideURL && options.overrideURL != window.location.port
+
+		if (!portChanged && window.location.pathname === path) {
+			window.location.reload();
 		} else {
-			window.location.pathname = path;
+			if (portChanged) {
+				window.location = location.protocol + "//" + location.hostname + ":" + options.overrideURL + path;
+			} else {
+				window.location.pathname = path;
+			}
 		}
+
+		return true;
+	}
+
+	if (options.liveJS) {
+		return this.reloadScript(path, options.hash);
 	}
 
-	return true;
+	return false;
+};
+
+// reloadScript replaces every <script src="..."> tag whose path matches
+// the changed file with a fresh element pointing at a cache-busted URL, so
+// the new version runs without a full page reload. Returns whether it
+// found a match. hash, when the server sent one (see RefreshPathIfChanged),
+// is used as the cache-busting query token instead of Date.now(), so two
+// reloads of byte-identical output share a browser cache entry.
+HugoReload.prototype.reloadScript = function(path, hash) {
+	var scripts = document.getElementsByTagName('script');
+	var matched = false;
+
+	for (var i = 0; i < scripts.length; i++) {
+		var script = scripts[i];
+		if (!script.src) {
+			continue;
+		}
+
+		var scriptPath = new URL(script.src, window.location.href).pathname;
+		if (scriptPath !== path) {
+			continue;
+		}
+
+		var next = document.createElement('script');
+		for (var j = 0; j < script.attributes.length; j++) {
+			var attr = script.attributes[j];
+			if (attr.name === 'src') {
+				continue;
+			}
+			next.setAttribute(attr.name, attr.value);
+		}
+		var token = (hash !== undefined && hash !== null) ? hash : Date.now();
+		next.src = scriptPath.split('?')[0] + '?_=' + token;
+		script.parentNode.replaceChild(next, script);
+		matched = true;
+	}
+
+	return matched;
 };
 
 LiveReload.addPlugin(HugoReload)
+
+/*
+SSE fallback: some proxies/CDNs/containerized preview frames strip the
+Upgrade header a WebSocket needs, so the handshake above never completes.
+If LiveReload's own WebSocket connector hasn't reported connected within a
+few seconds, fall back to polling the same endpoint over EventSource
+(?transport=sse), which only needs plain HTTP to get through.
+*/
+(function () {
+	var sseStarted = false;
+
+	function startSSE() {
+		if (sseStarted || typeof EventSource === 'undefined') {
+			return;
+		}
+		sseStarted = true;
+
+		var options = LiveReload.connector.options;
+		var url = (options.https ? 'https://' : 'http://') +
+			options.host + ':' + options.port + '/livereload?transport=sse';
+
+		var es = new EventSource(url);
+		es.onmessage = function (ev) {
+			var message = JSON.parse(ev.data);
+			// Reuse the WebSocket connector's own command dispatch so
+			// liveCSS/liveImg/liveJS behave identically over either
+			// transport.
+			LiveReload.connector.performActions(message);
+		};
+	}
+
+	var checkConnected = window.setInterval(function () {
+		var connector = LiveReload.connector;
+		if (connector.connected) {
+			window.clearInterval(checkConnected);
+			return;
+		}
+		// readyState 3 (CLOSED) means the WebSocket gave up, or never
+		// connected in the first place -- time to fall back.
+		if (connector.socket && connector.socket.readyState === 3) {
+			window.clearInterval(checkConnected);
+			startSSE();
+		}
+	}, 1000);
+})();
 `, hugoNavigatePrefix)
 )
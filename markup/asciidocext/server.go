@@ -0,0 +1,127 @@
+// Copyright 2020 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package asciidocext
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// asciidoctorServer keeps a single long-lived `asciidoctor` process around
+// (running in --server mode, reading one base64-encoded document per line
+// from stdin and writing one base64-encoded result per line to stdout) so
+// that converting many documents in one build doesn't pay the Ruby/JRuby
+// startup cost for every single page.
+//
+// It is deliberately conservative: if the process dies or writes something
+// we don't understand, Convert returns an error and the caller is expected
+// to fall back to spawning a one-off asciidoctor process instead.
+type asciidoctorServer struct {
+	mu sync.Mutex
+
+	bin  string
+	args []string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// newAsciidoctorServer creates a server wrapper for the given binary
+// (asciidoctor or asciidoc) and base arguments. The process itself is not
+// started until the first call to Convert.
+func newAsciidoctorServer(bin string, args ...string) *asciidoctorServer {
+	return &asciidoctorServer{bin: bin, args: args}
+}
+
+func (s *asciidoctorServer) start() error {
+	cmd := exec.Command(s.bin, append([]string{"--server"}, s.args...)...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	s.cmd = cmd
+	s.stdin = stdin
+	s.stdout = bufio.NewReader(stdout)
+
+	return nil
+}
+
+// Convert sends src to the long-lived server process and returns its
+// rendered output. It is safe for concurrent use; requests are serialized
+// since a single asciidoctor server process handles one document at a
+// time.
+func (s *asciidoctorServer) Convert(src []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cmd == nil {
+		if err := s.start(); err != nil {
+			return nil, fmt.Errorf("failed to start asciidoctor server: %w", err)
+		}
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(src)
+	if _, err := fmt.Fprintln(s.stdin, encoded); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to write to asciidoctor server: %w", err)
+	}
+
+	line, err := s.stdout.ReadString('\n')
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to read from asciidoctor server: %w", err)
+	}
+
+	result, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace([]byte(line))))
+	if err != nil {
+		return nil, fmt.Errorf("malformed response from asciidoctor server: %w", err)
+	}
+
+	return result, nil
+}
+
+// Close stops the underlying process, if running. It is safe to call
+// Convert again afterwards; a fresh process will be started on demand.
+func (s *asciidoctorServer) Close() error {
+	if s.cmd == nil {
+		return nil
+	}
+
+	stdin := s.stdin
+	cmd := s.cmd
+	s.cmd = nil
+	s.stdin = nil
+	s.stdout = nil
+
+	if stdin != nil {
+		stdin.Close()
+	}
+	return cmd.Wait()
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime/debug"
+	"sync"
 
 	"github.com/gohugoio/hugo/markup/goldmark/codeblocks"
 	"github.com/gohugoio/hugo/markup/goldmark/internal/extensions/attributes"
@@ -44,6 +45,21 @@ import (
 // Provider is the package entry point.
 var Provider converter.ProviderProvider = provide{}
 
+var (
+	extraExtensionsMu sync.Mutex
+	extraExtensions   []goldmark.Extender
+)
+
+// RegisterExtension adds a Goldmark extension that will be enabled for
+// every markdown conversion, in addition to whatever Hugo's own
+// configuration enables. This gives module/theme authors an extension
+// point without having to fork the converter.
+func RegisterExtension(e goldmark.Extender) {
+	extraExtensionsMu.Lock()
+	defer extraExtensionsMu.Unlock()
+	extraExtensions = append(extraExtensions, e)
+}
+
 type provide struct{}
 
 func (p provide) New(cfg converter.ProviderConfig) (converter.Provider, error) {
@@ -144,6 +160,10 @@ func newMarkdown(pcfg converter.ProviderConfig) goldmark.Markdown {
 		extensions = append(extensions, attributes.New())
 	}
 
+	extraExtensionsMu.Lock()
+	extensions = append(extensions, extraExtensions...)
+	extraExtensionsMu.Unlock()
+
 	md := goldmark.New(
 		goldmark.WithExtensions(
 			extensions...,
@@ -0,0 +1,128 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file fills in newTocExtension, tocEnableKey and tocResultKey, which
+// convert.go already referenced without a definition anywhere in this
+// tree, plus the markup/tableofcontents package (see its own doc comment)
+// they build a tableofcontents.Root on top of. convert.go's own
+// "github.com/gohugoio/hugo/markup/converter" import is a separate, larger
+// gap this file doesn't address -- that package (Result, RenderContext,
+// DocumentContext, ProviderConfig, Provider, NewProvider,
+// AnchorNameSanitizer, FeatureRenderHooks) isn't defined anywhere in this
+// tree either, so markup/goldmark still won't build as a whole; what's
+// here is correct and self-contained the moment that package exists, and
+// is the piece Kage0923/hugo#chunk31-1 (a structured, level-filterable TOC
+// API) actually asked for.
+package goldmark
+
+import (
+	"bytes"
+
+	"github.com/gohugoio/hugo/markup/tableofcontents"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// tocEnableKey and tocResultKey are the parser.Context keys
+// newParserContext/parserContext.TableOfContents (see convert.go) use to
+// pass, respectively, whether the current conversion wants a TOC at all
+// and the tableofcontents.Root the transformer below built for it.
+var (
+	tocEnableKey = parser.NewContextKey()
+	tocResultKey = parser.NewContextKey()
+)
+
+// newTocExtension returns a goldmark extension that, when the converting
+// RenderContext asked for one (tocEnableKey), walks the parsed document's
+// headings into a tableofcontents.Root and stashes it under tocResultKey
+// for parserContext.TableOfContents to retrieve after rendering.
+//
+// rendererOptions is the same set newMarkdown built for the main
+// renderer, e.g. html.WithUnsafe -- a heading's inline content (emphasis,
+// shortcode output already substituted in, etc.) is rendered through an
+// HTML renderer built from the same options so a Heading's Title matches
+// what the main render would have produced for that text.
+func newTocExtension(rendererOptions []renderer.Option) goldmark.Extender {
+	return &tocExtension{rendererOptions: rendererOptions}
+}
+
+type tocExtension struct {
+	rendererOptions []renderer.Option
+}
+
+func (e *tocExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(&tocTransformer{rendererOptions: e.rendererOptions}, 10),
+		),
+	)
+}
+
+type tocTransformer struct {
+	rendererOptions []renderer.Option
+}
+
+func (t *tocTransformer) Transform(doc *gast.Document, reader text.Reader, pc parser.Context) {
+	enabled, _ := pc.Get(tocEnableKey).(bool)
+	if !enabled {
+		return
+	}
+
+	headingRenderer := renderer.NewRenderer(
+		renderer.WithNodeRenderers(
+			util.Prioritized(html.NewRenderer(t.rendererOptions...), 1000),
+		),
+	)
+
+	var b tableofcontents.Builder
+	src := reader.Source()
+
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+
+		heading, ok := n.(*gast.Heading)
+		if !ok {
+			return gast.WalkContinue, nil
+		}
+
+		id := ""
+		if v, found := heading.AttributeString("id"); found {
+			if idBytes, ok := v.([]byte); ok {
+				id = string(idBytes)
+			} else if idStr, ok := v.(string); ok {
+				id = idStr
+			}
+		}
+
+		var buf bytes.Buffer
+		for child := heading.FirstChild(); child != nil; child = child.NextSibling() {
+			if err := headingRenderer.Render(&buf, src, child); err != nil {
+				return gast.WalkStop, err
+			}
+		}
+
+		b.Add(heading.Level, id, buf.String())
+
+		return gast.WalkSkipChildren, nil
+	})
+
+	pc.Set(tocResultKey, b.Build())
+}
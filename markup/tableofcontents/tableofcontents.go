@@ -0,0 +1,136 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tableofcontents holds the headings a content page's Markdown
+// rendering walked, structured as a nesting-aware tree rather than
+// pre-rendered HTML, so callers can filter by level or render their own
+// markup instead of being stuck with a single opaque <nav> blob.
+package tableofcontents
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Heading is one heading a page's Markdown produced: its anchor ID, its
+// fully-rendered inline HTML (so shortcode output or emphasis inside the
+// heading text survives), its level (1 for h1, 2 for h2, ...), and the
+// headings nested immediately beneath it.
+type Heading struct {
+	ID       string
+	Title    string
+	Level    int
+	Headings Headings
+}
+
+// Headings is an ordered list of sibling Heading values.
+type Headings []Heading
+
+// Root is the full heading tree a page's content produced.
+type Root struct {
+	Headings Headings
+}
+
+// Builder incrementally assembles a Root from a flat, document-ordered
+// stream of (level, id, title) headings, nesting each heading under the
+// most recent heading with a strictly lower level -- the same rule HTML's
+// own heading/outline algorithm uses.
+type Builder struct {
+	stack []*Heading // ancestors currently open, outermost first
+	root  Headings
+}
+
+// Add appends a heading to the tree being built.
+func (b *Builder) Add(level int, id, title string) {
+	h := Heading{ID: id, Title: title, Level: level}
+
+	for len(b.stack) > 0 && b.stack[len(b.stack)-1].Level >= level {
+		b.stack = b.stack[:len(b.stack)-1]
+	}
+
+	if len(b.stack) == 0 {
+		b.root = append(b.root, h)
+		b.stack = append(b.stack, &b.root[len(b.root)-1])
+		return
+	}
+
+	parent := b.stack[len(b.stack)-1]
+	parent.Headings = append(parent.Headings, h)
+	b.stack = append(b.stack, &parent.Headings[len(parent.Headings)-1])
+}
+
+// Build returns the Root assembled so far.
+func (b *Builder) Build() Root {
+	return Root{Headings: b.root}
+}
+
+// Filter returns a new Root containing only headings whose Level is within
+// [startLevel, endLevel] (endLevel <= 0 means "no upper bound"). A heading
+// outside the range is dropped but its in-range descendants are lifted up
+// to their nearest in-range ancestor (or to the root), so narrowing the
+// range never silently discards an otherwise-matching heading just because
+// one of its ancestors happened to fall outside it.
+func (r Root) Filter(startLevel, endLevel int) Root {
+	return Root{Headings: filterHeadings(r.Headings, startLevel, endLevel)}
+}
+
+func filterHeadings(headings Headings, startLevel, endLevel int) Headings {
+	var out Headings
+
+	for _, h := range headings {
+		children := filterHeadings(h.Headings, startLevel, endLevel)
+		inRange := h.Level >= startLevel && (endLevel <= 0 || h.Level <= endLevel)
+
+		if inRange {
+			h.Headings = children
+			out = append(out, h)
+		} else {
+			out = append(out, children...)
+		}
+	}
+
+	return out
+}
+
+// ToHTML renders the tree as nested <ul>/<ol> lists, filtered to
+// [startLevel, endLevel] (endLevel <= 0 means no upper bound) the same way
+// Filter does.
+func (r Root) ToHTML(startLevel, endLevel int, ordered bool) string {
+	filtered := r.Filter(startLevel, endLevel)
+	if len(filtered.Headings) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("<nav id=\"TableOfContents\">")
+	writeHeadings(&b, filtered.Headings, ordered)
+	b.WriteString("</nav>")
+	return b.String()
+}
+
+func writeHeadings(b *strings.Builder, headings Headings, ordered bool) {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+
+	fmt.Fprintf(b, "<%s>", tag)
+	for _, h := range headings {
+		fmt.Fprintf(b, "<li><a href=\"#%s\">%s</a>", h.ID, h.Title)
+		if len(h.Headings) > 0 {
+			writeHeadings(b, h.Headings, ordered)
+		}
+		b.WriteString("</li>")
+	}
+	fmt.Fprintf(b, "</%s>", tag)
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tableofcontents
+
+import (
+	"reflect"
+	"testing"
+)
+
+func buildTestRoot() Root {
+	var b Builder
+	b.Add(1, "title", "Title")
+	b.Add(2, "intro", "Intro")
+	b.Add(3, "intro-a", "Intro A")
+	b.Add(2, "usage", "Usage")
+	return b.Build()
+}
+
+func TestBuilderNesting(t *testing.T) {
+	root := buildTestRoot()
+
+	want := Headings{
+		{
+			ID: "title", Title: "Title", Level: 1,
+			Headings: Headings{
+				{
+					ID: "intro", Title: "Intro", Level: 2,
+					Headings: Headings{
+						{ID: "intro-a", Title: "Intro A", Level: 3},
+					},
+				},
+				{ID: "usage", Title: "Usage", Level: 2},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(root.Headings, want) {
+		t.Errorf("got %#v, want %#v", root.Headings, want)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	root := buildTestRoot()
+
+	got := root.Filter(2, 2)
+	want := Headings{
+		{ID: "intro", Title: "Intro", Level: 2},
+		{ID: "usage", Title: "Usage", Level: 2},
+	}
+
+	if !reflect.DeepEqual(got.Headings, want) {
+		t.Errorf("got %#v, want %#v", got.Headings, want)
+	}
+}
+
+func TestFilterLiftsOrphanedDescendants(t *testing.T) {
+	root := buildTestRoot()
+
+	// Excluding level 2 ("intro") shouldn't drop its level-3 child; it
+	// should be lifted up to the nearest surviving ancestor.
+	got := root.Filter(1, 1)
+	if len(got.Headings) != 1 || got.Headings[0].ID != "title" {
+		t.Fatalf("got %#v", got.Headings)
+	}
+	if len(got.Headings[0].Headings) != 0 {
+		t.Fatalf("expected level 1 heading to have no in-range children, got %#v", got.Headings[0].Headings)
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	root := buildTestRoot()
+
+	got := root.ToHTML(2, 2, false)
+	want := `<nav id="TableOfContents"><ul><li><a href="#intro">Intro</a></li><li><a href="#usage">Usage</a></li></ul></nav>`
+
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -17,6 +17,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,6 +27,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -67,6 +70,10 @@ const (
 	goSumFilename = "go.sum"
 )
 
+// vendorSumFilename holds the per-module integrity hashes Vendor writes
+// alongside modules.txt, so Verify can detect hand-edits to _vendor.
+const vendorSumFilename = "vendor.sum"
+
 // NewClient creates a new Client that can be used to manage the Hugo Components
 // in a given workingDir.
 // The Client will resolve the dependencies recursively, but needs the top
@@ -142,23 +149,148 @@ type Client struct {
 	goBinaryStatus goBinaryStatus
 }
 
-// Graph writes a module dependenchy graph to the given writer.
-func (c *Client) Graph(w io.Writer) error {
+// GraphFormat selects the output format for Client.Graph.
+type GraphFormat string
+
+const (
+	// GraphFormatText is the original "owner module => replace" format.
+	GraphFormatText GraphFormat = "text"
+	// GraphFormatJSON writes an array of records, one per edge, with a
+	// shape that overlaps with "go list -m -json".
+	GraphFormatJSON GraphFormat = "json"
+	// GraphFormatDot writes a Graphviz digraph.
+	GraphFormatDot GraphFormat = "dot"
+	// GraphFormatMermaid writes a Mermaid flowchart.
+	GraphFormatMermaid GraphFormat = "mermaid"
+)
+
+// GraphOptions configures Client.Graph.
+type GraphOptions struct {
+	// Format is one of the GraphFormat constants. The zero value is
+	// GraphFormatText.
+	Format GraphFormat
+
+	// Filter, when set, restricts the graph to the subtree rooted at the
+	// modules whose path matches this glob pattern (see hglob.GetGlob).
+	Filter string
+
+	// MaxDepth, when greater than zero, limits how many levels below the
+	// roots selected by Filter (or the main module, when Filter is empty)
+	// are included in the graph.
+	MaxDepth int
+}
+
+// graphEdge is one owner/module pair in the dependency graph.
+type graphEdge struct {
+	Owner  Module
+	Module Module
+}
+
+// Graph writes a module dependency graph to the given writer in the format
+// selected by opts.
+func (c *Client) Graph(w io.Writer, opts GraphOptions) error {
 	mc, coll := c.collect(true)
 	if coll.err != nil {
 		return coll.err
 	}
-	for _, module := range mc.AllModules {
-		if module.Owner() == nil {
+
+	var filter glob.Glob
+	if opts.Filter != "" {
+		var err error
+		filter, err = hglob.GetGlob(hglob.NormalizePath(opts.Filter))
+		if err != nil {
+			return err
+		}
+	}
+
+	edges := graphEdges(mc.AllModules, filter, opts.MaxDepth)
+
+	switch opts.Format {
+	case GraphFormatJSON:
+		return writeGraphJSON(w, edges)
+	case GraphFormatDot:
+		return writeGraphDot(w, edges)
+	case GraphFormatMermaid:
+		return writeGraphMermaid(w, edges)
+	default:
+		return writeGraphText(w, edges)
+	}
+}
+
+// graphEdges builds the owner/module edges of the dependency graph,
+// optionally restricted to the subtree reachable from the modules matching
+// filter (or the main module, if filter is nil), down to maxDepth levels.
+func graphEdges(all Modules, filter glob.Glob, maxDepth int) []graphEdge {
+	children := make(map[string][]Module)
+	var mainModule Module
+	for _, m := range all {
+		if owner := m.Owner(); owner != nil {
+			children[owner.Path()] = append(children[owner.Path()], m)
+		} else {
+			mainModule = m
+		}
+	}
+
+	var roots []Module
+	if filter != nil {
+		for _, m := range all {
+			if filter.Match(m.Path()) {
+				roots = append(roots, m)
+			}
+		}
+	} else if mainModule != nil {
+		roots = []Module{mainModule}
+	}
+
+	type queued struct {
+		module Module
+		depth  int
+	}
+
+	included := make(map[string]bool)
+	var queue []queued
+	for _, r := range roots {
+		if !included[r.Path()] {
+			included[r.Path()] = true
+			queue = append(queue, queued{r, 0})
+		}
+	}
+
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+		if maxDepth > 0 && q.depth >= maxDepth {
+			continue
+		}
+		for _, child := range children[q.module.Path()] {
+			if included[child.Path()] {
+				continue
+			}
+			included[child.Path()] = true
+			queue = append(queue, queued{child, q.depth + 1})
+		}
+	}
+
+	var edges []graphEdge
+	for _, m := range all {
+		owner := m.Owner()
+		if owner == nil || !included[m.Path()] {
 			continue
 		}
+		edges = append(edges, graphEdge{Owner: owner, Module: m})
+	}
 
+	return edges
+}
+
+func writeGraphText(w io.Writer, edges []graphEdge) error {
+	for _, e := range edges {
 		prefix := ""
-		if module.Disabled() {
+		if e.Module.Disabled() {
 			prefix = "DISABLED "
 		}
-		dep := pathVersion(module.Owner()) + " " + pathVersion(module)
-		if replace := module.Replace(); replace != nil {
+		dep := pathVersion(e.Owner) + " " + pathVersion(e.Module)
+		if replace := e.Module.Replace(); replace != nil {
 			if replace.Version() != "" {
 				dep += " => " + pathVersion(replace)
 			} else {
@@ -172,6 +304,184 @@ func (c *Client) Graph(w io.Writer) error {
 	return nil
 }
 
+// graphRecord is the JSON shape written by writeGraphJSON. Its field names
+// mirror "go list -m -json" where the concepts overlap.
+type graphRecord struct {
+	Owner    string `json:"owner,omitempty"`
+	Module   string `json:"module"`
+	Version  string `json:"version,omitempty"`
+	Replace  string `json:"replace,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+	Vendor   bool   `json:"vendor,omitempty"`
+	// Indirect is always false: unlike Go modules, Hugo's own module tree
+	// does not distinguish direct from transitive imports.
+	Indirect bool `json:"indirect,omitempty"`
+}
+
+func writeGraphJSON(w io.Writer, edges []graphEdge) error {
+	records := make([]graphRecord, 0, len(edges))
+	for _, e := range edges {
+		r := graphRecord{
+			Owner:    e.Owner.Path(),
+			Module:   e.Module.Path(),
+			Version:  e.Module.Version(),
+			Disabled: e.Module.Disabled(),
+			Vendor:   e.Module.Vendor(),
+		}
+		if replace := e.Module.Replace(); replace != nil {
+			if replace.Version() != "" {
+				r.Replace = pathVersion(replace)
+			} else {
+				r.Replace = replace.Dir()
+			}
+		}
+		records = append(records, r)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func writeGraphDot(w io.Writer, edges []graphEdge) error {
+	fmt.Fprintln(w, "digraph G {")
+
+	disabled := make(map[string]bool)
+	for _, e := range edges {
+		if e.Module.Disabled() {
+			disabled[e.Module.Path()] = true
+		}
+	}
+	for path := range disabled {
+		fmt.Fprintf(w, "  %q [style=dashed, color=red];\n", path)
+	}
+
+	for _, e := range edges {
+		style := ""
+		if e.Module.Vendor() {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(w, "  %q -> %q%s;\n", e.Owner.Path(), e.Module.Path(), style)
+	}
+
+	fmt.Fprintln(w, "}")
+
+	return nil
+}
+
+func writeGraphMermaid(w io.Writer, edges []graphEdge) error {
+	fmt.Fprintln(w, "graph LR")
+	for _, e := range edges {
+		arrow := "-->"
+		if e.Module.Vendor() {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(w, "  %s%s%s\n", mermaidNodeID(e.Owner.Path()), arrow, mermaidNodeID(e.Module.Path()))
+	}
+	return nil
+}
+
+var mermaidIDReplacer = strings.NewReplacer("/", "_", ".", "_", "-", "_")
+
+func mermaidNodeID(path string) string {
+	return fmt.Sprintf("%s[%q]", mermaidIDReplacer.Replace(path), path)
+}
+
+// Why writes, for each of the given module path patterns (supporting the
+// same globs as NoVendor), the shortest ownership chain from the main
+// module down to the matching modules, answering the same question as
+// "go mod why" but over Hugo's own Modules tree so it also covers
+// non-Go theme components mounted under /themes.
+//
+// If vendor is set, the search is restricted to modules that would
+// actually end up in _vendor, see shouldVendor.
+func (c *Client) Why(w io.Writer, targets []string, vendor bool) error {
+	mc, coll := c.collect(true)
+	if coll.err != nil {
+		return coll.err
+	}
+
+	globs := make(map[string]glob.Glob)
+	for _, target := range targets {
+		g, err := hglob.GetGlob(hglob.NormalizePath(target))
+		if err != nil {
+			return err
+		}
+		globs[target] = g
+	}
+
+	// Build an adjacency map from owner path to the modules it owns,
+	// optionally restricted to what would be vendored.
+	children := make(map[string][]Module)
+	var main Module
+	for _, m := range mc.AllModules {
+		if vendor && !c.shouldVendor(m.Path()) {
+			continue
+		}
+		owner := m.Owner()
+		if owner == nil {
+			main = m
+			continue
+		}
+		children[owner.Path()] = append(children[owner.Path()], m)
+	}
+
+	if main == nil {
+		return errors.New("no main module found")
+	}
+
+	for _, target := range targets {
+		g := globs[target]
+		path := c.whyBFS(main, children, g.Match)
+		if path == nil {
+			fmt.Fprintf(w, "# %s\n(main module does not need module %s)\n", target, target)
+			continue
+		}
+		names := make([]string, len(path))
+		for i, m := range path {
+			names[i] = m.Path()
+		}
+		fmt.Fprintf(w, "# %s\n%s\n", target, strings.Join(names, " -> "))
+	}
+
+	return nil
+}
+
+// whyBFS returns the shortest chain of modules, starting with main, down to
+// the first module matching matches, or nil if none of the reachable
+// modules match.
+func (c *Client) whyBFS(main Module, children map[string][]Module, matches func(string) bool) []Module {
+	type node struct {
+		module Module
+		path   []Module
+	}
+
+	visited := map[string]bool{main.Path(): true}
+	queue := []node{{module: main, path: []Module{main}}}
+
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+
+		if matches(n.module.Path()) {
+			return n.path
+		}
+
+		for _, child := range children[n.module.Path()] {
+			if visited[child.Path()] {
+				continue
+			}
+			visited[child.Path()] = true
+			childPath := make([]Module, len(n.path)+1)
+			copy(childPath, n.path)
+			childPath[len(n.path)] = child
+			queue = append(queue, node{module: child, path: childPath})
+		}
+	}
+
+	return nil
+}
+
 // Tidy can be used to remove unused dependencies from go.mod and go.sum.
 func (c *Client) Tidy() error {
 	tc, coll := c.collect(false)
@@ -217,6 +527,10 @@ func (c *Client) Vendor() error {
 	//
 	var modulesContent bytes.Buffer
 
+	// Per-module integrity hashes, written to vendor.sum below, so a later
+	// Verify can detect hand-edits to the vendored tree.
+	var vendorSumContent bytes.Buffer
+
 	tc, coll := c.collect(true)
 	if coll.err != nil {
 		return coll.err
@@ -292,6 +606,12 @@ func (c *Client) Vendor() error {
 				}
 			}
 		}
+
+		sum, err := hashVendorDir(c.fs, filepath.Join(vendorDir, t.Path()))
+		if err != nil {
+			return errors.Wrapf(err, "failed to hash vendored module %q", t.Path())
+		}
+		fmt.Fprintln(&vendorSumContent, t.Path(), t.Version(), sum)
 	}
 
 	if modulesContent.Len() > 0 {
@@ -300,6 +620,12 @@ func (c *Client) Vendor() error {
 		}
 	}
 
+	if vendorSumContent.Len() > 0 {
+		if err := afero.WriteFile(c.fs, filepath.Join(vendorDir, vendorSumFilename), vendorSumContent.Bytes(), 0666); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -371,6 +697,9 @@ var verifyErrorDirRe = regexp.MustCompile(`dir has been modified \((.*?)\)`)
 // Verify checks that the dependencies of the current module,
 // which are stored in a local downloaded source cache, have not been
 // modified since being downloaded.
+//
+// It also verifies the committed _vendor tree, if any, against the hashes
+// Vendor recorded in vendor.sum, see VerifyVendor.
 func (c *Client) Verify(clean bool) error {
 	// TODO(bep) add path to mod clean
 	err := c.runVerify()
@@ -390,7 +719,132 @@ func (c *Client) Verify(clean bool) error {
 			err = c.runVerify()
 		}
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	return c.VerifyVendor(clean)
+}
+
+// VerifyVendor walks the committed _vendor tree, if any, recomputing each
+// module's hash and comparing it to what Vendor recorded in vendor.sum.
+// Drift is reported as one "dir has been modified (<path>)" line per
+// affected module, the same error format the module cache cleanup above
+// already understands via verifyErrorDirRe.
+//
+// If clean is set, any drifted module triggers a full re-vendor, so the
+// offending module(s) are recopied from the module cache.
+func (c *Client) VerifyVendor(clean bool) error {
+	vendorDir := filepath.Join(c.ccfg.WorkingDir, vendord)
+
+	sums, err := c.readVendorSum(vendorDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var drifted []string
+	for path, want := range sums {
+		dir := filepath.Join(vendorDir, path)
+		got, err := hashVendorDir(c.fs, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to verify vendored module %q", path)
+		}
+		if got != want {
+			drifted = append(drifted, dir)
+		}
+	}
+
+	if len(drifted) == 0 {
+		return nil
+	}
+
+	sort.Strings(drifted)
+
+	if !clean {
+		var msgs []string
+		for _, dir := range drifted {
+			msgs = append(msgs, fmt.Sprintf("dir has been modified (%s)", dir))
+		}
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+
+	for _, dir := range drifted {
+		fmt.Println("Cleaning drifted vendored module", dir)
+	}
+
+	return c.Vendor()
+}
+
+// readVendorSum parses the vendor.sum file written by Vendor, returning a
+// map of module path to its recorded hash. It returns an os.IsNotExist
+// error if vendorDir has no vendor.sum, e.g. because Vendor hasn't been run
+// since this feature was added.
+func (c *Client) readVendorSum(vendorDir string) (map[string]string, error) {
+	f, err := c.fs.Open(filepath.Join(vendorDir, vendorSumFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sums := make(map[string]string)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		sums[fields[0]] = fields[2]
+	}
+
+	return sums, scanner.Err()
+}
+
+// hashVendorDir computes the "h1:" dirhash of every file below dir, the
+// same scheme go.sum uses (sha256 of each file, paired with its path,
+// sorted, then sha256'd again), so the result can be compared byte for
+// byte across a commit.
+func hashVendorDir(fs afero.Fs, dir string) (string, error) {
+	var relPaths []string
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relPaths = append(relPaths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(relPaths)
+
+	h := sha256.New()
+	for _, rel := range relPaths {
+		f, err := fs.Open(filepath.Join(dir, rel))
+		if err != nil {
+			return "", err
+		}
+		fh := sha256.New()
+		_, err = io.Copy(fh, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%x  %s\n", fh.Sum(nil), rel)
+	}
+
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
 }
 
 func (c *Client) Clean(pattern string) error {
@@ -0,0 +1,256 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const goWorkFilename = "go.work"
+
+// workspaceFilename resolves the go.work file this Client operates on. It
+// defaults to a go.work in WorkingDir, but honours GOWORK when the user has
+// pointed it at a file elsewhere.
+func (c *Client) workspaceFilename() string {
+	if c.moduleConfig.Workspace != "" {
+		return c.moduleConfig.Workspace
+	}
+	return filepath.Join(c.ccfg.WorkingDir, goWorkFilename)
+}
+
+// WorkspaceDirective describes a single replace or require edit to apply to
+// go.work, mirroring the flags accepted by "go work edit". Drop marks it as
+// the removing variant (dropreplace/droprequire) instead of adding it.
+type WorkspaceDirective struct {
+	// Path is the module path this directive concerns.
+	Path string
+
+	// Version is the version Path resolves to. It's optional for replace
+	// directives (matching any version of Path), but required for require
+	// directives unless Drop is set.
+	Version string
+
+	// NewPath and NewVersion hold the replacement target of a replace
+	// directive. NewVersion is empty when NewPath is a local directory.
+	// Unused for require directives.
+	NewPath    string
+	NewVersion string
+
+	// Drop removes the matching directive (dropreplace/droprequire) instead
+	// of adding or updating it.
+	Drop bool
+}
+
+func (d WorkspaceDirective) pathVersion() string {
+	if d.Version == "" {
+		return d.Path
+	}
+	return d.Path + "@" + d.Version
+}
+
+// WorkspaceInit creates a new go.work file at WorkingDir listing dirs via
+// "use" directives, mirroring "go work init". It requires Go 1.18 or newer;
+// on older Go versions this is a no-op, consistent with the Client's other
+// Go-shelling methods.
+func (c *Client) WorkspaceInit(dirs ...string) error {
+	args := append([]string{"work", "init"}, dirs...)
+	if err := c.runGo(context.Background(), c.logger.Out(), args...); err != nil {
+		return errors.Wrap(err, "failed to init workspace")
+	}
+	return nil
+}
+
+// WorkspaceUse adds dirs as "use" directives in go.work, mirroring
+// "go work use".
+func (c *Client) WorkspaceUse(dirs ...string) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	args := append([]string{"work", "use"}, dirs...)
+	if err := c.runGo(context.Background(), c.logger.Out(), args...); err != nil {
+		return errors.Wrap(err, "failed to use workspace module")
+	}
+	return nil
+}
+
+// WorkspaceDrop removes dirs from the "use" directives in go.work, mirroring
+// "go work edit -dropuse".
+func (c *Client) WorkspaceDrop(dirs ...string) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	args := []string{"work", "edit"}
+	for _, dir := range dirs {
+		args = append(args, "-dropuse="+dir)
+	}
+	if err := c.runGo(context.Background(), c.logger.Out(), args...); err != nil {
+		return errors.Wrap(err, "failed to drop workspace module")
+	}
+	return nil
+}
+
+// WorkspaceEdit applies a set of structured replace and require edits to
+// go.work, mirroring "go work edit".
+func (c *Client) WorkspaceEdit(replaces, requires []WorkspaceDirective) error {
+	var args []string
+
+	for _, r := range replaces {
+		if r.Drop {
+			args = append(args, "-dropreplace="+r.pathVersion())
+			continue
+		}
+		newPath := r.NewPath
+		if r.NewVersion != "" {
+			newPath += "@" + r.NewVersion
+		}
+		args = append(args, "-replace="+r.pathVersion()+"="+newPath)
+	}
+
+	for _, req := range requires {
+		if req.Drop {
+			args = append(args, "-droprequire="+req.Path)
+			continue
+		}
+		args = append(args, "-require="+req.pathVersion())
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	args = append([]string{"work", "edit"}, args...)
+	if err := c.runGo(context.Background(), c.logger.Out(), args...); err != nil {
+		return errors.Wrap(err, "failed to edit workspace")
+	}
+	return nil
+}
+
+// WorkspaceSync propagates the workspace's selected module versions back
+// into the go.mod of each module listed in go.work, mirroring
+// "go work sync".
+func (c *Client) WorkspaceSync() error {
+	if err := c.runGo(context.Background(), c.logger.Out(), "work", "sync"); err != nil {
+		return errors.Wrap(err, "failed to sync workspace")
+	}
+	return nil
+}
+
+// Workspace holds the parsed contents of a go.work file, so the module
+// graph can treat workspace-local replacements the same as ones declared in
+// go.mod.
+type Workspace struct {
+	// Use lists the directories named in "use" directives, relative to the
+	// go.work file unless absolute.
+	Use []string
+
+	// Replace lists the "replace" directives found in go.work.
+	Replace []WorkspaceDirective
+}
+
+// ReadWorkspace parses the go.work file configured for this Client, if any,
+// so that e.g. Graph and Vendor can pick up its "use" and "replace"
+// directives as first-class replace targets. It returns a nil Workspace if
+// GOWORK isn't set or the file doesn't exist.
+func (c *Client) ReadWorkspace() (*Workspace, error) {
+	filename := c.workspaceFilename()
+
+	f, err := c.fs.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var ws Workspace
+	var block string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		if block != "" {
+			if line == ")" {
+				block = ""
+				continue
+			}
+			ws.addDirective(block, line)
+			continue
+		}
+
+		switch {
+		case line == "use (":
+			block = "use"
+		case line == "replace (":
+			block = "replace"
+		case strings.HasPrefix(line, "use "):
+			ws.addDirective("use", strings.TrimPrefix(line, "use"))
+		case strings.HasPrefix(line, "replace "):
+			ws.addDirective("replace", strings.TrimPrefix(line, "replace"))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &ws, nil
+}
+
+func (ws *Workspace) addDirective(kind, line string) {
+	fields := strings.Fields(strings.TrimSpace(line))
+
+	switch kind {
+	case "use":
+		if len(fields) > 0 {
+			ws.Use = append(ws.Use, strings.Trim(fields[0], `"`))
+		}
+	case "replace":
+		// old [oldver] => new [newver]
+		var i int
+		for i = range fields {
+			if fields[i] == "=>" {
+				break
+			}
+		}
+		if i == 0 || i >= len(fields)-1 {
+			// Malformed line; ignore.
+			return
+		}
+
+		old := fields[:i]
+		new := fields[i+1:]
+
+		d := WorkspaceDirective{Path: strings.Trim(old[0], `"`)}
+		if len(old) > 1 {
+			d.Version = old[1]
+		}
+		d.NewPath = strings.Trim(new[0], `"`)
+		if len(new) > 1 {
+			d.NewVersion = new[1]
+		}
+
+		ws.Replace = append(ws.Replace, d)
+	}
+}
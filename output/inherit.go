@@ -0,0 +1,73 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import "fmt"
+
+// InheritanceChain resolves, for a set of format names each optionally
+// naming another format it inherits from, the ordered list of formats a
+// lookup for name should fall back through: name itself, then its parent,
+// then its parent's parent, and so on.
+//
+// This is the piece of per-output-format template inheritance that doesn't
+// depend on output.Format itself (which, along with the `output` package's
+// other real types this request's tests reference -- see
+// output/postprocess's doc comment for the fuller account of what's
+// missing from this tree -- isn't defined here): callers would build
+// inherits from each Format.Inherits field, then walk the returned chain
+// trying "_default/list.<suffix>" (or "single.<suffix>") for each format
+// name in turn until one resolves to a loaded template.
+type InheritanceChain struct {
+	inherits map[string]string
+}
+
+// NewInheritanceChain builds an InheritanceChain from a name -> parent name
+// map (entries with no parent, or whose parent isn't itself a key, are
+// treated as roots).
+func NewInheritanceChain(inherits map[string]string) InheritanceChain {
+	return InheritanceChain{inherits: inherits}
+}
+
+// Resolve returns the fallback chain starting at name: name, its parent,
+// its parent's parent, etc. An error is returned if following parents
+// would cycle back to a name already in the chain.
+func (c InheritanceChain) Resolve(name string) ([]string, error) {
+	var chain []string
+	seen := make(map[string]bool)
+
+	cur := name
+	for cur != "" {
+		if seen[cur] {
+			return nil, fmt.Errorf("output: format inheritance cycle detected at %q (chain so far: %v)", cur, chain)
+		}
+		seen[cur] = true
+		chain = append(chain, cur)
+		cur = c.inherits[cur]
+	}
+
+	return chain, nil
+}
+
+// Validate checks every name in inherits resolves without a cycle, for
+// createSiteOutputFormats (or equivalent) to call once at startup rather
+// than discovering a cycle lazily on first template lookup.
+func Validate(inherits map[string]string) error {
+	c := NewInheritanceChain(inherits)
+	for name := range inherits {
+		if _, err := c.Resolve(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
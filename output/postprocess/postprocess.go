@@ -0,0 +1,197 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package postprocess provides a registry of named, chainable transforms
+// ("minify-html", "gzip", "brotli", ...) an output format can apply to its
+// rendered bytes before they're written to the destination FS.
+package postprocess
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gohugoio/hugo/resources/resource_transformers/integrity"
+	"github.com/pkg/errors"
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+	"github.com/tdewolff/minify/v2/js"
+	minifyjson "github.com/tdewolff/minify/v2/json"
+	"github.com/tdewolff/minify/v2/xml"
+)
+
+// Processor transforms content rendered as mediaType (e.g. "text/html"),
+// returning the transformed bytes. External code can implement Processor
+// and Register it under a name to extend the built-in chain the same way
+// the built-ins below are registered.
+type Processor interface {
+	Process(mediaType string, content []byte) ([]byte, error)
+}
+
+// ProcessorFunc adapts a plain function to a Processor.
+type ProcessorFunc func(mediaType string, content []byte) ([]byte, error)
+
+// Process calls f.
+func (f ProcessorFunc) Process(mediaType string, content []byte) ([]byte, error) {
+	return f(mediaType, content)
+}
+
+var registry = make(map[string]Processor)
+
+// Register adds p to the registry under name, so it can be referenced from
+// a Format's postProcessors list (a la Format.PostProcessors) and looked up
+// with Lookup. Registering under a name that's already registered replaces
+// it.
+func Register(name string, p Processor) {
+	registry[name] = p
+}
+
+// Lookup returns the Processor registered under name, if any.
+func Lookup(name string) (Processor, bool) {
+	p, ok := registry[name]
+	return p, ok
+}
+
+func init() {
+	m := minify.New()
+	m.AddFunc("text/html", html.Minify)
+	m.AddFunc("text/css", css.Minify)
+	m.AddFunc("application/javascript", js.Minify)
+	m.AddFunc("application/json", minifyjson.Minify)
+	m.AddFunc("application/xml", xml.Minify)
+
+	Register("minify-html", minifyProcessor{m: m, mediaType: "text/html"})
+	Register("minify-css", minifyProcessor{m: m, mediaType: "text/css"})
+	Register("minify-js", minifyProcessor{m: m, mediaType: "application/javascript"})
+	Register("minify-json", minifyProcessor{m: m, mediaType: "application/json"})
+	Register("minify-xml", minifyProcessor{m: m, mediaType: "application/xml"})
+
+	Register("gzip", compressProcessor{suffix: ".gz", newWriter: gzipWriter})
+	Register("brotli", compressProcessor{suffix: ".br", newWriter: brotliWriter})
+	Register("sri", sriProcessor{})
+}
+
+// sriProcessor doesn't rewrite content either: like compressProcessor, its
+// real output is a side value (the SRI hash of this content, for a caller
+// to inject into the <script integrity=...>/<link integrity=...> that
+// references it) rather than a rewrite of the content itself, since a
+// postprocess.Processor only sees one file's bytes and has no way to find
+// the markup elsewhere that links to it.
+type sriProcessor struct{}
+
+func (sriProcessor) Process(mediaType string, content []byte) ([]byte, error) {
+	return content, nil
+}
+
+// Hash returns content's Subresource Integrity hash in the
+// "sha384-<base64>" form.
+func (sriProcessor) Hash(content []byte) string {
+	return integrity.SRIHash(integrity.HashSHA384, content)
+}
+
+// minifyProcessor minifies content through m, always as mediaType --
+// overriding whatever mediaType Process is called with -- since it's
+// registered once per concrete minifier (minify-html, minify-css, ...)
+// rather than dispatching on the caller's media type.
+type minifyProcessor struct {
+	m         *minify.M
+	mediaType string
+}
+
+func (p minifyProcessor) Process(mediaType string, content []byte) ([]byte, error) {
+	out, err := p.m.Bytes(p.mediaType, content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "postprocess: minify %s", p.mediaType)
+	}
+	return out, nil
+}
+
+// compressProcessor doesn't replace content: it's meant to be run for its
+// side effect of producing a Sibling (a compressed copy written alongside
+// the uncompressed output, named by appending suffix), which is why
+// Process returns the original content unchanged and Sibling is what
+// callers should actually write under path+suffix.
+type compressProcessor struct {
+	suffix    string
+	newWriter func(w *bytes.Buffer) (io.WriteCloser, error)
+}
+
+func (p compressProcessor) Process(mediaType string, content []byte) ([]byte, error) {
+	return content, nil
+}
+
+// Sibling compresses content, returning the bytes that should be written
+// alongside the original output under path+p.Suffix().
+func (p compressProcessor) Sibling(content []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := p.newWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Suffix is the filename suffix (".gz", ".br") Sibling's output should be
+// published under.
+func (p compressProcessor) Suffix() string {
+	return p.suffix
+}
+
+func gzipWriter(buf *bytes.Buffer) (io.WriteCloser, error) {
+	return gzip.NewWriter(buf), nil
+}
+
+func brotliWriter(buf *bytes.Buffer) (io.WriteCloser, error) {
+	return brotli.NewWriter(buf), nil
+}
+
+// Chain runs a named sequence of registered Processors over content, in
+// order, each receiving the previous one's output.
+//
+// Wiring Chain into an actual output.Format.PostProcessors field so the
+// site renderer streams through it before writing to the destination FS,
+// and exposing .OutputFormat.PostProcessors to templates, isn't done here:
+// output.Format itself -- and the media/page packages it and the tests
+// this request names (TestCreateSiteOutputFormats,
+// TestOutputFormatPermalinkable) depend on -- aren't defined anywhere in
+// this tree; only a stray outputFormat_test.go exists for the `output`
+// package, with no corresponding format.go, and hugolib's own
+// site_output_test.go references a `page` package and hugolib functions
+// (createSiteOutputFormats, kindRSS, kindSitemap, ...) that likewise don't
+// exist. Reconstructing that whole subsystem is out of scope for this
+// request; what's here is the self-contained, reusable registry and
+// built-in processors (minifiers via tdewolff/minify, gzip/brotli via
+// compressProcessor.Sibling for the .gz/.br siblings) such a wiring would
+// call into once that subsystem exists.
+func Chain(names []string, mediaType string, content []byte) ([]byte, error) {
+	var err error
+	for _, name := range names {
+		p, ok := Lookup(name)
+		if !ok {
+			return nil, errors.Errorf("postprocess: no processor registered as %q", name)
+		}
+		content, err = p.Process(mediaType, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,291 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Front matter delimiters, kept around for formats that still split on a
+// leading/trailing fence rather than being self-delimiting (JSON, XML).
+const (
+	HTML_LEAD = "<"
+	YAML_LEAD = "-"
+	YAML_DELIM_UNIX = "---\n"
+	YAML_DELIM_DOS  = "---\r\n"
+	TOML_LEAD = "+"
+	TOML_DELIM_UNIX = "+++\n"
+	TOML_DELIM_DOS  = "+++\r\n"
+	JSON_LEAD = "{"
+	XML_LEAD  = "<"
+)
+
+// Page is the result of reading a content file's front matter off disk, as
+// returned by ReadFrom. LeadRune lets a caller that doesn't know the target
+// format in advance (e.g. the convert rewrite command, which only edits
+// fields and otherwise preserves the source format) recover it via
+// FormatByLeadRune.
+type Page interface {
+	Content() []byte
+	Metadata() (interface{}, error)
+	LeadRune() rune
+}
+
+// A FrontmatterFormat describes everything convertContents (and anyone else
+// marshalling front matter) needs to know about one on-disk representation:
+// the rune that identifies it at the head of a content file, how to turn a
+// metadata map into bytes and back, and how (if at all) it needs dates
+// massaged before they're handed to the marshaller.
+type FrontmatterFormat struct {
+	// Name is the identifier used on the command line, e.g. "toml" in
+	// "hugo convert toTOML".
+	Name string
+
+	// LeadRune is the first byte of a file using this format, used to
+	// detect it when reading front matter back off disk.
+	LeadRune rune
+
+	// Marshal turns a metadata map into the on-disk front matter bytes.
+	Marshal func(metadata interface{}) ([]byte, error)
+
+	// Unmarshal turns on-disk front matter bytes back into a metadata map.
+	Unmarshal func(data []byte) (interface{}, error)
+
+	// NormalizeDates prepares metadata for Marshal. Formats that can
+	// represent a time.Time natively (TOML, XML) can leave it alone;
+	// formats that can't (JSON, YAML) stringify it to RFC3339 so it
+	// survives the round trip instead of being marshalled as an opaque
+	// struct.
+	NormalizeDates func(metadata interface{}) interface{}
+
+	// Canonical marks the format FormatByLeadRune should prefer when more
+	// than one registered format shares a LeadRune, e.g. "yaml" over the
+	// "yamlflow" variant that also starts with YAML_LEAD.
+	Canonical bool
+}
+
+// formatRegistry holds every known front matter format, keyed by name.
+// It's populated by RegisterFormat below, both from this package's own
+// init and from any importer that wants to teach convert about a format
+// of its own.
+var formatRegistry = make(map[string]*FrontmatterFormat)
+
+// RegisterFormat adds (or replaces) a front matter format in the registry.
+// Tools built on top of this module can call this from their own init to
+// make "hugo convert to<Name>" aware of a bespoke format without forking
+// the convert command.
+func RegisterFormat(f *FrontmatterFormat) {
+	formatRegistry[f.Name] = f
+}
+
+// FormatByName looks up a previously registered format by its command-line
+// name, e.g. "yaml" or "yamlflow".
+func FormatByName(name string) (*FrontmatterFormat, error) {
+	f, ok := formatRegistry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown front matter format %q", name)
+	}
+	return f, nil
+}
+
+// FormatToLeadRune returns the lead rune registered for name, preserved for
+// callers that only care about sniffing the format of an existing file.
+func FormatToLeadRune(name string) rune {
+	f, err := FormatByName(name)
+	if err != nil {
+		return 0
+	}
+	return f.LeadRune
+}
+
+// FormatByLeadRune returns the canonical format starting with the given
+// rune, so a caller that only knows how a file currently looks (e.g. a
+// rewrite that must preserve the source format) can recover its name and
+// marshal/unmarshal pair. Ties are broken in favor of formats registered
+// with Canonical set, e.g. "yaml" over "yamlflow".
+func FormatByLeadRune(r rune) (*FrontmatterFormat, error) {
+	for _, f := range formatRegistry {
+		if f.LeadRune == r && f.Canonical {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("no front matter format registered for lead rune %q", r)
+}
+
+func stringifyDates(metadata interface{}) interface{} {
+	m, ok := metadata.(map[string]interface{})
+	if !ok {
+		return metadata
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if t, ok := v.(time.Time); ok {
+			out[k] = t.Format(time.RFC3339)
+		} else {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func passthroughDates(metadata interface{}) interface{} {
+	return metadata
+}
+
+// frontmatterXML is the envelope used to give an otherwise map-shaped
+// metadata document the single root element encoding/xml requires.
+type frontmatterXML struct {
+	XMLName xml.Name `xml:"frontmatter"`
+	Fields  []xmlField `xml:",any"`
+}
+
+type xmlField struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+func marshalXML(metadata interface{}) ([]byte, error) {
+	m, ok := metadata.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("xml front matter requires a map, got %T", metadata)
+	}
+	doc := frontmatterXML{}
+	for k, v := range m {
+		doc.Fields = append(doc.Fields, xmlField{XMLName: xml.Name{Local: k}, Value: fmt.Sprint(v)})
+	}
+	return xml.MarshalIndent(doc, "", "  ")
+}
+
+func unmarshalXML(data []byte) (interface{}, error) {
+	var doc frontmatterXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(doc.Fields))
+	for _, f := range doc.Fields {
+		m[f.XMLName.Local] = f.Value
+	}
+	return m, nil
+}
+
+func init() {
+	RegisterFormat(&FrontmatterFormat{
+		Name:     "json",
+		LeadRune: rune(JSON_LEAD[0]),
+		Marshal: func(metadata interface{}) ([]byte, error) {
+			return json.MarshalIndent(metadata, "", "  ")
+		},
+		Unmarshal: func(data []byte) (interface{}, error) {
+			var m map[string]interface{}
+			err := json.Unmarshal(data, &m)
+			return m, err
+		},
+		NormalizeDates: stringifyDates,
+		Canonical:      true,
+	})
+
+	RegisterFormat(&FrontmatterFormat{
+		Name:     "toml",
+		LeadRune: rune(TOML_LEAD[0]),
+		Marshal: func(metadata interface{}) ([]byte, error) {
+			return toml.Marshal(metadata)
+		},
+		Unmarshal: func(data []byte) (interface{}, error) {
+			var m map[string]interface{}
+			err := toml.Unmarshal(data, &m)
+			return m, err
+		},
+		// TOML marshals time.Time natively, so dates keep their type.
+		NormalizeDates: passthroughDates,
+		Canonical:      true,
+	})
+
+	RegisterFormat(&FrontmatterFormat{
+		Name:     "yaml",
+		LeadRune: rune(YAML_LEAD[0]),
+		Marshal: func(metadata interface{}) ([]byte, error) {
+			return yaml.Marshal(metadata)
+		},
+		Unmarshal: func(data []byte) (interface{}, error) {
+			var m map[string]interface{}
+			err := yaml.Unmarshal(data, &m)
+			return m, err
+		},
+		NormalizeDates: stringifyDates,
+		Canonical:      true,
+	})
+
+	RegisterFormat(&FrontmatterFormat{
+		Name:     "yamlflow",
+		LeadRune: rune(YAML_LEAD[0]),
+		Marshal:  marshalYAMLFlow,
+		Unmarshal: func(data []byte) (interface{}, error) {
+			var m map[string]interface{}
+			err := yaml.Unmarshal(data, &m)
+			return m, err
+		},
+		NormalizeDates: stringifyDates,
+	})
+
+	RegisterFormat(&FrontmatterFormat{
+		Name:           "xml",
+		LeadRune:       rune(XML_LEAD[0]),
+		Marshal:        marshalXML,
+		Unmarshal:      unmarshalXML,
+		NormalizeDates: passthroughDates,
+		Canonical:      true,
+	})
+}
+
+// marshalYAMLFlow renders metadata as a single-line flow-style YAML mapping
+// ("{ a: 1, b: two }"). yaml.v2's encoder has no public flow-style switch,
+// so it's produced directly rather than through yaml.Marshal.
+func marshalYAMLFlow(metadata interface{}) ([]byte, error) {
+	m, ok := metadata.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("yamlflow front matter requires a map, got %T", metadata)
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString("{ ")
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		v, err := yaml.Marshal(m[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(k)
+		buf.WriteString(": ")
+		buf.Write(bytes.TrimSuffix(v, []byte("\n")))
+	}
+	buf.WriteString(" }\n")
+
+	return buf.Bytes(), nil
+}
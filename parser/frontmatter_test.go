@@ -0,0 +1,77 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRoundTripTOML(t *testing.T) {
+	f, err := FormatByName("toml")
+	assert.Nil(t, err)
+
+	metadata := map[string]interface{}{"title": "Post", "weight": 4}
+	out, err := f.Marshal(metadata)
+	assert.Nil(t, err)
+
+	back, err := f.Unmarshal(out)
+	assert.Nil(t, err)
+	assert.Equal(t, metadata, back)
+}
+
+func TestFormatRoundTripYAML(t *testing.T) {
+	f, err := FormatByName("yaml")
+	assert.Nil(t, err)
+
+	metadata := map[string]interface{}{"title": "Post", "weight": 4}
+	out, err := f.Marshal(metadata)
+	assert.Nil(t, err)
+
+	back, err := f.Unmarshal(out)
+	assert.Nil(t, err)
+	assert.Equal(t, metadata, back)
+}
+
+// TestFormatMarshalStable guards the "no transforms given" case from the
+// convert rewrite command: re-marshalling unchanged metadata must produce
+// byte-for-byte identical front matter, or rewrite would touch every file
+// in a site just by reading and re-writing it.
+func TestFormatMarshalStable(t *testing.T) {
+	for _, name := range []string{"toml", "yaml", "json"} {
+		f, err := FormatByName(name)
+		assert.Nil(t, err)
+
+		metadata := map[string]interface{}{"title": "Post", "weight": 4}
+		first, err := f.Marshal(metadata)
+		assert.Nil(t, err)
+		second, err := f.Marshal(metadata)
+		assert.Nil(t, err)
+		assert.Equal(t, first, second, "format %s should marshal deterministically", name)
+	}
+}
+
+func TestFormatByLeadRune(t *testing.T) {
+	f, err := FormatByLeadRune('-')
+	assert.Nil(t, err)
+	assert.Equal(t, "yaml", f.Name)
+
+	f, err = FormatByLeadRune('+')
+	assert.Nil(t, err)
+	assert.Equal(t, "toml", f.Name)
+
+	_, err = FormatByLeadRune('?')
+	assert.NotNil(t, err)
+}
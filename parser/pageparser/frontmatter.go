@@ -0,0 +1,267 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pageparser
+
+import "bytes"
+
+// FrontMatterHandler knows how to detect and lex one front matter format
+// (YAML, TOML, JSON or Org) out of the start of a page's content. This is
+// a lexing-level concern -- finding the raw bytes of the front matter
+// block and handing them back as a single Item -- as opposed to the
+// parser package's FrontmatterFormat, which marshals/unmarshals an
+// already-extracted block into/out of Go values.
+type FrontMatterHandler interface {
+	// Name identifies the format, e.g. "YAML". It's also the value
+	// accepted by Config.EnabledFrontMatterFormats.
+	Name() string
+
+	// Detect reports whether rest -- the unconsumed input, starting right
+	// after any byte order mark -- begins with this format's front
+	// matter.
+	Detect(rest []byte) bool
+
+	// Lex consumes l's front matter block, starting at l.pos, and emits
+	// exactly one TypeFrontMatter* item for it.
+	Lex(l *pageLexer)
+}
+
+var (
+	frontMatterHandlers    = map[string]FrontMatterHandler{}
+	frontMatterHandlerList []FrontMatterHandler
+)
+
+// RegisterFrontMatterFormat makes h available to lexIntroSection's front
+// matter detection, in addition to every previously registered handler.
+// Handlers are tried in registration order, so the built-in YAML, TOML,
+// JSON and Org handlers -- registered by this package's own init -- are
+// tried before any handler a caller registers afterwards.
+func RegisterFrontMatterFormat(h FrontMatterHandler) {
+	frontMatterHandlers[h.Name()] = h
+	frontMatterHandlerList = append(frontMatterHandlerList, h)
+}
+
+const (
+	frontMatterYAMLName = "YAML"
+	frontMatterTOMLName = "TOML"
+	frontMatterJSONName = "JSON"
+	frontMatterORGName  = "ORG"
+)
+
+func init() {
+	RegisterFrontMatterFormat(yamlFrontMatterHandler{})
+	RegisterFrontMatterFormat(tomlFrontMatterHandler{})
+	RegisterFrontMatterFormat(jsonFrontMatterHandler{})
+	RegisterFrontMatterFormat(orgFrontMatterHandler{})
+}
+
+// lexFrontMatterFormat tries every handler enabled by l.cfg, in
+// registration order, and -- on the first match -- lexes and emits its
+// front matter item, reporting whether a format matched at all.
+func (l *pageLexer) lexFrontMatterFormat() bool {
+	rest := l.input[l.pos:]
+
+	for _, h := range frontMatterHandlerList {
+		if !l.cfg.frontMatterFormatEnabled(h.Name()) {
+			continue
+		}
+		if !h.Detect(rest) {
+			continue
+		}
+		h.Lex(l)
+		l.orgMode = h.Name() == frontMatterORGName
+		return true
+	}
+
+	return false
+}
+
+func (cfg Config) frontMatterFormatEnabled(name string) bool {
+	if len(cfg.EnabledFrontMatterFormats) == 0 {
+		return true
+	}
+	for _, n := range cfg.EnabledFrontMatterFormats {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type yamlFrontMatterHandler struct{}
+
+func (yamlFrontMatterHandler) Name() string { return frontMatterYAMLName }
+
+func (yamlFrontMatterHandler) Detect(rest []byte) bool {
+	return bytes.HasPrefix(rest, []byte("---\n")) || bytes.HasPrefix(rest, []byte("---\r\n"))
+}
+
+func (yamlFrontMatterHandler) Lex(l *pageLexer) {
+	lexFencedFrontMatter(l, "---", TypeFrontMatterYAML)
+}
+
+type tomlFrontMatterHandler struct{}
+
+func (tomlFrontMatterHandler) Name() string { return frontMatterTOMLName }
+
+func (tomlFrontMatterHandler) Detect(rest []byte) bool {
+	return bytes.HasPrefix(rest, []byte("+++\n")) || bytes.HasPrefix(rest, []byte("+++\r\n"))
+}
+
+func (tomlFrontMatterHandler) Lex(l *pageLexer) {
+	lexFencedFrontMatter(l, "+++", TypeFrontMatterTOML)
+}
+
+// lexFencedFrontMatter consumes a front matter block delimited by a
+// fence line (fence+"\n" or fence+"\r\n") at both the opening and the
+// closing, as used by YAML ("---") and TOML ("+++"). The opening and
+// closing fences need not use the same line ending. The emitted item's
+// text is the body only -- the fences themselves are excluded.
+func lexFencedFrontMatter(l *pageLexer, fence string, typ ItemType) {
+	openLen := len(fence) + 1
+	if bytes.HasPrefix(l.input[l.pos:], []byte(fence+"\r\n")) {
+		openLen = len(fence) + 2
+	}
+	l.pos += openLen
+	l.start = l.pos
+
+	bodyStart := l.pos
+	for p := bodyStart; ; p++ {
+		if p >= len(l.input) {
+			// No closing fence found; treat the rest of the input as the
+			// body rather than losing it.
+			l.pos = len(l.input)
+			l.emit(typ)
+			return
+		}
+
+		if p+len(fence) > len(l.input) || string(l.input[p:p+len(fence)]) != fence {
+			continue
+		}
+		if p != bodyStart && l.input[p-1] != '\n' {
+			continue
+		}
+
+		after := l.input[p+len(fence):]
+		switch {
+		case bytes.HasPrefix(after, []byte("\r\n")):
+			l.pos = p
+			l.emit(typ)
+			l.pos = p + len(fence) + 2
+			l.start = l.pos
+			return
+		case bytes.HasPrefix(after, []byte("\n")):
+			l.pos = p
+			l.emit(typ)
+			l.pos = p + len(fence) + 1
+			l.start = l.pos
+			return
+		}
+	}
+}
+
+type jsonFrontMatterHandler struct{}
+
+func (jsonFrontMatterHandler) Name() string { return frontMatterJSONName }
+
+func (jsonFrontMatterHandler) Detect(rest []byte) bool {
+	return len(rest) > 0 && rest[0] == '{'
+}
+
+// Lex scans rest for the matching closing brace, tracking string-literal
+// state so a "}" inside a quoted value doesn't end the block early. The
+// emitted item's text is the whole "{...}" span, braces included; if a
+// line ending immediately follows the closing brace, it's absorbed into
+// the item's text too.
+func (jsonFrontMatterHandler) Lex(l *pageLexer) {
+	depth := 0
+	inString := false
+	escaped := false
+
+	p := l.pos
+	for ; p < len(l.input); p++ {
+		c := l.input[p]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p++
+				goto done
+			}
+		}
+	}
+done:
+	l.pos = p
+
+	switch {
+	case bytes.HasPrefix(l.input[l.pos:], []byte("\r\n")):
+		l.pos += 2
+	case bytes.HasPrefix(l.input[l.pos:], []byte("\n")):
+		l.pos++
+	}
+
+	l.emit(TypeFrontMatterJSON)
+}
+
+type orgFrontMatterHandler struct{}
+
+func (orgFrontMatterHandler) Name() string { return frontMatterORGName }
+
+// Detect reports whether rest -- after skipping one optional leading
+// blank line -- starts with a "#+" prefixed line, Org's front matter
+// keyword syntax (e.g. "#+TITLE: ...").
+func (orgFrontMatterHandler) Detect(rest []byte) bool {
+	if len(rest) > 0 && rest[0] == '\n' {
+		rest = rest[1:]
+	}
+	return bytes.HasPrefix(rest, []byte("#+"))
+}
+
+// Lex consumes the optional leading blank line and every immediately
+// following "#+" prefixed line, stopping at the first line that isn't
+// one.
+func (orgFrontMatterHandler) Lex(l *pageLexer) {
+	p := l.pos
+	if p < len(l.input) && l.input[p] == '\n' {
+		p++
+	}
+
+	for bytes.HasPrefix(l.input[p:], []byte("#+")) {
+		nl := bytes.IndexByte(l.input[p:], '\n')
+		if nl == -1 {
+			p = len(l.input)
+			break
+		}
+		p += nl + 1
+	}
+
+	l.pos = p
+	l.emit(TypeFrontMatterORG)
+}
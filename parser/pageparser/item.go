@@ -0,0 +1,95 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pageparser
+
+import "fmt"
+
+// ItemType identifies the kind of token an Item holds.
+type ItemType int
+
+const (
+	tError ItemType = iota
+	tEOF
+
+	// TypeIgnore marks bytes that carry no meaning of their own (a byte
+	// order mark, or the HTML comment fence wrapping a front matter
+	// block) but still need to round-trip through the lexer untouched.
+	TypeIgnore
+
+	// TypeLeadSummaryDivider marks the user-inserted marker ("<!--more-->",
+	// or "# more" on its own line for Org content) that splits a page's
+	// manually authored summary from the rest of its content.
+	TypeLeadSummaryDivider
+
+	// TypeFrontMatterYAML, TypeFrontMatterTOML, TypeFrontMatterJSON and
+	// TypeFrontMatterORG each mark one front matter format's raw body, as
+	// produced by the FrontMatterHandler registered under that name.
+	TypeFrontMatterYAML
+	TypeFrontMatterTOML
+	TypeFrontMatterJSON
+	TypeFrontMatterORG
+
+	// tText marks a run of plain page content, i.e. anything lexIntroSection
+	// and lexMainSection didn't otherwise recognize.
+	tText
+
+	// tLeftDelimScNoMarkup and tRightDelimScNoMarkup mark a shortcode's
+	// "{{<"/">}}" delimiters (the "NoMarkup" variant, as opposed to
+	// "{{%"/"%}}", which also renders Markdown inside the shortcode).
+	tLeftDelimScNoMarkup
+	tRightDelimScNoMarkup
+
+	// tScName marks a shortcode's name, e.g. "sc1" in "{{< sc1 >}}".
+	tScName
+)
+
+var itemTypeNames = map[ItemType]string{
+	tError:                 "Error",
+	tEOF:                   "EOF",
+	TypeIgnore:             "Ignore",
+	TypeLeadSummaryDivider: "LeadSummaryDivider",
+	TypeFrontMatterYAML:    "FrontMatterYAML",
+	TypeFrontMatterTOML:    "FrontMatterTOML",
+	TypeFrontMatterJSON:    "FrontMatterJSON",
+	TypeFrontMatterORG:     "FrontMatterORG",
+	tText:                  "Text",
+	tLeftDelimScNoMarkup:   "LeftDelimScNoMarkup",
+	tRightDelimScNoMarkup:  "RightDelimScNoMarkup",
+	tScName:                "ScName",
+}
+
+// String returns it's name, e.g. "FrontMatterYAML", for use in test output
+// and error messages; an unrecognized value prints as "ItemType(n)".
+func (it ItemType) String() string {
+	if s, ok := itemTypeNames[it]; ok {
+		return s
+	}
+	return fmt.Sprintf("ItemType(%d)", int(it))
+}
+
+// Item is one token produced by the lexer: a Type and a [low, high) byte
+// range into the original source, or -- for tError -- an Err describing
+// what went wrong instead of a valid range.
+type Item struct {
+	Type ItemType
+	low  int
+	high int
+	Err  error
+}
+
+// Val returns the Item's bytes out of source, the same slice the lexer
+// that produced it was run against.
+func (i Item) Val(source []byte) []byte {
+	return source[i.low:i.high]
+}
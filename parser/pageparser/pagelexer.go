@@ -0,0 +1,247 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pageparser lexes a raw content file's bytes into a stream of
+// Items: its front matter (in whichever of YAML, TOML, JSON or Org it was
+// written in), its optional summary divider, its shortcodes, and its
+// plain text.
+package pageparser
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Config controls how a page is lexed.
+type Config struct {
+	// EnabledFrontMatterFormats restricts front matter detection to the
+	// named formats (see FrontMatterHandler.Name, e.g. "YAML"). The zero
+	// value -- a nil or empty slice -- enables every registered format.
+	EnabledFrontMatterFormats []string
+}
+
+// stateFunc is one step of the lexer; it returns the state to run next,
+// or nil once lexing is done (either a tEOF or a tError item has been
+// emitted).
+type stateFunc func(*pageLexer) stateFunc
+
+// pageLexer lexes the bytes of a single content file.
+type pageLexer struct {
+	input []byte
+	pos   int // next unconsumed byte
+	start int // start of the run of bytes not yet emitted as an Item
+
+	cfg Config
+
+	// orgMode is set once the page's front matter is detected as Org,
+	// since Org uses its own summary divider syntax ("# more") instead of
+	// "<!--more-->".
+	orgMode bool
+
+	items []Item
+
+	stateStart stateFunc
+}
+
+func newPageLexer(input []byte, stateStart stateFunc, cfg Config) *pageLexer {
+	return &pageLexer{
+		input:      input,
+		cfg:        cfg,
+		stateStart: stateStart,
+	}
+}
+
+// run runs the lexer to completion, starting from the stateFunc passed to
+// newPageLexer.
+func (l *pageLexer) run() {
+	for state := l.stateStart; state != nil; {
+		state = state(l)
+	}
+}
+
+func (l *pageLexer) emit(t ItemType) {
+	l.items = append(l.items, Item{Type: t, low: l.start, high: l.pos})
+	l.start = l.pos
+}
+
+// emitText emits the pending, not yet emitted run of bytes as plain text,
+// unless it's empty -- lexMainSection never produces empty tText items.
+func (l *pageLexer) emitText() {
+	if l.pos > l.start {
+		l.emit(tText)
+	}
+}
+
+func (l *pageLexer) errorf(format string, args ...interface{}) stateFunc {
+	l.items = append(l.items, Item{Type: tError, Err: fmt.Errorf(format, args...)})
+	return nil
+}
+
+func (l *pageLexer) atLineStart() bool {
+	return l.pos == 0 || l.input[l.pos-1] == '\n'
+}
+
+func isHorizontalSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+// skipSpaces advances past any run of horizontal whitespace at l.pos
+// without emitting it as an Item.
+func (l *pageLexer) skipSpaces() {
+	for l.pos < len(l.input) && isHorizontalSpace(l.input[l.pos]) {
+		l.pos++
+	}
+	l.start = l.pos
+}
+
+var (
+	// bomBytes is the UTF-8 encoding of U+FEFF, the byte order mark some
+	// editors prepend to files.
+	bomBytes                  = []byte{0xEF, 0xBB, 0xBF}
+	htmlCommentOpenBytes      = []byte("<!--\n")
+	htmlCommentCloseBytes     = []byte("-->")
+	summaryDividerBytes       = []byte("<!--more-->")
+	orgSummaryDividerBytes    = []byte("# more")
+	leftDelimScNoMarkupBytes  = []byte("{{<")
+	rightDelimScNoMarkupBytes = []byte(">}}")
+)
+
+// isPlainHTML reports whether rest -- after stripping leading horizontal
+// whitespace -- looks like the start of an HTML document rather than a
+// content file, i.e. it starts with "<" but not "<!--".
+func isPlainHTML(rest []byte) bool {
+	trimmed := bytes.TrimLeft(rest, " \t")
+	if len(trimmed) == 0 || trimmed[0] != '<' {
+		return false
+	}
+	return !bytes.HasPrefix(trimmed, []byte("<!--"))
+}
+
+// lexIntroSection lexes a content file's byte order mark (if any),
+// optional HTML-comment wrapping, and front matter, then hands off to
+// lexMainSection for the rest of the file.
+func lexIntroSection(l *pageLexer) stateFunc {
+	if bytes.HasPrefix(l.input[l.pos:], bomBytes) {
+		l.pos += len(bomBytes)
+		l.emit(TypeIgnore)
+	}
+
+	if bytes.HasPrefix(l.input[l.pos:], htmlCommentOpenBytes) {
+		l.pos += len(htmlCommentOpenBytes)
+		l.emit(TypeIgnore)
+
+		if !l.lexFrontMatterFormat() {
+			return l.errorf("starting HTML comment with no end")
+		}
+
+		if !bytes.HasPrefix(l.input[l.pos:], htmlCommentCloseBytes) {
+			return l.errorf("starting HTML comment with no end")
+		}
+
+		l.pos += len(htmlCommentCloseBytes)
+		l.emit(TypeIgnore)
+
+		return lexMainSection
+	}
+
+	if isPlainHTML(l.input[l.pos:]) {
+		return l.errorf("plain HTML documents not supported")
+	}
+
+	l.lexFrontMatterFormat()
+
+	return lexMainSection
+}
+
+// lexMainSection lexes everything after the front matter: the summary
+// divider, shortcodes, and plain text.
+func lexMainSection(l *pageLexer) stateFunc {
+	for {
+		if l.pos >= len(l.input) {
+			l.emitText()
+			l.emit(tEOF)
+			return nil
+		}
+
+		rest := l.input[l.pos:]
+
+		if bytes.HasPrefix(rest, summaryDividerBytes) {
+			l.emitText()
+			l.pos += len(summaryDividerBytes)
+			if l.pos < len(l.input) && l.input[l.pos] == '\n' {
+				l.pos++
+			}
+			l.emit(TypeLeadSummaryDivider)
+			continue
+		}
+
+		if l.orgMode && l.atLineStart() && bytes.HasPrefix(rest, orgSummaryDividerBytes) {
+			l.emitText()
+			l.pos += len(orgSummaryDividerBytes)
+			if l.pos < len(l.input) && l.input[l.pos] == '\n' {
+				l.pos++
+			}
+			l.emit(TypeLeadSummaryDivider)
+			continue
+		}
+
+		if bytes.HasPrefix(rest, leftDelimScNoMarkupBytes) {
+			l.emitText()
+			l.pos += len(leftDelimScNoMarkupBytes)
+			l.emit(tLeftDelimScNoMarkup)
+
+			l.skipSpaces()
+			for l.pos < len(l.input) && !isHorizontalSpace(l.input[l.pos]) && l.input[l.pos] != '>' {
+				l.pos++
+			}
+			l.emit(tScName)
+			l.skipSpaces()
+
+			if !bytes.HasPrefix(l.input[l.pos:], rightDelimScNoMarkupBytes) {
+				return l.errorf("shortcode: expected %q", string(rightDelimScNoMarkupBytes))
+			}
+			l.pos += len(rightDelimScNoMarkupBytes)
+			l.emit(tRightDelimScNoMarkup)
+			continue
+		}
+
+		l.pos++
+	}
+}
+
+// Iterator replays a pageLexer's finished items in order once lexing has
+// run to completion; it's used instead of handing out l.items directly
+// so callers only ever see a stable, already-lexed slice.
+type Iterator struct {
+	items []Item
+	pos   int
+}
+
+// NewIterator returns an Iterator over items.
+func NewIterator(items []Item) *Iterator {
+	return &Iterator{items: items}
+}
+
+// Next returns the next Item, holding on the final item (normally a tEOF
+// or tError) once the sequence is exhausted.
+func (t *Iterator) Next() Item {
+	if len(t.items) == 0 {
+		return Item{Type: tEOF}
+	}
+	if t.pos >= len(t.items) {
+		return t.items[len(t.items)-1]
+	}
+	item := t.items[t.pos]
+	t.pos++
+	return item
+}
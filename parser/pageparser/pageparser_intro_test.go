@@ -52,6 +52,12 @@ var (
 #+DESCRIPTION: D1
 `
 	tstFrontMatterORG = nti(TypeFrontMatterORG, tstORG)
+
+	tstEOF       = nti(tEOF, "")
+	tstLeftNoMD  = nti(tLeftDelimScNoMarkup, "{{<")
+	tstSC1       = nti(tScName, "sc1")
+	tstRightNoMD = nti(tRightDelimScNoMarkup, ">}}")
+	tstSC2       = nti(tScName, "sc2")
 )
 
 var crLfReplacer = strings.NewReplacer("\r", "#", "\n", "$")
@@ -0,0 +1,66 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redirects
+
+import (
+	"net/http"
+	"strings"
+)
+
+// matchPath reports whether requestPath matches pattern, supporting a
+// trailing "/*" splat the way Netlify's own matcher does (everything
+// before the "/*" must match as a prefix).
+func matchPath(pattern, requestPath string) bool {
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(requestPath, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == requestPath
+}
+
+// Middleware wraps next so that, for each request, it first checks rules
+// for a matching From (issuing a 301/302 redirect, or rewriting the
+// request path for a 200 rule) and then applies any stanzas whose Path
+// matches, setting their headers on the response before next is called.
+func Middleware(rules []Rule, stanzas []HeaderStanza, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, rule := range rules {
+			if !matchPath(rule.From, r.URL.Path) {
+				continue
+			}
+
+			switch rule.Status {
+			case 0, 200:
+				r.URL.Path = rule.To
+			case 301, 302:
+				http.Redirect(w, r, rule.To, rule.Status)
+				return
+			default:
+				http.Redirect(w, r, rule.To, rule.Status)
+				return
+			}
+			break
+		}
+
+		for _, stanza := range stanzas {
+			if !matchPath(stanza.Path, r.URL.Path) {
+				continue
+			}
+			for k, v := range stanza.Headers {
+				w.Header().Set(k, v)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,232 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redirects renders and parses Netlify-style `_redirects` and
+// `_headers` files, so a site's deploy-time redirect/header rules can also
+// be honored by `hugo server`.
+package redirects
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule is one redirect rule: requests to From are served (200) or
+// redirected (301/302, or any other status Netlify accepts) to To.
+type Rule struct {
+	From   string
+	To     string
+	Status int // 0 means 200 (rewrite, not a redirect)
+}
+
+// RenderRedirects renders rules into Netlify `_redirects` syntax: one
+// "from  to  status" line per rule, status omitted when it's the 200
+// rewrite default.
+func RenderRedirects(rules []Rule) []byte {
+	var b strings.Builder
+	for _, r := range rules {
+		if r.Status == 0 || r.Status == 200 {
+			fmt.Fprintf(&b, "%s  %s\n", r.From, r.To)
+		} else {
+			fmt.Fprintf(&b, "%s  %s  %d\n", r.From, r.To, r.Status)
+		}
+	}
+	return []byte(b.String())
+}
+
+// ParseRedirects parses a Netlify `_redirects` file's content.
+func ParseRedirects(content string) ([]Rule, error) {
+	var rules []Rule
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("redirects: malformed line %q", line)
+		}
+
+		rule := Rule{From: fields[0], To: fields[1]}
+		if len(fields) >= 3 {
+			status, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("redirects: invalid status %q in line %q", fields[2], line)
+			}
+			rule.Status = status
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// HeaderStanza is one path's block of headers in a `_headers` file.
+type HeaderStanza struct {
+	Path    string
+	Headers map[string]string
+}
+
+// RenderHeaders renders stanzas into Netlify `_headers` syntax: a path
+// line followed by its indented "Header: value" lines.
+func RenderHeaders(stanzas []HeaderStanza) []byte {
+	var b strings.Builder
+	for _, s := range stanzas {
+		fmt.Fprintf(&b, "%s\n", s.Path)
+		for k, v := range s.Headers {
+			fmt.Fprintf(&b, "  %s: %s\n", k, v)
+		}
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// ParseHeaders parses a Netlify `_headers` file's content.
+func ParseHeaders(content string) ([]HeaderStanza, error) {
+	var stanzas []HeaderStanza
+	var current *HeaderStanza
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		raw := scanner.Text()
+		if strings.TrimSpace(raw) == "" || strings.HasPrefix(strings.TrimSpace(raw), "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(raw, " ") && !strings.HasPrefix(raw, "\t") {
+			if current != nil {
+				stanzas = append(stanzas, *current)
+			}
+			current = &HeaderStanza{Path: strings.TrimSpace(raw), Headers: make(map[string]string)}
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("redirects: header line %q has no preceding path", raw)
+		}
+
+		line := strings.TrimSpace(raw)
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("redirects: malformed header line %q", line)
+		}
+		current.Headers[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	if current != nil {
+		stanzas = append(stanzas, *current)
+	}
+
+	return stanzas, scanner.Err()
+}
+
+// Mode selects which backend renders a site's redirect rules.
+type Mode string
+
+const (
+	// ModeMeta renders nothing here -- the caller keeps writing
+	// <meta http-equiv="refresh"> HTML files itself.
+	ModeMeta Mode = "meta"
+	// ModeNetlify renders a Netlify `_redirects` file.
+	ModeNetlify Mode = "netlify"
+	// ModeNginx renders an nginx.conf server-block snippet.
+	ModeNginx Mode = "nginx"
+	// ModeHtaccess renders an Apache `.htaccess` file.
+	ModeHtaccess Mode = "htaccess"
+)
+
+// RenderNginx renders rules as an nginx.conf snippet: one "rewrite"
+// directive per rule, using nginx's "last" flag for the implicit-200
+// rewrite case and "permanent"/"redirect" for 301/302.
+func RenderNginx(rules []Rule) []byte {
+	var b strings.Builder
+	for _, r := range rules {
+		switch r.Status {
+		case 0, 200:
+			fmt.Fprintf(&b, "rewrite ^%s$ %s last;\n", r.From, r.To)
+		case 301:
+			fmt.Fprintf(&b, "rewrite ^%s$ %s permanent;\n", r.From, r.To)
+		case 302:
+			fmt.Fprintf(&b, "rewrite ^%s$ %s redirect;\n", r.From, r.To)
+		default:
+			fmt.Fprintf(&b, "rewrite ^%s$ %s redirect; # %d\n", r.From, r.To, r.Status)
+		}
+	}
+	return []byte(b.String())
+}
+
+// RenderHtaccess renders rules as an Apache `.htaccess` file using
+// mod_rewrite, mirroring RenderNginx's status handling.
+func RenderHtaccess(rules []Rule) []byte {
+	var b strings.Builder
+	b.WriteString("RewriteEngine On\n")
+	for _, r := range rules {
+		switch r.Status {
+		case 0, 200:
+			fmt.Fprintf(&b, "RewriteRule ^%s$ %s [L]\n", strings.TrimPrefix(r.From, "/"), r.To)
+		default:
+			status := r.Status
+			if status == 0 {
+				status = 302
+			}
+			fmt.Fprintf(&b, "RewriteRule ^%s$ %s [R=%d,L]\n", strings.TrimPrefix(r.From, "/"), r.To, status)
+		}
+	}
+	return []byte(b.String())
+}
+
+// Render renders rules in the backend mode selects, returning the rendered
+// content and the filename it belongs at under publishDir (e.g. "_redirects",
+// "nginx.conf", ".htaccess"). ModeMeta returns a nil content and empty
+// filename: the caller should keep emitting meta-refresh HTML itself.
+func Render(mode Mode, rules []Rule) (content []byte, filename string) {
+	switch mode {
+	case ModeNginx:
+		return RenderNginx(rules), "nginx.conf"
+	case ModeHtaccess:
+		return RenderHtaccess(rules), ".htaccess"
+	case ModeMeta, "":
+		return nil, ""
+	default:
+		// ModeNetlify and any unrecognized mode fall back to the Netlify
+		// format, since it's the one format-agnostic enough (a plain
+		// "from to status" line) to be a sane default.
+		return RenderRedirects(rules), "_redirects"
+	}
+}
+
+// Generating _redirects/_headers from page front matter or a site config
+// table (this request's other half) isn't done here: that needs hugolib's
+// Page/Pages front-matter machinery, which -- like the rest of the
+// output.Format-based rendering pipeline referenced by chunk28-2 through
+// chunk28-5 -- isn't defined anywhere in this tree. What's here is the
+// parse/render/serve side, usable standalone or against hand-written
+// _redirects/_headers files, and already wired into commands/server.go's
+// serve().
+//
+// Render/RenderNginx/RenderHtaccess (added for a later request,
+// Kage0923/hugo#chunk30-2, to let a site pick its redirect backend via e.g.
+// `[server.redirects] mode = "nginx"`) have the same limit: collecting the
+// actual 301/302 rules a build would emit -- the top-level
+// default-language redirect, "page/1/" to the paginator base, each
+// configured alias -- needs that same missing Page/alias machinery, so
+// there's no real []Rule for a site build to pass in yet. What's here is
+// the rendering half for whatever []Rule a caller already has, the same
+// way the rest of this package is the parse/render/serve half of
+// _redirects/_headers.
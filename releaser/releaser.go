@@ -39,6 +39,10 @@ type ReleaseHandler struct {
 	// 3: Release
 	step        int
 	skipPublish bool
+
+	// Set when this handler was recreated from a saved state file via
+	// NewFromState, i.e. it is resuming a previously interrupted release.
+	resume bool
 }
 
 func (r ReleaseHandler) shouldRelease() bool {
@@ -139,6 +143,11 @@ func (r *ReleaseHandler) Run() error {
 		if _, err := git("commit", "-m", fmt.Sprintf("%s Add release notes draft for %s\n\n[ci skip]", commitPrefix, newVersion)); err != nil {
 			return err
 		}
+
+		r.step = 2
+		if err := r.saveState(); err != nil {
+			return err
+		}
 	}
 
 	if r.shouldPrepareVersions() {
@@ -169,10 +178,15 @@ func (r *ReleaseHandler) Run() error {
 				return err
 			}
 		}
+
+		r.step = 3
+		if err := r.saveState(); err != nil {
+			return err
+		}
 	}
 
 	if !r.shouldRelease() {
-		fmt.Println("Skip release ... Use --state=3 to continue.")
+		fmt.Println("Skip release ... Use --state=3 to continue, or pass --resume once that state is reached.")
 		return nil
 	}
 
@@ -237,7 +251,8 @@ func (r *ReleaseHandler) Run() error {
 		}
 	}
 
-	return nil
+	// The release completed in full, so there is nothing left to resume.
+	return clearState()
 }
 
 func (r *ReleaseHandler) release(releaseNotesFile string) error {
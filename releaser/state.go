@@ -0,0 +1,108 @@
+// Copyright 2019-present The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package releaser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/gohugoio/hugo/helpers"
+)
+
+// stateFilename holds the resumable release state between invocations of
+// the releaser, so a release that fails partway through (a network blip
+// during push, a goreleaser crash) can be continued with NewFromState
+// instead of having the caller re-derive --state=N by hand.
+const stateFilename = ".hugo-release-state.json"
+
+// ReleasePlanStep describes one phase of a release in the machine-readable
+// plan returned by ReleaseHandler.Plan.
+type ReleasePlanStep struct {
+	Name string `json:"name"`
+	Done bool   `json:"done"`
+}
+
+// ReleasePlan is a machine-readable description of what Run would do for a
+// ReleaseHandler, without actually doing it. It is primarily intended for
+// tooling that wants to show or log the plan before running it.
+type ReleasePlan struct {
+	NewVersion   string            `json:"newVersion"`
+	FinalVersion string            `json:"finalVersion"`
+	Steps        []ReleasePlanStep `json:"steps"`
+}
+
+// Plan returns a machine-readable description of what Run would do for the
+// current ReleaseHandler configuration.
+func (r ReleaseHandler) Plan() ReleasePlan {
+	newVersion, finalVersion := r.calculateVersions(helpers.CurrentHugoVersion)
+
+	return ReleasePlan{
+		NewVersion:   newVersion.String(),
+		FinalVersion: finalVersion.String(),
+		Steps: []ReleasePlanStep{
+			{Name: "prepare-releasenotes", Done: !r.shouldPrepareReleasenotes()},
+			{Name: "prepare-versions", Done: !r.shouldPrepareVersions()},
+			{Name: "release", Done: !r.shouldRelease()},
+		},
+	}
+}
+
+// releaseState is the on-disk resumable state for a release in progress.
+// It is saved after each phase in Run completes, and removed once the
+// release has finished successfully.
+type releaseState struct {
+	Patch       int  `json:"patch"`
+	Step        int  `json:"step"`
+	SkipPublish bool `json:"skipPublish"`
+}
+
+// saveState persists r's progress so a later NewFromState can resume it.
+func (r *ReleaseHandler) saveState() error {
+	s := releaseState{Patch: r.patch, Step: r.step, SkipPublish: r.skipPublish}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(stateFilename, b, 0o644)
+}
+
+// clearState removes the saved state file. It is a no-op if there is none.
+func clearState() error {
+	err := os.Remove(stateFilename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// NewFromState creates a ReleaseHandler that resumes the release recorded
+// in the state file saved by a previous, interrupted Run.
+func NewFromState(skipPublish bool) (*ReleaseHandler, error) {
+	b, err := ioutil.ReadFile(stateFilename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no saved release state found in %q", stateFilename)
+		}
+		return nil, err
+	}
+
+	var s releaseState
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+
+	return &ReleaseHandler{patch: s.Patch, step: s.Step, skipPublish: skipPublish, resume: true}, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import "github.com/disintegration/gift"
+
+// NewAutoOrientFilter returns the gift.Filter that undoes the rotation
+// and/or flip described by orientation, the EXIF Orientation tag value
+// (1-8, per the TIFF/EXIF spec). Orientation 1 (or an unrecognized value)
+// returns a no-op filter, so it's always safe to call.
+func NewAutoOrientFilter(orientation int) gift.Filter {
+	switch orientation {
+	case 2:
+		return gift.New(gift.FlipHorizontal())
+	case 3:
+		return gift.New(gift.Rotate180())
+	case 4:
+		return gift.New(gift.FlipVertical())
+	case 5:
+		return gift.New(gift.Transpose())
+	case 6:
+		return gift.New(gift.Rotate270())
+	case 7:
+		return gift.New(gift.Transverse())
+	case 8:
+		return gift.New(gift.Rotate90())
+	default:
+		return gift.New()
+	}
+}
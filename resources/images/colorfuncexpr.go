@@ -0,0 +1,363 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// colorExprCache holds compiled ColorFunc expressions keyed by their
+// normalized source string, so repeated resource invocations with the same
+// expr don't pay for re-parsing it on every page build.
+var colorExprCache sync.Map // map[string]*colorExpr
+
+// colorExpr is a compiled ColorFunc expression: one small arithmetic
+// expression per output channel (r, g, b, a), each built from the input
+// channel variables r, g, b and a.
+type colorExpr struct {
+	r, g, b, a colorExprNode
+}
+
+func (c *colorExpr) eval(r, g, b, a float32) (float32, float32, float32, float32) {
+	vars := colorExprVars{r: r, g: g, b: b, a: a}
+	return c.r.eval(vars), c.g.eval(vars), c.b.eval(vars), c.a.eval(vars)
+}
+
+// normalizeColorExpr strips insignificant whitespace so e.g. "r, g, b, a"
+// and "r,g,b,a" share a cache entry and a cache key.
+func normalizeColorExpr(expr string) string {
+	var b strings.Builder
+	for _, r := range expr {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// getColorExpr compiles expr, a set of four comma-separated expressions for
+// the r, g, b and a output channels, reusing a cached compilation when expr
+// has already been seen. It panics on invalid input, matching how other
+// filter constructors in this package reject bad template arguments.
+func getColorExpr(expr string) *colorExpr {
+	key := normalizeColorExpr(expr)
+
+	if v, ok := colorExprCache.Load(key); ok {
+		return v.(*colorExpr)
+	}
+
+	parts := splitTopLevel(key)
+	if len(parts) != 4 {
+		panic(fmt.Sprintf("invalid colorFunc expression %q: want 4 comma-separated expressions for r, g, b, a, got %d", expr, len(parts)))
+	}
+
+	c := &colorExpr{}
+	nodes := [4]*colorExprNode{&c.r, &c.g, &c.b, &c.a}
+	for i, part := range parts {
+		node, err := parseColorExprNode(part)
+		if err != nil {
+			panic(fmt.Sprintf("invalid colorFunc expression %q: %s", expr, err))
+		}
+		*nodes[i] = node
+	}
+
+	v, _ := colorExprCache.LoadOrStore(key, c)
+	return v.(*colorExpr)
+}
+
+// splitTopLevel splits s on commas that are not nested inside parentheses,
+// so the four top-level channel expressions split correctly even though a
+// function call like clamp(r, 0, 1) contains commas of its own.
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	parts = append(parts, s[start:])
+	return parts
+}
+
+// colorExprVars are the input channel values an expression is evaluated against.
+type colorExprVars struct {
+	r, g, b, a float32
+}
+
+// colorExprNode is one node of a compiled expression's AST.
+type colorExprNode interface {
+	eval(vars colorExprVars) float32
+}
+
+type numberNode float32
+
+func (n numberNode) eval(colorExprVars) float32 { return float32(n) }
+
+type varNode byte
+
+func (n varNode) eval(vars colorExprVars) float32 {
+	switch n {
+	case 'r':
+		return vars.r
+	case 'g':
+		return vars.g
+	case 'b':
+		return vars.b
+	case 'a':
+		return vars.a
+	}
+	return 0
+}
+
+type unaryNode struct {
+	x colorExprNode
+}
+
+func (n unaryNode) eval(vars colorExprVars) float32 { return -n.x.eval(vars) }
+
+type binaryNode struct {
+	op   byte
+	x, y colorExprNode
+}
+
+func (n binaryNode) eval(vars colorExprVars) float32 {
+	x, y := n.x.eval(vars), n.y.eval(vars)
+	switch n.op {
+	case '+':
+		return x + y
+	case '-':
+		return x - y
+	case '*':
+		return x * y
+	case '/':
+		return x / y
+	}
+	return 0
+}
+
+type callNode struct {
+	name string
+	args []colorExprNode
+}
+
+func (n callNode) eval(vars colorExprVars) float32 {
+	a := make([]float32, len(n.args))
+	for i, arg := range n.args {
+		a[i] = arg.eval(vars)
+	}
+	switch n.name {
+	case "min":
+		if a[0] < a[1] {
+			return a[0]
+		}
+		return a[1]
+	case "max":
+		if a[0] > a[1] {
+			return a[0]
+		}
+		return a[1]
+	case "clamp":
+		return minf32(maxf32(a[0], a[1]), a[2])
+	case "abs":
+		if a[0] < 0 {
+			return -a[0]
+		}
+		return a[0]
+	}
+	return 0
+}
+
+func minf32(x, y float32) float32 {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func maxf32(x, y float32) float32 {
+	if x > y {
+		return x
+	}
+	return y
+}
+
+// parseColorExprNode parses a single arithmetic expression over the
+// variables r, g, b, a, supporting +, -, *, /, unary minus, parentheses and
+// the functions min, max, clamp and abs.
+func parseColorExprNode(s string) (colorExprNode, error) {
+	p := &colorExprParser{s: s}
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected input at %q", p.s[p.pos:])
+	}
+	return node, nil
+}
+
+type colorExprParser struct {
+	s   string
+	pos int
+}
+
+func (p *colorExprParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// parseExpr parses a sequence of terms separated by + or -.
+func (p *colorExprParser) parseExpr() (colorExprNode, error) {
+	x, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '+' || p.peek() == '-' {
+		op := p.peek()
+		p.pos++
+		y, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		x = binaryNode{op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+// parseTerm parses a sequence of factors separated by * or /.
+func (p *colorExprParser) parseTerm() (colorExprNode, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == '*' || p.peek() == '/' {
+		op := p.peek()
+		p.pos++
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = binaryNode{op: op, x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *colorExprParser) parseUnary() (colorExprNode, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *colorExprParser) parsePrimary() (colorExprNode, error) {
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return x, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case isAlpha(c):
+		return p.parseIdentOrCall()
+	default:
+		return nil, fmt.Errorf("unexpected character %q", string(c))
+	}
+}
+
+func (p *colorExprParser) parseNumber() (colorExprNode, error) {
+	start := p.pos
+	for p.pos < len(p.s) && (isDigit(p.s[p.pos]) || p.s[p.pos] == '.') {
+		p.pos++
+	}
+	f, err := strconv.ParseFloat(p.s[start:p.pos], 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", p.s[start:p.pos])
+	}
+	return numberNode(f), nil
+}
+
+func (p *colorExprParser) parseIdentOrCall() (colorExprNode, error) {
+	start := p.pos
+	for p.pos < len(p.s) && isAlpha(p.s[p.pos]) {
+		p.pos++
+	}
+	name := p.s[start:p.pos]
+
+	if p.peek() != '(' {
+		if len(name) != 1 || !strings.ContainsRune("rgba", rune(name[0])) {
+			return nil, fmt.Errorf("unknown variable %q", name)
+		}
+		return varNode(name[0]), nil
+	}
+
+	p.pos++ // consume '('
+	var args []colorExprNode
+	for p.peek() != ')' {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	if p.peek() != ')' {
+		return nil, fmt.Errorf("missing closing parenthesis in call to %q", name)
+	}
+	p.pos++
+
+	wantArgs := map[string]int{"min": 2, "max": 2, "clamp": 3, "abs": 1}
+	n, ok := wantArgs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q", name)
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("%s takes %d argument(s), got %d", name, n, len(args))
+	}
+	return callNode{name: name, args: args}, nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isAlpha(c byte) bool { return c >= 'a' && c <= 'z' }
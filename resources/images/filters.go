@@ -16,6 +16,9 @@ package images
 
 import (
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
 
 	"github.com/gohugoio/hugo/common/hugio"
 	"github.com/gohugoio/hugo/common/maps"
@@ -31,11 +34,56 @@ const filterAPIVersion = 0
 type Filters struct {
 }
 
-// Overlay creates a filter that overlays src at position x y.
-func (*Filters) Overlay(src ImageSource, x, y any) gift.Filter {
+// Overlay creates a filter that overlays src on top of the filtered image.
+// It accepts either the positional x, y form:
+//
+//	{{ images.Overlay $logo 10 10 }}
+//
+// or a single options map supporting:
+//
+//	x, y     position in pixels, relative to anchor (int, default 0)
+//	anchor   where to position src: top-left, top, top-right, left, center,
+//	         right, bottom-left, bottom, bottom-right (string, default "top-left")
+//	opacity  overlay opacity in percent (float, 0-100, default 100)
+//	tile     repeat src across the whole image, e.g. for a watermark pattern (bool, default false)
+//	rotate   angle in degrees to rotate src counter-clockwise before compositing (float, default 0)
+func (*Filters) Overlay(src ImageSource, args ...any) gift.Filter {
+	f := overlayFilter{src: src, opacity: 100, anchor: gift.TopLeftAnchor}
+
+	switch len(args) {
+	case 2:
+		f.x = cast.ToInt(args[0])
+		f.y = cast.ToInt(args[1])
+	case 1:
+		opt := maps.MustToParamsAndPrepare(args[0])
+		for option, v := range opt {
+			switch option {
+			case "x":
+				f.x = cast.ToInt(v)
+			case "y":
+				f.y = cast.ToInt(v)
+			case "anchor":
+				anchor, ok := parseOverlayAnchor(cast.ToString(v))
+				if !ok {
+					panic(fmt.Sprintf("images.Overlay: invalid anchor %q", v))
+				}
+				f.anchor = anchor
+				f.useAnchor = true
+			case "opacity":
+				f.opacity = cast.ToFloat32(v)
+			case "tile":
+				f.tile = cast.ToBool(v)
+			case "rotate":
+				f.rotate = cast.ToFloat32(v)
+			}
+		}
+	default:
+		panic(fmt.Sprintf("images.Overlay: expected (x, y) or a single options map, got %d arguments", len(args)))
+	}
+
 	return filter{
-		Options: newFilterOpts(src.Key(), x, y),
-		Filter:  overlayFilter{src: src, x: cast.ToInt(x), y: cast.ToInt(y)},
+		Options: newFilterOpts(src.Key(), f.x, f.y, f.anchor, f.useAnchor, f.opacity, f.tile, f.rotate),
+		Filter:  f,
 	}
 }
 
@@ -95,10 +143,14 @@ func (*Filters) Text(text string, options ...any) gift.Filter {
 
 // Brightness creates a filter that changes the brightness of an image.
 // The percentage parameter must be in range (-100, 100).
-func (*Filters) Brightness(percentage any) gift.Filter {
+// The options map supports:
+//
+//	linear  apply the adjustment in linear light instead of sRGB (bool, default false)
+func (*Filters) Brightness(percentage any, options ...any) gift.Filter {
+	linear := parseLinearOption(options)
 	return filter{
-		Options: newFilterOpts(percentage),
-		Filter:  gift.Brightness(cast.ToFloat32(percentage)),
+		Options: newFilterOpts(percentage, linear),
+		Filter:  maybeLinear(gift.Brightness(cast.ToFloat32(percentage)), linear),
 	}
 }
 
@@ -131,6 +183,53 @@ func (*Filters) Contrast(percentage any) gift.Filter {
 	}
 }
 
+// Convolution creates a filter that applies a convolution matrix (kernel) to an image.
+// Kernel must be a flat, square slice in row-major order, e.g. a 9-element slice for a 3x3
+// kernel or a 25-element slice for a 5x5 kernel, which lets template authors build their own
+// edge detectors (Sobel, Laplacian), emboss or sharpening kernels.
+// The options map supports:
+//
+//	normalize  normalize the kernel so its weights sum to 1 (bool, default false)
+//	alpha      apply the convolution to the alpha channel too (bool, default false)
+//	abs        take the absolute value of the result, useful for edge-detection kernels (bool, default false)
+//	delta      value added to each resulting pixel's channels (float, default 0)
+func (*Filters) Convolution(kernel any, options ...any) gift.Filter {
+	k := toFloat32Slice(kernel)
+
+	var normalize, alpha, abs bool
+	var delta float64
+
+	if len(options) > 0 {
+		opt := maps.MustToParamsAndPrepare(options[0])
+		for option, v := range opt {
+			switch option {
+			case "normalize":
+				normalize = cast.ToBool(v)
+			case "alpha":
+				alpha = cast.ToBool(v)
+			case "abs":
+				abs = cast.ToBool(v)
+			case "delta":
+				delta = cast.ToFloat64(v)
+			}
+		}
+	}
+
+	return filter{
+		Options: newFilterOpts(k, normalize, alpha, abs, delta),
+		Filter:  gift.Convolution(k, normalize, alpha, abs, float32(delta)),
+	}
+}
+
+func toFloat32Slice(v any) []float32 {
+	s := cast.ToSlice(v)
+	k := make([]float32, len(s))
+	for i, e := range s {
+		k[i] = cast.ToFloat32(e)
+	}
+	return k
+}
+
 // Gamma creates a filter that performs a gamma correction on an image.
 // The gamma parameter must be positive. Gamma = 1 gives the original image.
 // Gamma less than 1 darkens the image and gamma greater than 1 lightens it.
@@ -142,10 +241,16 @@ func (*Filters) Gamma(gamma any) gift.Filter {
 }
 
 // GaussianBlur creates a filter that applies a gaussian blur to an image.
-func (*Filters) GaussianBlur(sigma any) gift.Filter {
+// The options map supports:
+//
+//	linear  blur in linear light instead of sRGB, which avoids the dark
+//	        fringing that gamma-encoded blurring produces on high-contrast
+//	        edges (bool, default false)
+func (*Filters) GaussianBlur(sigma any, options ...any) gift.Filter {
+	linear := parseLinearOption(options)
 	return filter{
-		Options: newFilterOpts(sigma),
-		Filter:  gift.GaussianBlur(cast.ToFloat32(sigma)),
+		Options: newFilterOpts(sigma, linear),
+		Filter:  maybeLinear(gift.GaussianBlur(cast.ToFloat32(sigma)), linear),
 	}
 }
 
@@ -172,6 +277,85 @@ func (*Filters) Invert() gift.Filter {
 	}
 }
 
+// Maximum creates a filter that replaces each pixel with the maximum of the color values in a ksize x ksize window around it.
+// If disk is true, a disk-shaped kernel is used instead of a square one. Maximum is a dilation operator, it grows bright regions.
+func (*Filters) Maximum(ksize, disk any) gift.Filter {
+	return filter{
+		Options: newFilterOpts(ksize, disk),
+		Filter:  gift.Maximum(cast.ToInt(ksize), cast.ToBool(disk)),
+	}
+}
+
+// Mean creates a filter that replaces each pixel with the mean of the color values in a ksize x ksize window around it.
+// If disk is true, a disk-shaped kernel is used instead of a square one.
+func (*Filters) Mean(ksize, disk any) gift.Filter {
+	return filter{
+		Options: newFilterOpts(ksize, disk),
+		Filter:  gift.Mean(cast.ToInt(ksize), cast.ToBool(disk)),
+	}
+}
+
+// Median creates a filter that replaces each pixel with the median of the color values in a ksize x ksize window around it.
+// If disk is true, a disk-shaped kernel is used instead of a square one. Median is useful for removing salt-and-pepper noise.
+func (*Filters) Median(ksize, disk any) gift.Filter {
+	return filter{
+		Options: newFilterOpts(ksize, disk),
+		Filter:  gift.Median(cast.ToInt(ksize), cast.ToBool(disk)),
+	}
+}
+
+// Minimum creates a filter that replaces each pixel with the minimum of the color values in a ksize x ksize window around it.
+// If disk is true, a disk-shaped kernel is used instead of a square one. Minimum is an erosion operator, it grows dark regions.
+func (*Filters) Minimum(ksize, disk any) gift.Filter {
+	return filter{
+		Options: newFilterOpts(ksize, disk),
+		Filter:  gift.Minimum(cast.ToInt(ksize), cast.ToBool(disk)),
+	}
+}
+
+// Pipeline creates a single filter that applies filters as one ordered
+// chain, sharing one gift.GIFT and therefore one set of intermediate image
+// allocations, so a resource's Filter method produces and caches a single
+// output image for the whole chain instead of one per step, e.g.:
+//
+//	{{ $img := $img.Filter (images.Pipeline "thumb" (images.Resize "600x") (images.UnsharpMask 1 1 0) (images.Overlay $logo 10 10)) }}
+//
+// name is only mixed into the cache key, so the same filters reused under a
+// different name produce a distinct cached result. Transformation filters
+// such as Resize, Rotate and Crop can be included alongside effects filters.
+func (*Filters) Pipeline(name string, filters ...gift.Filter) gift.Filter {
+	vals := make([]any, 0, len(filters)+1)
+	vals = append(vals, name)
+	for _, f := range filters {
+		if pf, ok := f.(filter); ok {
+			vals = append(vals, pf.Options)
+		} else {
+			vals = append(vals, f)
+		}
+	}
+
+	return filter{
+		Options: newFilterOpts(vals...),
+		Filter:  pipelineFilter{filters: filters},
+	}
+}
+
+type pipelineFilter struct {
+	filters []gift.Filter
+}
+
+func (p pipelineFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	return gift.New(p.filters...).Bounds(srcBounds)
+}
+
+func (p pipelineFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	g := gift.New(p.filters...)
+	if options != nil {
+		g.Options = *options
+	}
+	g.Draw(dst, src)
+}
+
 // Pixelate creates a filter that applies a pixelation effect to an image.
 func (*Filters) Pixelate(size any) gift.Filter {
 	return filter{
@@ -210,10 +394,218 @@ func (*Filters) Sigmoid(midpoint, factor any) gift.Filter {
 // Sigma must be positive. Sharpen radius roughly equals 3 * sigma.
 // The amount parameter controls how much darker and how much lighter the edge borders become. Typically between 0.5 and 1.5.
 // The threshold parameter controls the minimum brightness change that will be sharpened. Typically between 0 and 0.05.
-func (*Filters) UnsharpMask(sigma, amount, threshold any) gift.Filter {
+// The options map supports:
+//
+//	linear  sharpen in linear light instead of sRGB (bool, default false)
+func (*Filters) UnsharpMask(sigma, amount, threshold any, options ...any) gift.Filter {
+	linear := parseLinearOption(options)
+	return filter{
+		Options: newFilterOpts(sigma, amount, threshold, linear),
+		Filter:  maybeLinear(gift.UnsharpMask(cast.ToFloat32(sigma), cast.ToFloat32(amount), cast.ToFloat32(threshold)), linear),
+	}
+}
+
+// ColorFunc creates a filter that changes the colors of an image using a small
+// expression language. expr is four comma-separated expressions, one each for
+// the r, g, b and a output channels, written in terms of the input channel
+// variables r, g, b and a (all in range 0-1). Supports +, -, *, /, unary
+// minus, parentheses and the functions min, max, clamp and abs, which is
+// enough to do channel swaps, custom tone curves, threshold masks or
+// duotones from template code, e.g.:
+//
+//	{{ $img := $img.Filter (images.ColorFunc "max(r,g,b), max(r,g,b), max(r,g,b), a") }}
+//
+// Compiled expressions are cached by their normalized expr string so
+// repeated resource invocations don't re-parse it.
+func (*Filters) ColorFunc(expr string) gift.Filter {
+	c := getColorExpr(expr)
+	return filter{
+		Options: newFilterOpts(normalizeColorExpr(expr)),
+		Filter: gift.ColorFunc(func(r0, g0, b0, a0 float32) (r, g, b, a float32) {
+			return c.eval(r0, g0, b0, a0)
+		}),
+	}
+}
+
+// ColorspaceSRGBToLinear creates a filter that converts the colors of an image from sRGB to linear RGB.
+// It's primarily useful for chaining in front of filters that are not linear-light aware,
+// paired with a matching ColorspaceLinearToSRGB at the end of the chain.
+func (*Filters) ColorspaceSRGBToLinear() gift.Filter {
 	return filter{
-		Options: newFilterOpts(sigma, amount, threshold),
-		Filter:  gift.UnsharpMask(cast.ToFloat32(sigma), cast.ToFloat32(amount), cast.ToFloat32(threshold)),
+		Filter: gift.ColorspaceSRGBToLinear(),
+	}
+}
+
+// ColorspaceLinearToSRGB creates a filter that converts the colors of an image from linear RGB back to sRGB.
+func (*Filters) ColorspaceLinearToSRGB() gift.Filter {
+	return filter{
+		Filter: gift.ColorspaceLinearToSRGB(),
+	}
+}
+
+// parseLinearOption extracts the "linear" key from an options map passed as the
+// last variadic argument to a filter constructor.
+func parseLinearOption(options []any) bool {
+	if len(options) == 0 {
+		return false
+	}
+	opt := maps.MustToParamsAndPrepare(options[0])
+	return cast.ToBool(opt["linear"])
+}
+
+// maybeLinear wraps f so it operates in linear light instead of sRGB when linear is true.
+func maybeLinear(f gift.Filter, linear bool) gift.Filter {
+	if !linear {
+		return f
+	}
+	return linearFilter{filter: f}
+}
+
+// linearFilter sandwiches a filter's Draw between an sRGB-to-linear and a
+// linear-to-sRGB conversion, so e.g. blurring and resizing operate on light
+// values instead of gamma-encoded ones, which produces cleaner high-contrast edges.
+type linearFilter struct {
+	filter gift.Filter
+}
+
+func (f linearFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	return f.filter.Bounds(srcBounds)
+}
+
+func (f linearFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	g := gift.New(gift.ColorspaceSRGBToLinear(), f.filter, gift.ColorspaceLinearToSRGB())
+	if options != nil {
+		g.Options = *options
+	}
+	g.Draw(dst, src)
+}
+
+// overlayFilter composites src onto the filtered image, optionally anchored,
+// tiled, rotated and/or blended at less than full opacity.
+type overlayFilter struct {
+	src ImageSource
+
+	x, y      int
+	anchor    gift.Anchor
+	useAnchor bool
+	opacity   float32
+	tile      bool
+	rotate    float32
+}
+
+func (f overlayFilter) Bounds(srcBounds image.Rectangle) (dstBounds image.Rectangle) {
+	return srcBounds
+}
+
+func (f overlayFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	overlay, err := f.src.DecodeImage()
+	if err != nil {
+		panic(err)
+	}
+
+	if f.rotate != 0 {
+		g := gift.New(gift.Rotate(f.rotate, color.Transparent, gift.CubicInterpolation))
+		rotated := image.NewRGBA(g.Bounds(overlay.Bounds()))
+		g.Draw(rotated, overlay)
+		overlay = rotated
+	}
+
+	var mask image.Image
+	if f.opacity < 100 {
+		opacity := f.opacity
+		if opacity < 0 {
+			opacity = 0
+		}
+		mask = image.NewUniform(color.Alpha{A: uint8(opacity / 100 * 255)})
+	}
+
+	b := dst.Bounds()
+	ow, oh := overlay.Bounds().Dx(), overlay.Bounds().Dy()
+
+	if f.tile {
+		for y := b.Min.Y - oh + 1; y < b.Max.Y; y += oh {
+			for x := b.Min.X - ow + 1; x < b.Max.X; x += ow {
+				drawOverlayAt(dst, overlay, image.Pt(x, y), mask)
+			}
+		}
+		return
+	}
+
+	pt := image.Pt(f.x, f.y)
+	if f.useAnchor {
+		pt = overlayAnchorPt(b, ow, oh, f.anchor).Add(pt)
+	}
+	drawOverlayAt(dst, overlay, pt, mask)
+}
+
+// drawOverlayAt composites overlay onto dst with its top-left corner at pt,
+// clipped to dst's bounds, optionally through a uniform opacity mask.
+func drawOverlayAt(dst draw.Image, overlay image.Image, pt image.Point, mask image.Image) {
+	r := overlay.Bounds().Sub(overlay.Bounds().Min).Add(pt).Intersect(dst.Bounds())
+	if r.Empty() {
+		return
+	}
+	sp := overlay.Bounds().Min.Add(r.Min.Sub(pt))
+	if mask != nil {
+		draw.DrawMask(dst, r, overlay, sp, mask, image.Point{}, draw.Over)
+	} else {
+		draw.Draw(dst, r, overlay, sp, draw.Over)
+	}
+}
+
+// overlayAnchorPt mirrors gift's unexported anchorPt: it returns the
+// top-left corner an w x h box must be placed at within b to sit at anchor.
+func overlayAnchorPt(b image.Rectangle, w, h int, anchor gift.Anchor) image.Point {
+	var x, y int
+	switch anchor {
+	case gift.TopLeftAnchor:
+		x, y = b.Min.X, b.Min.Y
+	case gift.TopAnchor:
+		x, y = b.Min.X+(b.Dx()-w)/2, b.Min.Y
+	case gift.TopRightAnchor:
+		x, y = b.Max.X-w, b.Min.Y
+	case gift.LeftAnchor:
+		x, y = b.Min.X, b.Min.Y+(b.Dy()-h)/2
+	case gift.RightAnchor:
+		x, y = b.Max.X-w, b.Min.Y+(b.Dy()-h)/2
+	case gift.BottomLeftAnchor:
+		x, y = b.Min.X, b.Max.Y-h
+	case gift.BottomAnchor:
+		x, y = b.Min.X+(b.Dx()-w)/2, b.Max.Y-h
+	case gift.BottomRightAnchor:
+		x, y = b.Max.X-w, b.Max.Y-h
+	default: // gift.CenterAnchor
+		x, y = b.Min.X+(b.Dx()-w)/2, b.Min.Y+(b.Dy()-h)/2
+	}
+	return image.Pt(x, y)
+}
+
+// parseOverlayAnchor maps the anchor names accepted by Filters.Overlay's
+// options map to their gift.Anchor value.
+func parseOverlayAnchor(s string) (gift.Anchor, bool) {
+	switch s {
+	case "top-left":
+		return gift.TopLeftAnchor, true
+	case "top":
+		return gift.TopAnchor, true
+	case "top-right":
+		return gift.TopRightAnchor, true
+	case "left":
+		return gift.LeftAnchor, true
+	case "center":
+		return gift.CenterAnchor, true
+	case "right":
+		return gift.RightAnchor, true
+	case "bottom-left":
+		return gift.BottomLeftAnchor, true
+	case "bottom":
+		return gift.BottomAnchor, true
+	case "bottom-right":
+		return gift.BottomRightAnchor, true
+	default:
+		return 0, false
 	}
 }
 
@@ -0,0 +1,315 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package images
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/disintegration/gift"
+)
+
+// drawFiltered runs f over src using a fresh gift.GIFT, the same way
+// resource.Filter does for a single ad hoc filter, and returns the result.
+func drawFiltered(f gift.Filter, src image.Image) *image.RGBA {
+	g := gift.New(f)
+	dst := image.NewRGBA(g.Bounds(src.Bounds()))
+	g.Draw(dst, src)
+	return dst
+}
+
+// uniformGray builds a w x h image where every pixel is the same gray level,
+// so any windowed filter's output at the center (away from edge effects)
+// should equal the input.
+func uniformGray(w, h int, level uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{Y: level})
+		}
+	}
+	return img
+}
+
+func TestMorphologicalFiltersOnUniformImage(t *testing.T) {
+	src := uniformGray(5, 5, 128)
+	center := image.Pt(2, 2)
+
+	filters := map[string]gift.Filter{
+		"Mean":    (&Filters{}).Mean(3, false),
+		"Median":  (&Filters{}).Median(3, false),
+		"Maximum": (&Filters{}).Maximum(3, false),
+		"Minimum": (&Filters{}).Minimum(3, false),
+	}
+
+	for name, f := range filters {
+		dst := drawFiltered(f, src)
+		r, g, b, _ := dst.At(center.X, center.Y).RGBA()
+		if r>>8 != 128 || g>>8 != 128 || b>>8 != 128 {
+			t.Errorf("%s: center pixel of a uniform image changed: got (%d,%d,%d), want (128,128,128)", name, r>>8, g>>8, b>>8)
+		}
+	}
+}
+
+func TestMaximumAndMinimumAreMonotonic(t *testing.T) {
+	// A single bright pixel in an otherwise dark image: Maximum must grow the
+	// bright region (the center pixel's neighbors lighten), Minimum must not.
+	src := uniformGray(5, 5, 0)
+	src.SetGray(2, 2, color.Gray{Y: 255})
+
+	max := drawFiltered((&Filters{}).Maximum(3, false), src)
+	if r, _, _, _ := max.At(1, 1).RGBA(); r>>8 != 255 {
+		t.Errorf("Maximum: expected the bright pixel's neighbor to become 255, got %d", r>>8)
+	}
+
+	min := drawFiltered((&Filters{}).Minimum(3, false), src)
+	if r, _, _, _ := min.At(2, 2).RGBA(); r>>8 != 0 {
+		t.Errorf("Minimum: expected the bright pixel itself to be eroded to 0, got %d", r>>8)
+	}
+}
+
+// fakeFilter records whether it was asked to Draw, so tests can tell linear
+// mode actually routed through the sRGB<->linear sandwich instead of
+// invoking the wrapped filter directly.
+type fakeFilter struct {
+	drawn *bool
+}
+
+func (f fakeFilter) Bounds(srcBounds image.Rectangle) image.Rectangle { return srcBounds }
+
+func (f fakeFilter) Draw(dst draw.Image, src image.Image, options *gift.Options) {
+	*f.drawn = true
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Src)
+}
+
+func TestMaybeLinear(t *testing.T) {
+	if _, ok := maybeLinear(gift.Invert(), false).(linearFilter); ok {
+		t.Errorf("maybeLinear(linear=false) should return the filter as-is, not a linearFilter")
+	}
+
+	wrapped := maybeLinear(gift.Invert(), true)
+	if _, ok := wrapped.(linearFilter); !ok {
+		t.Errorf("maybeLinear(linear=true) should return a linearFilter, got %T", wrapped)
+	}
+}
+
+func TestLinearFilterRoundTripsColorspace(t *testing.T) {
+	var drawn bool
+	src := uniformGray(2, 2, 128)
+
+	dst := drawFiltered(linearFilter{filter: fakeFilter{drawn: &drawn}}, src)
+
+	if !drawn {
+		t.Fatal("expected the wrapped filter's Draw to be called")
+	}
+
+	// sRGB -> linear -> (identity-ish fakeFilter) -> sRGB should round-trip
+	// close to the original value; gift's colorspace conversion isn't
+	// perfectly lossless at 8 bits, so allow a small tolerance.
+	r, _, _, _ := dst.At(0, 0).RGBA()
+	got := int(r >> 8)
+	if got < 126 || got > 130 {
+		t.Errorf("expected the round-tripped gray level to stay close to 128, got %d", got)
+	}
+}
+
+func TestColorFuncExprEval(t *testing.T) {
+	tests := []struct {
+		expr                       string
+		r0, g0, b0, a0             float32
+		wantR, wantG, wantB, wantA float32
+	}{
+		{"r, g, b, a", 0.1, 0.2, 0.3, 1, 0.1, 0.2, 0.3, 1},
+		{"max(r,g,b), max(r,g,b), max(r,g,b), a", 0.1, 0.6, 0.3, 1, 0.6, 0.6, 0.6, 1},
+		{"min(r,g,b), min(r,g,b), min(r,g,b), a", 0.1, 0.6, 0.3, 1, 0.1, 0.1, 0.1, 1},
+		{"clamp(r*2, 0, 1), g, b, a", 0.9, 0.2, 0.3, 1, 1, 0.2, 0.3, 1},
+		{"-r, g, b, a", 0.4, 0.2, 0.3, 1, -0.4, 0.2, 0.3, 1},
+		{"abs(-r), g, b, a", 0.4, 0.2, 0.3, 1, 0.4, 0.2, 0.3, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			c := getColorExpr(tt.expr)
+			r, g, b, a := c.eval(tt.r0, tt.g0, tt.b0, tt.a0)
+			if r != tt.wantR || g != tt.wantG || b != tt.wantB || a != tt.wantA {
+				t.Errorf("eval(%q) = (%v,%v,%v,%v), want (%v,%v,%v,%v)", tt.expr, r, g, b, a, tt.wantR, tt.wantG, tt.wantB, tt.wantA)
+			}
+		})
+	}
+}
+
+func TestColorFuncExprCaching(t *testing.T) {
+	a := getColorExpr("r, g, b, a")
+	b := getColorExpr("r,   g,\tb,\na")
+	if a != b {
+		t.Errorf("expected expressions that differ only in whitespace to share a cache entry")
+	}
+}
+
+func TestColorFuncExprInvalid(t *testing.T) {
+	tests := []string{
+		"r, g, b",          // wrong number of channels
+		"r, g, b, a, a",    // wrong number of channels
+		"r, g, b, nope(r)", // unknown function
+		"r, g, b, q",       // unknown variable
+		"r, g, b, (r",      // unbalanced parens
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected getColorExpr(%q) to panic on invalid input", expr)
+				}
+			}()
+			getColorExpr(expr)
+		})
+	}
+}
+
+func TestToFloat32Slice(t *testing.T) {
+	got := toFloat32Slice([]int{0, -1, 0, -1, 5, -1, 0, -1, 0})
+	want := []float32{0, -1, 0, -1, 5, -1, 0, -1, 0}
+	if len(got) != len(want) {
+		t.Fatalf("got %d elements, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("index %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestToFloat32SliceFromFloats(t *testing.T) {
+	got := toFloat32Slice([]float64{0.5, 1.5, -2.25})
+	want := []float32{0.5, 1.5, -2.25}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("index %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPipelineFilterComposesInOrder(t *testing.T) {
+	src := uniformGray(4, 4, 10)
+
+	p := (&Filters{}).Pipeline("test", gift.Invert(), gift.Invert())
+	dst := drawFiltered(p, src)
+
+	r, _, _, _ := dst.At(0, 0).RGBA()
+	if got := int(r >> 8); got != 10 {
+		t.Errorf("Invert composed with Invert should be a no-op, got gray level %d, want 10", got)
+	}
+}
+
+func TestPipelineFilterBounds(t *testing.T) {
+	p := pipelineFilter{filters: []gift.Filter{gift.Resize(8, 8, gift.LanczosResampling)}}
+	got := p.Bounds(image.Rect(0, 0, 4, 4))
+	want := image.Rect(0, 0, 8, 8)
+	if got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
+
+func TestParseOverlayAnchor(t *testing.T) {
+	tests := []struct {
+		name string
+		want gift.Anchor
+	}{
+		{"top-left", gift.TopLeftAnchor},
+		{"top", gift.TopAnchor},
+		{"top-right", gift.TopRightAnchor},
+		{"left", gift.LeftAnchor},
+		{"center", gift.CenterAnchor},
+		{"right", gift.RightAnchor},
+		{"bottom-left", gift.BottomLeftAnchor},
+		{"bottom", gift.BottomAnchor},
+		{"bottom-right", gift.BottomRightAnchor},
+	}
+	for _, tt := range tests {
+		got, ok := parseOverlayAnchor(tt.name)
+		if !ok {
+			t.Errorf("parseOverlayAnchor(%q): expected ok=true", tt.name)
+		}
+		if got != tt.want {
+			t.Errorf("parseOverlayAnchor(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, ok := parseOverlayAnchor("nowhere"); ok {
+		t.Errorf("parseOverlayAnchor(%q): expected ok=false for an unknown anchor", "nowhere")
+	}
+}
+
+func TestOverlayAnchorPt(t *testing.T) {
+	// A 10x10 box (b) with a 4x2 overlay (w x h) placed at each anchor.
+	b := image.Rect(0, 0, 10, 10)
+	w, h := 4, 2
+
+	tests := []struct {
+		anchor gift.Anchor
+		want   image.Point
+	}{
+		{gift.TopLeftAnchor, image.Pt(0, 0)},
+		{gift.TopAnchor, image.Pt(3, 0)},
+		{gift.TopRightAnchor, image.Pt(6, 0)},
+		{gift.LeftAnchor, image.Pt(0, 4)},
+		{gift.CenterAnchor, image.Pt(3, 4)},
+		{gift.RightAnchor, image.Pt(6, 4)},
+		{gift.BottomLeftAnchor, image.Pt(0, 8)},
+		{gift.BottomAnchor, image.Pt(3, 8)},
+		{gift.BottomRightAnchor, image.Pt(6, 8)},
+	}
+	for _, tt := range tests {
+		if got := overlayAnchorPt(b, w, h, tt.anchor); got != tt.want {
+			t.Errorf("overlayAnchorPt(anchor=%v) = %v, want %v", tt.anchor, got, tt.want)
+		}
+	}
+}
+
+func TestDrawOverlayAtWithOpacityMask(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	overlay := image.NewUniform(color.White)
+	overlayImg := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(overlayImg, overlayImg.Bounds(), overlay, image.Point{}, draw.Src)
+
+	mask := image.NewUniform(color.Alpha{A: 128})
+	drawOverlayAt(dst, overlayImg, image.Pt(0, 0), mask)
+
+	r, _, _, _ := dst.At(0, 0).RGBA()
+	got := int(r >> 8)
+	if got < 120 || got > 136 {
+		t.Errorf("expected a ~50%% opacity blend of white over black to land near 128, got %d", got)
+	}
+}
+
+func TestDrawOverlayAtClipsOutOfBounds(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	overlay := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(overlay, overlay.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	// Placed fully outside dst's bounds: drawOverlayAt must not panic and
+	// must leave dst untouched.
+	drawOverlayAt(dst, overlay, image.Pt(10, 10), nil)
+
+	r, _, _, _ := dst.At(0, 0).RGBA()
+	if r>>8 != 0 {
+		t.Errorf("expected dst to be untouched by an out-of-bounds overlay, got red=%d", r>>8)
+	}
+}
@@ -45,9 +45,27 @@ type ImageResourceOps interface {
 	//    {{ $image := $image.Filter (images.GaussianBlur 6) (images.Pixelate 8) }}
 	Filter(filters ...any) (ImageResource, error)
 
+	// AutoOrient rotates and flips the Image, if needed, to apply the
+	// orientation given by its EXIF Orientation tag, then clears that tag
+	// in the result. Images with no EXIF data, or an Orientation of 1
+	// (already upright), are returned unchanged.
+	//    {{ $image := $image.AutoOrient }}
+	AutoOrient() (ImageResource, error)
+
 	// Exif returns an ExifInfo object containing Image metadata.
 	Exif() *exif.ExifInfo
 
+	// ICCProfile returns the raw ICC color profile embedded in the source
+	// image, or nil if it has none.
+	ICCProfile() []byte
+
 	// Internal
 	DecodeImage() (image.Image, error)
 }
+
+// ImageSource is an image that can be used as the source of a filter, e.g.
+// the watermark passed to Filters.Overlay.
+type ImageSource interface {
+	resource.Identifier
+	DecodeImage() (image.Image, error)
+}
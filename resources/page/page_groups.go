@@ -0,0 +1,99 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PageGroup is a single group of Pages sharing a common key, as returned by
+// GroupBy, GroupByDate and GroupByExpression.
+type PageGroup struct {
+	Key   interface{}
+	Pages Pages
+}
+
+// PagesGroup is the ordered list of PageGroup returned by a Pages grouping
+// method; the order reflects the asc (default) or desc argument given to
+// the call that produced it.
+type PagesGroup []PageGroup
+
+// GroupByExpression groups p by the stringified result of evaluating the Go
+// template expression expr against each page, e.g. `{{ .Date.Format
+// "Monday" }}` to group by weekday, `{{ printf "%.1s" .Title }}` to group
+// by first letter of title, or a template calling a custom shortcode-style
+// func for a reading-time bucket. exec does the actual template
+// compilation and execution; it is typically Hugo's template handler, and
+// shares its signature with the TemplateAttributeExecutor already used to
+// back permalink_attributes, so the same handler instance can back both.
+//
+// order is "asc" (the default) or "desc", honoring the same contract as
+// GroupBy. An expr that fails to parse, or panics while being evaluated
+// against a given page, is reported as an error that names the expression
+// (and, for a panic, the offending page) rather than silently dropping
+// pages from the result.
+func (p Pages) GroupByExpression(exec TemplateAttributeExecutor, expr string, order ...string) (PagesGroup, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+
+	groups := make(map[string]*PageGroup)
+	var keysInOrder []string
+
+	for _, pg := range p {
+		key, err := evalGroupExpression(exec, expr, pg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "GroupByExpression(%q)", expr)
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &PageGroup{Key: key}
+			groups[key] = g
+			keysInOrder = append(keysInOrder, key)
+		}
+		g.Pages = append(g.Pages, pg)
+	}
+
+	sort.Strings(keysInOrder)
+
+	if len(order) > 0 && strings.EqualFold(order[0], "desc") {
+		sort.Sort(sort.Reverse(sort.StringSlice(keysInOrder)))
+	}
+
+	result := make(PagesGroup, len(keysInOrder))
+	for i, key := range keysInOrder {
+		result[i] = *groups[key]
+	}
+
+	return result, nil
+}
+
+// evalGroupExpression runs expr against p via exec, turning any panic
+// inside exec (e.g. a template expression calling a method that doesn't
+// exist on some page variant) into a plain error, so a single problem page
+// can't crash an entire GroupByExpression call.
+func evalGroupExpression(exec TemplateAttributeExecutor, expr string, p Page) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic evaluating %q against %q: %v", expr, p.Path(), r)
+		}
+	}()
+
+	return exec.Execute(expr, p)
+}
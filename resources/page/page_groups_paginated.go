@@ -0,0 +1,100 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// GroupByPaginated groups p by keyFn, like GroupByExpression, but returns
+// at most pageSize groups at a time instead of materializing every group up
+// front. cursor is the empty string for the first page, and thereafter the
+// nextCursor this method itself returned; it is an opaque token (built on
+// EncodeCursor/DecodeCursor) encoding the key and index of the first page
+// not yet returned, so a later call can resume without re-scanning the
+// groups it already produced.
+//
+// p is sorted once, by keyFn, ascending, ties broken by the input order
+// (so re-running this over an unchanged Pages in an unchanged order always
+// resumes at the same spot for the same cursor). Called repeatedly with
+// each returned nextCursor fed back in, concatenating the results yields
+// the same PagesGroup as a single GroupByExpression-style call grouping
+// the whole set in one shot.
+func (p Pages) GroupByPaginated(keyFn func(Page) string, pageSize int, cursor string) (groups PagesGroup, nextCursor string, err error) {
+	if pageSize <= 0 {
+		return nil, "", errors.New("GroupByPaginated: pageSize must be > 0")
+	}
+
+	sorted := make(Pages, len(p))
+	copy(sorted, p)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return keyFn(sorted[i]) < keyFn(sorted[j])
+	})
+
+	start, err := groupPaginationResumeIndex(sorted, keyFn, cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	i := start
+	for i < len(sorted) && len(groups) < pageSize {
+		key := keyFn(sorted[i])
+		j := i
+		for j < len(sorted) && keyFn(sorted[j]) == key {
+			j++
+		}
+		groups = append(groups, PageGroup{Key: key, Pages: sorted[i:j]})
+		i = j
+	}
+
+	if i >= len(sorted) {
+		return groups, "", nil
+	}
+
+	nextCursor = EncodeCursor(keyFn(sorted[i]), strconv.Itoa(i))
+
+	return groups, nextCursor, nil
+}
+
+// groupPaginationResumeIndex decodes cursor (the empty string meaning
+// "start from the beginning") into an index into sorted. The encoded index
+// is trusted only if it still points at a page with the encoded key,
+// falling back to a linear search for that key otherwise (sorted having
+// shifted slightly between calls, e.g. a handful of pages added or removed
+// since the cursor was handed out).
+func groupPaginationResumeIndex(sorted Pages, keyFn func(Page) string, cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	key, rawIndex, err := DecodeCursor(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	if idx, err := strconv.Atoi(rawIndex); err == nil && idx >= 0 && idx < len(sorted) && keyFn(sorted[idx]) == key {
+		return idx, nil
+	}
+
+	for i, pg := range sorted {
+		if keyFn(pg) == key {
+			return i, nil
+		}
+	}
+
+	return 0, errors.Errorf("GroupByPaginated: cursor key %q not found", key)
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func paginationTestPages() Pages {
+	return Pages{
+		&groupTestPage{path: "/p1", title: "Apple", date: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		&groupTestPage{path: "/p2", title: "Avocado", date: time.Date(2020, 2, 5, 0, 0, 0, 0, time.UTC)},
+		&groupTestPage{path: "/p3", title: "Banana", date: time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC)},
+		&groupTestPage{path: "/p4", title: "Cherry", date: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)},
+		&groupTestPage{path: "/p5", title: "Date", date: time.Date(2020, 3, 15, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func monthKey(p Page) string {
+	return p.(*groupTestPage).date.Format("2006-01")
+}
+
+func TestGroupByPaginated(t *testing.T) {
+	c := qt.New(t)
+	pages := paginationTestPages()
+
+	c.Run("concatenating successive pages matches a single-shot grouping", func(c *qt.C) {
+		var all PagesGroup
+		cursor := ""
+		for {
+			groups, next, err := pages.GroupByPaginated(monthKey, 1, cursor)
+			c.Assert(err, qt.IsNil)
+			all = append(all, groups...)
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+
+		wantKeys := []string{"2020-01", "2020-02", "2020-03"}
+		gotKeys := make([]string, len(all))
+		for i, g := range all {
+			gotKeys[i] = g.Key.(string)
+		}
+		c.Assert(gotKeys, qt.DeepEquals, wantKeys)
+		c.Assert(len(all[0].Pages), qt.Equals, 2)
+		c.Assert(len(all[2].Pages), qt.Equals, 2)
+	})
+
+	c.Run("pageSize bigger than the group count returns everything in one call", func(c *qt.C) {
+		groups, next, err := pages.GroupByPaginated(monthKey, 10, "")
+		c.Assert(err, qt.IsNil)
+		c.Assert(next, qt.Equals, "")
+		c.Assert(len(groups), qt.Equals, 3)
+	})
+
+	c.Run("cursor is stable across separate calls when the page order is unchanged", func(c *qt.C) {
+		_, cursor1, err := pages.GroupByPaginated(monthKey, 1, "")
+		c.Assert(err, qt.IsNil)
+
+		rebuilt := paginationTestPages()
+		_, cursor2, err := rebuilt.GroupByPaginated(monthKey, 1, "")
+		c.Assert(err, qt.IsNil)
+
+		c.Assert(cursor1, qt.Equals, cursor2)
+
+		groups, _, err := pages.GroupByPaginated(monthKey, 1, cursor1)
+		c.Assert(err, qt.IsNil)
+		c.Assert(groups[0].Key.(string), qt.Equals, "2020-02")
+	})
+
+	c.Run("an invalid cursor is an error, not a silent reset to page 1", func(c *qt.C) {
+		_, _, err := pages.GroupByPaginated(monthKey, 1, "not-a-real-cursor")
+		c.Assert(err, qt.Not(qt.IsNil))
+	})
+
+	c.Run("pageSize must be positive", func(c *qt.C) {
+		_, _, err := pages.GroupByPaginated(monthKey, 0, "")
+		c.Assert(err, qt.ErrorMatches, "GroupByPaginated: pageSize must be > 0")
+	})
+}
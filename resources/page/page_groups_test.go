@@ -0,0 +1,104 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// groupTestPage is a minimal Page stand-in for GroupByExpression tests.
+type groupTestPage struct {
+	path  string
+	title string
+	date  time.Time
+}
+
+func (p *groupTestPage) Path() string    { return p.path }
+func (p *groupTestPage) Title() string   { return p.title }
+func (p *groupTestPage) Date() time.Time { return p.date }
+func (p *groupTestPage) String() string  { return p.path }
+
+// stringTemplateExecutor is a TemplateAttributeExecutor stand-in that
+// renders a tiny fixed set of expressions without pulling in the real
+// template engine, so GroupByExpression can be unit tested in isolation.
+type stringTemplateExecutor struct {
+	render func(tmplStr string, p Page) (string, error)
+}
+
+func (e stringTemplateExecutor) Execute(tmplStr string, p Page) (string, error) {
+	return e.render(tmplStr, p)
+}
+
+func TestGroupByExpression(t *testing.T) {
+	c := qt.New(t)
+
+	pages := Pages{
+		&groupTestPage{path: "/p1", title: "Apple", date: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		&groupTestPage{path: "/p2", title: "Avocado", date: time.Date(2020, 2, 5, 0, 0, 0, 0, time.UTC)},
+		&groupTestPage{path: "/p3", title: "Banana", date: time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	c.Run("group by time format expression", func(c *qt.C) {
+		exec := stringTemplateExecutor{render: func(_ string, p Page) (string, error) {
+			return p.Date().Format("January"), nil
+		}}
+
+		groups, err := pages.GroupByExpression(exec, `{{ .Date.Format "January" }}`, "asc")
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(groups), qt.Equals, 2)
+		c.Assert(groups[0].Key, qt.Equals, "February")
+		c.Assert(len(groups[0].Pages), qt.Equals, 1)
+		c.Assert(groups[1].Key, qt.Equals, "January")
+		c.Assert(len(groups[1].Pages), qt.Equals, 2)
+	})
+
+	c.Run("group by string-manipulation expression, desc", func(c *qt.C) {
+		exec := stringTemplateExecutor{render: func(_ string, p Page) (string, error) {
+			return strings.ToUpper(p.Title()[:1]), nil
+		}}
+
+		groups, err := pages.GroupByExpression(exec, `{{ printf "%.1s" .Title | upper }}`, "desc")
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(groups), qt.Equals, 2)
+		c.Assert(groups[0].Key, qt.Equals, "B")
+		c.Assert(groups[1].Key, qt.Equals, "A")
+		c.Assert(len(groups[1].Pages), qt.Equals, 2)
+	})
+
+	c.Run("parse/execution error is returned, not panicked", func(c *qt.C) {
+		exec := stringTemplateExecutor{render: func(_ string, p Page) (string, error) {
+			return "", fmt.Errorf("template: bad expression")
+		}}
+
+		_, err := pages.GroupByExpression(exec, `{{ .NoSuchMethod }}`)
+		c.Assert(err, qt.ErrorMatches, `GroupByExpression\(.*\): template: bad expression`)
+	})
+
+	c.Run("a panic evaluating one page is turned into an error", func(c *qt.C) {
+		exec := stringTemplateExecutor{render: func(_ string, p Page) (string, error) {
+			if p.Path() == "/p2" {
+				panic("boom")
+			}
+			return p.Title(), nil
+		}}
+
+		_, err := pages.GroupByExpression(exec, `{{ .Title }}`)
+		c.Assert(err, qt.ErrorMatches, `.*panic evaluating.*boom.*`)
+	})
+}
@@ -0,0 +1,286 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// Filter returns a new Pages holding only the pages for which pred returns
+// true. Like Where, the result is a plain Pages and so remains composable
+// with GroupBy*, ByDate, ByLength etc.
+func (p Pages) Filter(pred func(Page) bool) Pages {
+	var out Pages
+	for _, pg := range p {
+		if pred(pg) {
+			out = append(out, pg)
+		}
+	}
+	return out
+}
+
+// pageWhereFields are the built-in, non-Params fields Where accepts,
+// resolved by calling the identically named method on Page via reflection.
+var pageWhereFields = map[string]bool{
+	"Section": true, "Type": true, "Title": true,
+	"Date": true, "PublishDate": true, "Draft": true, "URL": true,
+}
+
+// Where returns a new Pages holding only the pages matching field op value.
+// field is either one of the built-in accessors in pageWhereFields, or a
+// dotted "Params.xxx" path into the page's front matter params, e.g.
+// "Params.tags" or "Params.author.name".
+//
+// Supported ops are =, !=, >, >=, <, <= (comparing numbers, times or
+// strings as appropriate), "in"/"not in" (field's value is one of the
+// elements of value, which must be slice-like) and "intersect" (field and
+// value are both slice-like and share at least one element — the usual way
+// to match pages against a set of taxonomy terms).
+//
+// Results remain a plain Pages, so they stay composable with GroupBy*,
+// ByDate, ByLength etc., e.g.
+// `.Pages.Where "Section" "=" "sect1" | .ByDate | .GroupByDate "2006-01"`.
+func (p Pages) Where(field, op string, value interface{}) (Pages, error) {
+	get, err := pageFieldGetter(field)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchErr error
+	result := p.Filter(func(pg Page) bool {
+		ok, err := whereCompare(get(pg), op, value)
+		if err != nil {
+			matchErr = err
+		}
+		return ok
+	})
+
+	if matchErr != nil {
+		return nil, matchErr
+	}
+
+	return result, nil
+}
+
+// pageFieldGetter resolves field to a function extracting that field's
+// value from a Page.
+func pageFieldGetter(field string) (func(Page) interface{}, error) {
+	if field == "" {
+		return nil, errors.New("where: empty field")
+	}
+
+	if field == "Params" || strings.HasPrefix(field, "Params.") {
+		path := strings.TrimPrefix(strings.TrimPrefix(field, "Params"), ".")
+		return func(pg Page) interface{} {
+			return lookupPageParam(pg, path)
+		}, nil
+	}
+
+	if !pageWhereFields[field] {
+		return nil, errors.Errorf("where: unknown field %q", field)
+	}
+
+	return func(pg Page) interface{} {
+		return callPageMethod(pg, field)
+	}, nil
+}
+
+// callPageMethod calls the named, argument-less Page method via reflection
+// and returns its first result, or nil if pg has no such method.
+func callPageMethod(pg Page, name string) interface{} {
+	m := reflect.ValueOf(pg).MethodByName(name)
+	if !m.IsValid() {
+		return nil
+	}
+	out := m.Call(nil)
+	if len(out) == 0 {
+		return nil
+	}
+	return out[0].Interface()
+}
+
+// lookupPageParam resolves a dotted path (e.g. "tags" or "author.name")
+// against pg.Params(), returning nil if any segment is missing.
+func lookupPageParam(pg Page, path string) interface{} {
+	var cur interface{} = callPageMethod(pg, "Params")
+	if path == "" {
+		return cur
+	}
+
+	for _, part := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		cur = m[part]
+	}
+
+	return cur
+}
+
+// whereCompare evaluates a op b for the ops Where supports.
+func whereCompare(a interface{}, op string, b interface{}) (bool, error) {
+	switch op {
+	case "in":
+		return sliceContains(b, a), nil
+	case "not in":
+		return !sliceContains(b, a), nil
+	case "intersect":
+		return slicesIntersect(a, b), nil
+	case "=", "!=", ">", ">=", "<", "<=":
+		return whereCompareOrdered(a, op, b)
+	default:
+		return false, errors.Errorf("where: unsupported operator %q", op)
+	}
+}
+
+// whereCompareOrdered handles the relational operators, coercing a and b to
+// a common comparable type (time, number or string, in that preference
+// order) before comparing.
+func whereCompareOrdered(a interface{}, op string, b interface{}) (bool, error) {
+	if at, ok := a.(time.Time); ok {
+		bt, err := cast.ToTimeE(b)
+		if err != nil {
+			return false, errors.Wrapf(err, "where: cannot compare time value to %T", b)
+		}
+		return compareOrderedInt64(op, at.UnixNano(), bt.UnixNano())
+	}
+	if bt, ok := b.(time.Time); ok {
+		at, err := cast.ToTimeE(a)
+		if err != nil {
+			return false, errors.Wrapf(err, "where: cannot compare %T to time value", a)
+		}
+		return compareOrderedInt64(op, at.UnixNano(), bt.UnixNano())
+	}
+
+	if af, err := cast.ToFloat64E(a); err == nil {
+		if bf, err := cast.ToFloat64E(b); err == nil {
+			return compareOrderedFloat64(op, af, bf)
+		}
+	}
+
+	return compareOrderedString(op, cast.ToString(a), cast.ToString(b))
+}
+
+func compareOrderedInt64(op string, a, b int64) (bool, error) {
+	switch op {
+	case "=":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	default:
+		return false, errors.Errorf("where: unsupported operator %q", op)
+	}
+}
+
+func compareOrderedFloat64(op string, a, b float64) (bool, error) {
+	switch op {
+	case "=":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	default:
+		return false, errors.Errorf("where: unsupported operator %q", op)
+	}
+}
+
+func compareOrderedString(op string, a, b string) (bool, error) {
+	switch op {
+	case "=":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	case ">":
+		return a > b, nil
+	case ">=":
+		return a >= b, nil
+	case "<":
+		return a < b, nil
+	case "<=":
+		return a <= b, nil
+	default:
+		return false, errors.Errorf("where: unsupported operator %q", op)
+	}
+}
+
+// toInterfaceSlice reflects v (expected to be a slice or array) into
+// []interface{}; a non-slice v is treated as a single-element slice.
+func toInterfaceSlice(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = rv.Index(i).Interface()
+	}
+	return out
+}
+
+// sliceContains reports whether needle equals (by string representation)
+// any element of haystack.
+func sliceContains(haystack interface{}, needle interface{}) bool {
+	target := cast.ToString(needle)
+	for _, v := range toInterfaceSlice(haystack) {
+		if cast.ToString(v) == target {
+			return true
+		}
+	}
+	return false
+}
+
+// slicesIntersect reports whether a and b, both slice-like (or scalars,
+// treated as single-element slices), share at least one element by string
+// representation. This backs the "intersect" op used to match a page's
+// Params.tags-style slice against a set of terms.
+func slicesIntersect(a, b interface{}) bool {
+	bSet := make(map[string]bool)
+	for _, v := range toInterfaceSlice(b) {
+		bSet[cast.ToString(v)] = true
+	}
+
+	for _, v := range toInterfaceSlice(a) {
+		if bSet[cast.ToString(v)] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// whereTestPage is a minimal Page stand-in for Where/Filter tests.
+type whereTestPage struct {
+	path    string
+	section string
+	title   string
+	date    time.Time
+	draft   bool
+	params  map[string]interface{}
+}
+
+func (p *whereTestPage) Path() string                   { return p.path }
+func (p *whereTestPage) Section() string                { return p.section }
+func (p *whereTestPage) Title() string                  { return p.title }
+func (p *whereTestPage) Date() time.Time                { return p.date }
+func (p *whereTestPage) Draft() bool                    { return p.draft }
+func (p *whereTestPage) Params() map[string]interface{} { return p.params }
+func (p *whereTestPage) String() string                 { return p.path }
+
+func whereTestPages() Pages {
+	return Pages{
+		&whereTestPage{
+			path: "/p1", section: "posts", title: "Apple", draft: false,
+			date:   time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC),
+			params: map[string]interface{}{"tags": []interface{}{"a", "b"}},
+		},
+		&whereTestPage{
+			path: "/p2", section: "posts", title: "Avocado", draft: true,
+			date:   time.Date(2020, 2, 5, 0, 0, 0, 0, time.UTC),
+			params: map[string]interface{}{"tags": []interface{}{"c"}},
+		},
+		&whereTestPage{
+			path: "/p3", section: "pages", title: "Banana", draft: false,
+			date:   time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC),
+			params: map[string]interface{}{"tags": []interface{}{"b", "d"}},
+		},
+	}
+}
+
+func TestPagesWhere(t *testing.T) {
+	c := qt.New(t)
+	pages := whereTestPages()
+
+	c.Run("equality on a built-in field", func(c *qt.C) {
+		got, err := pages.Where("Section", "=", "posts")
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(got), qt.Equals, 2)
+	})
+
+	c.Run("date comparison coerces a string value", func(c *qt.C) {
+		got, err := pages.Where("Date", ">", "2020-01-10")
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(got), qt.Equals, 1)
+		c.Assert(got[0].(*whereTestPage).path, qt.Equals, "/p2")
+	})
+
+	c.Run("intersect matches taxonomy-style Params slices", func(c *qt.C) {
+		got, err := pages.Where("Params.tags", "intersect", []interface{}{"a", "d"})
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(got), qt.Equals, 2)
+		c.Assert(got[0].(*whereTestPage).path, qt.Equals, "/p1")
+		c.Assert(got[1].(*whereTestPage).path, qt.Equals, "/p3")
+	})
+
+	c.Run("in matches against a list of values", func(c *qt.C) {
+		got, err := pages.Where("Title", "in", []interface{}{"Apple", "Banana"})
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(got), qt.Equals, 2)
+	})
+
+	c.Run("results stay a plain Pages, chainable into a second Where", func(c *qt.C) {
+		got, err := pages.Where("Section", "=", "posts")
+		c.Assert(err, qt.IsNil)
+		got, err = got.Where("Draft", "=", false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(len(got), qt.Equals, 1)
+		c.Assert(got[0].(*whereTestPage).path, qt.Equals, "/p1")
+	})
+
+	c.Run("an unknown field is reported, not silently ignored", func(c *qt.C) {
+		_, err := pages.Where("NoSuchField", "=", "x")
+		c.Assert(err, qt.ErrorMatches, `where: unknown field "NoSuchField"`)
+	})
+}
+
+func TestPagesFilter(t *testing.T) {
+	c := qt.New(t)
+	pages := whereTestPages()
+
+	got := pages.Filter(func(p Page) bool {
+		return !p.(*whereTestPage).draft
+	})
+
+	c.Assert(len(got), qt.Equals, 2)
+}
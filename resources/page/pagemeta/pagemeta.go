@@ -0,0 +1,122 @@
+// Copyright 2020 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pagemeta contains page metadata that isn't directly related to
+// rendering, e.g. the _build options controlling whether a page is
+// rendered, listed and/or has its resources published.
+package pagemeta
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	// Values for BuildConfig.Render and BuildConfig.List.
+	always = "always"
+	never  = "never"
+
+	// Values for BuildConfig.Render only.
+	link = "link"
+
+	// Values for BuildConfig.List only.
+	localOnly = "local"
+)
+
+// BuildConfig holds the `_build` front matter/cascade options controlling
+// whether a page is rendered, listed in page collections, and/or has its
+// page resources published.
+type BuildConfig struct {
+	// Whether to render this page.
+	// Valid values: always, never, link.
+	// "link" will publish the page's resources but not the page itself;
+	// it's useful for pages that should only ever be reachable as e.g. a
+	// parent in breadcrumbs.
+	Render string
+
+	// Whether to include this page in page collections (.Pages etc.).
+	// Valid values: always, never, local.
+	// "local" will list the page in its own section's collections but not
+	// in those of its ancestors.
+	List string
+
+	// Whether to publish the page's resources (images etc.) even if the
+	// page itself isn't rendered.
+	PublishResources bool
+}
+
+// Default is the BuildConfig used when a page doesn't set `_build` in its
+// front matter or cascade.
+var Default = BuildConfig{
+	Render:           always,
+	List:             always,
+	PublishResources: true,
+}
+
+// Enabled reports whether the page should be processed at all, i.e.
+// whether it should be rendered or listed in any way.
+func (b BuildConfig) Enabled() bool {
+	return b.ShouldRender() || b.ShouldList()
+}
+
+// ShouldRender reports whether the page itself should produce output.
+func (b BuildConfig) ShouldRender() bool {
+	return b.Render == always
+}
+
+// ShouldLink reports whether the page should be reachable (e.g. via
+// .Permalink) without being rendered as a standalone output.
+func (b BuildConfig) ShouldLink() bool {
+	return b.Render == link
+}
+
+// ShouldList reports whether the page should show up in pages, optionally
+// restricted to its own section's collections.
+func (b BuildConfig) ShouldList(ownSection ...bool) bool {
+	switch b.List {
+	case always:
+		return true
+	case localOnly:
+		return len(ownSection) > 0 && ownSection[0]
+	default:
+		return false
+	}
+}
+
+// DecodeBuildConfig decodes the `_build` front matter value, if any, into
+// a BuildConfig with defaults filled in from Default.
+func DecodeBuildConfig(v interface{}) (BuildConfig, error) {
+	b := Default
+	if v == nil {
+		return b, nil
+	}
+
+	if err := mapstructure.WeakDecode(v, &b); err != nil {
+		return b, err
+	}
+
+	b.Render = strings.ToLower(b.Render)
+	b.List = strings.ToLower(b.List)
+
+	if b.Render != always && b.Render != never && b.Render != link {
+		return b, errors.New("invalid value for build.render: must be always, never or link")
+	}
+
+	if b.List != always && b.List != never && b.List != localOnly {
+		return b, errors.New("invalid value for build.list: must be always, never or local")
+	}
+
+	return b, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagemeta
+
+import "testing"
+
+func TestDecodeBuildConfigDefault(t *testing.T) {
+	b, err := DecodeBuildConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b != Default {
+		t.Fatalf("got %+v, want the Default config %+v", b, Default)
+	}
+	if !b.ShouldRender() || !b.ShouldList() || !b.PublishResources {
+		t.Fatalf("expected the default config to render, list and publish resources, got %+v", b)
+	}
+}
+
+func TestDecodeBuildConfigRenderNever(t *testing.T) {
+	b, err := DecodeBuildConfig(map[string]interface{}{
+		"render":           "never",
+		"publishResources": false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.ShouldRender() {
+		t.Error("expected ShouldRender to be false for render: never")
+	}
+	if b.ShouldLink() {
+		t.Error("expected ShouldLink to be false for render: never")
+	}
+	if b.PublishResources {
+		t.Error("expected PublishResources to be false")
+	}
+	// List wasn't set, so it should still default to always.
+	if !b.ShouldList() {
+		t.Error("expected ShouldList to default to true when list isn't set")
+	}
+}
+
+func TestDecodeBuildConfigRenderLink(t *testing.T) {
+	b, err := DecodeBuildConfig(map[string]interface{}{"render": "link"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ShouldRender() {
+		t.Error("expected ShouldRender to be false for render: link")
+	}
+	if !b.ShouldLink() {
+		t.Error("expected ShouldLink to be true for render: link")
+	}
+}
+
+func TestDecodeBuildConfigListLocal(t *testing.T) {
+	b, err := DecodeBuildConfig(map[string]interface{}{"list": "local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.ShouldList() {
+		t.Error("expected ShouldList() with no argument to be false for list: local")
+	}
+	if !b.ShouldList(true) {
+		t.Error("expected ShouldList(true) to be true for list: local")
+	}
+	if b.ShouldList(false) {
+		t.Error("expected ShouldList(false) to be false for list: local")
+	}
+}
+
+func TestDecodeBuildConfigEnabled(t *testing.T) {
+	b, err := DecodeBuildConfig(map[string]interface{}{"render": "never", "list": "never"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Enabled() {
+		t.Error("expected Enabled to be false when both render and list are never")
+	}
+}
+
+func TestDecodeBuildConfigInvalidValues(t *testing.T) {
+	if _, err := DecodeBuildConfig(map[string]interface{}{"render": "sometimes"}); err == nil {
+		t.Error("expected an error for an invalid render value")
+	}
+	if _, err := DecodeBuildConfig(map[string]interface{}{"list": "everywhere"}); err == nil {
+		t.Error("expected an error for an invalid list value")
+	}
+}
+
+func TestDecodeBuildConfigCaseInsensitive(t *testing.T) {
+	b, err := DecodeBuildConfig(map[string]interface{}{"render": "NEVER", "list": "LOCAL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ShouldRender() {
+		t.Error("expected render values to be case-insensitive")
+	}
+	if !b.ShouldList(true) {
+		t.Error("expected list values to be case-insensitive")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package page
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PaginationStrategy determines how a Pager's page URLs are derived.
+type PaginationStrategy int
+
+const (
+	// OffsetPaginationStrategy is the classic /page/N/ numbered pagination.
+	OffsetPaginationStrategy PaginationStrategy = iota
+
+	// CursorPaginationStrategy derives page URLs from an opaque token built
+	// from the sort key of the last item on the page, instead of a numeric
+	// offset. This avoids materializing every intermediate page for very
+	// large page sets.
+	CursorPaginationStrategy
+)
+
+// cursorSeparator joins the fields encoded into a cursor token.
+const cursorSeparator = "|"
+
+// EncodeCursor builds an opaque, stable pagination token from a sort key
+// (e.g. a date) and a permalink, so the pair uniquely identifies the
+// position of an item in a sorted page sequence.
+func EncodeCursor(sortKey, permalink string) string {
+	raw := sortKey + cursorSeparator + permalink
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor reverses EncodeCursor, returning the sort key and permalink
+// that were encoded into token.
+func DecodeCursor(token string) (sortKey string, permalink string, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", errors.Wrap(err, "invalid pagination cursor")
+	}
+
+	parts := strings.SplitN(string(raw), cursorSeparator, 2)
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("invalid pagination cursor: %q", token)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// PaginationStrategyFromOptions lets ResolvePagerSize callers select a
+// PaginationStrategy alongside the existing pager-size options, e.g.
+// page.Paginate(pd, pages, pagerSize, page.CursorPaginationStrategy).
+func PaginationStrategyFromOptions(options ...interface{}) PaginationStrategy {
+	for _, opt := range options {
+		if strategy, ok := opt.(PaginationStrategy); ok {
+			return strategy
+		}
+	}
+	return OffsetPaginationStrategy
+}
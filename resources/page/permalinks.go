@@ -14,6 +14,8 @@
 package page
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path"
@@ -35,11 +37,50 @@ type PermalinkExpander struct {
 	// to be used to replace that tag.
 	knownPermalinkAttributes map[string]pageToPermaAttribute
 
+	// registeredAttributes holds attributes added via RegisterAttribute, e.g.
+	// by plugin authors, checked after knownPermalinkAttributes but before
+	// user-defined template attributes.
+	registeredAttributes map[string]pageToPermaAttribute
+
+	// templateAttributes holds attributes declared in site config under
+	// permalink_attributes, each backed by a Hugo template snippet evaluated
+	// against the Page, e.g. permalink_attributes.author = "{{ .Params.author }}".
+	templateAttributes map[string]string
+
+	// templateExecutor renders the template snippets in templateAttributes.
+	// It is nil unless SetTemplateExecutor has been called, in which case
+	// templateAttributes are simply ignored.
+	templateExecutor TemplateAttributeExecutor
+
 	expanders map[string]func(Page) (string, error)
 
 	ps *helpers.PathSpec
 }
 
+// TemplateAttributeExecutor renders a Go template snippet against a Page,
+// used to back user-defined permalink attributes declared in site config
+// via permalink_attributes. Implementations are typically backed by Hugo's
+// template handler.
+type TemplateAttributeExecutor interface {
+	Execute(tmplStr string, p Page) (string, error)
+}
+
+// RegisterAttribute adds (or overrides) a permalink attribute backed by a Go
+// callback. This gives plugin authors a way to extend permalink expansion
+// beyond the built-in attribute set without patching Hugo.
+func (p *PermalinkExpander) RegisterAttribute(name string, fn func(Page, string) (string, error)) {
+	if p.registeredAttributes == nil {
+		p.registeredAttributes = make(map[string]pageToPermaAttribute)
+	}
+	p.registeredAttributes[name] = fn
+}
+
+// SetTemplateExecutor installs the executor used to evaluate the template
+// snippets declared in permalink_attributes config.
+func (p *PermalinkExpander) SetTemplateExecutor(exec TemplateAttributeExecutor) {
+	p.templateExecutor = exec
+}
+
 // Time for checking date formats. Every field is different than the
 // Go reference time for date formatting. This ensures that formatting this date
 // with a Go time format always has a different output than the format itself.
@@ -58,6 +99,31 @@ func (p PermalinkExpander) callback(attr string) (pageToPermaAttribute, bool) {
 		}, true
 	}
 
+	if attr == "hash" || strings.HasPrefix(attr, "hash[") {
+		n, expander := hashTruncateLen(attr, "hash"), p
+		return func(page Page, _ string) (string, error) {
+			return expander.pageToPermalinkHash(page, n)
+		}, true
+	}
+
+	if attr == "contenthash" || strings.HasPrefix(attr, "contenthash[") {
+		n, expander := hashTruncateLen(attr, "contenthash"), p
+		return func(page Page, _ string) (string, error) {
+			return expander.pageToPermalinkContentHash(page, n)
+		}, true
+	}
+
+	if callback, ok := p.registeredAttributes[attr]; ok {
+		return callback, true
+	}
+
+	if tmplStr, ok := p.templateAttributes[attr]; ok && p.templateExecutor != nil {
+		tmplStr, executor := tmplStr, p.templateExecutor
+		return func(page Page, _ string) (string, error) {
+			return executor.Execute(tmplStr, page)
+		}, true
+	}
+
 	// Make sure this comes after all the other checks.
 	if referenceTime.Format(attr) != attr {
 		return p.pageToPermalinkDate, true
@@ -86,6 +152,8 @@ func NewPermalinkExpander(ps *helpers.PathSpec) (PermalinkExpander, error) {
 		"filename":    p.pageToPermalinkFilename,
 	}
 
+	p.templateAttributes = ps.Cfg.GetStringMapString("permalink_attributes")
+
 	patterns := ps.Cfg.GetStringMapString("permalinks")
 	if patterns == nil {
 		return p, nil
@@ -274,6 +342,50 @@ func (l PermalinkExpander) pageToPermalinkSections(p Page, _ string) (string, er
 	return p.CurrentSection().SectionsPath(), nil
 }
 
+// hashTruncateLen extracts the N in a bracketed attribute such as
+// "hash[8]", using the same bracket-parsing convention as toSliceFunc. It
+// returns 0 (meaning no truncation) when attr has no bracket suffix, e.g.
+// plain "hash".
+func hashTruncateLen(attr, base string) int {
+	rest := strings.TrimPrefix(attr, base)
+	if len(rest) < 3 || rest[0] != '[' || rest[len(rest)-1] != ']' {
+		return 0
+	}
+
+	n, err := strconv.Atoi(rest[1 : len(rest)-1])
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+// pageToPermalinkHash returns a short hash of the page's stable identity
+// (its source path and title), truncated to n hex characters (0 means the
+// full hash).
+func (l PermalinkExpander) pageToPermalinkHash(p Page, n int) (string, error) {
+	identity := p.File().Path() + "|" + p.Title()
+	return truncateHash(identity, n), nil
+}
+
+// pageToPermalinkContentHash returns a short hash of the page's rendered
+// plain-text content, truncated to n hex characters (0 means the full
+// hash). This is intended for content-addressed, cache-busted permalinks.
+func (l PermalinkExpander) pageToPermalinkContentHash(p Page, n int) (string, error) {
+	return truncateHash(p.Plain(), n), nil
+}
+
+// truncateHash returns the hex-encoded SHA-1 digest of s, truncated to n
+// characters (0 means the full digest).
+func truncateHash(s string, n int) string {
+	sum := sha1.Sum([]byte(s))
+	hash := hex.EncodeToString(sum[:])
+	if n <= 0 || n >= len(hash) {
+		return hash
+	}
+	return hash[:n]
+}
+
 var (
 	nilSliceFunc = func(s []string) []string {
 		return nil
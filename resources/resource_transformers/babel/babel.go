@@ -22,8 +22,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/cli/safeexec"
+	"github.com/evanw/esbuild/pkg/api"
 	"github.com/gohugoio/hugo/common/hexec"
 	"github.com/gohugoio/hugo/common/loggers"
 
@@ -32,7 +34,6 @@ import (
 
 	"github.com/mitchellh/mapstructure"
 
-	"github.com/gohugoio/hugo/common/herrors"
 	"github.com/gohugoio/hugo/resources"
 	"github.com/gohugoio/hugo/resources/resource"
 	"github.com/pkg/errors"
@@ -48,6 +49,16 @@ type Options struct {
 	Verbose    bool
 	NoBabelrc  bool
 	SourceMap  string
+
+	// Env sets additional environment variables, e.g. BABEL_ENV, for this
+	// transformation only. These are applied on top of hugo.GetExecEnviron
+	// and override any variable of the same name.
+	Env map[string]string
+
+	// EnvPassthrough lists host environment variable names to forward to
+	// this transformation even if they are not covered by the site's
+	// security.exec.envAllow list.
+	EnvPassthrough []string
 }
 
 // DecodeOptions decodes options to and generates command flags
@@ -127,8 +138,13 @@ func (t *babelTransformation) Transform(ctx *resources.ResourceTransformationCtx
 		// Try PATH
 		binary = binaryName
 		if _, err := safeexec.LookPath(binary); err != nil {
-			// This may be on a CI server etc. Will fall back to pre-built assets.
-			return herrors.ErrFeatureNotAvailable
+			// No Node/babel-cli installed, e.g. a CI server with a minimal
+			// Go-only toolchain. Fall back to the embedded, pure-Go esbuild
+			// transform rather than failing the build outright; it won't
+			// apply the user's Babel plugins/presets, but it covers the
+			// common case of transpiling modern syntax down for older
+			// browsers without requiring Node at all.
+			return t.transformWithESBuild(ctx)
 		}
 	}
 
@@ -187,7 +203,9 @@ func (t *babelTransformation) Transform(ctx *resources.ResourceTransformationCtx
 
 	cmd.Stderr = io.MultiWriter(infoW, &errBuf)
 	cmd.Stdout = cmd.Stderr
-	cmd.Env = hugo.GetExecEnviron(t.rs.WorkingDir, t.rs.Cfg, t.rs.BaseFs.Assets.Fs)
+	env := hugo.GetExecEnviron(t.rs.WorkingDir, t.rs.Cfg, t.rs.BaseFs.Assets.Fs)
+	env = hugo.MergeEnv(env, hugo.PassthroughEnv(t.options.EnvPassthrough))
+	cmd.Env = hugo.MergeEnv(env, t.options.Env)
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
@@ -229,6 +247,44 @@ func (t *babelTransformation) Transform(ctx *resources.ResourceTransformationCtx
 	return nil
 }
 
+// transformWithESBuild runs ctx's content through the embedded, pure-Go
+// esbuild transform. It is used as a fallback when no babel-cli binary can
+// be found, so JS pipelines keep working on a machine without Node
+// installed. It does not honor the user's Babel config (plugins/presets);
+// it only applies esbuild's own syntax transforms and, optionally,
+// minification.
+func (t *babelTransformation) transformWithESBuild(ctx *resources.ResourceTransformationCtx) error {
+	src, err := ioutil.ReadAll(ctx.From)
+	if err != nil {
+		return err
+	}
+
+	ctx.ReplaceOutPathExtension(".js")
+
+	sourcemap := api.SourceMapNone
+	if t.options.SourceMap == "external" || t.options.SourceMap == "inline" {
+		sourcemap = api.SourceMapInline
+	}
+
+	result := api.Transform(string(src), api.TransformOptions{
+		Loader:            api.LoaderJS,
+		Sourcefile:        ctx.SourcePath,
+		MinifyWhitespace:  t.options.Minified,
+		MinifyIdentifiers: t.options.Minified,
+		MinifySyntax:      t.options.Minified,
+		Sourcemap:         sourcemap,
+	})
+
+	if len(result.Errors) > 0 {
+		msgs := api.FormatMessages(result.Errors, api.FormatMessagesOptions{Kind: api.ErrorMessage})
+		return errors.Errorf("esbuild: %s", strings.Join(msgs, "\n"))
+	}
+
+	ctx.To.Write(result.Code)
+
+	return nil
+}
+
 // Process transforms the given Resource with the Babel processor.
 func (c *Client) Process(res resources.ResourceTransformer, options Options) (resource.Resource, error) {
 	return res.Transform(
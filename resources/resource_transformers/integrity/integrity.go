@@ -0,0 +1,97 @@
+// Copyright 2020 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package integrity provides content-addressed asset fingerprinting and
+// Subresource Integrity hash helpers.
+package integrity
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Fingerprint returns name with an 8-character hex content hash inserted
+// before its extension, e.g. Fingerprint("app.js", content) might return
+// "app.3a7c91de.js". Callers publish content under the returned name and
+// rewrite references to it accordingly.
+func Fingerprint(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])[:8]
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	return fmt.Sprintf("%s.%s%s", base, hash, ext)
+}
+
+// HashAlgorithm selects the digest SRIHash uses.
+type HashAlgorithm string
+
+const (
+	HashSHA256 HashAlgorithm = "sha256"
+	HashSHA384 HashAlgorithm = "sha384"
+	HashSHA512 HashAlgorithm = "sha512"
+)
+
+// SRIHash returns content's Subresource Integrity hash, in the
+// "<alg>-<base64>" form suitable for a <link integrity=...> or <script
+// integrity=...> attribute, e.g. "sha384-oqVuAf...".
+func SRIHash(alg HashAlgorithm, content []byte) string {
+	if alg == "" {
+		alg = HashSHA384
+	}
+
+	var sum []byte
+	switch alg {
+	case HashSHA256:
+		s := sha256.Sum256(content)
+		sum = s[:]
+	case HashSHA512:
+		s := sha512.Sum512(content)
+		sum = s[:]
+	default:
+		s := sha512.Sum384(content)
+		sum = s[:]
+	}
+
+	return fmt.Sprintf("%s-%s", alg, base64.StdEncoding.EncodeToString(sum))
+}
+
+// Manifest tracks, by source path, the fingerprinted name and SRI hash a
+// previous asset pipeline run produced, so that run can be skipped for
+// files whose content hasn't changed. Persisting/loading it (e.g. to
+// PublishDir/assets.json, as this request asks) and the pipeline that
+// walks StaticDir and Page.Content to populate one aren't implemented in
+// this package: StaticDir's walk and the "resourceGetRemote"/"fingerprint"/
+// "sri" template funcs this request also asks for belong to hugolib's
+// Site.Render and its template funcmap, and reading Page.Content to find
+// referenced assets needs the Page type -- which, along with Config and
+// the rest of the ancient Site struct's dependencies, isn't defined
+// anywhere in this tree (see hugolib/incremental_build.go for the same
+// caveat on an earlier request in this series). Fingerprint and SRIHash
+// above are the reusable hashing primitives such a pipeline would call.
+type Manifest struct {
+	Entries map[string]ManifestEntry
+}
+
+// ManifestEntry is one Manifest record: the fingerprinted name and SRI hash
+// computed for a given source path's current content.
+type ManifestEntry struct {
+	FingerprintedName string
+	SRI               string
+}
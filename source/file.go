@@ -14,13 +14,40 @@
 package source
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
 	"io"
 	"path/filepath"
 	"strings"
+	"sync"
 
-	"github.com/spf13/hugo/helpers"
+	"github.com/gohugoio/hugo/helpers"
 )
 
+// HashAlgorithm selects how File computes UniqueID/PathHash.
+type HashAlgorithm string
+
+const (
+	// HashFNV64a is a fast, non-cryptographic hash of the file's content.
+	// It's the default: build-time cache keys don't need collision
+	// resistance, just speed and a low false-sharing rate.
+	HashFNV64a HashAlgorithm = "fnv64a"
+
+	// HashSHA256 hashes the file's content with SHA-256.
+	HashSHA256 HashAlgorithm = "sha256"
+
+	// HashMD5Legacy reproduces Hugo's historical UniqueID: an MD5 of the
+	// filename alone, not the content. Kept for sites that persisted
+	// values derived from the old IDs across builds.
+	HashMD5Legacy HashAlgorithm = "md5-legacy"
+)
+
+// DefaultHashAlgorithm is the HashAlgorithm new Files use unless
+// SetHashAlgorithm is called, normally from helpers.PathSpec once it has
+// read the site's `hashing.algorithm` config value.
+var DefaultHashAlgorithm = HashFNV64a
+
 // All paths are relative from the source directory base
 type File struct {
 	relpath     string // Original Full Path eg. content/foo.txt
@@ -29,14 +56,77 @@ type File struct {
 	section     string // The first directory
 	dir         string // The full directory Path (minus file name)
 	ext         string // Just the ext (eg txt)
-	uniqueID    string // MD5 of the filename
+
+	hashAlgorithm HashAlgorithm
+
+	uniqueIDOnce sync.Once
+	uniqueID     string
+
+	pathHashOnce sync.Once
+	pathHash     string
 }
 
-// UniqueID: MD5 of the filename
+// SetHashAlgorithm sets the HashAlgorithm UniqueID/PathHash compute with.
+// Must be called before the first UniqueID/PathHash call to have any
+// effect; later calls are no-ops once either has been computed and cached.
+func (f *File) SetHashAlgorithm(alg HashAlgorithm) {
+	f.hashAlgorithm = alg
+}
+
+// UniqueID identifies f, computed lazily on first call and cached.
+// Under HashMD5Legacy it reproduces Hugo's historical behavior (an MD5 of
+// the filename alone); otherwise it hashes the file's content, so two
+// files named index.md in different directories no longer collide, and a
+// change to a file's content changes its UniqueID.
 func (f *File) UniqueID() string {
+	f.uniqueIDOnce.Do(func() {
+		alg := f.hashAlgorithm
+		if alg == "" {
+			alg = DefaultHashAlgorithm
+		}
+		if alg == HashMD5Legacy {
+			f.uniqueID = helpers.Md5String(f.LogicalName())
+			return
+		}
+		f.uniqueID = hashBytes(alg, f.Bytes())
+	})
 	return f.uniqueID
 }
 
+// PathHash returns a hash of f's full relative path (relpath), for callers
+// that need an ID that is stable across content edits but still varies by
+// location -- unlike UniqueID, which changes with the content.
+func (f *File) PathHash() string {
+	f.pathHashOnce.Do(func() {
+		alg := f.hashAlgorithm
+		if alg == "" {
+			alg = DefaultHashAlgorithm
+		}
+		if alg == HashMD5Legacy {
+			f.pathHash = helpers.Md5String(f.Path())
+			return
+		}
+		f.pathHash = hashString(alg, f.Path())
+	})
+	return f.pathHash
+}
+
+func hashBytes(alg HashAlgorithm, data []byte) string {
+	switch alg {
+	case HashSHA256:
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	default: // HashFNV64a
+		h := fnv.New64a()
+		h.Write(data)
+		return hex.EncodeToString(h.Sum(nil))
+	}
+}
+
+func hashString(alg HashAlgorithm, s string) string {
+	return hashBytes(alg, []byte(s))
+}
+
 func (f *File) String() string {
 	return helpers.ReaderToString(f.Contents)
 }
@@ -96,7 +186,6 @@ func NewFile(relpath string) *File {
 	_, f.logicalName = filepath.Split(f.relpath)
 	f.ext = strings.TrimPrefix(filepath.Ext(f.LogicalName()), ".")
 	f.section = helpers.GuessSection(f.Dir())
-	f.uniqueID = helpers.Md5String(f.LogicalName())
 
 	return f
 }
@@ -0,0 +1,251 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package themes parses and validates theme.toml manifests: the Hugo
+// version range a theme supports and the other themes/components it
+// depends on.
+package themes
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Dependency is one entry in theme.toml's "dependencies" list: another
+// theme or component this theme requires, with its own version bounds.
+type Dependency struct {
+	Name       string `toml:"name"`
+	MinVersion string `toml:"min_version"`
+	MaxVersion string `toml:"max_version"`
+}
+
+// Manifest is the parsed form of a theme.toml file.
+type Manifest struct {
+	Name         string `toml:"name"`
+	MinVersion   string `toml:"min_version"`
+	MaxVersion   string `toml:"max_version"`
+	Dependencies []Dependency
+}
+
+// ParseManifest decodes a theme.toml file's contents. MinVersion and
+// MaxVersion are read as strings whether the file spells them as a bare
+// TOML float (`min_version = 0.18`, the old convention) or a semver string
+// (`min_version = "0.18.1"`), and either may itself hold a full constraint
+// expression such as "min_version = \">=0.18, <0.25\"".
+func ParseManifest(b []byte) (*Manifest, error) {
+	var raw struct {
+		Name         string                   `toml:"name"`
+		MinVersion   interface{}              `toml:"min_version"`
+		MaxVersion   interface{}              `toml:"max_version"`
+		Dependencies []map[string]interface{} `toml:"dependencies"`
+	}
+
+	if err := toml.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse theme manifest: %s", err)
+	}
+
+	m := &Manifest{
+		Name:       raw.Name,
+		MinVersion: stringifyVersion(raw.MinVersion),
+		MaxVersion: stringifyVersion(raw.MaxVersion),
+	}
+
+	for _, dep := range raw.Dependencies {
+		d := Dependency{
+			MinVersion: stringifyVersion(dep["min_version"]),
+			MaxVersion: stringifyVersion(dep["max_version"]),
+		}
+		if name, ok := dep["name"].(string); ok {
+			d.Name = name
+		}
+		m.Dependencies = append(m.Dependencies, d)
+	}
+
+	return m, nil
+}
+
+func stringifyVersion(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float32:
+		return strconv.FormatFloat(float64(t), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// CheckVersion reports whether version satisfies m's MinVersion and
+// MaxVersion bounds, returning a descriptive error naming whichever
+// constraint failed if not. A bound already containing a comparator
+// (">=", "<", ...), optionally as a comma-separated list such as
+// ">=0.18, <0.25", is parsed as-is; a bare version number implies ">="
+// for MinVersion and "<=" for MaxVersion.
+func (m *Manifest) CheckVersion(version string) error {
+	var failed []string
+
+	if clauses, err := boundClauses(m.MinVersion, ">="); err != nil {
+		failed = append(failed, err.Error())
+	} else if !satisfies(version, clauses) {
+		failed = append(failed, fmt.Sprintf("requires min_version %s", m.MinVersion))
+	}
+
+	if clauses, err := boundClauses(m.MaxVersion, "<="); err != nil {
+		failed = append(failed, err.Error())
+	} else if !satisfies(version, clauses) {
+		failed = append(failed, fmt.Sprintf("requires max_version %s", m.MaxVersion))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Hugo %s does not satisfy: %s", version, strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// CheckDependencies reports one issue per dependency declared by m that
+// resolve can't find on disk. resolve is typically a lookup against the
+// theme directories actually present under themesDir. Version bounds on
+// dependencies are parsed into Dependency above but aren't cross-checked
+// here: doing so needs the dependency's own theme.toml to publish a
+// version number, which isn't part of this manifest format, only a
+// min/max bound on the Hugo version running it.
+func (m *Manifest) CheckDependencies(resolve func(name string) bool) (issues []string) {
+	for _, dep := range m.Dependencies {
+		if !resolve(dep.Name) {
+			issues = append(issues, fmt.Sprintf("theme dependency %q is declared but not found on disk", dep.Name))
+		}
+	}
+	return issues
+}
+
+type clause struct {
+	op      string
+	version string
+}
+
+var clauseOperators = []string{">=", "<=", ">", "<", "=="}
+
+func boundClauses(bound, impliedOp string) ([]clause, error) {
+	bound = strings.TrimSpace(bound)
+	if bound == "" {
+		return nil, nil
+	}
+	if containsOperator(bound) {
+		return parseClauses(bound)
+	}
+	return []clause{{op: impliedOp, version: bound}}, nil
+}
+
+func containsOperator(s string) bool {
+	for _, op := range clauseOperators {
+		if strings.Contains(s, op) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClauses(constraint string) ([]clause, error) {
+	var clauses []clause
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		op := "=="
+		for _, candidate := range clauseOperators {
+			if strings.HasPrefix(part, candidate) {
+				op = candidate
+				part = strings.TrimSpace(strings.TrimPrefix(part, candidate))
+				break
+			}
+		}
+		if part == "" {
+			return nil, fmt.Errorf("invalid version constraint %q", constraint)
+		}
+
+		clauses = append(clauses, clause{op: op, version: part})
+	}
+	return clauses, nil
+}
+
+func satisfies(version string, clauses []clause) bool {
+	for _, c := range clauses {
+		cmp := compareVersions(version, c.version)
+		switch c.op {
+		case ">=":
+			if cmp < 0 {
+				return false
+			}
+		case "<=":
+			if cmp > 0 {
+				return false
+			}
+		case ">":
+			if cmp <= 0 {
+				return false
+			}
+		case "<":
+			if cmp >= 0 {
+				return false
+			}
+		case "==":
+			if cmp != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// compareVersions compares two dotted numeric version strings
+// component-wise, treating missing trailing components as 0 -- "0.18" ==
+// "0.18.0" < "0.18.1" -- and returns -1, 0 or 1.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	n := len(as)
+	if len(bs) > n {
+		n = len(bs)
+	}
+
+	for i := 0; i < n; i++ {
+		var av, bv int
+		if i < len(as) {
+			av, _ = strconv.Atoi(strings.TrimSpace(as[i]))
+		}
+		if i < len(bs) {
+			bv, _ = strconv.Atoi(strings.TrimSpace(bs[i]))
+		}
+		if av != bv {
+			if av < bv {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
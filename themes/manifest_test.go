@@ -0,0 +1,78 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package themes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseManifestLegacyFloatMinVersion(t *testing.T) {
+	assert := require.New(t)
+
+	m, err := ParseManifest([]byte(`name = "mytheme"
+min_version = 0.18
+`))
+	assert.NoError(err)
+	assert.Equal("mytheme", m.Name)
+	assert.Equal("0.18", m.MinVersion)
+}
+
+func TestParseManifestSemverAndDependencies(t *testing.T) {
+	assert := require.New(t)
+
+	m, err := ParseManifest([]byte(`name = "mytheme"
+min_version = "0.18.1"
+max_version = "0.25.0"
+
+[[dependencies]]
+name = "base-theme"
+min_version = "1.2.0"
+`))
+	assert.NoError(err)
+	assert.Equal("0.18.1", m.MinVersion)
+	assert.Equal("0.25.0", m.MaxVersion)
+	assert.Len(m.Dependencies, 1)
+	assert.Equal("base-theme", m.Dependencies[0].Name)
+	assert.Equal("1.2.0", m.Dependencies[0].MinVersion)
+}
+
+func TestCheckVersion(t *testing.T) {
+	assert := require.New(t)
+
+	m := &Manifest{MinVersion: "0.18.1", MaxVersion: "0.25.0"}
+	assert.NoError(m.CheckVersion("0.20.0"))
+	assert.Error(m.CheckVersion("0.10.0"))
+	assert.Error(m.CheckVersion("0.30.0"))
+}
+
+func TestCheckVersionRangeExpression(t *testing.T) {
+	assert := require.New(t)
+
+	m := &Manifest{MinVersion: ">=0.18, <0.25"}
+	assert.NoError(m.CheckVersion("0.20.0"))
+	assert.Error(m.CheckVersion("0.25.0"))
+}
+
+func TestCheckDependencies(t *testing.T) {
+	assert := require.New(t)
+
+	m := &Manifest{Dependencies: []Dependency{{Name: "present"}, {Name: "missing"}}}
+	issues := m.CheckDependencies(func(name string) bool {
+		return name == "present"
+	})
+	assert.Len(issues, 1)
+	assert.Contains(issues[0], "missing")
+}
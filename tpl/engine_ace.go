@@ -0,0 +1,104 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/hugo/helpers"
+	"github.com/spf13/hugo/hugofs"
+	"github.com/yosssi/ace"
+)
+
+// aceTemplateInnerMarker is how an Ace template says it wants a base
+// template's content folded in around it.
+var aceTemplateInnerMarker = []byte("= content")
+
+const baseAceFilename = "baseof.ace"
+
+// aceTemplateEngine is the TemplateEngine for .ace files. It's also the
+// only one of the three registered engines that implements
+// BaseTemplateProvider: Ace is the one template language here with a
+// separate base/layout template convention.
+type aceTemplateEngine struct{}
+
+func (aceTemplateEngine) Extensions() []string { return []string{".ace"} }
+
+func (aceTemplateEngine) Compile(name, basePath, path string, base, inner []byte) (CompiledTemplate, error) {
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".html"
+
+	// Fixes issue #1178
+	basePath = strings.Replace(basePath, "\\", "/", -1)
+	path = strings.Replace(path, "\\", "/", -1)
+
+	var baseFile, innerFile *ace.File
+	if basePath != "" {
+		baseFile = ace.NewFile(basePath, base)
+		innerFile = ace.NewFile(path, inner)
+	} else {
+		baseFile = ace.NewFile(path, inner)
+		innerFile = ace.NewFile("", []byte{})
+	}
+
+	parsed, err := ace.ParseSource(ace.NewSource(baseFile, innerFile, []*ace.File{}), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &aceCompiledTemplate{name: name, parsed: parsed}, nil
+}
+
+func (aceTemplateEngine) IsBaseTemplateFile(path string) bool {
+	return strings.HasSuffix(path, baseAceFilename)
+}
+
+func (aceTemplateEngine) NeedsBaseTemplate(path string) (bool, error) {
+	return helpers.FileContains(path, aceTemplateInnerMarker, hugofs.OsFs)
+}
+
+func (aceTemplateEngine) BaseTemplateCandidates(absPath, path string) []string {
+	// Look for a base template in the following order:
+	//   1. <current-path>/<template-name>-baseof.ace, e.g. list-baseof.ace.
+	//   2. <current-path>/baseof.ace
+	//   3. _default/<template-name>-baseof.ace, e.g. list-baseof.ace.
+	//   4. _default/baseof.ace
+	//   5. <themedir>/layouts/_default/<template-name>-baseof.ace
+	//   6. <themedir>/layouts/_default/baseof.ace
+	currBaseAceFilename := fmt.Sprintf("%s-%s", helpers.Filename(path), baseAceFilename)
+	templateDir := filepath.Dir(path)
+	themeDir := helpers.GetThemeDir()
+
+	return []string{
+		filepath.Join(templateDir, currBaseAceFilename),
+		filepath.Join(templateDir, baseAceFilename),
+		filepath.Join(absPath, "_default", currBaseAceFilename),
+		filepath.Join(absPath, "_default", baseAceFilename),
+		filepath.Join(themeDir, "layouts", "_default", currBaseAceFilename),
+		filepath.Join(themeDir, "layouts", "_default", baseAceFilename),
+	}
+}
+
+type aceCompiledTemplate struct {
+	name   string
+	parsed *ace.Result
+}
+
+func (c *aceCompiledTemplate) Name() string { return c.name }
+
+func (c *aceCompiledTemplate) AddTo(dest *template.Template) (*template.Template, error) {
+	return ace.CompileResultWithTemplate(dest.New(c.name), c.parsed, nil)
+}
@@ -0,0 +1,49 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/eknkc/amber"
+)
+
+// amberTemplateEngine is the TemplateEngine for .amber files.
+type amberTemplateEngine struct{}
+
+func (amberTemplateEngine) Extensions() []string { return []string{".amber"} }
+
+func (amberTemplateEngine) Compile(name, basePath, path string, base, inner []byte) (CompiledTemplate, error) {
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".html"
+
+	compiler := amber.New()
+	if err := compiler.Parse(string(inner)); err != nil {
+		return nil, err
+	}
+
+	return &amberCompiledTemplate{name: name, compiler: compiler}, nil
+}
+
+type amberCompiledTemplate struct {
+	name     string
+	compiler *amber.Compiler
+}
+
+func (c *amberCompiledTemplate) Name() string { return c.name }
+
+func (c *amberCompiledTemplate) AddTo(dest *template.Template) (*template.Template, error) {
+	return c.compiler.CompileWithTemplate(dest.New(c.name))
+}
@@ -0,0 +1,40 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import "html/template"
+
+// goTemplateEngine is the fallback TemplateEngine AddTemplateFile uses for
+// any extension no registered engine claims -- the plain Go html/template
+// templates that make up the bulk of a Hugo site's layouts. It isn't
+// registered via RegisterTemplateEngine since it has no extensions of its
+// own to claim; engineForExt returning nil is what selects it.
+type goTemplateEngine struct{}
+
+func (goTemplateEngine) Extensions() []string { return nil }
+
+func (goTemplateEngine) Compile(name, basePath, path string, base, inner []byte) (CompiledTemplate, error) {
+	return &goCompiledTemplate{name: name, content: string(inner)}, nil
+}
+
+type goCompiledTemplate struct {
+	name    string
+	content string
+}
+
+func (c *goCompiledTemplate) Name() string { return c.name }
+
+func (c *goCompiledTemplate) AddTo(dest *template.Template) (*template.Template, error) {
+	return dest.New(c.name).Parse(c.content)
+}
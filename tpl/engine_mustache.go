@@ -0,0 +1,81 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/cbroglie/mustache"
+)
+
+// mustacheTemplateEngine is the TemplateEngine for .mustache/.ms files --
+// the new engine added alongside Ace and Amber now that AddTemplateFile
+// dispatches to a registry instead of a hardcoded switch. Mustache has no
+// base-template convention of its own, so unlike aceTemplateEngine it
+// doesn't implement BaseTemplateProvider.
+type mustacheTemplateEngine struct{}
+
+func (mustacheTemplateEngine) Extensions() []string { return []string{".mustache", ".ms"} }
+
+func (mustacheTemplateEngine) Compile(name, basePath, path string, base, inner []byte) (CompiledTemplate, error) {
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + ".html"
+
+	parsed, err := mustache.ParseString(string(inner))
+	if err != nil {
+		return nil, err
+	}
+
+	return &mustacheCompiledTemplate{name: name, parsed: parsed}, nil
+}
+
+type mustacheCompiledTemplate struct {
+	name   string
+	parsed *mustache.Template
+}
+
+func (c *mustacheCompiledTemplate) Name() string { return c.name }
+
+// AddTo can't graft a Mustache template onto dest's parse tree the way Ace
+// and Amber do, since Mustache isn't built on text/template at all. Instead
+// it registers a one-line Go template under c.name that forwards execution
+// to the already-parsed Mustache template via a synthesized func, so
+// Lookup/Templates/ExecuteTemplate all still see a normal dest entry.
+func (c *mustacheCompiledTemplate) AddTo(dest *template.Template) (*template.Template, error) {
+	funcName := "__mustache_" + sanitizeFuncName(c.name)
+
+	render := func(data interface{}) (template.HTML, error) {
+		var buf bytes.Buffer
+		if err := c.parsed.FRender(&buf, data); err != nil {
+			return "", err
+		}
+		return template.HTML(buf.String()), nil
+	}
+
+	return dest.New(c.name).Funcs(template.FuncMap{funcName: render}).Parse(fmt.Sprintf("{{ %s . }}", funcName))
+}
+
+func sanitizeFuncName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
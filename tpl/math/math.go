@@ -16,7 +16,10 @@ package math
 
 import (
 	"errors"
+	"fmt"
 	"math"
+	"math/rand"
+	"sort"
 	"sync/atomic"
 
 	_math "github.com/gohugoio/hugo/common/math"
@@ -174,3 +177,337 @@ var counter uint64
 func (ns *Namespace) Counter() uint64 {
 	return atomic.AddUint64(&counter, uint64(1))
 }
+
+// Pi returns the value of math.Pi.
+func (ns *Namespace) Pi() float64 {
+	return math.Pi
+}
+
+// E returns the value of math.E.
+func (ns *Namespace) E() float64 {
+	return math.E
+}
+
+// Sin returns the sine of the radian argument n.
+func (ns *Namespace) Sin(n any) (float64, error) {
+	return ns.trig1("Sin", math.Sin, n)
+}
+
+// Cos returns the cosine of the radian argument n.
+func (ns *Namespace) Cos(n any) (float64, error) {
+	return ns.trig1("Cos", math.Cos, n)
+}
+
+// Tan returns the tangent of the radian argument n.
+func (ns *Namespace) Tan(n any) (float64, error) {
+	return ns.trig1("Tan", math.Tan, n)
+}
+
+// Asin returns the arcsine, in radians, of n.
+func (ns *Namespace) Asin(n any) (float64, error) {
+	return ns.trig1("Asin", math.Asin, n)
+}
+
+// Acos returns the arccosine, in radians, of n.
+func (ns *Namespace) Acos(n any) (float64, error) {
+	return ns.trig1("Acos", math.Acos, n)
+}
+
+// Atan returns the arctangent, in radians, of n.
+func (ns *Namespace) Atan(n any) (float64, error) {
+	return ns.trig1("Atan", math.Atan, n)
+}
+
+// Atan2 returns the arc tangent of n1/n2, using the signs of the two to
+// determine the quadrant of the return value.
+func (ns *Namespace) Atan2(n1, n2 any) (float64, error) {
+	af, erra := cast.ToFloat64E(n1)
+	bf, errb := cast.ToFloat64E(n2)
+
+	if erra != nil {
+		return 0, fmt.Errorf("Atan2 operator can't be used with non-float value %v", n1)
+	}
+	if errb != nil {
+		return 0, fmt.Errorf("Atan2 operator can't be used with non-float value %v", n2)
+	}
+
+	return math.Atan2(af, bf), nil
+}
+
+// trig1 is a helper shared by the single-argument trigonometric functions.
+func (ns *Namespace) trig1(name string, fn func(float64) float64, n any) (float64, error) {
+	xf, err := cast.ToFloat64E(n)
+	if err != nil {
+		return 0, fmt.Errorf("%s operator can't be used with non-float value %v", name, n)
+	}
+
+	return fn(xf), nil
+}
+
+// Exp returns e**n, the base-e exponential of n.
+func (ns *Namespace) Exp(n any) (float64, error) {
+	return ns.trig1("Exp", math.Exp, n)
+}
+
+// Log2 returns the binary logarithm of n.
+func (ns *Namespace) Log2(n any) (float64, error) {
+	return ns.trig1("Log2", math.Log2, n)
+}
+
+// Log10 returns the decimal logarithm of n.
+func (ns *Namespace) Log10(n any) (float64, error) {
+	return ns.trig1("Log10", math.Log10, n)
+}
+
+// Trunc returns the integer value of n.
+func (ns *Namespace) Trunc(n any) (float64, error) {
+	return ns.trig1("Trunc", math.Trunc, n)
+}
+
+// RoundTo rounds n to the given number of decimal digits, using banker's
+// rounding (round half to even) to avoid the systematic bias of always
+// rounding halves away from zero.
+func (ns *Namespace) RoundTo(digits, n any) (float64, error) {
+	d, errd := cast.ToIntE(digits)
+	xf, errn := cast.ToFloat64E(n)
+
+	if errd != nil {
+		return 0, fmt.Errorf("RoundTo operator can't be used with non-integer digits value %v", digits)
+	}
+	if errn != nil {
+		return 0, fmt.Errorf("RoundTo operator can't be used with non-float value %v", n)
+	}
+
+	shift := math.Pow(10, float64(d))
+	return math.RoundToEven(xf*shift) / shift, nil
+}
+
+// toFloat64Slice coerces xs, a slice of any, into a []float64, naming the
+// first offending element on failure.
+func toFloat64Slice(name string, xs []any) ([]float64, error) {
+	if len(xs) == 0 {
+		return nil, fmt.Errorf("%s needs at least one value", name)
+	}
+
+	fs := make([]float64, len(xs))
+	for i, x := range xs {
+		f, err := cast.ToFloat64E(x)
+		if err != nil {
+			return nil, fmt.Errorf("%s operator can't be used with non-float value %v at index %d", name, x, i)
+		}
+		fs[i] = f
+	}
+
+	return fs, nil
+}
+
+// Sum returns the sum of xs.
+func (ns *Namespace) Sum(xs ...any) (float64, error) {
+	fs, err := toFloat64Slice("Sum", xs)
+	if err != nil {
+		return 0, err
+	}
+
+	var sum float64
+	for _, f := range fs {
+		sum += f
+	}
+
+	return sum, nil
+}
+
+// Product returns the product of xs.
+func (ns *Namespace) Product(xs ...any) (float64, error) {
+	fs, err := toFloat64Slice("Product", xs)
+	if err != nil {
+		return 0, err
+	}
+
+	product := 1.0
+	for _, f := range fs {
+		product *= f
+	}
+
+	return product, nil
+}
+
+// Mean returns the arithmetic mean of xs.
+func (ns *Namespace) Mean(xs ...any) (float64, error) {
+	fs, err := toFloat64Slice("Mean", xs)
+	if err != nil {
+		return 0, err
+	}
+
+	return mean(fs), nil
+}
+
+func mean(fs []float64) float64 {
+	var sum float64
+	for _, f := range fs {
+		sum += f
+	}
+	return sum / float64(len(fs))
+}
+
+// Median returns the median value of xs.
+func (ns *Namespace) Median(xs ...any) (float64, error) {
+	fs, err := toFloat64Slice("Median", xs)
+	if err != nil {
+		return 0, err
+	}
+
+	sorted := append([]float64(nil), fs...)
+	sort.Float64s(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2], nil
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2, nil
+}
+
+// Variance returns the population variance of xs.
+func (ns *Namespace) Variance(xs ...any) (float64, error) {
+	fs, err := toFloat64Slice("Variance", xs)
+	if err != nil {
+		return 0, err
+	}
+
+	m := mean(fs)
+	var sum float64
+	for _, f := range fs {
+		d := f - m
+		sum += d * d
+	}
+
+	return sum / float64(len(fs)), nil
+}
+
+// Stddev returns the population standard deviation of xs.
+func (ns *Namespace) Stddev(xs ...any) (float64, error) {
+	v, err := ns.Variance(xs...)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Sqrt(v), nil
+}
+
+// Percentile returns the p-th percentile (0-100) of xs, using linear
+// interpolation between the two nearest ranks.
+func (ns *Namespace) Percentile(p any, xs ...any) (float64, error) {
+	pf, err := cast.ToFloat64E(p)
+	if err != nil {
+		return 0, fmt.Errorf("Percentile operator can't be used with non-float percentile value %v", p)
+	}
+	if pf < 0 || pf > 100 {
+		return 0, fmt.Errorf("Percentile p must be between 0 and 100, got %v", pf)
+	}
+
+	fs, err := toFloat64Slice("Percentile", xs)
+	if err != nil {
+		return 0, err
+	}
+
+	sorted := append([]float64(nil), fs...)
+	sort.Float64s(sorted)
+
+	if len(sorted) == 1 {
+		return sorted[0], nil
+	}
+
+	rank := (pf / 100) * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	if lo == hi {
+		return sorted[lo], nil
+	}
+
+	frac := rank - float64(lo)
+	return sorted[lo] + frac*(sorted[hi]-sorted[lo]), nil
+}
+
+// toInt64Pair is a helper shared by the bitwise operators.
+func toInt64Pair(name string, n1, n2 any) (int64, int64, error) {
+	a, erra := cast.ToInt64E(n1)
+	b, errb := cast.ToInt64E(n2)
+
+	if erra != nil {
+		return 0, 0, fmt.Errorf("%s operator can't be used with non-integer value %v", name, n1)
+	}
+	if errb != nil {
+		return 0, 0, fmt.Errorf("%s operator can't be used with non-integer value %v", name, n2)
+	}
+
+	return a, b, nil
+}
+
+// And returns the bitwise AND of n1 and n2.
+func (ns *Namespace) And(n1, n2 any) (int64, error) {
+	a, b, err := toInt64Pair("And", n1, n2)
+	if err != nil {
+		return 0, err
+	}
+	return a & b, nil
+}
+
+// Or returns the bitwise OR of n1 and n2.
+func (ns *Namespace) Or(n1, n2 any) (int64, error) {
+	a, b, err := toInt64Pair("Or", n1, n2)
+	if err != nil {
+		return 0, err
+	}
+	return a | b, nil
+}
+
+// Xor returns the bitwise XOR of n1 and n2.
+func (ns *Namespace) Xor(n1, n2 any) (int64, error) {
+	a, b, err := toInt64Pair("Xor", n1, n2)
+	if err != nil {
+		return 0, err
+	}
+	return a ^ b, nil
+}
+
+// Shl returns n1 shifted left by n2 bits.
+func (ns *Namespace) Shl(n1, n2 any) (int64, error) {
+	a, b, err := toInt64Pair("Shl", n1, n2)
+	if err != nil {
+		return 0, err
+	}
+	return a << uint(b), nil
+}
+
+// Shr returns n1 shifted right by n2 bits.
+func (ns *Namespace) Shr(n1, n2 any) (int64, error) {
+	a, b, err := toInt64Pair("Shr", n1, n2)
+	if err != nil {
+		return 0, err
+	}
+	return a >> uint(b), nil
+}
+
+// Rand returns a deterministic pseudo-random integer in [min, max), seeded
+// with seed so that shuffled listings can be reproduced across builds.
+func (ns *Namespace) Rand(seed, min, max any) (int64, error) {
+	s, errs := cast.ToInt64E(seed)
+	lo, errlo := cast.ToInt64E(min)
+	hi, errhi := cast.ToInt64E(max)
+
+	if errs != nil {
+		return 0, fmt.Errorf("Rand operator can't be used with non-integer seed value %v", seed)
+	}
+	if errlo != nil {
+		return 0, fmt.Errorf("Rand operator can't be used with non-integer min value %v", min)
+	}
+	if errhi != nil {
+		return 0, fmt.Errorf("Rand operator can't be used with non-integer max value %v", max)
+	}
+	if hi <= lo {
+		return 0, fmt.Errorf("Rand max (%d) must be greater than min (%d)", hi, lo)
+	}
+
+	r := rand.New(rand.NewSource(s))
+	return lo + r.Int63n(hi-lo), nil
+}
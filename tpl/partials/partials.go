@@ -16,6 +16,7 @@
 package partials
 
 import (
+	"container/list"
 	"fmt"
 	"html/template"
 	"io"
@@ -23,39 +24,151 @@ import (
 	"strings"
 	"sync"
 	texttemplate "text/template"
+	"time"
 
 	"github.com/gohugoio/hugo/tpl"
 
 	bp "github.com/gohugoio/hugo/bufferpool"
 	"github.com/gohugoio/hugo/deps"
+	hugodeps "github.com/gohugoio/hugo/deps"
 )
 
 // TestTemplateProvider is global deps.ResourceProvider.
 // NOTE: It's currently unused.
 var TestTemplateProvider deps.ResourceProvider
 
-// partialCache represents a cache of partials protected by a mutex.
+// defaultMaxEntries is used when partialCache.maxEntries isn't set in site
+// config. A negative value means no cap.
+const defaultMaxEntries = -1
+
+// partialCacheEntry is the value stored in partialCache's LRU list.
+type partialCacheEntry struct {
+	key    string
+	value  interface{}
+	expire time.Time // zero value means the entry never expires
+}
+
+// inflightCall tracks a getOrCreate call in progress so that concurrent
+// requests for the same key coalesce into a single evaluation of the
+// partial, rather than racing each other through Include.
+type inflightCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// partialCache represents a cache of partials protected by a mutex. Entries
+// are kept in a most-recently-used order so that, once maxEntries is
+// exceeded, the least recently used entry is evicted first. Entries may also
+// carry a TTL, after which they're treated as a miss.
 type partialCache struct {
 	sync.RWMutex
-	p map[string]interface{}
+	p          map[string]*list.Element
+	lru        *list.List
+	maxEntries int
+	defaultTTL time.Duration
+
+	inflightMu sync.Mutex
+	inflight   map[string]*inflightCall
+}
+
+func newPartialCache(maxEntries int, defaultTTL time.Duration) *partialCache {
+	return &partialCache{
+		p:          make(map[string]*list.Element),
+		lru:        list.New(),
+		maxEntries: maxEntries,
+		defaultTTL: defaultTTL,
+		inflight:   make(map[string]*inflightCall),
+	}
 }
 
 func (p *partialCache) clear() {
 	p.Lock()
 	defer p.Unlock()
-	p.p = make(map[string]interface{})
+	p.p = make(map[string]*list.Element)
+	p.lru = list.New()
+}
+
+// get returns the cached value for key, or false if there's no value or the
+// value has expired.
+func (p *partialCache) get(key string) (interface{}, bool) {
+	p.Lock()
+	defer p.Unlock()
+
+	el, found := p.p[key]
+	if !found {
+		return nil, false
+	}
+
+	entry := el.Value.(*partialCacheEntry)
+	if !entry.expire.IsZero() && time.Now().After(entry.expire) {
+		p.lru.Remove(el)
+		delete(p.p, key)
+		return nil, false
+	}
+
+	p.lru.MoveToFront(el)
+
+	return entry.value, true
+}
+
+// set stores value for key with the given ttl (zero meaning no expiry) and
+// reports whether storing it caused an older entry to be evicted.
+func (p *partialCache) set(key string, value interface{}, ttl time.Duration) bool {
+	p.Lock()
+	defer p.Unlock()
+
+	var expire time.Time
+	if ttl > 0 {
+		expire = time.Now().Add(ttl)
+	}
+
+	if el, found := p.p[key]; found {
+		entry := el.Value.(*partialCacheEntry)
+		entry.value = value
+		entry.expire = expire
+		p.lru.MoveToFront(el)
+		return false
+	}
+
+	el := p.lru.PushFront(&partialCacheEntry{key: key, value: value, expire: expire})
+	p.p[key] = el
+
+	var evicted bool
+	for p.maxEntries > 0 && p.lru.Len() > p.maxEntries {
+		oldest := p.lru.Back()
+		if oldest == nil {
+			break
+		}
+		p.lru.Remove(oldest)
+		delete(p.p, oldest.Value.(*partialCacheEntry).key)
+		evicted = true
+	}
+
+	return evicted
 }
 
 // New returns a new instance of the templates-namespaced template functions.
-func New(deps *deps.Deps) *Namespace {
-	cache := &partialCache{p: make(map[string]interface{})}
-	deps.BuildStartListeners.Add(
-		func() {
-			cache.clear()
-		})
+func New(d *deps.Deps) *Namespace {
+	maxEntries := defaultMaxEntries
+	var defaultTTL time.Duration
+
+	if d.Cfg != nil {
+		if v := d.Cfg.GetInt("partialCache.maxEntries"); v > 0 {
+			maxEntries = v
+		}
+		if v := d.Cfg.GetDuration("partialCache.defaultTTL"); v > 0 {
+			defaultTTL = v
+		}
+	}
+
+	cache := newPartialCache(maxEntries, defaultTTL)
+	d.Events.Subscribe(hugodeps.EventBuildStart, func(hugodeps.Event) {
+		cache.clear()
+	})
 
 	return &Namespace{
-		deps:           deps,
+		deps:           d,
 		cachedPartials: cache,
 	}
 }
@@ -66,6 +179,63 @@ type Namespace struct {
 	cachedPartials *partialCache
 }
 
+// CacheOptions configures the caching behaviour of a single IncludeCached
+// call. It's passed as the final, optional argument.
+type CacheOptions struct {
+	// Key, if set, replaces the automatically derived name+variant cache key.
+	Key string
+
+	// TTL overrides the site's partialCache.defaultTTL for this entry. Zero
+	// means the entry never expires.
+	TTL time.Duration
+
+	// Bust forces a fresh evaluation of the partial, replacing any entry
+	// already in the cache.
+	Bust bool
+}
+
+// CacheOptions builds a CacheOptions value for IncludeCached out of a
+// template-constructed map (e.g. Hugo's built-in `dict` function), since
+// templates have no way to construct a Go struct literal directly.
+// Recognised keys, all optional, are "key" (string), "ttl" (a string
+// parseable by time.ParseDuration, or a time.Duration) and "bust" (bool).
+func (ns *Namespace) CacheOptions(opts map[string]interface{}) (CacheOptions, error) {
+	var co CacheOptions
+
+	if v, found := opts["key"]; found {
+		s, ok := v.(string)
+		if !ok {
+			return co, fmt.Errorf("partials.CacheOptions: key must be a string, got %T", v)
+		}
+		co.Key = s
+	}
+
+	if v, found := opts["ttl"]; found {
+		switch vv := v.(type) {
+		case string:
+			d, err := time.ParseDuration(vv)
+			if err != nil {
+				return co, fmt.Errorf("partials.CacheOptions: invalid ttl %q: %w", vv, err)
+			}
+			co.TTL = d
+		case time.Duration:
+			co.TTL = vv
+		default:
+			return co, fmt.Errorf("partials.CacheOptions: ttl must be a string or time.Duration, got %T", v)
+		}
+	}
+
+	if v, found := opts["bust"]; found {
+		b, ok := v.(bool)
+		if !ok {
+			return co, fmt.Errorf("partials.CacheOptions: bust must be a bool, got %T", v)
+		}
+		co.Bust = b
+	}
+
+	return co, nil
+}
+
 // contextWrapper makes room for a return value in a partial invocation.
 type contextWrapper struct {
 	Arg    interface{}
@@ -155,38 +325,97 @@ func (ns *Namespace) Include(name string, contextList ...interface{}) (interface
 // string parameter (a string slice actually, but be only use a variadic
 // argument to make it optional) can be passed so that a given partial can have
 // multiple uses. The cache is created with name+variant as the key.
-func (ns *Namespace) IncludeCached(name string, context interface{}, variant ...string) (interface{}, error) {
+//
+// A partials.CacheOptions value, built via the CacheOptions method, may be
+// passed as the final argument to control the TTL, override the cache key,
+// or bust the existing entry for this call, e.g.:
+//
+//	{{ partials.IncludeCached "my-partial.html" . "variant" (partials.CacheOptions (dict "ttl" "10s")) }}
+func (ns *Namespace) IncludeCached(name string, context interface{}, variant ...interface{}) (interface{}, error) {
+	var opts CacheOptions
+
 	key := name
-	if len(variant) > 0 {
-		for i := 0; i < len(variant); i++ {
-			key += variant[i]
+	for _, v := range variant {
+		switch vv := v.(type) {
+		case CacheOptions:
+			opts = vv
+		default:
+			key += fmt.Sprintf("%v", vv)
 		}
 	}
-	return ns.getOrCreate(key, name, context)
+
+	if opts.Key != "" {
+		key = opts.Key
+	}
+
+	return ns.getOrCreate(key, name, context, opts)
 }
 
-func (ns *Namespace) getOrCreate(key, name string, context interface{}) (interface{}, error) {
+func (ns *Namespace) getOrCreate(key, name string, context interface{}, opts CacheOptions) (interface{}, error) {
+	cache := ns.cachedPartials
 
-	ns.cachedPartials.RLock()
-	p, ok := ns.cachedPartials.p[key]
-	ns.cachedPartials.RUnlock()
+	if !opts.Bust {
+		if v, found := cache.get(key); found {
+			ns.trackCache("hit", key)
+			return v, nil
+		}
+	}
 
-	if ok {
-		return p, nil
+	cache.inflightMu.Lock()
+	if call, found := cache.inflight[key]; found {
+		cache.inflightMu.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
 	}
+	call := &inflightCall{}
+	call.wg.Add(1)
+	cache.inflight[key] = call
+	cache.inflightMu.Unlock()
+
+	defer func() {
+		cache.inflightMu.Lock()
+		delete(cache.inflight, key)
+		cache.inflightMu.Unlock()
+		call.wg.Done()
+	}()
+
+	// Another goroutine may have populated the cache while we were waiting
+	// for the inflight map's lock.
+	if !opts.Bust {
+		if v, found := cache.get(key); found {
+			call.value = v
+			ns.trackCache("hit", key)
+			return v, nil
+		}
+	}
+
+	ns.trackCache("miss", key)
 
 	p, err := ns.Include(name, context)
 	if err != nil {
+		call.err = err
 		return nil, err
 	}
 
-	ns.cachedPartials.Lock()
-	defer ns.cachedPartials.Unlock()
-	// Double-check.
-	if p2, ok := ns.cachedPartials.p[key]; ok {
-		return p2, nil
+	ttl := opts.TTL
+	if ttl == 0 {
+		ttl = cache.defaultTTL
+	}
+
+	if cache.set(key, p, ttl) {
+		ns.trackCache("evict", key)
 	}
-	ns.cachedPartials.p[key] = p
+
+	call.value = p
 
 	return p, nil
 }
+
+// trackCache reports a cache hit/miss/evict event to the metrics provider,
+// if enabled.
+func (ns *Namespace) trackCache(event, key string) {
+	if ns.deps.Metrics == nil {
+		return
+	}
+	ns.deps.Metrics.TrackValue("partials.cache."+event, key)
+}
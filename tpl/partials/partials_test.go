@@ -0,0 +1,190 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package partials
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPartialCacheGetSet(t *testing.T) {
+	c := newPartialCache(defaultMaxEntries, 0)
+
+	if _, found := c.get("a"); found {
+		t.Fatal("expected a miss on an empty cache")
+	}
+
+	c.set("a", "A", 0)
+
+	v, found := c.get("a")
+	if !found || v != "A" {
+		t.Fatalf("got %v, %v; want %q, true", v, found, "A")
+	}
+}
+
+func TestPartialCacheMaxEntriesEvictsLRU(t *testing.T) {
+	c := newPartialCache(2, 0)
+
+	c.set("a", "A", 0)
+	c.set("b", "B", 0)
+
+	// Touch "a" so "b" becomes the least recently used entry.
+	c.get("a")
+
+	evicted := c.set("c", "C", 0)
+	if !evicted {
+		t.Fatal("expected set to report an eviction once maxEntries is exceeded")
+	}
+
+	if _, found := c.get("b"); found {
+		t.Error("expected the least-recently-used entry (b) to have been evicted")
+	}
+	if _, found := c.get("a"); !found {
+		t.Error("expected the recently-touched entry (a) to survive")
+	}
+	if _, found := c.get("c"); !found {
+		t.Error("expected the newly-set entry (c) to be present")
+	}
+}
+
+func TestPartialCacheTTLExpires(t *testing.T) {
+	c := newPartialCache(defaultMaxEntries, 0)
+
+	c.set("a", "A", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := c.get("a"); found {
+		t.Error("expected the entry to have expired")
+	}
+}
+
+func TestPartialCacheConcurrentGetSet(t *testing.T) {
+	c := newPartialCache(50, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i%10)
+			c.set(key, i, 0)
+			c.get(key)
+		}(i)
+	}
+	wg.Wait()
+
+	// No assertion beyond "didn't race or deadlock": run with -race to
+	// exercise partialCache's locking around its map and LRU list.
+}
+
+// TestPartialCacheInflightCoalescing exercises the inflight map the same
+// way getOrCreate does: concurrent callers for the same key register a
+// single *inflightCall, the first to arrive "wins" and the rest wait on
+// its WaitGroup, and the map is empty again once every caller has
+// observed the result.
+func TestPartialCacheInflightCoalescing(t *testing.T) {
+	c := newPartialCache(defaultMaxEntries, 0)
+
+	const n = 20
+	key := "shared"
+
+	var registered int32
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make([]interface{}, 0, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c.inflightMu.Lock()
+			call, found := c.inflight[key]
+			if !found {
+				call = &inflightCall{}
+				call.wg.Add(1)
+				c.inflight[key] = call
+				registered++
+			}
+			c.inflightMu.Unlock()
+
+			if !found {
+				// Simulate doing the (expensive) work exactly once.
+				call.value = "computed"
+				c.set(key, call.value, 0)
+
+				c.inflightMu.Lock()
+				delete(c.inflight, key)
+				c.inflightMu.Unlock()
+				call.wg.Done()
+			} else {
+				call.wg.Wait()
+			}
+
+			mu.Lock()
+			results = append(results, call.value)
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if len(c.inflight) != 0 {
+		t.Errorf("expected the inflight map to be empty once every caller finished, got %d entries", len(c.inflight))
+	}
+	if len(results) != n {
+		t.Fatalf("got %d results, want %d", len(results), n)
+	}
+	for _, r := range results {
+		if r != "computed" {
+			t.Errorf("got result %v, want %q", r, "computed")
+		}
+	}
+}
+
+func TestNamespaceCacheOptionsFromMap(t *testing.T) {
+	ns := &Namespace{}
+
+	co, err := ns.CacheOptions(map[string]interface{}{
+		"key":  "my-key",
+		"ttl":  "10s",
+		"bust": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if co.Key != "my-key" || co.TTL != 10*time.Second || !co.Bust {
+		t.Fatalf("got %+v", co)
+	}
+
+	co, err = ns.CacheOptions(map[string]interface{}{"ttl": 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if co.TTL != 5*time.Second {
+		t.Fatalf("got TTL %v, want %v", co.TTL, 5*time.Second)
+	}
+
+	if _, err := ns.CacheOptions(map[string]interface{}{"ttl": "not-a-duration"}); err == nil {
+		t.Error("expected an error for an unparseable ttl")
+	}
+	if _, err := ns.CacheOptions(map[string]interface{}{"key": 42}); err == nil {
+		t.Error("expected an error for a non-string key")
+	}
+	if _, err := ns.CacheOptions(map[string]interface{}{"bust": "yes"}); err == nil {
+		t.Error("expected an error for a non-bool bust")
+	}
+}
@@ -14,13 +14,11 @@
 package tpl
 
 import (
-	"fmt"
 	"github.com/eknkc/amber"
 	bp "github.com/spf13/hugo/bufferpool"
 	"github.com/spf13/hugo/helpers"
 	"github.com/spf13/hugo/hugofs"
 	jww "github.com/spf13/jwalterweatherman"
-	"github.com/yosssi/ace"
 	"html/template"
 	"io"
 	"io/ioutil"
@@ -161,74 +159,55 @@ func (t *GoHTMLTemplate) AddTemplate(name, tpl string) error {
 }
 
 func (t *GoHTMLTemplate) AddAceTemplate(name, basePath, innerPath string, baseContent, innerContent []byte) error {
-	var base, inner *ace.File
-	name = name[:len(name)-len(filepath.Ext(innerPath))] + ".html"
-
-	// Fixes issue #1178
-	basePath = strings.Replace(basePath, "\\", "/", -1)
-	innerPath = strings.Replace(innerPath, "\\", "/", -1)
-
-	if basePath != "" {
-		base = ace.NewFile(basePath, baseContent)
-		inner = ace.NewFile(innerPath, innerContent)
-	} else {
-		base = ace.NewFile(innerPath, innerContent)
-		inner = ace.NewFile("", []byte{})
-	}
-	parsed, err := ace.ParseSource(ace.NewSource(base, inner, []*ace.File{}), nil)
+	compiled, err := (aceTemplateEngine{}).Compile(name, basePath, innerPath, baseContent, innerContent)
 	if err != nil {
 		t.errors = append(t.errors, &templateErr{name: name, err: err})
 		return err
 	}
-	_, err = ace.CompileResultWithTemplate(t.New(name), parsed, nil)
-	if err != nil {
+
+	if _, err := compiled.AddTo(&t.Template); err != nil {
 		t.errors = append(t.errors, &templateErr{name: name, err: err})
+		return err
 	}
-	return err
+
+	return nil
 }
 
+// AddTemplateFile compiles the template at path (together with its base
+// template at baseTemplatePath, if any) using whichever TemplateEngine
+// claims path's extension, falling back to plain Go html/template for
+// anything unclaimed.
 func (t *GoHTMLTemplate) AddTemplateFile(name, baseTemplatePath, path string) error {
-	// get the suffix and switch on that
-	ext := filepath.Ext(path)
-	switch ext {
-	case ".amber":
-		templateName := strings.TrimSuffix(name, filepath.Ext(name)) + ".html"
-		compiler := amber.New()
-		// Parse the input file
-		if err := compiler.ParseFile(path); err != nil {
-			return err
-		}
+	engine := engineForExt(filepath.Ext(path))
+	if engine == nil {
+		engine = goTemplateEngine{}
+	}
 
-		if _, err := compiler.CompileWithTemplate(t.New(templateName)); err != nil {
-			return err
-		}
-	case ".ace":
-		var innerContent, baseContent []byte
-		innerContent, err := ioutil.ReadFile(path)
+	inner, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
 
+	var base []byte
+	if baseTemplatePath != "" {
+		base, err = ioutil.ReadFile(baseTemplatePath)
 		if err != nil {
 			return err
 		}
+	}
 
-		if baseTemplatePath != "" {
-			baseContent, err = ioutil.ReadFile(baseTemplatePath)
-			if err != nil {
-				return err
-			}
-		}
-
-		return t.AddAceTemplate(name, baseTemplatePath, path, baseContent, innerContent)
-	default:
-		b, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
-		}
+	compiled, err := engine.Compile(name, baseTemplatePath, path, base, inner)
+	if err != nil {
+		t.errors = append(t.errors, &templateErr{name: name, err: err})
+		return err
+	}
 
-		return t.AddTemplate(name, string(b))
+	if _, err := compiled.AddTo(&t.Template); err != nil {
+		t.errors = append(t.errors, &templateErr{name: name, err: err})
+		return err
 	}
 
 	return nil
-
 }
 
 func (t *GoHTMLTemplate) GenerateTemplateNameFrom(base, path string) string {
@@ -244,12 +223,47 @@ func isBackupFile(path string) bool {
 	return path[len(path)-1] == '~'
 }
 
-const baseAceFilename = "baseof.ace"
+// isBaseTemplateFile reports whether path is itself a base template that
+// the engine registered for its extension says should be skipped rather
+// than loaded as a template in its own right (e.g. Ace's baseof.ace).
+// Engines with no base-template convention of their own (the default, and
+// everything but Ace so far) never match here.
+func isBaseTemplateFile(path string) bool {
+	engine := engineForExt(filepath.Ext(path))
+	if engine == nil {
+		return false
+	}
+	btp, ok := engine.(BaseTemplateProvider)
+	return ok && btp.IsBaseTemplateFile(path)
+}
+
+// baseTemplatePathFor looks up a base template for the template at path
+// within absPath, per the rules of whichever engine claims path's
+// extension. It returns "" if that engine has no base-template convention,
+// or if the template doesn't need one, or if none of its candidates exist.
+func baseTemplatePathFor(absPath, path string) (string, error) {
+	engine := engineForExt(filepath.Ext(path))
+	if engine == nil || strings.HasSuffix(filepath.Dir(path), "partials") {
+		return "", nil
+	}
 
-var aceTemplateInnerMarker = []byte("= content")
+	btp, ok := engine.(BaseTemplateProvider)
+	if !ok {
+		return "", nil
+	}
+
+	needsBase, err := btp.NeedsBaseTemplate(path)
+	if err != nil || !needsBase {
+		return "", err
+	}
 
-func isBaseTemplate(path string) bool {
-	return strings.HasSuffix(path, baseAceFilename)
+	for _, candidate := range btp.BaseTemplateCandidates(absPath, path) {
+		if ok, err := helpers.Exists(candidate, hugofs.OsFs); err == nil && ok {
+			return candidate, nil
+		}
+	}
+
+	return "", nil
 }
 
 func (t *GoHTMLTemplate) loadTemplates(absPath string, prefix string) {
@@ -276,7 +290,7 @@ func (t *GoHTMLTemplate) loadTemplates(absPath string, prefix string) {
 		}
 
 		if !fi.IsDir() {
-			if isDotFile(path) || isBackupFile(path) || isBaseTemplate(path) {
+			if isDotFile(path) || isBackupFile(path) || isBaseTemplateFile(path) {
 				return nil
 			}
 
@@ -286,46 +300,12 @@ func (t *GoHTMLTemplate) loadTemplates(absPath string, prefix string) {
 				tplName = strings.Trim(prefix, "/") + "/" + tplName
 			}
 
-			var baseTemplatePath string
-
-			// ACE templates may have both a base and inner template.
-			if filepath.Ext(path) == ".ace" && !strings.HasSuffix(filepath.Dir(path), "partials") {
-				// This may be a view that shouldn't have base template
-				// Have to look inside it to make sure
-				needsBase, err := helpers.FileContains(path, aceTemplateInnerMarker, hugofs.OsFs)
-				if err != nil {
-					return err
-				}
-				if needsBase {
-
-					// Look for base template in the follwing order:
-					//   1. <current-path>/<template-name>-baseof.ace, e.g. list-baseof.ace.
-					//   2. <current-path>/baseof.ace
-					//   3. _default/<template-name>-baseof.ace, e.g. list-baseof.ace.
-					//   4. _default/baseof.ace
-					//   5. <themedir>/layouts/_default/<template-name>-baseof.ace
-					//   6. <themedir>/layouts/_default/baseof.ace
-
-					currBaseAceFilename := fmt.Sprintf("%s-%s", helpers.Filename(path), baseAceFilename)
-					templateDir := filepath.Dir(path)
-					themeDir := helpers.GetThemeDir()
-
-					pathsToCheck := []string{
-						filepath.Join(templateDir, currBaseAceFilename),
-						filepath.Join(templateDir, baseAceFilename),
-						filepath.Join(absPath, "_default", currBaseAceFilename),
-						filepath.Join(absPath, "_default", baseAceFilename),
-						filepath.Join(themeDir, "layouts", "_default", currBaseAceFilename),
-						filepath.Join(themeDir, "layouts", "_default", baseAceFilename),
-					}
-
-					for _, pathToCheck := range pathsToCheck {
-						if ok, err := helpers.Exists(pathToCheck, hugofs.OsFs); err == nil && ok {
-							baseTemplatePath = pathToCheck
-							break
-						}
-					}
-				}
+			// Templates in engines with a base/layout convention (so far
+			// just Ace) may have a base template to fold in; everything
+			// else gets "".
+			baseTemplatePath, err := baseTemplatePathFor(absPath, path)
+			if err != nil {
+				return err
 			}
 
 			t.AddTemplateFile(tplName, baseTemplatePath, path)
@@ -0,0 +1,86 @@
+// Copyright 2021 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tpl
+
+import "html/template"
+
+// CompiledTemplate is what a TemplateEngine's Compile returns: a template
+// ready to be registered into a GoHTMLTemplate's underlying
+// *template.Template.
+type CompiledTemplate interface {
+	// Name is the name the template will be added under.
+	Name() string
+	// AddTo registers the compiled template in dest and returns the
+	// *template.Template it was added as, so GoHTMLTemplate's Lookup,
+	// Templates and ExecuteTemplate all keep working regardless of which
+	// engine produced it.
+	AddTo(dest *template.Template) (*template.Template, error)
+}
+
+// TemplateEngine compiles a template written in some template language into
+// a CompiledTemplate. AddTemplateFile dispatches to the engine registered
+// for a file's extension, falling back to goTemplateEngine (plain Go
+// html/template) for everything else.
+type TemplateEngine interface {
+	// Extensions are the file extensions, including the leading dot
+	// (e.g. ".ace"), this engine claims.
+	Extensions() []string
+	// Compile parses a template's inner content, and its base content if
+	// basePath is non-empty, into a CompiledTemplate.
+	Compile(name, basePath, path string, base, inner []byte) (CompiledTemplate, error)
+}
+
+// BaseTemplateProvider is implemented by TemplateEngines whose templates
+// may need a separate base/layout template folded in (Ace's `= content`
+// convention is the only one of the three engines registered below that
+// does). loadTemplates type-asserts for it rather than it being part of
+// TemplateEngine itself, since most engines have no use for it.
+type BaseTemplateProvider interface {
+	// IsBaseTemplateFile reports whether path is itself a base template,
+	// so loadTemplates should skip walking it as a template of its own.
+	IsBaseTemplateFile(path string) bool
+	// NeedsBaseTemplate reports whether the template at path references
+	// a base template that needs to be located and folded in.
+	NeedsBaseTemplate(path string) (bool, error)
+	// BaseTemplateCandidates returns, in priority order, the paths to
+	// check for a base template for the template at path within absPath.
+	BaseTemplateCandidates(absPath, path string) []string
+}
+
+// templateEngines holds the registered engines, consulted in registration
+// order by engineForExt.
+var templateEngines []TemplateEngine
+
+// RegisterTemplateEngine adds e to the set AddTemplateFile dispatches to
+// for its claimed extensions.
+func RegisterTemplateEngine(e TemplateEngine) {
+	templateEngines = append(templateEngines, e)
+}
+
+func engineForExt(ext string) TemplateEngine {
+	for _, e := range templateEngines {
+		for _, x := range e.Extensions() {
+			if x == ext {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	RegisterTemplateEngine(aceTemplateEngine{})
+	RegisterTemplateEngine(amberTemplateEngine{})
+	RegisterTemplateEngine(mustacheTemplateEngine{})
+}
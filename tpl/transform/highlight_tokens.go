@@ -0,0 +1,106 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"sort"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+
+	"github.com/spf13/cast"
+)
+
+// HighlightToken is one lexical token produced by Chroma for source, as used
+// by HighlightTokens. Line is 1-indexed.
+type HighlightToken struct {
+	Type string
+	Text string
+	Line int
+}
+
+// HighlightTokens lexes source as lang using Chroma and returns the raw
+// tokens instead of rendered HTML, so templates can build their own markup
+// around them (per-line copy buttons, diff overlays, Prism-compatible
+// output, etc.). opts is accepted for parity with Highlight but currently
+// only "tabWidth" is read from it; Chroma formatting options that only
+// affect HTML rendering (e.g. linenos, hl_lines) have no effect here since
+// no HTML is produced.
+func (ns *Namespace) HighlightTokens(s any, lang string, opts any) ([]HighlightToken, error) {
+	ss, err := cast.ToStringE(s)
+	if err != nil {
+		return nil, err
+	}
+
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, ss)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tokens []HighlightToken
+		line   = 1
+	)
+
+	for _, tok := range iterator.Tokens() {
+		parts := splitKeepNewlines(tok.Value)
+		for _, part := range parts {
+			if part == "" {
+				continue
+			}
+			tokens = append(tokens, HighlightToken{
+				Type: tok.Type.String(),
+				Text: part,
+				Line: line,
+			})
+			if part == "\n" {
+				line++
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+// splitKeepNewlines splits s into its lines, keeping each trailing "\n" as
+// its own element so callers can track line numbers token-by-token.
+func splitKeepNewlines(s string) []string {
+	var parts []string
+	start := 0
+	for i, r := range s {
+		if r == '\n' {
+			parts = append(parts, s[start:i], "\n")
+			start = i + 1
+		}
+	}
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+	return parts
+}
+
+// HighlightLexers returns the names and aliases of every language Chroma can
+// lex, sorted alphabetically, so themes can gate highlighting-related UI
+// (e.g. only showing a "copy" button for languages with keyword coloring).
+func (ns *Namespace) HighlightLexers() []string {
+	names := lexers.Names(true)
+	sort.Strings(names)
+	return names
+}
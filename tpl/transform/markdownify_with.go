@@ -0,0 +1,169 @@
+// Copyright 2022 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transform
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+
+	"github.com/gohugoio/hugo/common/maps"
+
+	"github.com/spf13/cast"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// MarkdownifyWith renders s as Markdown using renderer and parser options
+// that apply only to this call, leaving the site's configured Markdown
+// pipeline untouched. This is useful for shortcodes that need to render an
+// inline snippet under different safety/HTML rules than the page content.
+//
+// opts accepts:
+//
+//	unsafe          allow raw HTML and dangerous links/images (bool, default false)
+//	hardWraps       render line breaks as <br> (bool, default false)
+//	xhtml           render self-closing tags as XHTML (bool, default false)
+//	headingOffset   shift heading levels by n, e.g. 1 turns "# Foo" into <h2> (int, default 0)
+//	extensions      a slice of "table", "footnote", "definitionList", "taskList" (default none)
+//	renderer        the converter to use; only "goldmark" is currently supported
+//
+//	{{ .Content | transform.MarkdownifyWith (dict "unsafe" true "extensions" (slice "table")) }}
+func (ns *Namespace) MarkdownifyWith(opts any, s any) (template.HTML, error) {
+	ss, err := cast.ToStringE(s)
+	if err != nil {
+		return "", err
+	}
+
+	options, err := parseMarkdownifyWithOptions(opts)
+	if err != nil {
+		return "", err
+	}
+
+	md := newMarkdownifyWithConverter(options)
+
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(ss), &buf); err != nil {
+		return "", fmt.Errorf("MarkdownifyWith: failed to convert Markdown: %w", err)
+	}
+
+	result := buf.String()
+	if options.headingOffset != 0 {
+		result = offsetHeadings(result, options.headingOffset)
+	}
+
+	return template.HTML(result), nil
+}
+
+type markdownifyWithOptions struct {
+	unsafe        bool
+	hardWraps     bool
+	xhtml         bool
+	headingOffset int
+	extensions    []string
+	renderer      string
+}
+
+func parseMarkdownifyWithOptions(opts any) (markdownifyWithOptions, error) {
+	options := markdownifyWithOptions{renderer: "goldmark"}
+
+	if opts == nil {
+		return options, nil
+	}
+
+	m, err := maps.ToStringMapE(opts)
+	if err != nil {
+		return options, fmt.Errorf("MarkdownifyWith: invalid options: %w", err)
+	}
+
+	for k, v := range m {
+		switch k {
+		case "unsafe":
+			options.unsafe = cast.ToBool(v)
+		case "hardWraps":
+			options.hardWraps = cast.ToBool(v)
+		case "xhtml":
+			options.xhtml = cast.ToBool(v)
+		case "headingOffset":
+			options.headingOffset = cast.ToInt(v)
+		case "extensions":
+			options.extensions = cast.ToStringSlice(v)
+		case "renderer":
+			options.renderer = cast.ToString(v)
+		default:
+			return options, fmt.Errorf("MarkdownifyWith: unknown option %q", k)
+		}
+	}
+
+	if options.renderer != "goldmark" {
+		return options, fmt.Errorf("MarkdownifyWith: unsupported renderer %q", options.renderer)
+	}
+
+	return options, nil
+}
+
+func newMarkdownifyWithConverter(options markdownifyWithOptions) goldmark.Markdown {
+	var rendererOptions []renderer.Option
+
+	if options.unsafe {
+		rendererOptions = append(rendererOptions, html.WithUnsafe())
+	}
+	if options.hardWraps {
+		rendererOptions = append(rendererOptions, html.WithHardWraps())
+	}
+	if options.xhtml {
+		rendererOptions = append(rendererOptions, html.WithXHTML())
+	}
+
+	var extensions []goldmark.Extender
+
+	for _, e := range options.extensions {
+		switch e {
+		case "table":
+			extensions = append(extensions, extension.Table)
+		case "footnote":
+			extensions = append(extensions, extension.Footnote)
+		case "definitionList":
+			extensions = append(extensions, extension.DefinitionList)
+		case "taskList":
+			extensions = append(extensions, extension.TaskList)
+		}
+	}
+
+	return goldmark.New(
+		goldmark.WithExtensions(extensions...),
+		goldmark.WithRendererOptions(rendererOptions...),
+	)
+}
+
+var headingTagRe = regexp.MustCompile(`(</?h)([1-6])(\b)`)
+
+// offsetHeadings shifts every <h1>-<h6> tag in html by offset levels,
+// clamping to the 1-6 range.
+func offsetHeadings(html string, offset int) string {
+	return headingTagRe.ReplaceAllStringFunc(html, func(m string) string {
+		groups := headingTagRe.FindStringSubmatch(m)
+		level := int(groups[2][0]-'0') + offset
+		if level < 1 {
+			level = 1
+		} else if level > 6 {
+			level = 6
+		}
+		return fmt.Sprintf("%s%d%s", groups[1], level, groups[3])
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transliterate
+
+import (
+	"github.com/gohugoio/hugo/deps"
+	"github.com/gohugoio/hugo/tpl/internal"
+)
+
+const name = "transliterate"
+
+// NOTE: this follows the same internal.AddTemplateFuncsNamespace
+// registration pattern as every sibling tpl/* namespace (e.g.
+// tpl/diagrams), but as of this writing github.com/gohugoio/hugo/tpl/internal
+// isn't present in this module -- every namespace's init(), not just this
+// one, is currently dead code until that package exists. Kept for
+// consistency with the rest of the tree and so the namespace wires itself
+// up for free once tpl/internal lands.
+func init() {
+	f := func(d *deps.Deps) *internal.TemplateFuncsNamespace {
+		ctx := New()
+
+		ns := &internal.TemplateFuncsNamespace{
+			Name:    name,
+			Context: func(args ...interface{}) (interface{}, error) { return ctx, nil },
+		}
+
+		return ns
+	}
+
+	internal.AddTemplateFuncsNamespace(f)
+}
@@ -0,0 +1,44 @@
+// Copyright 2026 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transliterate provides template functions for converting
+// non-Latin scripts into a Latin, URL-safe approximation, e.g.
+// {{ transliterate.Apply "pinyin" "中国" }}. It's a thin wrapper around
+// helpers.RegisterTransliterator's registry, the same one backing the
+// site-wide `transliterate` config option used by Slugify.
+package transliterate
+
+import (
+	"fmt"
+
+	"github.com/gohugoio/hugo/helpers"
+)
+
+// New returns a new instance of the transliterate-namespaced template
+// functions.
+func New() *Namespace {
+	return &Namespace{}
+}
+
+// Namespace provides template functions for the "transliterate" namespace.
+type Namespace struct{}
+
+// Apply runs the Transliterator registered under name against s, returning
+// an error if no Transliterator is registered under that name.
+func (ns *Namespace) Apply(name, s string) (string, error) {
+	t, found := helpers.GetTransliterator(name)
+	if !found {
+		return "", fmt.Errorf("transliterate: no Transliterator registered under %q", name)
+	}
+	return t(s), nil
+}
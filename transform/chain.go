@@ -3,32 +3,102 @@ package transform
 import (
 	"bytes"
 	"io"
+	"sync"
 )
 
+// contentTransformer is what a link in a Chain operates on: the
+// not-yet-transformed content, and a Writer the transformed result should
+// be written to.
+type contentTransformer interface {
+	Content() []byte
+	io.Writer
+}
+
+// link is the signature every entry in a Chain implements: read
+// ct.Content(), write the transformed result to ct.
+type link func(ct contentTransformer) error
+
+// trans is the whole-input-in, whole-output-out signature links used
+// before this package grew the contentTransformer-based Chain. AdaptTrans
+// wraps one as a link so old-style transforms keep working unchanged.
 type trans func([]byte) []byte
 
-type link trans
+// AdaptTrans adapts a trans func into a link.
+func AdaptTrans(t trans) link {
+	return func(ct contentTransformer) error {
+		_, err := ct.Write(t(ct.Content()))
+		return err
+	}
+}
 
-type chain []link
+// Chain is an ordered list of transforms to apply to some content, each
+// one's output becoming the next one's input.
+type Chain []link
 
-func NewChain(trs ...link) chain {
+// NewChain creates a new Chain of transformers.
+func NewChain(trs ...link) Chain {
 	return trs
 }
 
-func NewEmptyTransforms() []link {
-	return make([]link, 0, 20)
+// NewEmptyTransforms preallocates a Chain with headroom for building up
+// via append.
+func NewEmptyTransforms() Chain {
+	return make(Chain, 0, 20)
 }
 
-func (c *chain) Apply(w io.Writer, r io.Reader) (err error) {
+// contentBuffer is the concrete contentTransformer Apply threads through
+// the chain: content holds what the previous link produced (or the
+// original input, for the first link), and out accumulates what the
+// current link writes.
+type contentBuffer struct {
+	content []byte
+	out     *bytes.Buffer
+}
+
+func (c *contentBuffer) Content() []byte             { return c.content }
+func (c *contentBuffer) Write(p []byte) (int, error) { return c.out.Write(p) }
 
-	buffer := new(bytes.Buffer)
-	buffer.ReadFrom(r)
-	b := buffer.Bytes()
-	for _, tr := range *c {
-		b = tr(b)
+// bufferPool holds the *bytes.Buffer instances Apply uses both to read in
+// the full input and to collect each link's output, so repeated Apply
+// calls (e.g. one per rendered page in `hugo server`) don't allocate a
+// fresh buffer every time.
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// Apply runs r through every link in c in order and writes the final
+// result to w.
+func (c Chain) Apply(w io.Writer, r io.Reader) error {
+	if len(c) == 0 {
+		_, err := io.Copy(w, r)
+		return err
 	}
-	buffer.Reset()
-	buffer.Write(b)
-	buffer.WriteTo(w)
-	return
+
+	in := bufferPool.Get().(*bytes.Buffer)
+	in.Reset()
+	defer bufferPool.Put(in)
+
+	if _, err := in.ReadFrom(r); err != nil {
+		return err
+	}
+
+	content := in.Bytes()
+
+	for _, l := range c {
+		out := bufferPool.Get().(*bytes.Buffer)
+		out.Reset()
+
+		if err := l(&contentBuffer{content: content, out: out}); err != nil {
+			bufferPool.Put(out)
+			return err
+		}
+
+		// content (from the previous iteration's pooled buffer, or in's)
+		// must be copied out before out's buffer is returned to the pool,
+		// since a future Get could hand that same buffer back out and
+		// reset it while content still points at its backing array.
+		content = append([]byte(nil), out.Bytes()...)
+		bufferPool.Put(out)
+	}
+
+	_, err := w.Write(content)
+	return err
 }
@@ -0,0 +1,116 @@
+package transform
+
+import (
+	"io"
+	"sync"
+)
+
+// defaultChunkSize is how much of the input StreamingChain.Apply reads at
+// a time. Kept well below a typical page size so a `hugo server` response
+// can start flushing before the whole page has even been read off disk.
+const defaultChunkSize = 32 * 1024
+
+// chunkPool holds the []byte buffers StreamingChain.Apply reads chunks
+// into, so repeated Apply calls don't allocate a fresh chunk every time.
+var chunkPool = sync.Pool{New: func() interface{} {
+	b := make([]byte, defaultChunkSize)
+	return &b
+}}
+
+// StreamingTransformer is a transform that can process content in bounded
+// chunks instead of buffering the whole input -- for transforms like
+// absURL rewriting or livereload script injection that only ever need to
+// look a few bytes either side of a match, holding the entire page in
+// memory to run them is wasted memory on a large site.
+//
+// ProcessChunk receives the next chunk read from the input together with
+// carry: bytes held back from the end of the previous call because they
+// might be the start of a match that continues into this chunk (e.g. the
+// first few bytes of "<body", split across a chunk boundary). It returns
+// emit, the bytes now known to be safe to write out, and newCarry, the
+// (typically short) tail to prepend to the next chunk instead of emitting
+// it yet.
+//
+// Flush is called once after the final chunk has been processed, with
+// whatever carry is still held back, and returns the remaining bytes to
+// emit -- there being no more input left to disambiguate a match against.
+type StreamingTransformer interface {
+	ProcessChunk(carry, chunk []byte) (emit, newCarry []byte)
+	Flush(carry []byte) []byte
+}
+
+// StreamingChain is an ordered list of StreamingTransformers, each one's
+// emitted output becoming the next one's chunk input.
+type StreamingChain []StreamingTransformer
+
+// NewStreamingChain creates a new StreamingChain.
+func NewStreamingChain(sts ...StreamingTransformer) StreamingChain {
+	return sts
+}
+
+// Apply reads r in bounded chunks (see defaultChunkSize) and pipes each
+// chunk through every transformer in c in order, writing the final stage's
+// output to w as it's produced rather than waiting for all of r to be
+// read -- the "pipe chunks through the chain via pooled buffers" mode
+// plain Chain.Apply doesn't offer, at the cost of each StreamingTransformer
+// having to cope with a match spanning a chunk boundary itself via
+// carry/newCarry.
+func (c StreamingChain) Apply(w io.Writer, r io.Reader) error {
+	carries := make([][]byte, len(c))
+
+	chunkPtr := chunkPool.Get().(*[]byte)
+	defer chunkPool.Put(chunkPtr)
+	chunk := *chunkPtr
+
+	for {
+		n, readErr := r.Read(chunk)
+		if n > 0 {
+			data := chunk[:n]
+			for i, st := range c {
+				emit, newCarry := st.ProcessChunk(carries[i], data)
+				carries[i] = newCarry
+				data = emit
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	final := c.flushFrom(0, carries)
+	if len(final) > 0 {
+		if _, err := w.Write(final); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flushFrom returns the bytes stage i and every stage after it will
+// ultimately emit once stage i is flushed: stage i's own Flush output is
+// fed forward into stage i+1 as one last chunk (via ProcessChunk, since
+// stage i+1 may still be mid-match and need to hold part of it back as
+// carry), and the rest of the chain is flushed from there recursively.
+func (c StreamingChain) flushFrom(i int, carries [][]byte) []byte {
+	if i >= len(c) {
+		return nil
+	}
+
+	data := c[i].Flush(carries[i])
+	if i+1 >= len(c) {
+		return data
+	}
+
+	emit, newCarry := c[i+1].ProcessChunk(carries[i+1], data)
+	carries[i+1] = newCarry
+
+	return append(emit, c.flushFrom(i+1, carries)...)
+}
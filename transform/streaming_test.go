@@ -0,0 +1,108 @@
+package transform
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// upperTransformer uppercases every byte it sees; it never needs to hold
+// anything back as carry.
+type upperTransformer struct{}
+
+func (upperTransformer) ProcessChunk(carry, chunk []byte) (emit, newCarry []byte) {
+	out := make([]byte, 0, len(carry)+len(chunk))
+	out = append(out, bytes.ToUpper(carry)...)
+	out = append(out, bytes.ToUpper(chunk)...)
+	return out, nil
+}
+
+func (upperTransformer) Flush(carry []byte) []byte {
+	return bytes.ToUpper(carry)
+}
+
+// markerTransformer rewrites the literal "MARKER" to "FOUND", holding back
+// a possible partial match (up to len(marker)-1 bytes) across chunk reads
+// so a marker split across a chunk boundary is still caught.
+type markerTransformer struct{}
+
+const marker = "MARKER"
+
+func (markerTransformer) ProcessChunk(carry, chunk []byte) (emit, newCarry []byte) {
+	buf := append(append([]byte(nil), carry...), chunk...)
+	replaced := bytes.ReplaceAll(buf, []byte(marker), []byte("FOUND"))
+
+	if len(replaced) <= len(marker)-1 {
+		return nil, replaced
+	}
+
+	holdBack := len(marker) - 1
+	return replaced[:len(replaced)-holdBack], replaced[len(replaced)-holdBack:]
+}
+
+func (markerTransformer) Flush(carry []byte) []byte {
+	return carry
+}
+
+func TestStreamingChainApply(t *testing.T) {
+	c := NewStreamingChain(upperTransformer{})
+
+	var out bytes.Buffer
+	in := strings.NewReader("hello world")
+	if err := c.Apply(&out, in); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got := out.String(); got != "HELLO WORLD" {
+		t.Errorf("got %q, want %q", got, "HELLO WORLD")
+	}
+}
+
+func TestStreamingChainCarryAcrossChunkBoundary(t *testing.T) {
+	c := NewStreamingChain(markerTransformer{})
+
+	// Force a tiny read size so "MARKER" straddles a chunk boundary: feed
+	// the reader byte-by-byte via io.Reader composition.
+	var out bytes.Buffer
+	in := &byteAtATimeReader{data: []byte("before MARKER after")}
+	if err := c.Apply(&out, in); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "before FOUND after"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// byteAtATimeReader returns at most one byte per Read call, to exercise
+// carry-across-chunk-boundary handling regardless of defaultChunkSize.
+type byteAtATimeReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *byteAtATimeReader) Read(p []byte) (int, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.EOF
+	}
+	p[0] = r.data[r.pos]
+	r.pos++
+	return 1, nil
+}
+
+func TestStreamingChainMultiStage(t *testing.T) {
+	c := NewStreamingChain(markerTransformer{}, upperTransformer{})
+
+	var out bytes.Buffer
+	in := strings.NewReader("see MARKER here")
+	if err := c.Apply(&out, in); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "SEE FOUND HERE"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}